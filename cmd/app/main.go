@@ -34,14 +34,27 @@ func main() {
 	}
 
 	var seeds bool
+	var migrate bool
 
 	// flags declaration using flag package
 	flag.BoolVar(&seeds, "seeds", false, "Running seeders")
+	flag.BoolVar(&migrate, "migrate", false, "Running data migrations")
 
 	flag.Parse() // after declaring flags we need to call it
 
+	// management subcommands, e.g. `app admin create -email ...`
+	switch flag.Arg(0) {
+	case "admin":
+		app.RunAdmin("config/config.yml", flag.Args()[1:])
+		return
+	case "keys":
+		app.RunKeys("config/config.yml", flag.Args()[1:])
+		return
+	}
+
 	command := domain.Command{
-		Seeds: seeds,
+		Seeds:   seeds,
+		Migrate: migrate,
 	}
 
 	app.Run("config/config.yml", command)
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sigit14ap/go-commerce/internal/config"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
+	"github.com/sigit14ap/go-commerce/pkg/database/mongodb"
+	"github.com/sigit14ap/go-commerce/pkg/database/redis"
+	log "github.com/sirupsen/logrus"
+)
+
+const adminUsage = `usage: app admin <command> [flags]
+
+commands:
+  create          create an admin account
+  reset-password  set a new password for an admin and sign out its sessions
+  list            list admin accounts`
+
+// RunAdmin executes an admin management subcommand and exits, without
+// starting the HTTP server. It is the way to bootstrap the first super admin.
+func RunAdmin(configPath string, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, adminUsage)
+		os.Exit(2)
+	}
+
+	cfg := config.GetConfig(configPath)
+	ctx := context.Background()
+
+	mongoClient, err := mongodb.NewClient(ctx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	admins := service.NewAdminsService(repository.NewAdminsRepo(mongoClient.Database(cfg.DB.Database)))
+
+	switch args[0] {
+	case "create":
+		err = createAdmin(ctx, admins, args[1:])
+	case "reset-password":
+		err = resetAdminPassword(ctx, cfg, admins, args[1:])
+	case "list":
+		err = listAdmins(ctx, admins)
+	default:
+		fmt.Fprintln(os.Stderr, adminUsage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func createAdmin(ctx context.Context, admins service.Admins, args []string) error {
+	flags := flag.NewFlagSet("admin create", flag.ExitOnError)
+	name := flags.String("name", "", "admin name")
+	email := flags.String("email", "", "admin email")
+	password := flags.String("password", "", "admin password, read from stdin when empty")
+	roles := flags.String("roles", string(domain.RoleSuperAdmin), "comma separated roles")
+	_ = flags.Parse(args)
+
+	input := dto.CreateAdminInput{
+		Name:     *name,
+		Email:    *email,
+		Password: *password,
+		Roles:    parseRoles(*roles),
+	}
+
+	if input.Password == "" {
+		input.Password = readPassword()
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		return err
+	}
+
+	admin, err := admins.Create(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created admin %s (%s) with roles %s\n", admin.Email, admin.ID.Hex(), formatRoles(admin.Roles))
+	return nil
+}
+
+func resetAdminPassword(ctx context.Context, cfg *config.Config, admins service.Admins, args []string) error {
+	flags := flag.NewFlagSet("admin reset-password", flag.ExitOnError)
+	email := flags.String("email", "", "admin email")
+	password := flags.String("password", "", "new password, read from stdin when empty")
+	_ = flags.Parse(args)
+
+	input := dto.AdminPasswordInput{Password: *password}
+	if input.Password == "" {
+		input.Password = readPassword()
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		return err
+	}
+
+	admin, err := admins.FindByEmail(ctx, *email)
+	if err != nil {
+		return fmt.Errorf("admin %s: %w", *email, err)
+	}
+
+	if err = admins.ResetPassword(ctx, admin.ID, input.Password); err != nil {
+		return err
+	}
+
+	fmt.Printf("password of %s changed\n", admin.Email)
+
+	redisClient, err := redis.NewClient(cfg)
+	if err != nil {
+		log.Warnf("sessions of %s were not revoked: %s", admin.Email, err)
+		return nil
+	}
+	defer redisClient.Close()
+
+	// revoking sessions only touches Redis, no signing keys are needed
+	return auth.NewTokenProvider(cfg, redisClient, nil).RevokeAll(auth.Subject("adminID", admin.ID.Hex()))
+}
+
+func listAdmins(ctx context.Context, admins service.Admins) error {
+	list, err := admins.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tEMAIL\tNAME\tROLES")
+	for _, admin := range list {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", admin.ID.Hex(), admin.Email, admin.Name, formatRoles(admin.Roles))
+	}
+
+	return writer.Flush()
+}
+
+func readPassword() string {
+	fmt.Fprint(os.Stderr, "password: ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func parseRoles(value string) []domain.Role {
+	var roles []domain.Role
+	for _, role := range strings.Split(value, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, domain.Role(role))
+		}
+	}
+
+	return roles
+}
+
+func formatRoles(roles []domain.Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+
+	return strings.Join(names, ",")
+}
@@ -3,10 +3,14 @@ package app
 import (
 	"context"
 	"fmt"
+	"github.com/sigit14ap/go-commerce/internal/database/migrations"
 	"github.com/sigit14ap/go-commerce/internal/database/seeds"
 	"github.com/sigit14ap/go-commerce/internal/delivery/http/middleware"
 	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/worker"
 	"github.com/sigit14ap/go-commerce/pkg/courier"
+	"github.com/sigit14ap/go-commerce/pkg/mailer"
+	"github.com/sigit14ap/go-commerce/pkg/ratelimit"
 	"github.com/sigit14ap/go-commerce/pkg/storage"
 	"net/http"
 	"time"
@@ -38,28 +42,54 @@ func Run(configPath string, command domain.Command) {
 	log.Info("Mongodb connected ...")
 	db := mongoClient.Database(cfg.DB.Database)
 
+	if command.Migrate {
+		err = migrations.NewDatabase(db, cfg).Run(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	redisClient, err := redis.NewClient(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 	log.Info("Redis connected ...")
 
-	tokenProvider := auth.NewTokenProvider(cfg, redisClient)
-	log.Info("Token provider initialized")
+	keys, err := auth.NewKeySet(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	repos := repository.NewRepositories(db)
-	services := service.NewServices(service.Deps{
-		Repos:       repos,
-		RedisClient: redisClient,
-	})
+	keyReloadInterval := time.Duration(cfg.JWT.KeyReloadMinutes) * time.Minute
+	go keys.Watch(context.Background(), keyReloadInterval)
+
+	tokenProvider := auth.NewTokenProvider(cfg, redisClient, keys)
+	log.Info("Token provider initialized")
 
 	storageProvider := storage.NewStorageProvider(cfg)
+	mailProvider := mailer.NewMailer(cfg)
+
+	secretBox, err := auth.NewSecretBox(cfg.TwoFactor.EncryptionKey)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	courierProvider := courier.NewCourierProvider()
 
-	middlewares := middleware.NewMiddleware(services)
+	repos := repository.NewRepositories(db)
+	services := service.NewServices(service.Deps{
+		Repos:           repos,
+		RedisClient:     redisClient,
+		Config:          cfg,
+		StorageProvider: storageProvider,
+		Mailer:          mailProvider,
+		SecretBox:       secretBox,
+		Courier:         courierProvider,
+	})
 
-	handlers := delivery.NewHandler(services, tokenProvider, storageProvider, courierProvider, middlewares)
+	middlewares := middleware.NewMiddleware(services, ratelimit.NewLimiter(cfg, redisClient))
+
+	handlers := delivery.NewHandler(services, tokenProvider, storageProvider, courierProvider, middlewares, cfg.Listen.TrustedProxies)
 	log.Info("Services, repositories and handlers initialized")
 
 	seeder := seeds.NewDatabase(services, courierProvider)
@@ -67,6 +97,23 @@ func Run(configPath string, command domain.Command) {
 		seeder.Run()
 	}
 
+	trackingInterval := time.Duration(cfg.Courier.TrackingIntervalMinutes) * time.Minute
+	go worker.NewTrackingPoller(services, courierProvider, trackingInterval).Run(context.Background())
+	log.Info("Tracking poller started ...")
+
+	completeAfter := time.Duration(cfg.Order.AutoCompleteDays) * 24 * time.Hour
+	completionInterval := time.Duration(cfg.Order.CompletionIntervalMinutes) * time.Minute
+	go worker.NewCompletionJob(services, completeAfter, completionInterval).Run(context.Background())
+	log.Info("Order completion job started ...")
+
+	flushInterval := time.Duration(cfg.Analytics.FlushMinutes) * time.Minute
+	go worker.NewStatsFlusher(services, flushInterval).Run(context.Background())
+	log.Info("Product stats flusher started ...")
+
+	purgeInterval := time.Duration(cfg.Audit.PurgeHours) * time.Hour
+	go worker.NewAuditPurger(services, purgeInterval).Run(context.Background())
+	log.Info("Audit purger started ...")
+
 	server := &http.Server{
 		Handler:      handlers.Init(),
 		Addr:         fmt.Sprintf("%s:%s", cfg.Listen.BindIP, cfg.Listen.Port),
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/config"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const keysUsage = `usage: app keys <command>
+
+commands:
+  rotate  generate a new jwt signing key and remove expired ones
+  list    list the published jwt signing keys`
+
+// RunKeys manages the jwt signing keys. Running instances pick up a rotated
+// key on their next reload and start signing with it after the activation
+// delay.
+func RunKeys(configPath string, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, keysUsage)
+		os.Exit(2)
+	}
+
+	keys, err := auth.NewKeySet(config.GetConfig(configPath))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch args[0] {
+	case "rotate":
+		key, err := keys.Rotate()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("created key %s (%s)\n", key.ID, key.Algorithm)
+	case "list":
+		signing, err := keys.SigningKey()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "KID\tALGORITHM\tCREATED\tSIGNING")
+		for _, key := range keys.Keys() {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%t\n", key.ID, key.Algorithm, key.CreatedAt.Format(time.RFC3339), key == signing)
+		}
+		_ = writer.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, keysUsage)
+		os.Exit(2)
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -12,6 +13,10 @@ type Config struct {
 		Type   string `yaml:"type" env-default:"port"`
 		BindIP string `yaml:"bind_ip" env-default:"127.0.0.1"`
 		Port   string `yaml:"port" env-default:"8080"`
+		// TrustedProxies are the addresses or CIDRs of the reverse proxies
+		// whose X-Forwarded-For is believed. Without any, clients are known
+		// by the address they connect from.
+		TrustedProxies []string `yaml:"trustedProxies" env:"TRUSTED_PROXIES"`
 	} `yaml:"listen"`
 	DB struct {
 		Database string `yaml:"database" env-required:"true"`
@@ -20,13 +25,109 @@ type Config struct {
 		Password string `yaml:"password"`
 	}
 	JWT struct {
-		Secret           string `yaml:"secret" env-required:"true"`
-		AccessTokenTime  int64  `yaml:"accessTokenTime" env-default:"15"`
-		RefreshTokenTime int64  `yaml:"refreshTokenTime" env-default:"86400"`
+		// Secret only signs one-time links such as email verification, access
+		// tokens are signed with the keys in KeysDirectory.
+		Secret               string `yaml:"secret" env:"JWT_SECRET" env-required:"true"`
+		Algorithm            string `yaml:"algorithm" env:"JWT_ALGORITHM" env-default:"RS256"`
+		KeysDirectory        string `yaml:"keysDirectory" env:"JWT_KEYS_DIRECTORY" env-default:"storage/keys"`
+		KeyRotationDays      int    `yaml:"keyRotationDays" env-default:"90"`
+		KeyActivationMinutes int    `yaml:"keyActivationMinutes" env-default:"10"`
+		KeyReloadMinutes     int    `yaml:"keyReloadMinutes" env-default:"1"`
+		AccessTokenTime      int64  `yaml:"accessTokenTime" env-default:"15"`
+		RefreshTokenTime     int64  `yaml:"refreshTokenTime" env-default:"86400"`
+		ImpersonationMinutes int    `yaml:"impersonationMinutes" env-default:"15"`
 	} `yaml:"jwt"`
 	Redis struct {
 		URI string `yaml:"uri" env-default:"localhost:6379"`
 	} `yaml:"redis"`
+	Payment struct {
+		StripeKey     string `yaml:"stripeKey" env:"STRIPE_SECRET_KEY"`
+		WebhookSecret string `yaml:"webhookSecret" env:"STRIPE_WEBHOOK_SECRET"`
+	} `yaml:"payment"`
+	Order struct {
+		ReturnWindowDays          int `yaml:"returnWindowDays" env-default:"7"`
+		AutoCompleteDays          int `yaml:"autoCompleteDays" env-default:"7"`
+		CompletionIntervalMinutes int `yaml:"completionIntervalMinutes" env-default:"60"`
+	} `yaml:"order"`
+	Tax struct {
+		Name     string  `yaml:"name" env-default:"PPN"`
+		Rate     float64 `yaml:"rate" env-default:"0.11"`
+		Timezone string  `yaml:"timezone" env-default:"Asia/Jakarta"`
+	} `yaml:"tax"`
+	Wallet struct {
+		CommissionRate float64 `yaml:"commissionRate" env-default:"0.05"`
+	} `yaml:"wallet"`
+	Account struct {
+		FrontendURL               string `yaml:"frontendURL" env-default:"http://localhost:3000"`
+		VerifyEmailTokenMinutes   int    `yaml:"verifyEmailTokenMinutes" env-default:"1440"`
+		ResetPasswordTokenMinutes int    `yaml:"resetPasswordTokenMinutes" env-default:"60"`
+	} `yaml:"account"`
+	Mail struct {
+		Driver    string `yaml:"driver" env:"MAIL_DRIVER" env-default:"file"`
+		From      string `yaml:"from" env:"MAIL_FROM" env-default:"no-reply@go-commerce.local"`
+		Host      string `yaml:"host" env:"MAIL_HOST"`
+		Port      string `yaml:"port" env:"MAIL_PORT" env-default:"587"`
+		Username  string `yaml:"username" env:"MAIL_USERNAME"`
+		Password  string `yaml:"password" env:"MAIL_PASSWORD"`
+		Directory string `yaml:"directory" env-default:"storage/mail"`
+	} `yaml:"mail"`
+	TwoFactor struct {
+		Issuer           string `yaml:"issuer" env-default:"go-commerce"`
+		EncryptionKey    string `yaml:"encryptionKey" env:"TWO_FACTOR_KEY" env-required:"true"`
+		ChallengeMinutes int    `yaml:"challengeMinutes" env-default:"5"`
+		MaxAttempts      int    `yaml:"maxAttempts" env-default:"5"`
+		RecoveryCodes    int    `yaml:"recoveryCodes" env-default:"10"`
+	} `yaml:"twoFactor"`
+	OIDC struct {
+		Name         string   `yaml:"name" env-default:"oidc"`
+		Issuer       string   `yaml:"issuer" env:"OIDC_ISSUER"`
+		ClientID     string   `yaml:"clientID" env:"OIDC_CLIENT_ID"`
+		ClientSecret string   `yaml:"clientSecret" env:"OIDC_CLIENT_SECRET"`
+		RedirectURL  string   `yaml:"redirectURL" env:"OIDC_REDIRECT_URL" env-default:"http://localhost:3000/oidc/callback"`
+		Scopes       []string `yaml:"scopes" env-default:"openid,email,profile"`
+		StateMinutes int      `yaml:"stateMinutes" env-default:"10"`
+	} `yaml:"oidc"`
+	Migration struct {
+		// LegacyStock is the stock given to products created before stock
+		// was tracked.
+		LegacyStock int64 `yaml:"legacyStock" env-default:"100"`
+	} `yaml:"migration"`
+	Courier struct {
+		TrackingIntervalMinutes int `yaml:"trackingIntervalMinutes" env-default:"60"`
+	} `yaml:"courier"`
+	Analytics struct {
+		Timezone     string `yaml:"timezone" env-default:"Asia/Jakarta"`
+		DefaultDays  int    `yaml:"defaultDays" env-default:"30"`
+		MaxDays      int    `yaml:"maxDays" env-default:"366"`
+		CacheMinutes int    `yaml:"cacheMinutes" env-default:"10"`
+		FlushMinutes int    `yaml:"flushMinutes" env-default:"5"`
+	} `yaml:"analytics"`
+	Audit struct {
+		// RetentionDays of zero keeps audit entries forever.
+		RetentionDays int `yaml:"retentionDays" env:"AUDIT_RETENTION_DAYS" env-default:"365"`
+		PurgeHours    int `yaml:"purgeHours" env-default:"24"`
+	} `yaml:"audit"`
+	RateLimit struct {
+		Enabled  bool                       `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+		Policies map[string]RateLimitPolicy `yaml:"policies"`
+		// Lockout locks an account after Threshold failed sign-ins, for
+		// BaseSeconds doubled with every further failure up to MaxMinutes.
+		Lockout struct {
+			Threshold   int `yaml:"threshold" env-default:"5"`
+			BaseSeconds int `yaml:"baseSeconds" env-default:"60"`
+			MaxMinutes  int `yaml:"maxMinutes" env-default:"60"`
+			ResetHours  int `yaml:"resetHours" env-default:"24"`
+		} `yaml:"lockout"`
+	} `yaml:"rateLimit"`
+}
+
+// RateLimitPolicy allows Limit requests per window for every key the request
+// is counted under. Keys are ip, email (from the JSON body) and user (the
+// signed in account, or the IP for anonymous requests).
+type RateLimitPolicy struct {
+	Limit         int      `yaml:"limit"`
+	WindowSeconds int      `yaml:"windowSeconds"`
+	Keys          []string `yaml:"keys"`
 }
 
 var instance *Config
@@ -41,6 +142,20 @@ func GetConfig(configPath string) *Config {
 			log.Info(help)
 			log.Fatal(err)
 		}
+		if err := instance.validate(); err != nil {
+			log.Fatal(err)
+		}
 	})
 	return instance
 }
+
+func (c *Config) validate() error {
+	// Delivered orders complete on their own after AutoCompleteDays, buyers
+	// must be able to request a return until then.
+	if c.Order.AutoCompleteDays < c.Order.ReturnWindowDays {
+		return fmt.Errorf("order.autoCompleteDays (%d) must not be shorter than order.returnWindowDays (%d)",
+			c.Order.AutoCompleteDays, c.Order.ReturnWindowDays)
+	}
+
+	return nil
+}
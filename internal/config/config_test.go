@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestValidateReturnWindow(t *testing.T) {
+	tests := []struct {
+		returnWindowDays int
+		autoCompleteDays int
+		valid            bool
+	}{
+		{7, 7, true},
+		{7, 14, true},
+		{7, 3, false},
+	}
+
+	for _, test := range tests {
+		cfg := &Config{}
+		cfg.Order.ReturnWindowDays = test.returnWindowDays
+		cfg.Order.AutoCompleteDays = test.autoCompleteDays
+
+		err := cfg.validate()
+		if (err == nil) != test.valid {
+			t.Errorf("validate with a %d day return window and auto-completion after %d days = %v, want valid %t",
+				test.returnWindowDays, test.autoCompleteDays, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AdminRolesMigration makes every admin created before roles existed a super
+// admin, so the accounts keep the access they had. Admins that already have
+// roles are left alone.
+type AdminRolesMigration struct {
+	db *mongo.Database
+}
+
+func (migration *AdminRolesMigration) Run(ctx context.Context) error {
+	log.Warn("Admin roles migration running ...")
+
+	result, err := migration.db.Collection("admins").UpdateMany(ctx,
+		bson.M{"roles": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"roles": []domain.Role{domain.RoleSuperAdmin}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Admin roles migration granted %s to %d admins", domain.RoleSuperAdmin, result.ModifiedCount)
+	return nil
+}
+
+func NewAdminRolesMigration(db *mongo.Database) *AdminRolesMigration {
+	return &AdminRolesMigration{
+		db: db,
+	}
+}
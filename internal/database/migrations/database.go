@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/sigit14ap/go-commerce/internal/config"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Function interface {
+	Run(ctx context.Context) error
+}
+
+type DatabaseMigration struct {
+	Money         Function
+	AdminRoles    Function
+	Stock         Function
+	OrderStores   Function
+	EmailVerified Function
+}
+
+// Run applies every migration in order and stops at the first failure. Each
+// migration is safe to run again.
+func (migrations *DatabaseMigration) Run(ctx context.Context) error {
+	for _, migration := range []Function{migrations.Money, migrations.AdminRoles, migrations.Stock, migrations.OrderStores,
+		migrations.EmailVerified} {
+		if err := migration.Run(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func NewDatabase(db *mongo.Database, cfg *config.Config) *DatabaseMigration {
+	return &DatabaseMigration{
+		Money:         NewMoneyMigration(db),
+		AdminRoles:    NewAdminRolesMigration(db),
+		Stock:         NewStockMigration(db, cfg.Migration.LegacyStock),
+		OrderStores:   NewOrderStoresMigration(db),
+		EmailVerified: NewEmailVerifiedMigration(db),
+	}
+}
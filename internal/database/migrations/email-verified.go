@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// EmailVerifiedMigration treats users registered before email verification
+// existed as verified, checkout requires a verified email and they had no way
+// to verify. Users registered since always carry the field, verified or not,
+// so they are left alone.
+type EmailVerifiedMigration struct {
+	db *mongo.Database
+}
+
+func (migration *EmailVerifiedMigration) Run(ctx context.Context) error {
+	log.Warn("Email verified migration running ...")
+
+	result, err := migration.db.Collection("users").UpdateMany(ctx,
+		bson.M{"emailVerified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"emailVerified": true}},
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Email verified migration marked %d users as verified", result.ModifiedCount)
+	return nil
+}
+
+func NewEmailVerifiedMigration(db *mongo.Database) *EmailVerifiedMigration {
+	return &EmailVerifiedMigration{
+		db: db,
+	}
+}
@@ -0,0 +1,143 @@
+package migrations
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// moneyFields lists the amounts that used to be stored as float64 in major
+// units, per collection. Arrays on the path are walked element by element.
+var moneyFields = map[string][]string{
+	"products": {"price"},
+	"orders": {
+		"orderItems.price", "shippingCost", "discount", "taxTotal", "grandTotal",
+		"tax.base", "tax.amount",
+	},
+	"returns": {"refundAmount"},
+	"ledger":  {"entries.amount"},
+	"payouts": {"amount"},
+	"invoices": {
+		"subtotal", "shipping", "discount", "tax", "total",
+		"lines.unitPrice", "lines.total", "taxLines.base", "taxLines.amount",
+	},
+}
+
+// MoneyMigration rewrites float prices into money documents in the default
+// currency. Documents that are already converted are left alone, so it can be
+// run more than once.
+type MoneyMigration struct {
+	db *mongo.Database
+}
+
+func (migration *MoneyMigration) Run(ctx context.Context) error {
+	for collectionName, fields := range moneyFields {
+		log.Warnf("Money migration running on %s ...", collectionName)
+
+		converted, err := migration.migrateCollection(ctx, migration.db.Collection(collectionName), fields)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Money migration converted %d %s", converted, collectionName)
+	}
+
+	return nil
+}
+
+func (migration *MoneyMigration) migrateCollection(ctx context.Context, collection *mongo.Collection, fields []string) (int, error) {
+	legacy := bson.A{}
+	for _, field := range fields {
+		legacy = append(legacy, bson.M{field: bson.M{"$type": "number"}})
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"$or": legacy})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	converted := 0
+	for cursor.Next(ctx) {
+		var document bson.M
+		if err = cursor.Decode(&document); err != nil {
+			return converted, err
+		}
+
+		set := bson.M{}
+		for _, field := range fields {
+			path := strings.Split(field, ".")
+			if convertMoney(document, path) {
+				set[path[0]] = document[path[0]]
+			}
+		}
+
+		if len(set) == 0 {
+			continue
+		}
+
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": document["_id"]}, bson.M{"$set": set})
+		if err != nil {
+			return converted, err
+		}
+		converted++
+	}
+
+	return converted, cursor.Err()
+}
+
+// convertMoney replaces the numbers found at path inside value with money
+// documents and reports whether anything changed.
+func convertMoney(value interface{}, path []string) bool {
+	switch node := value.(type) {
+	case bson.M:
+		child, ok := node[path[0]]
+		if !ok {
+			return false
+		}
+
+		if len(path) == 1 {
+			money, ok := legacyMoney(child)
+			if ok {
+				node[path[0]] = money
+			}
+			return ok
+		}
+
+		return convertMoney(child, path[1:])
+	case primitive.A:
+		changed := false
+		for _, element := range node {
+			if convertMoney(element, path) {
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	return false
+}
+
+func legacyMoney(value interface{}) (domain.Money, bool) {
+	switch number := value.(type) {
+	case float64:
+		return domain.MoneyFromMajor(number, domain.DefaultCurrency), true
+	case int32:
+		return domain.MoneyFromMajor(float64(number), domain.DefaultCurrency), true
+	case int64:
+		return domain.MoneyFromMajor(float64(number), domain.DefaultCurrency), true
+	}
+
+	return domain.Money{}, false
+}
+
+func NewMoneyMigration(db *mongo.Database) *MoneyMigration {
+	return &MoneyMigration{
+		db: db,
+	}
+}
@@ -0,0 +1,115 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type legacyOrder struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	OrderItems []struct {
+		ProductID primitive.ObjectID `bson:"_id"`
+	} `bson:"orderItems"`
+}
+
+// OrderStoresMigration gives orders placed before orders were split per store
+// the store of their products, stores can only act on orders that carry their
+// ID. Orders with products of several stores, or of products that were
+// deleted, only get the stores that can be found and are left to admins.
+type OrderStoresMigration struct {
+	db *mongo.Database
+}
+
+func (migration *OrderStoresMigration) Run(ctx context.Context) error {
+	log.Warn("Order stores migration running ...")
+
+	orders := migration.db.Collection("orders")
+	cursor, err := orders.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"storeID": bson.M{"$exists": false}},
+		bson.M{"storeID": primitive.NilObjectID},
+	}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	productStores := map[primitive.ObjectID]primitive.ObjectID{}
+	assigned, unresolved := 0, 0
+
+	for cursor.Next(ctx) {
+		var order legacyOrder
+		if err = cursor.Decode(&order); err != nil {
+			return err
+		}
+
+		set := bson.M{}
+		stores := map[primitive.ObjectID]bool{}
+		for i, orderItem := range order.OrderItems {
+			storeID, err := migration.productStore(ctx, productStores, orderItem.ProductID)
+			if err != nil {
+				return err
+			}
+
+			stores[storeID] = true
+			if !storeID.IsZero() {
+				set[fmt.Sprintf("orderItems.%d.storeID", i)] = storeID
+			}
+		}
+
+		for storeID := range stores {
+			if len(stores) == 1 && !storeID.IsZero() {
+				set["storeID"] = storeID
+			}
+		}
+
+		if _, ok := set["storeID"]; !ok {
+			log.Warnf("Order stores migration can't assign a single store to order %s", order.ID.Hex())
+			unresolved++
+		} else {
+			assigned++
+		}
+
+		if len(set) == 0 {
+			continue
+		}
+
+		_, err = orders.UpdateOne(ctx, bson.M{"_id": order.ID}, bson.M{"$set": set})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Order stores migration assigned a store to %d orders, %d left to admins", assigned, unresolved)
+	return cursor.Err()
+}
+
+// productStore looks up the store of a product once, deleted products have
+// no store.
+func (migration *OrderStoresMigration) productStore(ctx context.Context,
+	productStores map[primitive.ObjectID]primitive.ObjectID, productID primitive.ObjectID) (primitive.ObjectID, error) {
+	if storeID, ok := productStores[productID]; ok {
+		return storeID, nil
+	}
+
+	var product struct {
+		StoreID primitive.ObjectID `bson:"store_id"`
+	}
+	err := migration.db.Collection("products").FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return primitive.NilObjectID, err
+	}
+
+	productStores[productID] = product.StoreID
+	return product.StoreID, nil
+}
+
+func NewOrderStoresMigration(db *mongo.Database) *OrderStoresMigration {
+	return &OrderStoresMigration{
+		db: db,
+	}
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StockMigration gives products created before stock was tracked a starting
+// stock, orders reserve stock and would turn every one of them away. Products
+// that already have a stock are left alone.
+type StockMigration struct {
+	db    *mongo.Database
+	stock int64
+}
+
+func (migration *StockMigration) Run(ctx context.Context) error {
+	log.Warn("Stock migration running ...")
+
+	result, err := migration.db.Collection("products").UpdateMany(ctx,
+		bson.M{"stock": nil},
+		bson.M{"$set": bson.M{"stock": migration.stock}},
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Stock migration set a stock of %d on %d products", migration.stock, result.ModifiedCount)
+	return nil
+}
+
+func NewStockMigration(db *mongo.Database, stock int64) *StockMigration {
+	return &StockMigration{
+		db:    db,
+		stock: stock,
+	}
+}
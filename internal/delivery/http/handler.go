@@ -1,6 +1,8 @@
 package http
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sigit14ap/go-commerce/internal/delivery/http/middleware"
 	v1 "github.com/sigit14ap/go-commerce/internal/delivery/http/v1"
@@ -8,6 +10,7 @@ import (
 	"github.com/sigit14ap/go-commerce/pkg/auth"
 	"github.com/sigit14ap/go-commerce/pkg/courier"
 	"github.com/sigit14ap/go-commerce/pkg/storage"
+	log "github.com/sirupsen/logrus"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -22,32 +25,51 @@ type Handler struct {
 	storageProvider storage.StorageProvider
 	courierProvider courier.CourierProvider
 	middlewares     *middleware.MiddlewareService
+	trustedProxies  []string
 }
 
-func NewHandler(services *service.Services, tokenProvider auth.TokenProvider, storageProvider storage.StorageProvider, courierProvider courier.CourierProvider, middlewares *middleware.MiddlewareService) *Handler {
+func NewHandler(services *service.Services, tokenProvider auth.TokenProvider, storageProvider storage.StorageProvider, courierProvider courier.CourierProvider, middlewares *middleware.MiddlewareService, trustedProxies []string) *Handler {
 	return &Handler{
 		services:        services,
 		tokenProvider:   tokenProvider,
 		storageProvider: storageProvider,
 		courierProvider: courierProvider,
 		middlewares:     middlewares,
+		trustedProxies:  trustedProxies,
 	}
 }
 
 func (h *Handler) Init() *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
-	router := gin.New()
+	router, err := newRouter(h.trustedProxies)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	router.GET("/ping", func(c *gin.Context) {
 		c.String(http.StatusOK, "pong")
 	})
+	router.GET("/.well-known/jwks.json", h.jwks)
 
 	h.initAPI(router)
 
 	return router
 }
 
+// newRouter returns an engine whose ClientIP only follows X-Forwarded-For
+// when the request comes from one of the trusted proxies. Gin trusts every
+// address by default, which would let clients pick their own IP for rate
+// limits and the audit log.
+func newRouter(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+
+	return router, nil
+}
+
 func (h *Handler) initAPI(router *gin.Engine) {
 	handlerV1 := v1.NewHandler(h.services, h.tokenProvider, h.storageProvider, h.courierProvider, h.middlewares)
 	api := router.Group("/api")
@@ -55,3 +77,10 @@ func (h *Handler) initAPI(router *gin.Engine) {
 		handlerV1.Init(api)
 	}
 }
+
+// jwks publishes the public keys access tokens are signed with, so other
+// services can verify tokens without sharing a secret.
+func (h *Handler) jwks(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokenProvider.JWKS())
+}
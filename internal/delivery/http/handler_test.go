@@ -0,0 +1,114 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/middleware"
+	v1 "github.com/sigit14ap/go-commerce/internal/delivery/http/v1"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"github.com/sigit14ap/go-commerce/pkg/ratelimit"
+)
+
+type memoryLimiter struct {
+	limit  int
+	counts map[string]int
+}
+
+func (l *memoryLimiter) Policy(name string) (ratelimit.Policy, bool) {
+	return ratelimit.Policy{Name: name, Limit: l.limit, Keys: []string{ratelimit.KeyIP}}, true
+}
+
+func (l *memoryLimiter) Allow(policy ratelimit.Policy, keyType string, key string) (ratelimit.Result, error) {
+	l.counts[keyType+":"+key]++
+	count := l.counts[keyType+":"+key]
+	return ratelimit.Result{Allowed: count <= policy.Limit, Limit: policy.Limit, Remaining: policy.Limit - count}, nil
+}
+
+func limitedRouter(t *testing.T, trustedProxies []string, limiter *memoryLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router, err := newRouter(trustedProxies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router.POST("/sign-in", middleware.NewRateLimit(limiter).Limit("sign-in"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	return router
+}
+
+func signIn(router *gin.Engine, remoteAddr string, forwardedFor string) int {
+	request := httptest.NewRequest(http.MethodPost, "/sign-in", nil)
+	request.RemoteAddr = remoteAddr
+	request.Header.Set("X-Forwarded-For", forwardedFor)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func TestSpoofedForwardedForKeepsCounter(t *testing.T) {
+	limiter := &memoryLimiter{limit: 2, counts: map[string]int{}}
+	router := limitedRouter(t, nil, limiter)
+
+	for i, forwardedFor := range []string{"198.51.100.1", "198.51.100.2"} {
+		if code := signIn(router, "203.0.113.7:40000", forwardedFor); code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want %d", i+1, code, http.StatusOK)
+		}
+	}
+
+	if code := signIn(router, "203.0.113.7:40001", "198.51.100.3"); code != http.StatusTooManyRequests {
+		t.Fatalf("spoofed X-Forwarded-For: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+
+	if count := limiter.counts["ip:203.0.113.7"]; count != 3 {
+		t.Fatalf("counted %d requests for the remote address, want 3", count)
+	}
+}
+
+func TestTrustedProxyForwardedFor(t *testing.T) {
+	limiter := &memoryLimiter{limit: 1, counts: map[string]int{}}
+	router := limitedRouter(t, []string{"10.0.0.0/8"}, limiter)
+
+	for _, forwardedFor := range []string{"198.51.100.1", "198.51.100.2"} {
+		if code := signIn(router, "10.0.0.5:40000", forwardedFor); code != http.StatusOK {
+			t.Fatalf("client %s behind the proxy: status %d, want %d", forwardedFor, code, http.StatusOK)
+		}
+	}
+
+	if code := signIn(router, "10.0.0.5:40000", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("repeated client behind the proxy: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRequestIDMiddlewareAuditsRemoteIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router, err := newRouter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ip string
+	router.GET("/audited", v1.RequestIDMiddleware(), func(c *gin.Context) {
+		ip = service.AuditContextFrom(c.Request.Context()).IP
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/audited", nil)
+	request.RemoteAddr = "203.0.113.7:40000"
+	request.Header.Set("X-Forwarded-For", "198.51.100.1")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	if ip != "203.0.113.7" {
+		t.Fatalf("audited IP %q, want 203.0.113.7", ip)
+	}
+}
+
+func TestNewRouterRejectsInvalidProxy(t *testing.T) {
+	if _, err := newRouter([]string{"not-an-address"}); err == nil {
+		t.Fatal("newRouter accepted an invalid trusted proxy")
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Authorize checks the roles of the signed in admin against the permission a
+// route needs. Roles are read from the database on every request so that a
+// role change applies without waiting for the access token to expire.
+type Authorize struct {
+	Handler *service.Services
+}
+
+func NewAuthorize(services *service.Services) *Authorize {
+	return &Authorize{
+		Handler: services,
+	}
+}
+
+// Require returns a handler that lets the request through only when the admin
+// has the permission. Denials are written to the audit log.
+func (authorize *Authorize) Require(permission domain.Permission) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		adminID, err := services.GetIdFromRequestContext(context, "adminID")
+		if err != nil {
+			services.ErrorResponse(context, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		admin, err := authorize.Handler.Admins.FindByID(context, adminID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			services.ErrorResponse(context, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if err != nil {
+			log.Errorf("failed to load admin %s: %s", adminID.Hex(), err)
+			services.ErrorResponse(context, http.StatusInternalServerError, "failed to load admin")
+			return
+		}
+
+		ctx := service.WithAuditActor(context.Request.Context(), domain.AuditActorAdmin, admin.ID, admin.Roles)
+
+		if admin.HasPermission(permission) {
+			context.Set("adminRoles", admin.Roles)
+			context.Request = context.Request.WithContext(ctx)
+			return
+		}
+
+		err = authorize.Handler.Audit.Record(ctx, domain.AuditLog{
+			Action:     domain.AuditActionPermissionDenied,
+			Permission: permission,
+		})
+		if err != nil {
+			log.Errorf("failed to audit permission denial for admin %s: %s", admin.ID.Hex(), err)
+		}
+
+		services.ErrorResponse(context, http.StatusForbidden, fmt.Sprintf("missing permission %s", permission))
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type lookupAdmins struct {
+	service.Admins
+	admin domain.Admin
+	err   error
+}
+
+func (a *lookupAdmins) FindByID(ctx context.Context, adminID primitive.ObjectID) (domain.Admin, error) {
+	return a.admin, a.err
+}
+
+type memoryAudit struct {
+	service.Audit
+	entries []domain.AuditLog
+}
+
+func (a *memoryAudit) Record(ctx context.Context, entry domain.AuditLog) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func authorizeRequest(admins *lookupAdmins, audit *memoryAudit, permission domain.Permission) int {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	authorize := NewAuthorize(&service.Services{Admins: admins, Audit: audit})
+	router.GET("/admins/orders", func(c *gin.Context) {
+		c.Set("adminID", admins.admin.ID.Hex())
+	}, authorize.Require(permission), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admins/orders", nil))
+	return recorder.Code
+}
+
+func TestRequire(t *testing.T) {
+	finance := domain.Admin{ID: primitive.NewObjectID(), Roles: []domain.Role{domain.RoleFinance}}
+
+	tests := []struct {
+		name       string
+		admins     *lookupAdmins
+		permission domain.Permission
+		want       int
+		audited    bool
+	}{
+		{"granted", &lookupAdmins{admin: finance}, domain.PermissionOrdersRead, http.StatusOK, false},
+		{"missing permission", &lookupAdmins{admin: finance}, domain.PermissionOrdersWrite, http.StatusForbidden, true},
+		{"deleted admin", &lookupAdmins{admin: finance, err: mongo.ErrNoDocuments}, domain.PermissionOrdersRead,
+			http.StatusUnauthorized, false},
+		{"lookup failure", &lookupAdmins{admin: finance, err: errors.New("connection reset")}, domain.PermissionOrdersRead,
+			http.StatusInternalServerError, false},
+	}
+
+	for _, test := range tests {
+		audit := &memoryAudit{}
+		if code := authorizeRequest(test.admins, audit, test.permission); code != test.want {
+			t.Errorf("%s: status %d, want %d", test.name, code, test.want)
+		}
+
+		if audited := len(audit.entries) == 1 && audit.entries[0].Action == domain.AuditActionPermissionDenied; audited != test.audited {
+			t.Errorf("%s: audit entries %+v, want a denial %t", test.name, audit.entries, test.audited)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/sigit14ap/go-commerce/internal/service"
+	"github.com/sigit14ap/go-commerce/pkg/ratelimit"
 )
 
 type Function interface {
@@ -10,11 +11,17 @@ type Function interface {
 }
 
 type MiddlewareService struct {
-	VerifyStore Function
+	VerifyStore *VerifyStore
+	VerifyEmail Function
+	Authorize   *Authorize
+	RateLimit   *RateLimit
 }
 
-func NewMiddleware(services *service.Services) *MiddlewareService {
+func NewMiddleware(services *service.Services, limiter *ratelimit.Limiter) *MiddlewareService {
 	return &MiddlewareService{
 		VerifyStore: NewVerifyStore(services),
+		VerifyEmail: NewVerifyEmail(services),
+		Authorize:   NewAuthorize(services),
+		RateLimit:   NewRateLimit(limiter),
 	}
 }
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/pkg/ratelimit"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxEmailBody caps how much of the body is read to find the email key.
+const maxEmailBody = 64 << 10
+
+// Limiter counts requests, it is implemented by ratelimit.Limiter.
+type Limiter interface {
+	Policy(name string) (ratelimit.Policy, bool)
+	Allow(policy ratelimit.Policy, keyType string, key string) (ratelimit.Result, error)
+}
+
+type RateLimit struct {
+	Limiter Limiter
+}
+
+func NewRateLimit(limiter Limiter) *RateLimit {
+	return &RateLimit{
+		Limiter: limiter,
+	}
+}
+
+// Limit returns a handler that counts the request under the named policy
+// from config.yml. The request is counted once per key of the policy and
+// rejected when any of them is over its limit. Routes whose policy is not
+// configured are not limited, and Redis errors let the request through so an
+// outage of the limiter does not take the API down with it.
+func (rateLimit *RateLimit) Limit(name string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		policy, ok := rateLimit.Limiter.Policy(name)
+		if !ok {
+			return
+		}
+
+		var tightest *ratelimit.Result
+		for _, keyType := range policy.Keys {
+			key := limitKey(context, keyType)
+			if key == "" {
+				continue
+			}
+
+			result, err := rateLimit.Limiter.Allow(policy, keyType, key)
+			if err != nil {
+				log.Errorf("rate limit %s: %s", name, err)
+				return
+			}
+
+			if tightest == nil || !result.Allowed || (tightest.Allowed && result.Remaining < tightest.Remaining) {
+				tightest = &result
+			}
+
+			if !result.Allowed {
+				break
+			}
+		}
+
+		if tightest == nil {
+			return
+		}
+
+		context.Header("X-RateLimit-Limit", strconv.Itoa(tightest.Limit))
+		context.Header("X-RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		context.Header("X-RateLimit-Reset", strconv.FormatInt(tightest.ResetAt.Unix(), 10))
+
+		if !tightest.Allowed {
+			retryAfter := int(math.Ceil(tightest.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+
+			context.Header("Retry-After", strconv.Itoa(retryAfter))
+			services.ErrorResponse(context, http.StatusTooManyRequests,
+				fmt.Sprintf("too many requests, retry in %d seconds", retryAfter))
+		}
+	}
+}
+
+// limitKey relies on ClientIP, which only believes X-Forwarded-For from the
+// trusted proxies the router was set up with, so a client cannot move to a
+// fresh counter by sending the header itself.
+func limitKey(context *gin.Context, keyType string) string {
+	switch keyType {
+	case ratelimit.KeyIP:
+		return context.ClientIP()
+	case ratelimit.KeyEmail:
+		return bodyEmail(context)
+	case ratelimit.KeyUser:
+		for _, idName := range []string{"userID", "adminID"} {
+			if id, ok := context.Get(idName); ok {
+				return fmt.Sprintf("%s:%v", idName, id)
+			}
+		}
+		return "ip:" + context.ClientIP()
+	default:
+		return ""
+	}
+}
+
+// bodyEmail reads the email field of a JSON body and puts what it read back
+// in front of the rest of the body for the handler.
+func bodyEmail(context *gin.Context) string {
+	if context.Request.Body == nil {
+		return ""
+	}
+
+	original := context.Request.Body
+	body, err := ioutil.ReadAll(io.LimitReader(original, maxEmailBody))
+	context.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), original), original}
+	if err != nil {
+		return ""
+	}
+
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err = json.Unmarshal(body, &input); err != nil {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimSpace(input.Email))
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"net/http"
+)
+
+type VerifyEmail struct {
+	Handler *service.Services
+}
+
+func NewVerifyEmail(services *service.Services) *VerifyEmail {
+	return &VerifyEmail{
+		Handler: services,
+	}
+}
+
+func (verify *VerifyEmail) Handle(context *gin.Context) {
+	userID, err := services.GetIdFromRequestContext(context, "userID")
+
+	if err != nil {
+		services.ErrorResponse(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	user, err := verify.Handler.Users.FindByID(context, userID)
+
+	if err != nil {
+		services.ErrorResponse(context, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if !user.EmailVerified {
+		services.ErrorResponse(context, http.StatusForbidden, service.ErrEmailNotVerified.Error())
+		return
+	}
+}
@@ -1,8 +1,10 @@
 package middleware
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/service"
 	"net/http"
 )
@@ -32,6 +34,30 @@ func (verify *VerifyStore) Handle(context *gin.Context) {
 		return
 	}
 
+	if !domain.IsActive(store.Status) {
+		services.ErrorResponse(context, http.StatusForbidden, fmt.Sprintf("Store is %s", store.Status))
+		return
+	}
+
 	context.Set("storeID", store.ID.Hex())
 	context.Set("storeData", store)
+	context.Request = context.Request.WithContext(
+		service.WithAuditActor(context.Request.Context(), domain.AuditActorSeller, userID, nil))
+}
+
+// Approved lets only stores that passed verification through, it runs after
+// Handle on the routes that make products visible to shoppers.
+func (verify *VerifyStore) Approved(context *gin.Context) {
+	storeData, err := services.GetDataFromContext(context, "storeData")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusForbidden, "Not registered as store")
+		return
+	}
+
+	store := storeData.(domain.Store)
+	if !store.IsApproved() {
+		services.ErrorResponse(context, http.StatusForbidden,
+			fmt.Sprintf("Store verification is %s, products can only be published once it is approved", store.Verification.Status))
+		return
+	}
 }
@@ -0,0 +1,258 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetAllAdmins godoc
+// @Summary   Get all admins
+// @Tags      admin-admins
+// @Accept    json
+// @Produce   json
+// @Success   200  {array}   success
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/admins [get]
+func (h *Handler) getAllAdminsAdmin(context *gin.Context) {
+	admins, err := h.services.Admins.FindAll(context.Request.Context())
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	adminsArray := make([]domain.Admin, len(admins))
+	if admins != nil {
+		adminsArray = admins
+	}
+
+	successResponse(context, adminsArray)
+}
+
+// GetAdminById godoc
+// @Summary   Get admin by id
+// @Tags      admin-admins
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "admin id"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/admins/{id} [get]
+func (h *Handler) getAdminByIdAdmin(context *gin.Context) {
+	id, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	admin, err := h.services.Admins.FindByID(context.Request.Context(), id)
+	if err != nil {
+		adminErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, admin)
+}
+
+// CreateAdmin godoc
+// @Summary   Create admin
+// @Tags      admin-admins
+// @Accept    json
+// @Produce   json
+// @Param     admin  body      dto.CreateAdminInput  true  "admin"
+// @Success   201    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   403    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/admins [post]
+func (h *Handler) createAdminAdmin(context *gin.Context) {
+	var input dto.CreateAdminInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	admin, err := h.services.Admins.Create(context.Request.Context(), input)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			ErrorResponse(context, http.StatusBadRequest,
+				fmt.Sprintf("admin with email %s already exists", input.Email))
+			return
+		}
+
+		adminErrorResponse(context, err)
+		return
+	}
+
+	createdResponse(context, admin)
+}
+
+// UpdateAdmin godoc
+// @Summary   Update admin name and roles
+// @Tags      admin-admins
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                true  "admin id"
+// @Param     admin  body      dto.UpdateAdminInput  true  "admin update fields"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   403    {object}  failure
+// @Failure   404    {object}  failure
+// @Failure   409    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/admins/{id} [put]
+func (h *Handler) updateAdminAdmin(context *gin.Context) {
+	id, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.UpdateAdminInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	admin, err := h.services.Admins.Update(context.Request.Context(), id, input)
+	if err != nil {
+		adminErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, admin)
+}
+
+// ResetAdminPassword godoc
+// @Summary   Set a new password for an admin and sign out all their sessions
+// @Tags      admin-admins
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                  true  "admin id"
+// @Param     input  body      dto.AdminPasswordInput  true  "new password"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   403    {object}  failure
+// @Failure   404    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/admins/{id}/password [put]
+func (h *Handler) resetAdminPasswordAdmin(context *gin.Context) {
+	id, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.AdminPasswordInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	err = h.services.Admins.ResetPassword(context.Request.Context(), id, input.Password)
+	if err != nil {
+		adminErrorResponse(context, err)
+		return
+	}
+
+	err = h.tokenProvider.RevokeAll(auth.Subject("adminID", id.Hex()))
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data interface{}
+	successResponse(context, data)
+}
+
+// DeleteAdmin godoc
+// @Summary   Delete admin
+// @Tags      admin-admins
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "admin id"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   409  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/admins/{id} [delete]
+func (h *Handler) deleteAdminAdmin(context *gin.Context) {
+	id, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	adminID, err := getIdFromRequestContext(context, "adminID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if adminID == id {
+		ErrorResponse(context, http.StatusBadRequest, service.ErrAdminSelfDelete.Error())
+		return
+	}
+
+	err = h.services.Admins.Delete(context.Request.Context(), id)
+	if err != nil {
+		adminErrorResponse(context, err)
+		return
+	}
+
+	err = h.tokenProvider.RevokeAll(auth.Subject("adminID", id.Hex()))
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data interface{}
+	successResponse(context, data)
+}
+
+func adminErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		ErrorResponse(context, http.StatusNotFound, "admin not found")
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrLastSuperAdmin):
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrAdminsBusy):
+		ErrorResponse(context, http.StatusConflict, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
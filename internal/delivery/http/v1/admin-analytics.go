@@ -0,0 +1,248 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+)
+
+// GetAnalyticsOverviewAdmin godoc
+// @Summary   Get the dashboard totals
+// @Tags      admin-analytics
+// @Accept    json
+// @Produce   json
+// @Param     from      query     string  false  "first day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param     to        query     string  false  "last day (YYYY-MM-DD), defaults to today"
+// @Param     timezone  query     string  false  "IANA timezone of the dates, defaults to Asia/Jakarta"
+// @Success   200  {object}  domain.AnalyticsOverview
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/analytics/overview [get]
+func (h *Handler) getAnalyticsOverviewAdmin(context *gin.Context) {
+	input, ok := bindAnalyticsInput(context)
+	if !ok {
+		return
+	}
+
+	overview, err := h.services.Analytics.Overview(context.Request.Context(), input)
+	if err != nil {
+		analyticsErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, overview)
+}
+
+// GetSalesAnalyticsAdmin godoc
+// @Summary   Get GMV, order count and average order value per period
+// @Tags      admin-analytics
+// @Accept    json
+// @Produce   json
+// @Param     period    query     string  false  "day, week or month, defaults to day"
+// @Param     from      query     string  false  "first day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param     to        query     string  false  "last day (YYYY-MM-DD), defaults to today"
+// @Param     timezone  query     string  false  "IANA timezone of the dates, defaults to Asia/Jakarta"
+// @Success   200  {object}  domain.SalesReport
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/analytics/sales [get]
+func (h *Handler) getSalesAnalyticsAdmin(context *gin.Context) {
+	input, ok := bindAnalyticsInput(context)
+	if !ok {
+		return
+	}
+
+	report, err := h.services.Analytics.Sales(context.Request.Context(), input)
+	if err != nil {
+		analyticsErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, report)
+}
+
+// GetTopProductsAdmin godoc
+// @Summary   Get the best selling products
+// @Tags      admin-analytics
+// @Accept    json
+// @Produce   json
+// @Param     from      query     string  false  "first day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param     to        query     string  false  "last day (YYYY-MM-DD), defaults to today"
+// @Param     timezone  query     string  false  "IANA timezone of the dates, defaults to Asia/Jakarta"
+// @Param     limit     query     int     false  "number of rows, 1 to 100, defaults to 10"
+// @Success   200  {array}   domain.TopProduct
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/analytics/top-products [get]
+func (h *Handler) getTopProductsAdmin(context *gin.Context) {
+	input, ok := bindAnalyticsInput(context)
+	if !ok {
+		return
+	}
+
+	products, err := h.services.Analytics.TopProducts(context.Request.Context(), input)
+	if err != nil {
+		analyticsErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, products)
+}
+
+// GetTopStoresAdmin godoc
+// @Summary   Get the stores with the highest GMV
+// @Tags      admin-analytics
+// @Accept    json
+// @Produce   json
+// @Param     from      query     string  false  "first day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param     to        query     string  false  "last day (YYYY-MM-DD), defaults to today"
+// @Param     timezone  query     string  false  "IANA timezone of the dates, defaults to Asia/Jakarta"
+// @Param     limit     query     int     false  "number of rows, 1 to 100, defaults to 10"
+// @Success   200  {array}   domain.TopStore
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/analytics/top-stores [get]
+func (h *Handler) getTopStoresAdmin(context *gin.Context) {
+	input, ok := bindAnalyticsInput(context)
+	if !ok {
+		return
+	}
+
+	stores, err := h.services.Analytics.TopStores(context.Request.Context(), input)
+	if err != nil {
+		analyticsErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, stores)
+}
+
+// GetCartConversionAdmin godoc
+// @Summary   Get the conversion from cart to order
+// @Tags      admin-analytics
+// @Accept    json
+// @Produce   json
+// @Param     from      query     string  false  "first day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param     to        query     string  false  "last day (YYYY-MM-DD), defaults to today"
+// @Param     timezone  query     string  false  "IANA timezone of the dates, defaults to Asia/Jakarta"
+// @Success   200  {object}  domain.CartConversion
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/analytics/conversion [get]
+func (h *Handler) getCartConversionAdmin(context *gin.Context) {
+	input, ok := bindAnalyticsInput(context)
+	if !ok {
+		return
+	}
+
+	conversion, err := h.services.Analytics.CartConversion(context.Request.Context(), input)
+	if err != nil {
+		analyticsErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, conversion)
+}
+
+// GetNewUsersAdmin godoc
+// @Summary   Get new users per period
+// @Tags      admin-analytics
+// @Accept    json
+// @Produce   json
+// @Param     period    query     string  false  "day, week or month, defaults to day"
+// @Param     from      query     string  false  "first day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param     to        query     string  false  "last day (YYYY-MM-DD), defaults to today"
+// @Param     timezone  query     string  false  "IANA timezone of the dates, defaults to Asia/Jakarta"
+// @Success   200  {object}  domain.NewUsersReport
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/analytics/new-users [get]
+func (h *Handler) getNewUsersAdmin(context *gin.Context) {
+	input, ok := bindAnalyticsInput(context)
+	if !ok {
+		return
+	}
+
+	report, err := h.services.Analytics.NewUsers(context.Request.Context(), input)
+	if err != nil {
+		analyticsErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, report)
+}
+
+// GetOrderRatesAdmin godoc
+// @Summary   Get the cancellation and refund rates
+// @Tags      admin-analytics
+// @Accept    json
+// @Produce   json
+// @Param     from      query     string  false  "first day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param     to        query     string  false  "last day (YYYY-MM-DD), defaults to today"
+// @Param     timezone  query     string  false  "IANA timezone of the dates, defaults to Asia/Jakarta"
+// @Success   200  {object}  domain.OrderRates
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/analytics/rates [get]
+func (h *Handler) getOrderRatesAdmin(context *gin.Context) {
+	input, ok := bindAnalyticsInput(context)
+	if !ok {
+		return
+	}
+
+	rates, err := h.services.Analytics.OrderRates(context.Request.Context(), input)
+	if err != nil {
+		analyticsErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, rates)
+}
+
+func bindAnalyticsInput(context *gin.Context) (dto.AnalyticsInput, bool) {
+	var input dto.AnalyticsInput
+	if err := context.ShouldBindQuery(&input); err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return dto.AnalyticsInput{}, false
+	}
+
+	if err := validate.Struct(input); err != nil {
+		errorValidationResponse(context, err)
+		return dto.AnalyticsInput{}, false
+	}
+
+	return input, true
+}
+
+func analyticsErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTimezone), errors.Is(err, service.ErrInvalidDateRange):
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
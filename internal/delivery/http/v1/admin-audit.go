@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+)
+
+// GetAuditLogAdmin godoc
+// @Summary   Search the audit log
+// @Tags      admin-audit
+// @Accept    json
+// @Produce   json
+// @Param     actorType   query     string  false  "admin or seller"
+// @Param     actorID     query     string  false  "admin or seller user id"
+// @Param     action      query     string  false  "action, e.g. order.update"
+// @Param     targetType  query     string  false  "target entity, e.g. order"
+// @Param     targetID    query     string  false  "target entity id"
+// @Param     requestID   query     string  false  "X-Request-ID of the request"
+// @Param     from        query     string  false  "first day (YYYY-MM-DD)"
+// @Param     to          query     string  false  "last day (YYYY-MM-DD)"
+// @Param     page        query     int     false  "page, defaults to 1"
+// @Param     limit       query     int     false  "entries per page, defaults to 20"
+// @Success   200  {object}  domain.AuditPage
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/audit [get]
+func (h *Handler) getAuditLogAdmin(context *gin.Context) {
+	var input dto.AuditFilterInput
+	err := context.ShouldBindQuery(&input)
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	page, err := h.services.Audit.Find(context.Request.Context(), input)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, page)
+}
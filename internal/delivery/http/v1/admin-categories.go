@@ -110,6 +110,7 @@ func (h *Handler) createCategoryAdmin(context *gin.Context) {
 	categoryDTO.Name = categoryInput.Name
 	categoryDTO.Description = categoryInput.Description
 	categoryDTO.Icon = uploadedFile
+	categoryDTO.TaxExempt = categoryInput.TaxExempt
 
 	category, err := h.services.Categories.Create(context.Request.Context(), categoryDTO)
 	if err != nil {
@@ -153,6 +154,7 @@ func (h *Handler) updateCategoryAdmin(context *gin.Context) {
 	var categoryDTO dto.UpdateCategoryDTO
 	categoryDTO.Name = categoryInput.Name
 	categoryDTO.Description = categoryInput.Description
+	categoryDTO.TaxExempt = categoryInput.TaxExempt
 
 	icon, err := context.FormFile("icon")
 
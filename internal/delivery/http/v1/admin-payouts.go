@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+)
+
+// GetAllPayoutsAdmin godoc
+// @Summary   Get payout requests
+// @Tags      admin-payouts
+// @Accept    json
+// @Produce   json
+// @Param     status  query     string  false  "requested, approved, rejected or paid"
+// @Success   200  {array}   success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/payouts [get]
+func (h *Handler) getAllPayoutsAdmin(context *gin.Context) {
+	var input dto.PayoutFilterInput
+	_ = context.ShouldBindQuery(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	payouts, err := h.services.Wallets.FindPayouts(context.Request.Context(), input.Status)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, payouts)
+}
+
+// ApprovePayoutAdmin godoc
+// @Summary   Approve payout request
+// @Tags      admin-payouts
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                 true  "payout id"
+// @Param     input  body      dto.PayoutReviewInput  true  "note"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/payouts/{id}/approve [post]
+func (h *Handler) approvePayoutAdmin(context *gin.Context) {
+	h.reviewPayoutAdmin(context, domain.PayoutStatusApproved)
+}
+
+// RejectPayoutAdmin godoc
+// @Summary   Reject payout request
+// @Tags      admin-payouts
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                 true  "payout id"
+// @Param     input  body      dto.PayoutReviewInput  true  "note"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/payouts/{id}/reject [post]
+func (h *Handler) rejectPayoutAdmin(context *gin.Context) {
+	h.reviewPayoutAdmin(context, domain.PayoutStatusRejected)
+}
+
+// MarkPayoutPaidAdmin godoc
+// @Summary   Mark approved payout as transferred
+// @Tags      admin-payouts
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                 true  "payout id"
+// @Param     input  body      dto.PayoutReviewInput  true  "transfer note"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/payouts/{id}/paid [post]
+func (h *Handler) markPayoutPaidAdmin(context *gin.Context) {
+	h.reviewPayoutAdmin(context, domain.PayoutStatusPaid)
+}
+
+func (h *Handler) reviewPayoutAdmin(context *gin.Context, status string) {
+	adminID, err := getIdFromRequestContext(context, "adminID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	payoutID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.PayoutReviewInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	review := h.services.Wallets.ApprovePayout
+	switch status {
+	case domain.PayoutStatusRejected:
+		review = h.services.Wallets.RejectPayout
+	case domain.PayoutStatusPaid:
+		review = h.services.Wallets.MarkPayoutPaid
+	}
+
+	payout, err := review(context.Request.Context(), payoutID, dto.PayoutReviewDTO{
+		AdminID: adminID,
+		Note:    input.Note,
+	})
+	if err != nil {
+		payoutErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, payout)
+}
@@ -0,0 +1,260 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const storeDocumentLinkTTL = 5 * time.Minute
+
+type storeDocumentLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// GetStores godoc
+// @Summary   Get all stores
+// @Tags      admin-stores
+// @Accept    json
+// @Produce   json
+// @Param     verification  query     string  false  "pending, approved or rejected"
+// @Success   200           {array}   domain.Store
+// @Failure   401           {object}  failure
+// @Failure   403           {object}  failure
+// @Failure   422           {object}  failure
+// @Failure   500           {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/stores [get]
+func (h *Handler) getAllStoresAdmin(context *gin.Context) {
+	var input dto.StoreFilterInput
+	_ = context.ShouldBindQuery(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	stores, err := h.services.Stores.FindAll(context.Request.Context(), input)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	storesArray := make([]domain.Store, len(stores))
+	if stores != nil {
+		storesArray = stores
+	}
+
+	successResponse(context, storesArray)
+}
+
+// GetStoreById godoc
+// @Summary   Get store by id with its verification documents
+// @Tags      admin-stores
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "store id"
+// @Success   200  {object}  domain.Store
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/stores/{id} [get]
+func (h *Handler) getStoreByIdAdmin(context *gin.Context) {
+	storeID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	store, err := h.services.Stores.FindByID(context.Request.Context(), storeID)
+	if err != nil {
+		storeVerificationErrorResponse(context, storeID, err)
+		return
+	}
+
+	successResponse(context, store)
+}
+
+// GetStoreDocument godoc
+// @Summary   Get a short-lived link to a store verification document
+// @Tags      admin-stores
+// @Accept    json
+// @Produce   json
+// @Param     id          path      string  true  "store id"
+// @Param     documentID  path      string  true  "document id"
+// @Success   200         {object}  storeDocumentLink
+// @Failure   400         {object}  failure
+// @Failure   401         {object}  failure
+// @Failure   403         {object}  failure
+// @Failure   404         {object}  failure
+// @Failure   500         {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/stores/{id}/documents/{documentID} [get]
+func (h *Handler) getStoreDocumentAdmin(context *gin.Context) {
+	storeID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	documentID, err := getIdFromPath(context, "documentID")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	document, err := h.services.Stores.FindDocument(context.Request.Context(), storeID, documentID)
+	if err != nil {
+		storeVerificationErrorResponse(context, storeID, err)
+		return
+	}
+
+	if document.Key == "" {
+		successResponse(context, storeDocumentLink{URL: document.URL})
+		return
+	}
+
+	url, err := h.storageProvider.PresignPrivate(document.Key, storeDocumentLinkTTL)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, storeDocumentLink{
+		URL:       url,
+		ExpiresAt: time.Now().Add(storeDocumentLinkTTL),
+	})
+}
+
+// ApproveStore godoc
+// @Summary   Approve a pending store
+// @Tags      admin-stores
+// @Accept    json
+// @Produce   json
+// @Param     id      path      string                true   "store id"
+// @Param     review  body      dto.StoreReviewInput  false  "note for the store"
+// @Success   200     {object}  domain.Store
+// @Failure   400     {object}  failure
+// @Failure   401     {object}  failure
+// @Failure   403     {object}  failure
+// @Failure   404     {object}  failure
+// @Failure   409     {object}  failure
+// @Failure   422     {object}  failure
+// @Failure   500     {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/stores/{id}/approve [post]
+func (h *Handler) approveStoreAdmin(context *gin.Context) {
+	h.reviewStoreAdmin(context, domain.VerificationApproved)
+}
+
+// RejectStore godoc
+// @Summary   Reject a pending store
+// @Tags      admin-stores
+// @Accept    json
+// @Produce   json
+// @Param     id      path      string                true  "store id"
+// @Param     review  body      dto.StoreReviewInput  true  "reason shown to the store"
+// @Success   200     {object}  domain.Store
+// @Failure   400     {object}  failure
+// @Failure   401     {object}  failure
+// @Failure   403     {object}  failure
+// @Failure   404     {object}  failure
+// @Failure   409     {object}  failure
+// @Failure   422     {object}  failure
+// @Failure   500     {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/stores/{id}/reject [post]
+func (h *Handler) rejectStoreAdmin(context *gin.Context) {
+	h.reviewStoreAdmin(context, domain.VerificationRejected)
+}
+
+func (h *Handler) reviewStoreAdmin(context *gin.Context, status string) {
+	adminID, err := getIdFromRequestContext(context, "adminID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	storeID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.StoreReviewInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	review := h.services.Stores.Approve
+	if status == domain.VerificationRejected {
+		review = h.services.Stores.Reject
+	}
+
+	store, err := review(context.Request.Context(), storeID, adminID, input.Note)
+	if err != nil {
+		storeVerificationErrorResponse(context, storeID, err)
+		return
+	}
+
+	successResponse(context, store)
+}
+
+// UpdateStoreStatus godoc
+// @Summary   Activate, suspend or ban a store
+// @Tags      admin-stores
+// @Accept    json
+// @Produce   json
+// @Param     id      path      string                 true  "store id"
+// @Param     status  body      dto.UpdateStatusInput  true  "new status and the reason for it"
+// @Success   200     {object}  domain.Store
+// @Failure   400     {object}  failure
+// @Failure   401     {object}  failure
+// @Failure   403     {object}  failure
+// @Failure   404     {object}  failure
+// @Failure   500     {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/stores/{id}/status [put]
+func (h *Handler) updateStoreStatusAdmin(context *gin.Context) {
+	storeID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.UpdateStatusInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	store, err := h.services.Stores.UpdateStatus(context.Request.Context(), storeID, input)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no store with id: %s", storeID.Hex()))
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	successResponse(context, store)
+}
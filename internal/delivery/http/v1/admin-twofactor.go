@@ -0,0 +1,171 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+)
+
+// AdminTwoFactorVerify godoc
+// @Summary  Finish an admin sign-in with a two-factor code
+// @Tags     admin-auth
+// @Accept   json
+// @Produce  json
+// @Param    input  body      dto.TwoFactorVerifyInput  true  "challenge token from sign-in and a TOTP or recovery code"
+// @Success  200    {object}  auth.AuthDetails
+// @Failure  401    {object}  failure
+// @Failure  422    {object}  failure
+// @Failure  500    {object}  failure
+// @Router   /admins/auth/2fa/verify [post]
+func (h *Handler) adminTwoFactorVerify(context *gin.Context) {
+	h.verifyTwoFactor(context, domain.AccountAdmin)
+}
+
+// AdminTwoFactorEnrol godoc
+// @Summary  Start the two-factor enrolment required to sign in
+// @Tags     admin-auth
+// @Accept   json
+// @Produce  json
+// @Param    input  body      dto.TwoFactorChallengeInput  true  "enrolment challenge token from sign-in"
+// @Success  200    {object}  domain.TwoFactorSetup
+// @Failure  400    {object}  failure
+// @Failure  401    {object}  failure
+// @Failure  422    {object}  failure
+// @Failure  500    {object}  failure
+// @Router   /admins/auth/2fa/setup [post]
+func (h *Handler) adminTwoFactorEnrol(context *gin.Context) {
+	var input dto.TwoFactorChallengeInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	setup, err := h.services.TwoFactor.SetupWithChallenge(context.Request.Context(), domain.AccountAdmin, input.ChallengeToken)
+	if err != nil {
+		twoFactorErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, setup)
+}
+
+// AdminTwoFactorSetup godoc
+// @Summary   Start two-factor enrolment
+// @Tags      admin
+// @Accept    json
+// @Produce   json
+// @Success   200  {object}  domain.TwoFactorSetup
+// @Failure   401  {object}  failure
+// @Failure   409  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/2fa/setup [post]
+func (h *Handler) adminTwoFactorSetup(context *gin.Context) {
+	h.setupTwoFactor(context, domain.AccountAdmin)
+}
+
+// AdminTwoFactorConfirm godoc
+// @Summary   Enable two-factor authentication
+// @Tags      admin
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.TwoFactorCodeInput  true  "code from the authenticator app"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   409    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/2fa/confirm [post]
+func (h *Handler) adminTwoFactorConfirm(context *gin.Context) {
+	h.confirmTwoFactor(context, domain.AccountAdmin)
+}
+
+// AdminTwoFactorDisable godoc
+// @Summary   Disable two-factor authentication
+// @Tags      admin
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.TwoFactorCodeInput  true  "TOTP or recovery code"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   403    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/2fa/disable [post]
+func (h *Handler) adminTwoFactorDisable(context *gin.Context) {
+	h.disableTwoFactor(context, domain.AccountAdmin)
+}
+
+// AdminTwoFactorRecoveryCodes godoc
+// @Summary   Replace the two-factor recovery codes
+// @Tags      admin
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.TwoFactorCodeInput  true  "TOTP or recovery code"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/2fa/recovery-codes [post]
+func (h *Handler) adminTwoFactorRecoveryCodes(context *gin.Context) {
+	h.regenerateRecoveryCodes(context, domain.AccountAdmin)
+}
+
+// GetSecuritySettingsAdmin godoc
+// @Summary   Get the security settings
+// @Tags      admin
+// @Accept    json
+// @Produce   json
+// @Success   200  {object}  domain.SecuritySettings
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/settings/security [get]
+func (h *Handler) getSecuritySettingsAdmin(context *gin.Context) {
+	settings, err := h.services.TwoFactor.SecuritySettings(context.Request.Context())
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, settings)
+}
+
+// UpdateSecuritySettingsAdmin godoc
+// @Summary   Update the security settings
+// @Tags      admin
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.SecuritySettingsInput  true  "security settings"
+// @Success   200    {object}  domain.SecuritySettings
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   403    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/settings/security [put]
+func (h *Handler) updateSecuritySettingsAdmin(context *gin.Context) {
+	var input dto.SecuritySettingsInput
+	err := context.ShouldBindJSON(&input)
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	settings, err := h.services.TwoFactor.UpdateSecuritySettings(context.Request.Context(), input)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, settings)
+}
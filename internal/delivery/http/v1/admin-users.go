@@ -1,12 +1,17 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -159,7 +164,7 @@ func (h *Handler) deleteUserAdmin(context *gin.Context) {
 		return
 	}
 
-	err = h.services.Users.Delete(context, userID)
+	err = h.services.Users.Delete(context.Request.Context(), userID)
 	if err != nil {
 		ErrorResponse(context, http.StatusInternalServerError, err.Error())
 		return
@@ -167,3 +172,131 @@ func (h *Handler) deleteUserAdmin(context *gin.Context) {
 
 	context.Status(http.StatusOK)
 }
+
+// UpdateUserStatus godoc
+// @Summary   Activate, suspend or ban a user
+// @Tags      admin-users
+// @Accept    json
+// @Produce   json
+// @Param     id      path      string                 true  "user id"
+// @Param     status  body      dto.UpdateStatusInput  true  "new status and the reason for it"
+// @Success   200     {object}  domain.User
+// @Failure   400     {object}  failure
+// @Failure   401     {object}  failure
+// @Failure   403     {object}  failure
+// @Failure   404     {object}  failure
+// @Failure   500     {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/users/{id}/status [put]
+func (h *Handler) updateUserStatusAdmin(context *gin.Context) {
+	userID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.UpdateStatusInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	user, err := h.services.Users.UpdateStatus(context.Request.Context(), userID, input)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no user with id: %s", userID.Hex()))
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Signed in devices keep working until their tokens expire otherwise,
+	// verifyUser checks the status but the refresh tokens would live on.
+	if !domain.IsActive(user.Status) {
+		err = h.tokenProvider.RevokeAll(auth.Subject("userID", userID.Hex()))
+		if err != nil {
+			log.Errorf("failed to revoke sessions of user %s: %s", userID.Hex(), err)
+		}
+	}
+
+	successResponse(context, user)
+}
+
+// ImpersonateUser godoc
+// @Summary   Get a read-only access token to see the shop as the user
+// @Tags      admin-users
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "user id"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/users/{id}/impersonate [post]
+func (h *Handler) impersonateUserAdmin(context *gin.Context) {
+	adminID, err := getIdFromRequestContext(context, "adminID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	userID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, err = h.services.Users.FindByID(context.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no user with id: %s", userID.Hex()))
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	accessToken, err := h.tokenProvider.IssueReadOnlyToken(auth.Subject("userID", userID.Hex()), jwt.MapClaims{
+		"userID":         userID,
+		"impersonatorID": adminID,
+	})
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.audit(context, domain.AuditLog{
+		Action:     domain.AuditActionImpersonate,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+	})
+
+	successResponse(context, gin.H{"accessToken": accessToken})
+}
+
+// auditImpersonatedRequest keeps a trail of every page support staff looked
+// at with an impersonation token.
+func (h *Handler) auditImpersonatedRequest(context *gin.Context, userID primitive.ObjectID) {
+	adminID, err := getIdFromRequestContext(context, "impersonatorID")
+	if err != nil {
+		log.Errorf("impersonation token for user %s without a valid impersonator", userID.Hex())
+		return
+	}
+
+	h.audit(context, domain.AuditLog{
+		AuditContext: domain.AuditContext{
+			ActorType: domain.AuditActorAdmin,
+			ActorID:   adminID,
+		},
+		Action:     domain.AuditActionImpersonated,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+	})
+}
@@ -4,65 +4,126 @@ import (
 	"errors"
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
-	"github.com/sigit14ap/go-commerce/pkg/auth"
+	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (h *Handler) initAdminsRoutes(api *gin.RouterGroup) {
 	admins := api.Group("/admins")
 	{
-		admins.POST("/auth/sign-in", h.adminSignIn)
-		admins.POST("/auth/refresh", h.adminRefresh)
+		admins.POST("/auth/sign-in", h.limit("admin-sign-in"), h.adminSignIn)
+		admins.POST("/auth/refresh", h.limit("refresh"), h.adminRefresh)
+		admins.POST("/auth/2fa/verify", h.limit("two-factor"), h.adminTwoFactorVerify)
+		admins.POST("/auth/2fa/setup", h.limit("two-factor"), h.adminTwoFactorEnrol)
 
 		authenticated := admins.Group("/", h.verifyAdmin)
 		{
 			categories := authenticated.Group("/categories")
 			{
-				categories.GET("/", h.getAllCategoryAdmin)
-				categories.GET("/:id", h.getCategoryByIdAdmin)
-				categories.POST("/", h.createCategoryAdmin)
-				categories.PUT("/:id", h.updateCategoryAdmin)
-				categories.DELETE("/:id", h.deleteCategoryAdmin)
+				categories.GET("/", h.can(domain.PermissionCatalogRead), h.getAllCategoryAdmin)
+				categories.GET("/:id", h.can(domain.PermissionCatalogRead), h.getCategoryByIdAdmin)
+				categories.POST("/", h.can(domain.PermissionCatalogWrite), h.createCategoryAdmin)
+				categories.PUT("/:id", h.can(domain.PermissionCatalogWrite), h.updateCategoryAdmin)
+				categories.DELETE("/:id", h.can(domain.PermissionCatalogWrite), h.deleteCategoryAdmin)
 			}
 
 			products := authenticated.Group("/products")
 			{
-				products.GET("/", h.getAllProductsAdmin)
-				products.GET("/:id", h.getProductByIdAdmin)
-				products.GET("/:id/reviews", h.getProductReviewsAdmin)
+				products.GET("/", h.can(domain.PermissionCatalogRead), h.getAllProductsAdmin)
+				products.GET("/:id", h.can(domain.PermissionCatalogRead), h.getProductByIdAdmin)
+				products.GET("/:id/reviews", h.can(domain.PermissionReviewsRead), h.getProductReviewsAdmin)
 			}
 
 			reviews := authenticated.Group("/reviews")
 			{
-				reviews.GET("/", h.getAllReviewsAdmin)
-				reviews.GET("/:id", h.getReviewByIdAdmin)
-				reviews.POST("/", h.createReviewAdmin)
-				reviews.DELETE("/:id", h.deleteReviewAdmin)
+				reviews.GET("/", h.can(domain.PermissionReviewsRead), h.getAllReviewsAdmin)
+				reviews.GET("/:id", h.can(domain.PermissionReviewsRead), h.getReviewByIdAdmin)
+				reviews.POST("/", h.can(domain.PermissionReviewsWrite), h.createReviewAdmin)
+				reviews.DELETE("/:id", h.can(domain.PermissionReviewsWrite), h.deleteReviewAdmin)
 			}
 
 			users := authenticated.Group("/users")
 			{
-				users.GET("/", h.getAllUsersAdmin)
-				users.GET("/:id", h.getUserByIdAdmin)
-				users.POST("/", h.createUserAdmin)
-				users.PUT("/:id", h.updateUserAdmin)
-				users.DELETE("/:id", h.deleteUserAdmin)
+				users.GET("/", h.can(domain.PermissionUsersRead), h.getAllUsersAdmin)
+				users.GET("/:id", h.can(domain.PermissionUsersRead), h.getUserByIdAdmin)
+				users.POST("/", h.can(domain.PermissionUsersWrite), h.createUserAdmin)
+				users.PUT("/:id", h.can(domain.PermissionUsersWrite), h.updateUserAdmin)
+				users.PUT("/:id/status", h.can(domain.PermissionUsersWrite), h.updateUserStatusAdmin)
+				users.POST("/:id/impersonate", h.can(domain.PermissionImpersonate), h.impersonateUserAdmin)
+				users.DELETE("/:id", h.can(domain.PermissionUsersWrite), h.deleteUserAdmin)
+			}
+
+			stores := authenticated.Group("/stores")
+			{
+				stores.GET("/", h.can(domain.PermissionStoresRead), h.getAllStoresAdmin)
+				stores.GET("/:id", h.can(domain.PermissionStoresRead), h.getStoreByIdAdmin)
+				stores.GET("/:id/documents/:documentID", h.can(domain.PermissionStoresRead), h.getStoreDocumentAdmin)
+				stores.PUT("/:id/status", h.can(domain.PermissionStoresWrite), h.updateStoreStatusAdmin)
+				stores.POST("/:id/approve", h.can(domain.PermissionStoresWrite), h.approveStoreAdmin)
+				stores.POST("/:id/reject", h.can(domain.PermissionStoresWrite), h.rejectStoreAdmin)
 			}
 
 			cart := authenticated.Group("/carts")
 			{
-				cart.GET("/", h.getAllCartsAdmin)
-				cart.GET("/:id", h.getCartByIdAdmin)
-				cart.DELETE("/:id", h.deleteCartAdmin)
+				cart.GET("/", h.can(domain.PermissionCartsRead), h.getAllCartsAdmin)
+				cart.GET("/:id", h.can(domain.PermissionCartsRead), h.getCartByIdAdmin)
+				cart.DELETE("/:id", h.can(domain.PermissionCartsWrite), h.deleteCartAdmin)
 			}
 
 			orders := authenticated.Group("/orders")
 			{
-				orders.GET("/", h.getAllOrdersAdmin)
-				orders.PUT("/:id", h.updateOrderAdmin)
+				orders.GET("/", h.can(domain.PermissionOrdersRead), h.getAllOrdersAdmin)
+				orders.PUT("/:id", h.can(domain.PermissionOrdersWrite), h.updateOrderAdmin)
+				orders.POST("/:id/cancel", h.can(domain.PermissionOrdersWrite), h.cancelOrderAdmin)
+			}
+
+			authenticated.GET("/taxes", h.can(domain.PermissionTaxesRead), h.getTaxReportAdmin)
+			authenticated.GET("/audit", h.can(domain.PermissionAuditRead), h.getAuditLogAdmin)
+
+			analytics := authenticated.Group("/analytics", h.can(domain.PermissionAnalyticsRead))
+			{
+				analytics.GET("/overview", h.getAnalyticsOverviewAdmin)
+				analytics.GET("/sales", h.getSalesAnalyticsAdmin)
+				analytics.GET("/top-products", h.getTopProductsAdmin)
+				analytics.GET("/top-stores", h.getTopStoresAdmin)
+				analytics.GET("/conversion", h.getCartConversionAdmin)
+				analytics.GET("/new-users", h.getNewUsersAdmin)
+				analytics.GET("/rates", h.getOrderRatesAdmin)
+			}
+
+			payouts := authenticated.Group("/payouts")
+			{
+				payouts.GET("/", h.can(domain.PermissionPayoutsRead), h.getAllPayoutsAdmin)
+				payouts.POST("/:id/approve", h.can(domain.PermissionPayoutsWrite), h.approvePayoutAdmin)
+				payouts.POST("/:id/reject", h.can(domain.PermissionPayoutsWrite), h.rejectPayoutAdmin)
+				payouts.POST("/:id/paid", h.can(domain.PermissionPayoutsWrite), h.markPayoutPaidAdmin)
+			}
+
+			twoFactor := authenticated.Group("/2fa")
+			{
+				twoFactor.POST("/setup", h.adminTwoFactorSetup)
+				twoFactor.POST("/confirm", h.adminTwoFactorConfirm)
+				twoFactor.POST("/disable", h.adminTwoFactorDisable)
+				twoFactor.POST("/recovery-codes", h.adminTwoFactorRecoveryCodes)
+			}
+
+			settings := authenticated.Group("/settings", h.can(domain.PermissionAdminsManage))
+			{
+				settings.GET("/security", h.getSecuritySettingsAdmin)
+				settings.PUT("/security", h.updateSecuritySettingsAdmin)
+			}
+
+			adminAccounts := authenticated.Group("/admins", h.can(domain.PermissionAdminsManage))
+			{
+				adminAccounts.GET("/", h.getAllAdminsAdmin)
+				adminAccounts.GET("/:id", h.getAdminByIdAdmin)
+				adminAccounts.POST("/", h.createAdminAdmin)
+				adminAccounts.PUT("/:id", h.updateAdminAdmin)
+				adminAccounts.PUT("/:id/password", h.resetAdminPasswordAdmin)
+				adminAccounts.DELETE("/:id", h.deleteAdminAdmin)
 			}
 		}
 	}
@@ -74,10 +135,11 @@ func (h *Handler) initAdminsRoutes(api *gin.RouterGroup) {
 // @Accept   json
 // @Produce  json
 // @Param    admin  body      dto.SignInDTO  true  "admin credentials"
-// @Success  200    {object}  auth.AuthDetails
+// @Success  200    {object}  auth.AuthDetails  "tokens, or a domain.TwoFactorChallenge when 2FA is enabled or required"
 // @Failure  400    {object}  failure
 // @Failure  401    {object}  failure
 // @Failure  404    {object}  failure
+// @Failure  429    {object}  failure
 // @Failure  500    {object}  failure
 // @Router   /admins/auth/sign-in [post]
 func (h *Handler) adminSignIn(context *gin.Context) {
@@ -88,10 +150,14 @@ func (h *Handler) adminSignIn(context *gin.Context) {
 		return
 	}
 
+	if h.signInLocked(context, domain.AccountAdmin, signInDTO.Email) {
+		return
+	}
+
 	admin, err := h.services.Admins.FindByCredentials(context, signInDTO)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			ErrorResponse(context, http.StatusUnauthorized, "Email not found")
+			h.signInFailed(context, domain.AccountAdmin, signInDTO.Email, "Email not found")
 		} else {
 			ErrorResponse(context, http.StatusInternalServerError, err.Error())
 		}
@@ -100,20 +166,12 @@ func (h *Handler) adminSignIn(context *gin.Context) {
 
 	matchPassword := h.services.Users.CheckPasswordHash(signInDTO.Password, admin.Password)
 	if matchPassword == false {
-		ErrorResponse(context, http.StatusUnauthorized, "Password does not match")
+		h.signInFailed(context, domain.AccountAdmin, signInDTO.Email, "Password does not match")
 		return
 	}
 
-	adminClaims := jwt.MapClaims{"adminID": admin.ID}
-	authDetails, err := h.tokenProvider.CreateJWTSession(auth.CreateSessionInput{
-		Fingerprint: signInDTO.Fingerprint,
-		Claims:      adminClaims,
-	})
-	if err != nil {
-		ErrorResponse(context, http.StatusUnauthorized, err.Error())
-		return
-	}
-	successResponse(context, authDetails)
+	h.signInSucceeded(context, domain.AccountAdmin, signInDTO.Email)
+	h.signIn(context, domain.AccountRef{Kind: domain.AccountAdmin, ID: admin.ID}, signInDTO.Fingerprint, signInDTO.Device)
 }
 
 // AdminRefresh godoc
@@ -135,3 +193,17 @@ func (h *Handler) adminRefresh(context *gin.Context) {
 func (h *Handler) verifyAdmin(context *gin.Context) {
 	h.verifyToken(context, "adminID")
 }
+
+// can guards an admin route with the permission it needs.
+func (h *Handler) can(permission domain.Permission) gin.HandlerFunc {
+	return h.middlewares.Authorize.Require(permission)
+}
+
+// audit writes an action that no service call covers to the audit log.
+// Failures are only logged, the action has already happened.
+func (h *Handler) audit(context *gin.Context, entry domain.AuditLog) {
+	err := h.services.Audit.Record(context.Request.Context(), entry)
+	if err != nil {
+		log.Errorf("failed to audit %s: %s", entry.Action, err)
+	}
+}
@@ -3,14 +3,30 @@ package v1
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
 	"github.com/sigit14ap/go-commerce/pkg/auth"
+	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// twoFactorSignIn is the response of a passed two-factor challenge. Recovery
+// codes are only included when the challenge enrolled the account.
+type twoFactorSignIn struct {
+	*auth.AuthDetails
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
 func extractAuthToken(context *gin.Context) (string, error) {
 	authHeader := context.GetHeader("Authorization")
 	if authHeader == "" {
@@ -41,6 +57,7 @@ func (h *Handler) refreshToken(context *gin.Context) {
 	authDetails, err := h.tokenProvider.Refresh(auth.RefreshInput{
 		RefreshToken: input.RefreshToken,
 		Fingerprint:  input.Fingerprint,
+		Metadata:     sessionMetadata(context, ""),
 	})
 
 	if err != nil {
@@ -70,7 +87,265 @@ func (h *Handler) verifyToken(context *gin.Context, idName string) {
 		return
 	}
 
+	// Impersonation tokens let support staff look at the shop as the user,
+	// they can never change anything.
+	if readOnly, _ := tokenClaims["readOnly"].(bool); readOnly {
+		switch context.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			ErrorResponse(context, http.StatusForbidden, "impersonation tokens are read-only")
+			return
+		}
+
+		context.Set("impersonatorID", tokenClaims["impersonatorID"])
+	}
+
 	context.Set(idName, id)
+	if sessionID, ok := tokenClaims["sid"].(string); ok {
+		context.Set("sessionID", sessionID)
+	}
+}
+
+// signInLocked answers the sign-in with 429 when the account is locked
+// after too many failed attempts.
+func (h *Handler) signInLocked(context *gin.Context, kind string, email string) bool {
+	lockedFor, err := h.services.Lockout.Locked(context.Request.Context(), kind, email)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return true
+	}
+
+	if lockedFor > 0 {
+		lockedResponse(context, lockedFor)
+		return true
+	}
+
+	return false
+}
+
+// signInFailed counts a failed sign-in towards the account lockout.
+func (h *Handler) signInFailed(context *gin.Context, kind string, email string, message string) {
+	lockedFor, err := h.services.Lockout.Failed(context.Request.Context(), kind, email)
+	if err != nil {
+		log.Errorf("failed to count failed sign-in: %s", err)
+	}
+
+	if lockedFor > 0 {
+		lockedResponse(context, lockedFor)
+		return
+	}
+
+	ErrorResponse(context, http.StatusUnauthorized, message)
+}
+
+func lockedResponse(context *gin.Context, lockedFor time.Duration) {
+	retryAfter := int(math.Ceil(lockedFor.Seconds()))
+	context.Header("Retry-After", strconv.Itoa(retryAfter))
+	ErrorResponse(context, http.StatusTooManyRequests,
+		fmt.Sprintf("too many failed sign-ins, the account is locked for %d seconds", retryAfter))
+}
+
+// signInSucceeded forgets the failed sign-ins once the password matched.
+func (h *Handler) signInSucceeded(context *gin.Context, kind string, email string) {
+	err := h.services.Lockout.Succeeded(context.Request.Context(), kind, email)
+	if err != nil {
+		log.Errorf("failed to reset failed sign-ins: %s", err)
+	}
+}
+
+// signIn finishes a sign-in whose password was accepted. Accounts that use
+// two-factor authentication get a challenge instead of tokens.
+func (h *Handler) signIn(context *gin.Context, account domain.AccountRef, fingerprint string, device string) {
+	if account.Kind == domain.AccountUser {
+		err := h.services.Users.CheckStatus(context.Request.Context(), account.ID)
+		if err != nil {
+			accountStatusErrorResponse(context, err)
+			return
+		}
+	}
+
+	challenge, err := h.services.TwoFactor.Challenge(context.Request.Context(), account, fingerprint, device)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if challenge != nil {
+		successResponse(context, challenge)
+		return
+	}
+
+	authDetails, err := h.createSession(context, account, fingerprint, device)
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	successResponse(context, authDetails)
+}
+
+func (h *Handler) createSession(context *gin.Context, account domain.AccountRef, fingerprint string, device string) (*auth.AuthDetails, error) {
+	return h.tokenProvider.CreateJWTSession(auth.CreateSessionInput{
+		Fingerprint: fingerprint,
+		Subject:     auth.Subject(account.IDName(), account.ID.Hex()),
+		Metadata:    sessionMetadata(context, device),
+		Claims:      jwt.MapClaims{account.IDName(): account.ID},
+	})
+}
+
+func (h *Handler) verifyTwoFactor(context *gin.Context, kind string) {
+	var input dto.TwoFactorVerifyInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	login, err := h.services.TwoFactor.VerifyChallenge(context.Request.Context(), kind, input)
+	if err != nil {
+		twoFactorErrorResponse(context, err)
+		return
+	}
+
+	authDetails, err := h.createSession(context, login.Account, login.Fingerprint, login.Device)
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	successResponse(context, twoFactorSignIn{AuthDetails: authDetails, RecoveryCodes: login.RecoveryCodes})
+}
+
+func (h *Handler) setupTwoFactor(context *gin.Context, kind string) {
+	account, err := accountFromContext(context, kind)
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	setup, err := h.services.TwoFactor.Setup(context.Request.Context(), account)
+	if err != nil {
+		twoFactorErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, setup)
+}
+
+func (h *Handler) confirmTwoFactor(context *gin.Context, kind string) {
+	var input dto.TwoFactorCodeInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	account, err := accountFromContext(context, kind)
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	recoveryCodes, err := h.services.TwoFactor.Confirm(context.Request.Context(), account, input.Code)
+	if err != nil {
+		twoFactorErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, gin.H{"recoveryCodes": recoveryCodes})
+}
+
+func (h *Handler) disableTwoFactor(context *gin.Context, kind string) {
+	var input dto.TwoFactorCodeInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	account, err := accountFromContext(context, kind)
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err = h.services.TwoFactor.Disable(context.Request.Context(), account, input.Code)
+	if err != nil {
+		twoFactorErrorResponse(context, err)
+		return
+	}
+
+	var data interface{}
+	successResponse(context, data)
+}
+
+func (h *Handler) regenerateRecoveryCodes(context *gin.Context, kind string) {
+	var input dto.TwoFactorCodeInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	account, err := accountFromContext(context, kind)
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	recoveryCodes, err := h.services.TwoFactor.RegenerateRecoveryCodes(context.Request.Context(), account, input.Code)
+	if err != nil {
+		twoFactorErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, gin.H{"recoveryCodes": recoveryCodes})
+}
+
+func accountFromContext(context *gin.Context, kind string) (domain.AccountRef, error) {
+	account := domain.AccountRef{Kind: kind}
+
+	id, err := getIdFromRequestContext(context, account.IDName())
+	if err != nil {
+		return domain.AccountRef{}, err
+	}
+
+	account.ID = id
+	return account, nil
+}
+
+func twoFactorErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode), errors.Is(err, service.ErrInvalidChallenge):
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrTwoFactorRequired):
+		ErrorResponse(context, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrTwoFactorEnabled):
+		ErrorResponse(context, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrTwoFactorNotEnabled), errors.Is(err, service.ErrTwoFactorNotStarted),
+		errors.Is(err, service.ErrEnrolmentNotRequired):
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+	case errors.Is(err, mongo.ErrNoDocuments):
+		ErrorResponse(context, http.StatusNotFound, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func sessionMetadata(context *gin.Context, device string) auth.SessionMetadata {
+	return auth.SessionMetadata{
+		Device:    device,
+		IP:        context.ClientIP(),
+		UserAgent: context.Request.UserAgent(),
+	}
 }
 
 func (h *Handler) extractIdFromAuthHeader(context *gin.Context, idName string) (primitive.ObjectID, error) {
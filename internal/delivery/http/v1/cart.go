@@ -6,6 +6,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
 )
@@ -59,6 +61,7 @@ func (h *Handler) getCartItems(context *gin.Context) {
 // @Failure  400       {object}  failure
 // @Failure  401       {object}  failure
 // @Failure  404       {object}  failure
+// @Failure  409       {object}  failure
 // @Failure  500       {object}  failure
 // @Router   /cart [post]
 func (h *Handler) createCartItem(context *gin.Context) {
@@ -88,10 +91,11 @@ func (h *Handler) createCartItem(context *gin.Context) {
 
 	cartItem, err := h.services.Carts.AddCartItem(context, cartData, userID)
 	if err != nil {
-		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		cartItemErrorResponse(context, productID, err)
 		return
 	}
 
+	h.recordCartAdd(context, cartItem.Product)
 	createdResponse(context, cartItem)
 }
 
@@ -107,6 +111,7 @@ func (h *Handler) createCartItem(context *gin.Context) {
 // @Failure  400     {object}  failure
 // @Failure  401     {object}  failure
 // @Failure  404     {object}  failure
+// @Failure  409     {object}  failure
 // @Failure  500     {object}  failure
 // @Router   /cart/{productID} [put]
 func (h *Handler) updateCartItem(context *gin.Context) {
@@ -134,7 +139,7 @@ func (h *Handler) updateCartItem(context *gin.Context) {
 		Quantity:  cartItemInput.Quantity,
 	}, userID)
 	if err != nil {
-		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		cartItemErrorResponse(context, productID, err)
 		return
 	}
 
@@ -291,3 +296,14 @@ func (h *Handler) deleteCartAdmin(context *gin.Context) {
 
 	context.Status(http.StatusOK)
 }
+
+func cartItemErrorResponse(context *gin.Context, productID primitive.ObjectID, err error) {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no products with id: %s", productID.Hex()))
+	case errors.Is(err, service.ErrStoreOnHoliday):
+		ErrorResponse(context, http.StatusConflict, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+)
+
+func (h *Handler) initCheckoutsRoutes(api *gin.RouterGroup) {
+	checkouts := api.Group("/users/checkouts", h.verifyUser)
+	{
+		checkouts.POST("/", h.middlewares.VerifyEmail.Handle, h.createCheckout)
+		checkouts.GET("/:id", h.getCheckout)
+		checkouts.POST("/:id/cancel", h.cancelCheckout)
+	}
+}
+
+// CreateCheckout godoc
+// @Summary   Create one order per store from the cart
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     order  body      dto.CreateOrderDTO  true  "contact info, delivery address and shipping per store"
+// @Success   201    {object}  domain.Checkout
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   403    {object}  failure
+// @Failure   404    {object}  failure
+// @Failure   409    {object}  failure
+// @Failure   422    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/checkouts [post]
+func (h *Handler) createCheckout(context *gin.Context) {
+	checkout, ok := h.checkoutCart(context, false)
+	if !ok {
+		return
+	}
+
+	createdResponse(context, checkout)
+}
+
+// GetCheckout godoc
+// @Summary   Orders of a checkout
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "checkout id"
+// @Success   200  {object}  domain.Checkout
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/checkouts/{id} [get]
+func (h *Handler) getCheckout(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	checkout, err := h.services.Orders.FindCheckout(context.Request.Context(), context.Param("id"), userID)
+	if err != nil {
+		checkoutErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, checkout)
+}
+
+// CancelCheckout godoc
+// @Summary   Cancel every order of a checkout that can still be cancelled
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                true  "checkout id"
+// @Param     input  body      dto.CancelOrderInput  true  "cancel reason"
+// @Success   200    {object}  domain.Checkout
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   404    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/checkouts/{id}/cancel [post]
+func (h *Handler) cancelCheckout(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var input dto.CancelOrderInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	checkout, err := h.services.Orders.CancelCheckout(context.Request.Context(), context.Param("id"), dto.CancelOrderDTO{
+		Actor:   domain.OrderActorUser,
+		ActorID: userID,
+		Reason:  input.Reason,
+		Note:    input.Note,
+	})
+	if err != nil {
+		checkoutErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, checkout)
+}
+
+func checkoutErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCheckoutNotFound):
+		ErrorResponse(context, http.StatusNotFound, err.Error())
+	default:
+		orderErrorResponse(context, err)
+	}
+}
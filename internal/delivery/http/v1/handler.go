@@ -11,9 +11,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/service"
 	"github.com/sigit14ap/go-commerce/pkg/auth"
 	log "github.com/sirupsen/logrus"
+	"github.com/twinj/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -38,14 +40,18 @@ func NewHandler(services *service.Services, tokenProvider auth.TokenProvider, st
 func (h *Handler) Init(api *gin.RouterGroup) {
 	v1 := api.Group("/v1")
 
-	v1.Use(LoggerMiddleware())
+	v1.Use(RequestIDMiddleware(), LoggerMiddleware(), h.limit("api"))
 	{
 		h.initAdminsRoutes(v1)
 		h.initUsersRoutes(v1)
 		h.initProductsRoutes(v1)
+		h.initStorefrontRoutes(v1)
 		h.initCartRoutes(v1)
 		h.initOrdersRoutes(v1)
+		h.initCheckoutsRoutes(v1)
+		h.initUserReturnRoutes(v1)
 		h.initAreasRoutes(v1)
+		h.initPaymentRoutes(v1)
 
 		user := v1.Group("user")
 		{
@@ -66,7 +72,13 @@ func (h *Handler) Init(api *gin.RouterGroup) {
 				storeAuth := store.Group("/", h.middlewares.VerifyStore.Handle)
 				{
 					h.initStoreSettingRoutes(storeAuth)
+					h.initStoreVerificationRoutes(storeAuth)
 					h.initStoreProductRoutes(storeAuth)
+					h.initStoreOrderRoutes(storeAuth)
+					h.initStoreReturnRoutes(storeAuth)
+					h.initStoreWalletRoutes(storeAuth)
+					h.initStoreTaxRoutes(storeAuth)
+					h.initStoreAnalyticsRoutes(storeAuth)
 				}
 
 			}
@@ -74,6 +86,26 @@ func (h *Handler) Init(api *gin.RouterGroup) {
 	}
 }
 
+// RequestIDMiddleware tags the request with the caller's X-Request-ID, or a
+// new one, and starts the audit context services record changes with.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" || len(requestID) > 64 {
+			requestID = uuid.NewV4().String()
+		}
+
+		c.Set("requestID", requestID)
+		c.Header("X-Request-ID", requestID)
+		c.Request = c.Request.WithContext(service.WithAuditContext(c.Request.Context(), domain.AuditContext{
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			IP:        c.ClientIP(),
+			RequestID: requestID,
+		}))
+	}
+}
+
 func LoggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		path := c.Request.URL.Path
@@ -98,6 +130,11 @@ func LoggerMiddleware() gin.HandlerFunc {
 	}
 }
 
+// limit applies the named rate limit policy from config.yml.
+func (h *Handler) limit(policy string) gin.HandlerFunc {
+	return h.middlewares.RateLimit.Limit(policy)
+}
+
 func getIdFromPath(c *gin.Context, paramName string) (primitive.ObjectID, error) {
 	return services.GetIdFromPath(c, paramName)
 }
@@ -1,11 +1,16 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (h *Handler) initOrdersRoutes(api *gin.RouterGroup) {
@@ -13,8 +18,13 @@ func (h *Handler) initOrdersRoutes(api *gin.RouterGroup) {
 	{
 		orders.GET("/delivery-cost", h.getDeliveryCost)
 		orders.GET("/", h.getUserOrders)
-		orders.POST("/", h.createOrder)
-		orders.GET("/payment/:id", h.getOrderPaymentLink)
+		orders.POST("/", h.middlewares.VerifyEmail.Handle, h.createOrder)
+		orders.GET("/payment/:id", h.middlewares.VerifyEmail.Handle, h.getOrderPaymentLink)
+		orders.POST("/:id/cancel", h.cancelOrder)
+		orders.POST("/:id/complete", h.completeOrder)
+		orders.POST("/:id/returns", h.createReturn)
+		orders.GET("/:id/tracking", h.getOrderTracking)
+		orders.GET("/:id/invoice", h.getOrderInvoice)
 	}
 }
 
@@ -23,10 +33,14 @@ func (h *Handler) initOrdersRoutes(api *gin.RouterGroup) {
 // @Tags      user
 // @Accept    json
 // @Produce   json
-// @Success   200  {array}   success
-// @Failure   401    {object}  failure
-// @Failure   404    {object}  failure
-// @Failure   500    {object}  failure
+// @Param     address_id  query     string  true  "address to deliver the cart to"
+// @Success   200         {array}   domain.ShippingQuote
+// @Failure   400         {object}  failure
+// @Failure   401         {object}  failure
+// @Failure   404         {object}  failure
+// @Failure   409         {object}  failure
+// @Failure   422         {object}  failure
+// @Failure   500         {object}  failure
 // @Security  UserAuth
 // @Router    /users/orders/delivery-cost [get]
 func (h *Handler) getDeliveryCost(context *gin.Context) {
@@ -37,7 +51,7 @@ func (h *Handler) getDeliveryCost(context *gin.Context) {
 	}
 
 	var input dto.DeliveryCostInput
-	_ = context.ShouldBindJSON(&input)
+	_ = context.ShouldBindQuery(&input)
 
 	err = validate.Struct(input)
 	if err != nil {
@@ -45,23 +59,15 @@ func (h *Handler) getDeliveryCost(context *gin.Context) {
 		return
 	}
 
-	for _, product := range input.Product {
-		productID, err := getIdFromRequest(product.ProductID)
-
-		if err != nil {
-			ErrorResponse(context, http.StatusBadRequest, err.Error())
-			return
-		}
-
-		_, err = h.services.Carts.FindItem(context, userID, productID)
+	addressID, _ := primitive.ObjectIDFromHex(input.AddressID)
 
-		if err != nil {
-			ErrorResponse(context, http.StatusBadRequest, "Product id "+product.ProductID+" not found in cart")
-			return
-		}
+	quotes, err := h.services.Shipping.Quote(context.Request.Context(), userID, addressID)
+	if err != nil {
+		shippingErrorResponse(context, err)
+		return
 	}
 
-	successResponse(context, input)
+	successResponse(context, quotes)
 }
 
 // GerUserOrders godoc
@@ -92,34 +98,48 @@ func (h *Handler) getUserOrders(context *gin.Context) {
 }
 
 // CreateOrder godoc
-// @Summary   Create order
+// @Summary   Create order from a cart of a single store
 // @Tags      user
 // @Accept    json
 // @Produce   json
-// @Param     order  body      dto.CreateOrderDTO  true  "contact info"
+// @Param     order  body      dto.CreateOrderDTO  true  "contact info, delivery address and shipping per store"
 // @Success   201    {object}  success
 // @Failure   400  {object}  failure
 // @Failure   401    {object}  failure
+// @Failure   403    {object}  failure
 // @Failure   404    {object}  failure
+// @Failure   409    {object}  failure
+// @Failure   422    {object}  failure
 // @Failure   500    {object}  failure
 // @Security  UserAuth
 // @Router    /users/orders [post]
 func (h *Handler) createOrder(context *gin.Context) {
+	checkout, ok := h.checkoutCart(context, true)
+	if !ok {
+		return
+	}
+
+	successResponse(context, checkout.Orders[0])
+}
+
+// checkoutCart turns the cart of the user into orders and clears it, the
+// error response is written when it fails.
+func (h *Handler) checkoutCart(context *gin.Context, singleStore bool) (domain.Checkout, bool) {
 	userID, err := getIdFromRequestContext(context, "userID")
 	if err != nil {
 		ErrorResponse(context, http.StatusUnauthorized, err.Error())
-		return
+		return domain.Checkout{}, false
 	}
 
 	cart, err := h.services.Carts.FindByID(context.Request.Context(), userID)
 	if err != nil {
 		ErrorResponse(context, http.StatusInternalServerError, err.Error())
-		return
+		return domain.Checkout{}, false
 	}
 
 	if len(cart.CartItems) == 0 {
 		ErrorResponse(context, http.StatusBadRequest, "user cart is empty")
-		return
+		return domain.Checkout{}, false
 	}
 
 	orderItems := make([]domain.OrderItem, len(cart.CartItems))
@@ -134,27 +154,36 @@ func (h *Handler) createOrder(context *gin.Context) {
 	err = context.BindJSON(&createOrderDTO)
 	if err != nil {
 		ErrorResponse(context, http.StatusBadRequest, "invalid input body")
-		return
+		return domain.Checkout{}, false
 	}
 
-	order, err := h.services.Orders.Create(context.Request.Context(), dto.CreateOrderDTO{
+	err = validate.Struct(createOrderDTO)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return domain.Checkout{}, false
+	}
+
+	checkout, err := h.services.Orders.Create(context.Request.Context(), dto.CreateOrderDTO{
 		OrderItems:  orderItems,
 		ContactInfo: createOrderDTO.ContactInfo,
 		UserID:      userID,
+		AddressID:   createOrderDTO.AddressID,
+		Shipping:    createOrderDTO.Shipping,
+		SingleStore: singleStore,
 	})
 
 	if err != nil {
-		ErrorResponse(context, http.StatusInternalServerError, err.Error())
-		return
+		shippingErrorResponse(context, err)
+		return domain.Checkout{}, false
 	}
 
-	err = h.services.Carts.ClearCart(context.Request.Context(), cart.ID)
+	err = h.services.Carts.ClearCart(context.Request.Context(), userID)
 	if err != nil {
 		ErrorResponse(context, http.StatusInternalServerError, "cart can't be cleared")
-		return
+		return domain.Checkout{}, false
 	}
 
-	successResponse(context, order)
+	return checkout, true
 }
 
 // PaymentLink godoc
@@ -166,18 +195,36 @@ func (h *Handler) createOrder(context *gin.Context) {
 // @Success   200  {object}  success
 // @Failure   400    {object}  failure
 // @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
 // @Failure   404  {object}  failure
 // @Failure   500  {object}  failure
 // @Security  UserAuth
 // @Router    /users/orders/{id}/payment [get]
 func (h *Handler) getOrderPaymentLink(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	orderID, err := getIdFromPath(context, "id")
 	if err != nil {
 		ErrorResponse(context, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	link, err := h.services.Payment.GetPaymentLink(context.Request.Context(), orderID)
+	order, err := h.services.Orders.FindByID(context.Request.Context(), orderID)
+	if err != nil || order.UserID != userID {
+		ErrorResponse(context, http.StatusNotFound, "order not found")
+		return
+	}
+
+	if order.Status != domain.OrderStatusReserved {
+		ErrorResponse(context, http.StatusBadRequest, "order is not awaiting payment")
+		return
+	}
+
+	link, err := h.services.Payment.GetPaymentLink(context.Request.Context(), order)
 	if err != nil {
 		ErrorResponse(context, http.StatusInternalServerError, err.Error())
 		return
@@ -186,6 +233,177 @@ func (h *Handler) getOrderPaymentLink(context *gin.Context) {
 	successResponse(context, link)
 }
 
+// CancelOrder godoc
+// @Summary   Cancel a single order, other orders of its checkout are left as they are
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                true  "order id"
+// @Param     input  body      dto.CancelOrderInput  true  "cancel reason"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   404    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/orders/{id}/cancel [post]
+func (h *Handler) cancelOrder(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	h.cancelOrderAs(context, domain.OrderActorUser, userID)
+}
+
+func (h *Handler) cancelOrderAs(context *gin.Context, actor string, actorID primitive.ObjectID) {
+	orderID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.CancelOrderInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	order, err := h.services.Orders.Cancel(context.Request.Context(), orderID, dto.CancelOrderDTO{
+		Actor:   actor,
+		ActorID: actorID,
+		Reason:  input.Reason,
+		Note:    input.Note,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, service.ErrOrderNotFound):
+			ErrorResponse(context, http.StatusNotFound, "order not found")
+		case errors.Is(err, service.ErrOrderNotCancellable):
+			ErrorResponse(context, http.StatusBadRequest, err.Error())
+		default:
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	successResponse(context, order)
+}
+
+// CompleteOrder godoc
+// @Summary   Confirm receipt of delivered order
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "order id"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/orders/{id}/complete [post]
+func (h *Handler) completeOrder(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	orderID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	order, err := h.services.Orders.Complete(context.Request.Context(), orderID, domain.OrderActorUser, userID)
+	if err != nil {
+		orderErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, order)
+}
+
+// GetOrderTracking godoc
+// @Summary   Get order shipment tracking
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "order id"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/orders/{id}/tracking [get]
+func (h *Handler) getOrderTracking(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	orderID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tracking, err := h.services.Orders.FindTracking(context.Request.Context(), orderID, userID)
+	if err != nil {
+		orderErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, tracking)
+}
+
+// GetOrderInvoice godoc
+// @Summary   Download order invoice
+// @Tags      user
+// @Accept    json
+// @Produce   application/pdf
+// @Param     id   path      string  true  "order id"
+// @Success   200  {file}    file
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/orders/{id}/invoice [get]
+func (h *Handler) getOrderInvoice(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	h.orderInvoiceAs(context, domain.OrderActorUser, userID)
+}
+
+func (h *Handler) orderInvoiceAs(context *gin.Context, actor string, actorID primitive.ObjectID) {
+	orderID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	invoice, document, err := h.services.Invoices.Invoice(context.Request.Context(), orderID, actor, actorID)
+	if err != nil {
+		orderErrorResponse(context, err)
+		return
+	}
+
+	context.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, service.InvoiceFilename(invoice)))
+	context.Data(http.StatusOK, "application/pdf", document)
+}
+
 // GetOrdersAdmin godoc
 // @Summary   Get all orders
 // @Tags      admin-orders
@@ -249,3 +467,43 @@ func (h *Handler) updateOrderAdmin(context *gin.Context) {
 
 	successResponse(context, order)
 }
+
+// CancelOrderAdmin godoc
+// @Summary   Cancel order
+// @Tags      admin-orders
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                true  "order id"
+// @Param     input  body      dto.CancelOrderInput  true  "cancel reason"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   404    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/orders/{id}/cancel [post]
+func (h *Handler) cancelOrderAdmin(context *gin.Context) {
+	adminID, err := getIdFromRequestContext(context, "adminID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	h.cancelOrderAs(context, domain.OrderActorAdmin, adminID)
+}
+
+// shippingErrorResponse maps the errors of quoting and checking out, both
+// stop when a store is on holiday or cannot ship.
+func shippingErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		ErrorResponse(context, http.StatusNotFound, "address not found")
+	case errors.Is(err, service.ErrStoreOnHoliday), errors.Is(err, service.ErrOrderMultipleStores):
+		ErrorResponse(context, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrShippingUnavailable), errors.Is(err, service.ErrNoShipmentOrigin),
+		errors.Is(err, service.ErrShippingExcluded):
+		ErrorResponse(context, http.StatusUnprocessableEntity, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
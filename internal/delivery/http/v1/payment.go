@@ -1,7 +1,11 @@
 package v1
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/service"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -9,6 +13,44 @@ func (h *Handler) initPaymentRoutes(api *gin.RouterGroup) {
 	api.POST("/payment/webhook", h.webhook)
 }
 
+// PaymentWebhook godoc
+// @Summary   Receive Stripe payment events
+// @Tags      payment
+// @Accept    json
+// @Produce   json
+// @Param     Stripe-Signature  header    string  true  "Stripe webhook signature"
+// @Success   200               {object}  success
+// @Failure   400               {object}  failure
+// @Failure   500               {object}  failure
+// @Router    /payment/webhook [post]
 func (h *Handler) webhook(context *gin.Context) {
-	log.Error("HELLO from webhook")
+	payload, err := context.GetRawData()
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	payment, captured, err := h.services.Payment.CapturedPayment(payload, context.GetHeader("Stripe-Signature"))
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !captured {
+		successResponse(context, "ignored")
+		return
+	}
+
+	order, err := h.services.Orders.Capture(context.Request.Context(), payment)
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrOrderInvalidStatus),
+		errors.Is(err, service.ErrPaymentMismatch):
+		// Retrying does not change the outcome, the event is acknowledged.
+		log.Warnf("payment webhook: payment %s of order %s not applied: %v", payment.PaymentIntentID, payment.OrderID.Hex(), err)
+		successResponse(context, "not applied")
+	case err != nil:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	default:
+		successResponse(context, order.Status)
+	}
 }
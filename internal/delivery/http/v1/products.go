@@ -36,7 +36,7 @@ func (h *Handler) initProductsRoutes(api *gin.RouterGroup) {
 // @Failure  500  {object}  failure
 // @Router   /products [get]
 func (h *Handler) getAllProducts(context *gin.Context) {
-	products, err := h.services.Products.FindAll(context.Request.Context())
+	products, err := h.services.Products.FindListed(context.Request.Context())
 	if err != nil {
 		ErrorResponse(context, http.StatusInternalServerError, err.Error())
 		return
@@ -68,7 +68,7 @@ func (h *Handler) getProductById(context *gin.Context) {
 		ErrorResponse(context, http.StatusBadRequest, err.Error())
 		return
 	}
-	product, err := h.services.Products.FindByID(context.Request.Context(), id)
+	product, err := h.services.Products.FindListedByID(context.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			ErrorResponse(context, http.StatusInternalServerError,
@@ -79,6 +79,7 @@ func (h *Handler) getProductById(context *gin.Context) {
 		return
 	}
 
+	h.recordProductView(context, product)
 	successResponse(context, product)
 }
 
@@ -170,7 +171,18 @@ func (h *Handler) createProductReview(context *gin.Context) {
 // @Security  AdminAuth
 // @Router    /admins/products [get]
 func (h *Handler) getAllProductsAdmin(context *gin.Context) {
-	h.getAllProducts(context)
+	products, err := h.services.Products.FindAll(context.Request.Context())
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	productsArray := make([]domain.Product, len(products))
+	if products != nil {
+		productsArray = products
+	}
+
+	successResponse(context, productsArray)
 }
 
 // GetProductByIdAdmin godoc
@@ -187,7 +199,23 @@ func (h *Handler) getAllProductsAdmin(context *gin.Context) {
 // @Security  AdminAuth
 // @Router    /admins/products/{id} [get]
 func (h *Handler) getProductByIdAdmin(context *gin.Context) {
-	h.getProductById(context)
+	id, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	product, err := h.services.Products.FindByID(context.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no products with id: %s", id.Hex()))
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	successResponse(context, product)
 }
 
 // GetProductReviewsAdmin godoc
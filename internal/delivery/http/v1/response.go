@@ -1,8 +1,12 @@
 package v1
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain"
 )
 
 func successResponse(context *gin.Context, data interface{}) {
@@ -30,3 +34,19 @@ func contains(s []string, str string) bool {
 
 	return false
 }
+
+// requireDefaultCurrency turns away amounts in any currency but the one the
+// marketplace trades in, amounts left out of the input have no currency and
+// pass. It reports whether the input may go on.
+func requireDefaultCurrency(context *gin.Context, amounts ...domain.Money) bool {
+	for _, amount := range amounts {
+		if amount.Currency == domain.DefaultCurrency || (amount.Currency == "" && amount.IsZero()) {
+			continue
+		}
+
+		ErrorResponse(context, http.StatusBadRequest, fmt.Sprintf("amounts must be in %s", domain.DefaultCurrency))
+		return false
+	}
+
+	return true
+}
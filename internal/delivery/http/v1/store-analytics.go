@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	log "github.com/sirupsen/logrus"
+)
+
+func (h *Handler) initStoreAnalyticsRoutes(api *gin.RouterGroup) {
+	analytics := api.Group("/analytics")
+	{
+		analytics.GET("/", h.storeGetAnalytics)
+		analytics.GET("/export", h.storeExportAnalytics)
+	}
+}
+
+// StoreGetAnalytics godoc
+// @Summary   Get the sales, views and cart adds of the store
+// @Tags      store-analytics
+// @Accept    json
+// @Produce   json
+// @Param     period    query     string  false  "day, week or month, defaults to day"
+// @Param     from      query     string  false  "first day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param     to        query     string  false  "last day (YYYY-MM-DD), defaults to today"
+// @Param     timezone  query     string  false  "IANA timezone of the dates, defaults to Asia/Jakarta"
+// @Success   200  {object}  domain.StoreAnalytics
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/analytics [get]
+func (h *Handler) storeGetAnalytics(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	input, ok := bindAnalyticsInput(context)
+	if !ok {
+		return
+	}
+
+	analytics, err := h.services.Analytics.Store(context.Request.Context(), storeID, input)
+	if err != nil {
+		analyticsErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, analytics)
+}
+
+// StoreExportAnalytics godoc
+// @Summary   Export the store analytics as CSV
+// @Tags      store-analytics
+// @Accept    json
+// @Produce   text/csv
+// @Param     report    query     string  false  "series or products, defaults to series"
+// @Param     period    query     string  false  "day, week or month, defaults to day"
+// @Param     from      query     string  false  "first day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param     to        query     string  false  "last day (YYYY-MM-DD), defaults to today"
+// @Param     timezone  query     string  false  "IANA timezone of the dates, defaults to Asia/Jakarta"
+// @Success   200  {file}    file
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/analytics/export [get]
+func (h *Handler) storeExportAnalytics(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	var exportInput dto.StoreAnalyticsExportInput
+	_ = context.ShouldBindQuery(&exportInput)
+
+	if err := validate.Struct(exportInput); err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	input, ok := bindAnalyticsInput(context)
+	if !ok {
+		return
+	}
+
+	analytics, err := h.services.Analytics.Store(context.Request.Context(), storeID, input)
+	if err != nil {
+		analyticsErrorResponse(context, err)
+		return
+	}
+
+	report := exportInput.Report
+	if report == "" {
+		report = "series"
+	}
+
+	var rows [][]string
+	if report == "products" {
+		rows = productPerformanceRows(analytics.Products)
+	} else {
+		rows = storeSeriesRows(analytics.Series)
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err = writer.WriteAll(rows); err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	context.Header("Content-Disposition",
+		fmt.Sprintf("attachment; filename=store-%s-%s.csv", storeID.Hex(), report))
+	context.Data(http.StatusOK, "text/csv", buffer.Bytes())
+}
+
+// recordProductView and recordCartAdd only log failures, the counters must
+// never fail the request they are counted for.
+func (h *Handler) recordProductView(context *gin.Context, product domain.Product) {
+	if err := h.services.Stats.RecordView(context.Request.Context(), product); err != nil {
+		log.Errorf("failed to count view of product %s: %s", product.ID.Hex(), err)
+	}
+}
+
+func (h *Handler) recordCartAdd(context *gin.Context, product domain.Product) {
+	if err := h.services.Stats.RecordCartAdd(context.Request.Context(), product); err != nil {
+		log.Errorf("failed to count cart add of product %s: %s", product.ID.Hex(), err)
+	}
+}
+
+func storeSeriesRows(series []domain.StoreSalesPoint) [][]string {
+	rows := [][]string{{"period", "orders", "units", "revenue", "currency", "views", "cart_adds"}}
+	for _, point := range series {
+		revenue, currency := moneyColumns(point.Revenue)
+		rows = append(rows, []string{
+			point.Period,
+			strconv.FormatInt(point.Orders, 10),
+			strconv.FormatInt(point.Units, 10),
+			revenue,
+			currency,
+			strconv.FormatInt(point.Views, 10),
+			strconv.FormatInt(point.CartAdds, 10),
+		})
+	}
+
+	return rows
+}
+
+func productPerformanceRows(products []domain.ProductPerformance) [][]string {
+	rows := [][]string{{"product_id", "name", "orders", "units", "revenue", "currency", "views", "cart_adds",
+		"view_conversion", "cart_conversion"}}
+	for _, product := range products {
+		revenue, currency := moneyColumns(product.Revenue)
+		rows = append(rows, []string{
+			product.ProductID.Hex(),
+			product.Name,
+			strconv.FormatInt(product.Orders, 10),
+			strconv.FormatInt(product.Units, 10),
+			revenue,
+			currency,
+			strconv.FormatInt(product.Views, 10),
+			strconv.FormatInt(product.CartAdds, 10),
+			strconv.FormatFloat(product.ViewConversion, 'f', 4, 64),
+			strconv.FormatFloat(product.CartConversion, 'f', 4, 64),
+		})
+	}
+
+	return rows
+}
+
+// moneyColumns writes amounts in major units, a period sold in several
+// currencies lists them separated by semicolons.
+func moneyColumns(amounts []domain.Money) (string, string) {
+	values := make([]string, 0, len(amounts))
+	currencies := make([]string, 0, len(amounts))
+	for _, amount := range amounts {
+		values = append(values, amount.Decimal())
+		currencies = append(currencies, string(amount.Currency))
+	}
+
+	return strings.Join(values, ";"), strings.Join(currencies, ";")
+}
@@ -0,0 +1,257 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"net/http"
+)
+
+func (h *Handler) initStoreOrderRoutes(api *gin.RouterGroup) {
+	orders := api.Group("/orders")
+	{
+		orders.GET("/", h.storeGetOrders)
+		orders.GET("/:id", h.storeDetailOrder)
+		orders.GET("/:id/packing", h.storeOrderPacking)
+		orders.GET("/:id/invoice", h.storeOrderInvoice)
+		orders.POST("/:id/accept", h.storeAcceptOrder)
+		orders.POST("/:id/reject", h.storeCancelOrder)
+		orders.POST("/:id/ship", h.storeShipOrder)
+		orders.POST("/:id/cancel", h.storeCancelOrder)
+	}
+}
+
+// StoreGetOrders godoc
+// @Summary   Get all orders store
+// @Tags      store-orders
+// @Accept    json
+// @Produce   json
+// @Param     status  query     string  false  "order status"
+// @Param     from    query     string  false  "created from (YYYY-MM-DD)"
+// @Param     to      query     string  false  "created to (YYYY-MM-DD)"
+// @Success   200  {array}   success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/orders [get]
+func (h *Handler) storeGetOrders(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	var input dto.StoreOrderFilterInput
+	err := context.ShouldBindQuery(&input)
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, "invalid query params")
+		return
+	}
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	orders, err := h.services.Orders.FindByStoreID(context.Request.Context(), storeID, dto.StoreOrderFilter{
+		Status: input.Status,
+		From:   input.From,
+		To:     input.To,
+	})
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, orders)
+}
+
+// StoreDetailOrder godoc
+// @Summary   Get order by id store
+// @Tags      store-orders
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "order id"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/orders/{id} [get]
+func (h *Handler) storeDetailOrder(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	orderID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	order, err := h.services.Orders.FindByStore(context.Request.Context(), orderID, storeID)
+	if err != nil {
+		orderErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, order)
+}
+
+// StoreOrderPacking godoc
+// @Summary   Get order packing data store
+// @Tags      store-orders
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "order id"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/orders/{id}/packing [get]
+func (h *Handler) storeOrderPacking(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	orderID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	slip, err := h.services.Orders.PackingSlip(context.Request.Context(), orderID, storeID)
+	if err != nil {
+		orderErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, slip)
+}
+
+// StoreAcceptOrder godoc
+// @Summary   Accept paid order store
+// @Tags      store-orders
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "order id"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/orders/{id}/accept [post]
+func (h *Handler) storeAcceptOrder(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	orderID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	order, err := h.services.Orders.Accept(context.Request.Context(), orderID, storeID)
+	if err != nil {
+		orderErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, order)
+}
+
+// StoreShipOrder godoc
+// @Summary   Mark order shipped store
+// @Tags      store-orders
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string              true  "order id"
+// @Param     input  body      dto.ShipOrderInput  true  "courier and tracking number"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/orders/{id}/ship [post]
+func (h *Handler) storeShipOrder(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	orderID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.ShipOrderInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	order, err := h.services.Orders.Ship(context.Request.Context(), orderID, storeID, input)
+	if err != nil {
+		orderErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, order)
+}
+
+// StoreCancelOrder godoc
+// @Summary   Reject or cancel the order of this store, orders of other stores from the same checkout go on
+// @Tags      store-orders
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                true  "order id"
+// @Param     input  body      dto.CancelOrderInput  true  "cancel reason"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   404    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  StoreAuth
+// @Router    /store/orders/{id}/cancel [post]
+func (h *Handler) storeCancelOrder(context *gin.Context) {
+	storeID, err := services.GetIdFromRequestContext(context, "storeID")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.cancelOrderAs(context, domain.OrderActorStore, storeID)
+}
+
+// StoreOrderInvoice godoc
+// @Summary   Download order invoice
+// @Tags      store-orders
+// @Accept    json
+// @Produce   application/pdf
+// @Param     id   path      string  true  "order id"
+// @Success   200  {file}    file
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/orders/{id}/invoice [get]
+func (h *Handler) storeOrderInvoice(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	h.orderInvoiceAs(context, domain.OrderActorStore, storeID)
+}
+
+func orderErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		ErrorResponse(context, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrOrderInvalidStatus), errors.Is(err, service.ErrOrderNotCancellable),
+		errors.Is(err, service.ErrOrderDisputed), errors.Is(err, service.ErrInvoiceNotAvailable):
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -24,6 +24,7 @@ func (h *Handler) initStoreProductRoutes(api *gin.RouterGroup) {
 		products.POST("/", h.storeCreateProduct)
 		products.PUT("/:id", h.storeUpdateProduct)
 		products.DELETE("/:id", h.storeDeleteProduct)
+		products.POST("/:id/publish", h.middlewares.VerifyStore.Approved, h.storePublishProduct)
 		products.GET("/:id/reviews", h.getProductReviewsAdmin)
 	}
 }
@@ -126,6 +127,15 @@ func (h *Handler) storeCreateProduct(context *gin.Context) {
 		return
 	}
 
+	if !productInput.Price.IsPositive() {
+		services.ErrorResponse(context, http.StatusBadRequest, "price must be positive")
+		return
+	}
+
+	if !requireDefaultCurrency(context, productInput.Price) {
+		return
+	}
+
 	categoryID, err := primitive.ObjectIDFromHex(productInput.CategoryID)
 	if err != nil {
 		services.ErrorResponse(context, http.StatusInternalServerError, err.Error())
@@ -164,8 +174,11 @@ func (h *Handler) storeCreateProduct(context *gin.Context) {
 
 	productDTO := dto.CreateProductDTO{}
 	copier.Copy(&productDTO, &productInput)
+	productDTO.Stock = *productInput.Stock
 	productDTO.CategoryID = category.ID
 	productDTO.StoreID = store.ID
+	// Stores waiting for verification build their catalogue as drafts.
+	productDTO.Draft = !store.IsApproved()
 
 	productDTO.Images = imagesArray
 	product, err := h.services.Products.Create(context.Request.Context(), productDTO)
@@ -203,6 +216,15 @@ func (h *Handler) storeUpdateProduct(context *gin.Context) {
 		return
 	}
 
+	if !productInput.Price.IsPositive() {
+		ErrorResponse(context, http.StatusBadRequest, "price must be positive")
+		return
+	}
+
+	if !requireDefaultCurrency(context, productInput.Price) {
+		return
+	}
+
 	productID, err := getIdFromPath(context, "id")
 	if err != nil {
 		ErrorResponse(context, http.StatusBadRequest, err.Error())
@@ -248,6 +270,7 @@ func (h *Handler) storeUpdateProduct(context *gin.Context) {
 
 	productDTO := dto.UpdateProductDTO{}
 	copier.Copy(&productDTO, &productInput)
+	productDTO.Stock = productInput.Stock
 	productDTO.CategoryID = category.ID
 	productDTO.StoreID = storeID
 
@@ -255,7 +278,47 @@ func (h *Handler) storeUpdateProduct(context *gin.Context) {
 
 	product, err := h.services.Products.Update(context.Request.Context(), productDTO, productID)
 	if err != nil {
-		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no products with id: %s", productID.Hex()))
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	successResponse(context, product)
+}
+
+// StorePublishProduct godoc
+// @Summary   Publish a draft product store
+// @Tags      store-products
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "product id"
+// @Success   200  {object}  domain.Product
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/products/{id}/publish [post]
+func (h *Handler) storePublishProduct(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	productID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	product, err := h.services.Products.Publish(context.Request.Context(), productID, storeID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no products with id: %s", productID.Hex()))
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -285,9 +348,13 @@ func (h *Handler) storeDeleteProduct(context *gin.Context) {
 		return
 	}
 
-	err = h.services.Products.Delete(context, productID, storeID)
+	err = h.services.Products.Delete(context.Request.Context(), productID, storeID)
 	if err != nil {
-		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no products with id: %s", productID.Hex()))
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
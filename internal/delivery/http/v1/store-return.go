@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"net/http"
+)
+
+func (h *Handler) initStoreReturnRoutes(api *gin.RouterGroup) {
+	returns := api.Group("/returns")
+	{
+		returns.GET("/", h.storeGetReturns)
+		returns.POST("/:id/approve", h.storeApproveReturn)
+		returns.POST("/:id/reject", h.storeRejectReturn)
+		returns.POST("/:id/receive", h.storeReceiveReturn)
+		returns.POST("/:id/refund", h.storeRefundReturn)
+	}
+}
+
+// StoreGetReturns godoc
+// @Summary   Get all return requests store
+// @Tags      store-returns
+// @Accept    json
+// @Produce   json
+// @Success   200  {array}   success
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/returns [get]
+func (h *Handler) storeGetReturns(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	returns, err := h.services.Returns.FindByStoreID(context.Request.Context(), storeID)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, returns)
+}
+
+// StoreApproveReturn godoc
+// @Summary   Approve return request
+// @Tags      store-returns
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                 true  "return request id"
+// @Param     input  body      dto.ReviewReturnInput  true  "note"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/returns/{id}/approve [post]
+func (h *Handler) storeApproveReturn(context *gin.Context) {
+	h.storeReviewReturn(context, true)
+}
+
+// StoreRejectReturn godoc
+// @Summary   Reject return request
+// @Tags      store-returns
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                 true  "return request id"
+// @Param     input  body      dto.ReviewReturnInput  true  "note"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/returns/{id}/reject [post]
+func (h *Handler) storeRejectReturn(context *gin.Context) {
+	h.storeReviewReturn(context, false)
+}
+
+func (h *Handler) storeReviewReturn(context *gin.Context, approve bool) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	returnID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.ReviewReturnInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	review := h.services.Returns.Reject
+	if approve {
+		review = h.services.Returns.Approve
+	}
+
+	returnRequest, err := review(context.Request.Context(), returnID, storeID, input.Note)
+	if err != nil {
+		returnErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, returnRequest)
+}
+
+// StoreReceiveReturn godoc
+// @Summary   Receive returned items and refund buyer
+// @Tags      store-returns
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string                  true  "return request id"
+// @Param     input  body      dto.ReceiveReturnInput  true  "refund amount, 0 for full refund"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/returns/{id}/receive [post]
+func (h *Handler) storeReceiveReturn(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	returnID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.ReceiveReturnInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	if !requireDefaultCurrency(context, input.RefundAmount) {
+		return
+	}
+
+	returnRequest, err := h.services.Returns.Receive(context.Request.Context(), returnID, storeID, input.RefundAmount)
+	if err != nil {
+		returnErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, returnRequest)
+}
+
+// StoreRefundReturn godoc
+// @Summary   Retry the refund of a received return
+// @Tags      store-returns
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "return request id"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/returns/{id}/refund [post]
+func (h *Handler) storeRefundReturn(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	returnID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	returnRequest, err := h.services.Returns.Refund(context.Request.Context(), returnID, storeID)
+	if err != nil {
+		returnErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, returnRequest)
+}
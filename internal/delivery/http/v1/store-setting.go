@@ -1,16 +1,32 @@
 package v1
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
 	"net/http"
+	"path/filepath"
+	"strings"
 )
 
+const maxStoreImageSize = 2 << 20
+
 func (h *Handler) initStoreSettingRoutes(api *gin.RouterGroup) {
 	settings := api.Group("/settings")
 	{
 		settings.POST("/shipment", h.storeSettingShipment)
+		settings.POST("/tax", h.storeSettingTax)
+		settings.POST("/profile", h.storeSettingProfile)
+		settings.POST("/logo", h.storeSettingLogo)
+		settings.POST("/banner", h.storeSettingBanner)
+		settings.POST("/hours", h.storeSettingHours)
+		settings.POST("/holiday", h.storeSettingHoliday)
+		settings.DELETE("/holiday", h.storeEndHoliday)
+		settings.POST("/handling", h.storeSettingHandling)
+		settings.POST("/shipping", h.storeSettingShipping)
 	}
 }
 
@@ -79,3 +95,378 @@ func (h *Handler) storeSettingShipment(context *gin.Context) {
 
 	services.SuccessResponse(context, store)
 }
+
+// StoreSettingTax godoc
+// @Summary   Setting tax pricing store
+// @Tags      store-setting
+// @Accept    json
+// @Produce   json
+// @Param     tax  body      dto.StoreTaxInput  true  "whether product prices include tax"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/settings/tax [post]
+func (h *Handler) storeSettingTax(context *gin.Context) {
+
+	storeID, err := services.GetIdFromRequestContext(context, "storeID")
+
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.StoreTaxInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		services.ErrorValidationResponse(context, err)
+		return
+	}
+
+	store, err := h.services.Stores.UpdateTax(context.Request.Context(), storeID, *input.PricesIncludeTax)
+
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	services.SuccessResponse(context, store)
+}
+
+// StoreSettingProfile godoc
+// @Summary   Setting the storefront name and description
+// @Tags      store-setting
+// @Accept    json
+// @Produce   json
+// @Param     profile  body      dto.StoreProfileInput  true  "profile"
+// @Success   200      {object}  domain.Store
+// @Failure   400      {object}  failure
+// @Failure   401      {object}  failure
+// @Failure   422      {object}  failure
+// @Failure   500      {object}  failure
+// @Security  StoreAuth
+// @Router    /store/settings/profile [post]
+func (h *Handler) storeSettingProfile(context *gin.Context) {
+	storeID, err := services.GetIdFromRequestContext(context, "storeID")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.StoreProfileInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		services.ErrorValidationResponse(context, err)
+		return
+	}
+
+	store, err := h.services.Stores.UpdateProfile(context.Request.Context(), storeID, input)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	services.SuccessResponse(context, store)
+}
+
+// StoreSettingLogo godoc
+// @Summary   Upload the storefront logo
+// @Tags      store-setting
+// @Accept    multipart/form-data
+// @Produce   json
+// @Param     image  formData  file  true  "jpg, jpeg or png image of at most 2 MB"
+// @Success   200    {object}  domain.Store
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  StoreAuth
+// @Router    /store/settings/logo [post]
+func (h *Handler) storeSettingLogo(context *gin.Context) {
+	h.storeSettingImage(context, "Logo")
+}
+
+// StoreSettingBanner godoc
+// @Summary   Upload the storefront banner
+// @Tags      store-setting
+// @Accept    multipart/form-data
+// @Produce   json
+// @Param     image  formData  file  true  "jpg, jpeg or png image of at most 2 MB"
+// @Success   200    {object}  domain.Store
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  StoreAuth
+// @Router    /store/settings/banner [post]
+func (h *Handler) storeSettingBanner(context *gin.Context) {
+	h.storeSettingImage(context, "Banner")
+}
+
+func (h *Handler) storeSettingImage(context *gin.Context, kind string) {
+	storeID, err := services.GetIdFromRequestContext(context, "storeID")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, err := context.FormFile("image")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, "image file is required")
+		return
+	}
+
+	allowedExt := []string{".jpg", ".jpeg", ".png"}
+	if !contains(allowedExt, strings.ToLower(filepath.Ext(file.Filename))) {
+		services.ErrorResponse(context, http.StatusBadRequest, kind+" must be jpg, jpeg or png")
+		return
+	}
+
+	if file.Size > maxStoreImageSize {
+		services.ErrorResponse(context, http.StatusBadRequest, kind+" must not be larger than 2 MB")
+		return
+	}
+
+	imageURL := h.storageProvider.Upload("Store"+kind, file)
+
+	image := dto.StoreImageDTO{Logo: imageURL}
+	if kind == "Banner" {
+		image = dto.StoreImageDTO{Banner: imageURL}
+	}
+
+	store, err := h.services.Stores.UpdateImage(context.Request.Context(), storeID, image)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	services.SuccessResponse(context, store)
+}
+
+// StoreSettingHours godoc
+// @Summary   Setting weekly operating hours store
+// @Tags      store-setting
+// @Accept    json
+// @Produce   json
+// @Param     hours  body      dto.StoreHoursInput  true  "opening times per weekday, 0 is Sunday"
+// @Success   200    {object}  domain.Store
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   422    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  StoreAuth
+// @Router    /store/settings/hours [post]
+func (h *Handler) storeSettingHours(context *gin.Context) {
+	storeID, err := services.GetIdFromRequestContext(context, "storeID")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.StoreHoursInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		services.ErrorValidationResponse(context, err)
+		return
+	}
+
+	store, err := h.services.Stores.UpdateHours(context.Request.Context(), storeID, input)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidOperatingHours) {
+			ErrorResponse(context, http.StatusUnprocessableEntity, err.Error())
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	services.SuccessResponse(context, store)
+}
+
+// StoreSettingHoliday godoc
+// @Summary   Setting holiday mode store
+// @Tags      store-setting
+// @Accept    json
+// @Produce   json
+// @Param     holiday  body      dto.StoreHolidayInput  true  "holiday period and message for buyers"
+// @Success   200      {object}  domain.Store
+// @Failure   400      {object}  failure
+// @Failure   401      {object}  failure
+// @Failure   422      {object}  failure
+// @Failure   500      {object}  failure
+// @Security  StoreAuth
+// @Router    /store/settings/holiday [post]
+func (h *Handler) storeSettingHoliday(context *gin.Context) {
+	storeID, err := services.GetIdFromRequestContext(context, "storeID")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.StoreHolidayInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		services.ErrorValidationResponse(context, err)
+		return
+	}
+
+	store, err := h.services.Stores.UpdateHoliday(context.Request.Context(), storeID, &domain.StoreHoliday{
+		Start:   input.Start,
+		End:     input.End,
+		Message: input.Message,
+	})
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	services.SuccessResponse(context, store)
+}
+
+// StoreEndHoliday godoc
+// @Summary   End holiday mode store
+// @Tags      store-setting
+// @Accept    json
+// @Produce   json
+// @Success   200  {object}  domain.Store
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/settings/holiday [delete]
+func (h *Handler) storeEndHoliday(context *gin.Context) {
+	storeID, err := services.GetIdFromRequestContext(context, "storeID")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	store, err := h.services.Stores.UpdateHoliday(context.Request.Context(), storeID, nil)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	services.SuccessResponse(context, store)
+}
+
+// StoreSettingHandling godoc
+// @Summary   Setting handling time store
+// @Tags      store-setting
+// @Accept    json
+// @Produce   json
+// @Param     handling  body      dto.StoreHandlingInput  true  "days to hand orders over to the courier"
+// @Success   200       {object}  domain.Store
+// @Failure   400       {object}  failure
+// @Failure   401       {object}  failure
+// @Failure   422       {object}  failure
+// @Failure   500       {object}  failure
+// @Security  StoreAuth
+// @Router    /store/settings/handling [post]
+func (h *Handler) storeSettingHandling(context *gin.Context) {
+	storeID, err := services.GetIdFromRequestContext(context, "storeID")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.StoreHandlingInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		services.ErrorValidationResponse(context, err)
+		return
+	}
+
+	store, err := h.services.Stores.UpdateHandling(context.Request.Context(), storeID, *input.HandlingDays)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	services.SuccessResponse(context, store)
+}
+
+// StoreSettingShipping godoc
+// @Summary   Setting couriers, free shipping threshold, handling fee and excluded provinces store
+// @Tags      store-setting
+// @Accept    json
+// @Produce   json
+// @Param     shipping  body      dto.StoreShippingInput  true  "couriers and services, all when empty, and delivery rules"
+// @Success   200       {object}  domain.Store
+// @Failure   400       {object}  failure
+// @Failure   401       {object}  failure
+// @Failure   422       {object}  failure
+// @Failure   500       {object}  failure
+// @Security  StoreAuth
+// @Router    /store/settings/shipping [post]
+func (h *Handler) storeSettingShipping(context *gin.Context) {
+	storeID, err := services.GetIdFromRequestContext(context, "storeID")
+	if err != nil {
+		services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.StoreShippingInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		services.ErrorValidationResponse(context, err)
+		return
+	}
+
+	amounts := []domain.Money{input.HandlingFee}
+	if input.FreeShippingMin != nil {
+		amounts = append(amounts, *input.FreeShippingMin)
+	}
+
+	if !requireDefaultCurrency(context, amounts...) {
+		return
+	}
+
+	rules := domain.ShippingRules{
+		FreeShippingMin: input.FreeShippingMin,
+		HandlingFee:     input.HandlingFee,
+	}
+
+	for _, courier := range input.Couriers {
+		rules.Couriers = append(rules.Couriers, domain.CourierServices{Code: courier.Code, Services: courier.Services})
+	}
+
+	for _, provinceHex := range input.ExcludedProvinces {
+		provinceID, err := services.GetIdFromRequest(provinceHex)
+		if err != nil {
+			services.ErrorResponse(context, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		_, err = h.services.Areas.FindProvince(context.Request.Context(), provinceID)
+		if err != nil {
+			services.ErrorResponse(context, http.StatusBadRequest, "Province not found")
+			return
+		}
+
+		rules.ExcludedProvinces = append(rules.ExcludedProvinces, provinceID)
+	}
+
+	store, err := h.services.Stores.UpdateShipping(context.Request.Context(), storeID, rules)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidShippingRules) {
+			ErrorResponse(context, http.StatusUnprocessableEntity, err.Error())
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	services.SuccessResponse(context, store)
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+)
+
+func (h *Handler) initStoreTaxRoutes(api *gin.RouterGroup) {
+	api.GET("/taxes", h.storeGetTaxReport)
+}
+
+// StoreGetTaxReport godoc
+// @Summary   Get collected tax per period
+// @Tags      store-tax
+// @Accept    json
+// @Produce   json
+// @Param     period  query     string  false  "day or month, defaults to month"
+// @Param     from    query     string  false  "from date (YYYY-MM-DD)"
+// @Param     to      query     string  false  "to date (YYYY-MM-DD)"
+// @Success   200  {array}   success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/taxes [get]
+func (h *Handler) storeGetTaxReport(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	filter, ok := bindTaxReportFilter(context)
+	if !ok {
+		return
+	}
+
+	filter.StoreID = storeID
+	h.taxReport(context, filter)
+}
+
+// GetTaxReportAdmin godoc
+// @Summary   Get collected tax per store and period
+// @Tags      admin-tax
+// @Accept    json
+// @Produce   json
+// @Param     store_id  query     string  false  "store id"
+// @Param     period    query     string  false  "day or month, defaults to month"
+// @Param     from      query     string  false  "from date (YYYY-MM-DD)"
+// @Param     to        query     string  false  "to date (YYYY-MM-DD)"
+// @Success   200  {array}   success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  AdminAuth
+// @Router    /admins/taxes [get]
+func (h *Handler) getTaxReportAdmin(context *gin.Context) {
+	filter, ok := bindTaxReportFilter(context)
+	if !ok {
+		return
+	}
+
+	h.taxReport(context, filter)
+}
+
+func (h *Handler) taxReport(context *gin.Context, filter dto.TaxReportFilter) {
+	report, err := h.services.Taxes.Report(context.Request.Context(), filter)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, report)
+}
+
+func bindTaxReportFilter(context *gin.Context) (dto.TaxReportFilter, bool) {
+	var input dto.TaxReportInput
+	if err := context.ShouldBindQuery(&input); err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return dto.TaxReportFilter{}, false
+	}
+
+	if err := validate.Struct(input); err != nil {
+		errorValidationResponse(context, err)
+		return dto.TaxReportFilter{}, false
+	}
+
+	filter := dto.TaxReportFilter{
+		Period: input.Period,
+		From:   input.From,
+		To:     input.To,
+	}
+
+	if input.StoreID != "" {
+		storeID, err := getIdFromRequest(input.StoreID)
+		if err != nil {
+			ErrorResponse(context, http.StatusBadRequest, err.Error())
+			return dto.TaxReportFilter{}, false
+		}
+		filter.StoreID = storeID
+	}
+
+	return filter, true
+}
@@ -0,0 +1,142 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxStoreDocumentSize = 5 << 20
+
+func (h *Handler) initStoreVerificationRoutes(api *gin.RouterGroup) {
+	verification := api.Group("/verification")
+	{
+		verification.GET("/", h.storeGetVerification)
+		verification.POST("/documents", h.storeUploadDocument)
+	}
+}
+
+// StoreGetVerification godoc
+// @Summary   Get the verification status and documents of the store
+// @Tags      store-verification
+// @Accept    json
+// @Produce   json
+// @Success   200  {object}  domain.StoreVerification
+// @Failure   401  {object}  failure
+// @Failure   403  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/verification [get]
+func (h *Handler) storeGetVerification(context *gin.Context) {
+	storeData, err := services.GetDataFromContext(context, "storeData")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(context, storeData.(domain.Store).Verification)
+}
+
+// StoreUploadDocument godoc
+// @Summary   Upload an identity or business document for verification
+// @Tags      store-verification
+// @Accept    multipart/form-data
+// @Produce   json
+// @Param     type      formData  string  true  "identity or business"
+// @Param     document  formData  file    true  "jpg, jpeg, png or pdf of at most 5 MB"
+// @Success   200       {object}  domain.StoreVerification
+// @Failure   400       {object}  failure
+// @Failure   401       {object}  failure
+// @Failure   409       {object}  failure
+// @Failure   422       {object}  failure
+// @Failure   500       {object}  failure
+// @Security  StoreAuth
+// @Router    /store/verification/documents [post]
+func (h *Handler) storeUploadDocument(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	var input dto.StoreDocumentInput
+	_ = context.ShouldBindWith(&input, binding.FormMultipart)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	file, err := context.FormFile("document")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, "document file is required")
+		return
+	}
+
+	allowedExt := []string{".jpg", ".jpeg", ".png", ".pdf"}
+	if !contains(allowedExt, strings.ToLower(filepath.Ext(file.Filename))) {
+		ErrorResponse(context, http.StatusBadRequest, "Document must be jpg, jpeg, png or pdf")
+		return
+	}
+
+	if file.Size > maxStoreDocumentSize {
+		ErrorResponse(context, http.StatusBadRequest, "Document must not be larger than 5 MB")
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	body, err := readFormFile(file)
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	documentKey, err := h.storageProvider.PutPrivate("StoreDocument", input.Type+ext, body, mime.TypeByExtension(ext))
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	store, err := h.services.Stores.AddDocument(context.Request.Context(), storeID, input.Type, documentKey)
+	if err != nil {
+		storeVerificationErrorResponse(context, storeID, err)
+		return
+	}
+
+	successResponse(context, store.Verification)
+}
+
+func storeVerificationErrorResponse(context *gin.Context, storeID primitive.ObjectID, err error) {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no store with id: %s", storeID.Hex()))
+	case errors.Is(err, service.ErrStoreDocumentNotFound):
+		ErrorResponse(context, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrStoreAlreadyVerified), errors.Is(err, service.ErrStoreNotPending):
+		ErrorResponse(context, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrStoreDocumentsMissing), errors.Is(err, service.ErrStoreNoteRequired):
+		ErrorResponse(context, http.StatusUnprocessableEntity, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func readFormFile(file *multipart.FileHeader) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
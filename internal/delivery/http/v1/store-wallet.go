@@ -0,0 +1,167 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/delivery/http/services"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+)
+
+func (h *Handler) initStoreWalletRoutes(api *gin.RouterGroup) {
+	wallet := api.Group("/wallet")
+	{
+		wallet.GET("/", h.storeGetWallet)
+		wallet.PUT("/bank-account", h.storeUpdateBankAccount)
+		wallet.GET("/payouts", h.storeGetPayouts)
+		wallet.POST("/payouts", h.storeRequestPayout)
+	}
+}
+
+// StoreGetWallet godoc
+// @Summary   Get store wallet balance and statement
+// @Tags      store-wallet
+// @Accept    json
+// @Produce   json
+// @Param     page   query     int  false  "statement page"
+// @Param     limit  query     int  false  "statement page size"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/wallet [get]
+func (h *Handler) storeGetWallet(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	var input dto.WalletInput
+	_ = context.ShouldBindQuery(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	wallet, err := h.services.Wallets.FindWallet(context.Request.Context(), storeID, input.Page, input.Limit)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, wallet)
+}
+
+// StoreUpdateBankAccount godoc
+// @Summary   Register store bank account for payouts
+// @Tags      store-wallet
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.BankAccountInput  true  "bank account"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/wallet/bank-account [put]
+func (h *Handler) storeUpdateBankAccount(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	var input dto.BankAccountInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	store, err := h.services.Stores.UpdateBankAccount(context.Request.Context(), storeID, domain.BankAccount{
+		BankName:      input.BankName,
+		AccountNumber: input.AccountNumber,
+		AccountHolder: input.AccountHolder,
+	})
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, store)
+}
+
+// StoreGetPayouts godoc
+// @Summary   Get store payout requests
+// @Tags      store-wallet
+// @Accept    json
+// @Produce   json
+// @Success   200  {array}   success
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/wallet/payouts [get]
+func (h *Handler) storeGetPayouts(context *gin.Context) {
+	storeID, _ := services.GetIdFromRequestContext(context, "storeID")
+
+	payouts, err := h.services.Wallets.FindPayoutsByStore(context.Request.Context(), storeID)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, payouts)
+}
+
+// StoreRequestPayout godoc
+// @Summary   Request payout to registered bank account
+// @Tags      store-wallet
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.PayoutRequestInput  true  "amount"
+// @Success   201  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  StoreAuth
+// @Router    /store/wallet/payouts [post]
+func (h *Handler) storeRequestPayout(context *gin.Context) {
+	storeData, err := services.GetDataFromContext(context, "storeData")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var input dto.PayoutRequestInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	if !requireDefaultCurrency(context, input.Amount) {
+		return
+	}
+
+	payout, err := h.services.Wallets.RequestPayout(context.Request.Context(), storeData.(domain.Store), input.Amount)
+	if err != nil {
+		payoutErrorResponse(context, err)
+		return
+	}
+
+	createdResponse(context, payout)
+}
+
+func payoutErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPayoutNotFound):
+		ErrorResponse(context, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrPayoutNotAllowed):
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
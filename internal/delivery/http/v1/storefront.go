@@ -0,0 +1,104 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (h *Handler) initStorefrontRoutes(api *gin.RouterGroup) {
+	stores := api.Group("/stores")
+	{
+		stores.GET("/:domain", h.getStorefront)
+		stores.GET("/:domain/products", h.getStorefrontProducts)
+	}
+}
+
+// GetStorefront godoc
+// @Summary  Get the storefront of a store
+// @Tags     stores
+// @Accept   json
+// @Produce  json
+// @Param    domain  path      string  true  "store domain"
+// @Success  200     {object}  domain.Storefront
+// @Failure  404     {object}  failure
+// @Failure  500     {object}  failure
+// @Router   /stores/{domain} [get]
+func (h *Handler) getStorefront(context *gin.Context) {
+	domainStore := context.Param("domain")
+
+	storefront, err := h.services.Stores.Storefront(context.Request.Context(), domainStore)
+	if err != nil {
+		storefrontErrorResponse(context, domainStore, err)
+		return
+	}
+
+	successResponse(context, storefront)
+}
+
+// GetStorefrontProducts godoc
+// @Summary  Get the products of a store
+// @Tags     stores
+// @Accept   json
+// @Produce  json
+// @Param    domain       path      string  true   "store domain"
+// @Param    q            query     string  false  "text the product name contains"
+// @Param    category_id  query     string  false  "category id"
+// @Param    min_price    query     int     false  "lowest price in minor units"
+// @Param    max_price    query     int     false  "highest price in minor units"
+// @Param    sort         query     string  false  "newest, price_asc, price_desc or name, defaults to newest"
+// @Param    page         query     int     false  "page, defaults to 1"
+// @Param    limit        query     int     false  "products per page, defaults to 20"
+// @Success  200          {object}  domain.ProductPage
+// @Failure  400          {object}  failure
+// @Failure  404          {object}  failure
+// @Failure  422          {object}  failure
+// @Failure  500          {object}  failure
+// @Router   /stores/{domain}/products [get]
+func (h *Handler) getStorefrontProducts(context *gin.Context) {
+	domainStore := context.Param("domain")
+
+	var input dto.ProductFilterInput
+	err := context.ShouldBindQuery(&input)
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	if input.MinPrice > 0 && input.MaxPrice > 0 && input.MinPrice > input.MaxPrice {
+		ErrorResponse(context, http.StatusBadRequest, "min_price must not be higher than max_price")
+		return
+	}
+
+	store, err := h.services.Stores.FindListedByDomain(context.Request.Context(), domainStore)
+	if err != nil {
+		storefrontErrorResponse(context, domainStore, err)
+		return
+	}
+
+	page, err := h.services.Products.FindStorePage(context.Request.Context(), store.ID, input)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, page)
+}
+
+func storefrontErrorResponse(context *gin.Context, domainStore string, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		ErrorResponse(context, http.StatusNotFound, fmt.Sprintf("no store with domain: %s", domainStore))
+	} else {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,326 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxAvatarSize = 2 << 20
+
+// UpdateUserAccount godoc
+// @Summary   Update the user profile
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.UpdateProfileInput  true  "profile"
+// @Success   200    {object}  domain.User
+// @Failure   401    {object}  failure
+// @Failure   422    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/account [put]
+func (h *Handler) updateUserAccount(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var input dto.UpdateProfileInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	user, err := h.services.Account.UpdateProfile(context.Request.Context(), userID, input)
+	if err != nil {
+		accountErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, user)
+}
+
+// UpdateUserAvatar godoc
+// @Summary   Upload the user avatar
+// @Tags      user
+// @Accept    multipart/form-data
+// @Produce   json
+// @Param     avatar  formData  file  true  "jpg, jpeg or png image of at most 2 MB"
+// @Success   200     {object}  domain.User
+// @Failure   400     {object}  failure
+// @Failure   401     {object}  failure
+// @Failure   500     {object}  failure
+// @Security  UserAuth
+// @Router    /users/account/avatar [put]
+func (h *Handler) updateUserAvatar(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	file, err := context.FormFile("avatar")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, "avatar file is required")
+		return
+	}
+
+	allowedExt := []string{".jpg", ".jpeg", ".png"}
+	if !contains(allowedExt, strings.ToLower(filepath.Ext(file.Filename))) {
+		ErrorResponse(context, http.StatusBadRequest, "Avatar must be jpg, jpeg or png")
+		return
+	}
+
+	if file.Size > maxAvatarSize {
+		ErrorResponse(context, http.StatusBadRequest, "Avatar must not be larger than 2 MB")
+		return
+	}
+
+	avatarURL := h.storageProvider.Upload("Avatar", file)
+
+	user, err := h.services.Account.UpdateAvatar(context.Request.Context(), userID, avatarURL)
+	if err != nil {
+		accountErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, user)
+}
+
+// ChangeUserEmail godoc
+// @Summary   Change the user email address
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.ChangeEmailInput  true  "new email and current password"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   409    {object}  failure
+// @Failure   422    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/account/email [post]
+func (h *Handler) changeUserEmail(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var input dto.ChangeEmailInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	err = h.services.Account.RequestEmailChange(context.Request.Context(), userID, input)
+	if err != nil {
+		accountErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, gin.H{"message": "A confirmation link has been sent to the new email address"})
+}
+
+// UserConfirmEmail godoc
+// @Summary  Confirm a change of email address
+// @Tags     user-auth
+// @Accept   json
+// @Produce  json
+// @Param    input  body      dto.VerifyEmailInput  true  "token from the confirmation email"
+// @Success  200    {object}  success
+// @Failure  400    {object}  failure
+// @Failure  409    {object}  failure
+// @Failure  422    {object}  failure
+// @Failure  500    {object}  failure
+// @Router   /users/auth/confirm-email [post]
+func (h *Handler) userConfirmEmail(context *gin.Context) {
+	var input dto.VerifyEmailInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	_, err = h.services.Account.ConfirmEmailChange(context.Request.Context(), input.Token)
+	if err != nil {
+		accountErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, gin.H{"emailChanged": true})
+}
+
+// ChangeUserPassword godoc
+// @Summary   Change the user password
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.ChangePasswordInput  true  "current and new password"
+// @Success   200    {object}  success
+// @Failure   401    {object}  failure
+// @Failure   403    {object}  failure
+// @Failure   422    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/account/password [put]
+func (h *Handler) changeUserPassword(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var input dto.ChangePasswordInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	err = h.services.Account.ChangePassword(context.Request.Context(), userID, input)
+	if err != nil {
+		accountErrorResponse(context, err)
+		return
+	}
+
+	subject := auth.Subject("userID", userID.Hex())
+	sessions, err := h.tokenProvider.Sessions(subject)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	currentSessionID := context.GetString("sessionID")
+	for _, session := range sessions {
+		if session.ID == currentSessionID {
+			continue
+		}
+
+		err = h.tokenProvider.RevokeSession(subject, session.ID)
+		if err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	successResponse(context, gin.H{"passwordChanged": true})
+}
+
+// ExportUserAccount godoc
+// @Summary   Export the user data
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Success   200  {object}  domain.AccountExport
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/account/export [post]
+func (h *Handler) exportUserAccount(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	export, err := h.services.Account.Export(context.Request.Context(), userID)
+	if err != nil {
+		accountErrorResponse(context, err)
+		return
+	}
+
+	archive, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	context.Header("Content-Disposition",
+		fmt.Sprintf("attachment; filename=account-%s-%s.json", userID.Hex(), export.ExportedAt.Format("20060102")))
+	context.Data(http.StatusOK, "application/json", archive)
+}
+
+// DeleteUserAccount godoc
+// @Summary   Delete the user account
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.DeleteAccountInput  true  "current password"
+// @Success   200    {object}  success
+// @Failure   401    {object}  failure
+// @Failure   403    {object}  failure
+// @Failure   409    {object}  failure
+// @Failure   422    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/account [delete]
+func (h *Handler) deleteUserAccount(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var input dto.DeleteAccountInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	err = h.services.Account.Delete(context.Request.Context(), userID, input.Password)
+	if err != nil {
+		accountErrorResponse(context, err)
+		return
+	}
+
+	err = h.tokenProvider.RevokeAll(auth.Subject("userID", userID.Hex()))
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data interface{}
+	successResponse(context, data)
+}
+
+func accountErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWrongPassword):
+		ErrorResponse(context, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrEmailUnchanged):
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrEmailTaken), errors.Is(err, service.ErrAccountHasStore),
+		errors.Is(err, service.ErrAccountHasOpenOrders):
+		ErrorResponse(context, http.StatusConflict, err.Error())
+	case errors.Is(err, mongo.ErrNoDocuments):
+		ErrorResponse(context, http.StatusNotFound, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
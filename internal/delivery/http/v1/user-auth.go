@@ -6,11 +6,13 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"net/http"
+	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
 	"github.com/sigit14ap/go-commerce/pkg/auth"
+	"github.com/sigit14ap/go-commerce/pkg/oidc"
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -18,9 +20,19 @@ import (
 func (h *Handler) initUserAuthRoutes(api *gin.RouterGroup) {
 	auth := api.Group("/auth")
 	{
-		auth.POST("/sign-in", h.userSignIn)
-		auth.POST("/sign-up", h.userSignUp)
-		auth.POST("/refresh", h.userRefresh)
+		auth.POST("/sign-in", h.limit("sign-in"), h.userSignIn)
+		auth.POST("/sign-up", h.limit("sign-up"), h.userSignUp)
+		auth.POST("/refresh", h.limit("refresh"), h.userRefresh)
+		auth.POST("/verify-email", h.limit("account-email"), h.userVerifyEmail)
+		auth.POST("/resend-verification", h.limit("account-email"), h.userResendVerification)
+		auth.POST("/forgot-password", h.limit("account-email"), h.userForgotPassword)
+		auth.POST("/reset-password", h.limit("account-email"), h.userResetPassword)
+		auth.POST("/confirm-email", h.limit("account-email"), h.userConfirmEmail)
+		auth.POST("/logout", h.verifyUser, h.userLogout)
+		auth.POST("/logout-all", h.verifyUser, h.userLogoutAll)
+		auth.GET("/oidc/authorize", h.limit("oidc"), h.userOIDCAuthorize)
+		auth.POST("/oidc/callback", h.limit("oidc"), h.userOIDCCallback)
+		auth.POST("/2fa/verify", h.limit("two-factor"), h.userTwoFactorVerify)
 	}
 }
 
@@ -30,10 +42,11 @@ func (h *Handler) initUserAuthRoutes(api *gin.RouterGroup) {
 // @Accept   json
 // @Produce  json
 // @Param    user  body      dto.SignInDTO  true  "user credentials"
-// @Success  200   {object}  auth.AuthDetails
+// @Success  200   {object}  auth.AuthDetails  "tokens, or a domain.TwoFactorChallenge when 2FA is enabled"
 // @Failure  400   {object}  failure
 // @Failure  401   {object}  failure
 // @Failure  404   {object}  failure
+// @Failure  429   {object}  failure
 // @Failure  500   {object}  failure
 // @Router   /users/auth/sign-in [post]
 func (h *Handler) userSignIn(context *gin.Context) {
@@ -45,12 +58,16 @@ func (h *Handler) userSignIn(context *gin.Context) {
 		return
 	}
 
+	if h.signInLocked(context, domain.AccountUser, signInDTO.Email) {
+		return
+	}
+
 	user, err := h.services.Users.FindByCredentials(context, signInDTO)
 
 	log.Error(h.services.Users.CheckPasswordHash(signInDTO.Password, user.Password))
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			ErrorResponse(context, http.StatusUnauthorized, "Email not found")
+			h.signInFailed(context, domain.AccountUser, signInDTO.Email, "Email not found")
 		} else {
 			ErrorResponse(context, http.StatusInternalServerError, err.Error())
 		}
@@ -59,21 +76,12 @@ func (h *Handler) userSignIn(context *gin.Context) {
 
 	matchPassword := h.services.Users.CheckPasswordHash(signInDTO.Password, user.Password)
 	if matchPassword == false {
-		ErrorResponse(context, http.StatusUnauthorized, "Password does not match")
+		h.signInFailed(context, domain.AccountUser, signInDTO.Email, "Password does not match")
 		return
 	}
 
-	userClaims := jwt.MapClaims{"userID": user.ID}
-	authDetails, err := h.tokenProvider.CreateJWTSession(auth.CreateSessionInput{
-		Fingerprint: signInDTO.Fingerprint,
-		Claims:      userClaims,
-	})
-
-	if err != nil {
-		ErrorResponse(context, http.StatusUnauthorized, err.Error())
-		return
-	}
-	successResponse(context, authDetails)
+	h.signInSucceeded(context, domain.AccountUser, signInDTO.Email)
+	h.signIn(context, domain.AccountRef{Kind: domain.AccountUser, ID: user.ID}, signInDTO.Fingerprint, signInDTO.Device)
 }
 
 // UserSignUp godoc
@@ -139,6 +147,327 @@ func (h *Handler) userRefresh(context *gin.Context) {
 	h.refreshToken(context)
 }
 
+// UserVerifyEmail godoc
+// @Summary  Verify user email address
+// @Tags     user-auth
+// @Accept   json
+// @Produce  json
+// @Param    input  body      dto.VerifyEmailInput  true  "token from the verification email"
+// @Success  200    {object}  success
+// @Failure  400    {object}  failure
+// @Failure  422    {object}  failure
+// @Failure  500    {object}  failure
+// @Router   /users/auth/verify-email [post]
+func (h *Handler) userVerifyEmail(context *gin.Context) {
+	var input dto.VerifyEmailInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	err = h.services.Users.VerifyEmail(context.Request.Context(), input.Token)
+	if err != nil {
+		accountTokenErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, gin.H{"emailVerified": true})
+}
+
+// UserResendVerification godoc
+// @Summary  Resend the email verification link
+// @Tags     user-auth
+// @Accept   json
+// @Produce  json
+// @Param    input  body      dto.EmailInput  true  "email"
+// @Success  200    {object}  success
+// @Failure  422    {object}  failure
+// @Failure  500    {object}  failure
+// @Router   /users/auth/resend-verification [post]
+func (h *Handler) userResendVerification(context *gin.Context) {
+	var input dto.EmailInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	err = h.services.Users.ResendVerification(context.Request.Context(), input.Email)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, gin.H{"message": "If the account exists and is not verified, a verification email has been sent"})
+}
+
+// UserForgotPassword godoc
+// @Summary  Request a password reset link
+// @Tags     user-auth
+// @Accept   json
+// @Produce  json
+// @Param    input  body      dto.EmailInput  true  "email"
+// @Success  200    {object}  success
+// @Failure  422    {object}  failure
+// @Failure  500    {object}  failure
+// @Router   /users/auth/forgot-password [post]
+func (h *Handler) userForgotPassword(context *gin.Context) {
+	var input dto.EmailInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	err = h.services.Users.ForgotPassword(context.Request.Context(), input.Email)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, gin.H{"message": "If the account exists, a password reset email has been sent"})
+}
+
+// UserResetPassword godoc
+// @Summary  Reset user password
+// @Tags     user-auth
+// @Accept   json
+// @Produce  json
+// @Param    input  body      dto.ResetPasswordInput  true  "token from the reset email and new password"
+// @Success  200    {object}  success
+// @Failure  400    {object}  failure
+// @Failure  422    {object}  failure
+// @Failure  500    {object}  failure
+// @Router   /users/auth/reset-password [post]
+func (h *Handler) userResetPassword(context *gin.Context) {
+	var input dto.ResetPasswordInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	userID, err := h.services.Users.ResetPassword(context.Request.Context(), input.Token, input.Password)
+	if err != nil {
+		accountTokenErrorResponse(context, err)
+		return
+	}
+
+	err = h.tokenProvider.RevokeAll(auth.Subject("userID", userID.Hex()))
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, gin.H{"passwordReset": true})
+}
+
+// UserLogout godoc
+// @Summary   Log out the current session
+// @Tags      user-auth
+// @Accept    json
+// @Produce   json
+// @Success   200  {object}  success
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/auth/logout [post]
+func (h *Handler) userLogout(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	sessionID := context.GetString("sessionID")
+	if sessionID == "" {
+		ErrorResponse(context, http.StatusUnauthorized, "token has no session, sign in again")
+		return
+	}
+
+	err = h.tokenProvider.RevokeSession(auth.Subject("userID", userID.Hex()), sessionID)
+	if err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data interface{}
+	successResponse(context, data)
+}
+
+// UserLogoutAll godoc
+// @Summary   Log out every session of the user
+// @Tags      user-auth
+// @Accept    json
+// @Produce   json
+// @Success   200  {object}  success
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/auth/logout-all [post]
+func (h *Handler) userLogoutAll(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err = h.tokenProvider.RevokeAll(auth.Subject("userID", userID.Hex()))
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data interface{}
+	successResponse(context, data)
+}
+
+// UserOIDCAuthorize godoc
+// @Summary  Start a login with the OpenID Connect provider
+// @Tags     user-auth
+// @Accept   json
+// @Produce  json
+// @Success  200  {object}  success
+// @Failure  404  {object}  failure
+// @Failure  500  {object}  failure
+// @Router   /users/auth/oidc/authorize [get]
+func (h *Handler) userOIDCAuthorize(context *gin.Context) {
+	authorizationURL, state, err := h.services.OIDC.Authorize(context.Request.Context())
+	if err != nil {
+		oidcErrorResponse(context, err)
+		return
+	}
+
+	setOIDCStateCookie(context, state, oidcStateCookieAge)
+	successResponse(context, gin.H{"authorizationURL": authorizationURL})
+}
+
+// UserOIDCCallback godoc
+// @Summary  Finish a login with the OpenID Connect provider
+// @Tags     user-auth
+// @Accept   json
+// @Produce  json
+// @Param    input  body      dto.OIDCCallbackInput  true  "code and state returned by the provider"
+// @Success  200    {object}  auth.AuthDetails
+// @Failure  400    {object}  failure
+// @Failure  401    {object}  failure
+// @Failure  403    {object}  failure
+// @Failure  409    {object}  failure
+// @Failure  500    {object}  failure
+// @Router   /users/auth/oidc/callback [post]
+func (h *Handler) userOIDCCallback(context *gin.Context) {
+	var input dto.OIDCCallbackInput
+	_ = context.ShouldBindJSON(&input)
+
+	err := validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	browserState, _ := context.Cookie(oidcStateCookie)
+	user, err := h.services.OIDC.Login(context.Request.Context(), input.State, browserState, input.Code)
+	if err != nil {
+		oidcErrorResponse(context, err)
+		return
+	}
+
+	setOIDCStateCookie(context, "", -1)
+
+	h.signIn(context, domain.AccountRef{Kind: domain.AccountUser, ID: user.ID}, input.Fingerprint, input.Device)
+}
+
+const (
+	oidcStateCookie = "oidc_state"
+	// oidcStateCookieAge bounds the cookie, the state itself expires in Redis
+	// after oidc.stateMinutes.
+	oidcStateCookieAge = 60 * 60
+)
+
+// setOIDCStateCookie keeps the state of a login in the browser that started
+// it, only for the OIDC routes. A negative maxAge removes it.
+func setOIDCStateCookie(context *gin.Context, state string, maxAge int) {
+	path := context.FullPath()
+	path = path[:strings.LastIndex(path, "/")]
+	secure := context.Request.TLS != nil || context.GetHeader("X-Forwarded-Proto") == "https"
+
+	context.SetSameSite(http.SameSiteLaxMode)
+	context.SetCookie(oidcStateCookie, state, maxAge, path, "", secure, true)
+}
+
+func oidcErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, oidc.ErrNotConfigured):
+		ErrorResponse(context, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrOIDCState):
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+	case errors.Is(err, oidc.ErrInvalidIDToken), errors.Is(err, oidc.ErrTokenExchange):
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrOIDCEmailNotVerified):
+		ErrorResponse(context, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrOIDCAccountNotVerified):
+		ErrorResponse(context, http.StatusConflict, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func accountTokenErrorResponse(context *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidToken) {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ErrorResponse(context, http.StatusInternalServerError, err.Error())
+}
+
+// verifyUser also turns away suspended and banned users, their status is read
+// on every request so that it applies to tokens issued before it changed.
 func (h *Handler) verifyUser(context *gin.Context) {
+	// The store routes sit behind verifyUser twice, the second run has
+	// nothing left to check.
+	if _, verified := context.Get("userID"); verified {
+		return
+	}
+
 	h.verifyToken(context, "userID")
+	if context.IsAborted() {
+		return
+	}
+
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err = h.services.Users.CheckStatus(context.Request.Context(), userID)
+	if err != nil {
+		accountStatusErrorResponse(context, err)
+		return
+	}
+
+	if _, impersonated := context.Get("impersonatorID"); impersonated {
+		h.auditImpersonatedRequest(context, userID)
+	}
+}
+
+func accountStatusErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAccountSuspended), errors.Is(err, service.ErrAccountBanned):
+		ErrorResponse(context, http.StatusForbidden, err.Error())
+	case errors.Is(err, mongo.ErrNoDocuments):
+		ErrorResponse(context, http.StatusUnauthorized, "account no longer exists")
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
 }
@@ -0,0 +1,200 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	maxReturnPhotos    = 5
+	maxReturnPhotoSize = 2 << 20
+)
+
+func (h *Handler) initUserReturnRoutes(api *gin.RouterGroup) {
+	returns := api.Group("/users/returns", h.verifyUser)
+	{
+		returns.GET("/", h.getUserReturns)
+		returns.POST("/:id/ship", h.shipReturn)
+	}
+}
+
+// GetUserReturns godoc
+// @Summary   User return request list
+// @Tags      user-returns
+// @Accept    json
+// @Produce   json
+// @Success   200  {array}   success
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/returns [get]
+func (h *Handler) getUserReturns(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	returns, err := h.services.Returns.FindByUserID(context.Request.Context(), userID)
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(context, returns)
+}
+
+// CreateReturn godoc
+// @Summary   Open return request for a delivered order item
+// @Tags      user-returns
+// @Accept    multipart/form-data
+// @Produce   json
+// @Param     id          path      string  true  "order id"
+// @Param     product_id  formData  string  true  "product id"
+// @Param     quantity    formData  int     true  "quantity to return"
+// @Param     reason      formData  string  true  "reason"
+// @Param     photos[]    formData  file    false "photos, at most 5 jpg, jpeg or png of 2 MB each"
+// @Success   201  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/orders/{id}/returns [post]
+func (h *Handler) createReturn(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	orderID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.CreateReturnInput
+	err = context.ShouldBindWith(&input, binding.FormMultipart)
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	productID, err := getIdFromRequest(input.ProductID)
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	form, _ := context.MultipartForm()
+	files := form.File["photos[]"]
+
+	if len(files) > maxReturnPhotos {
+		ErrorResponse(context, http.StatusBadRequest, "At most 5 photos can be attached")
+		return
+	}
+
+	allowedExt := []string{".jpg", ".jpeg", ".png"}
+	for _, file := range files {
+		if !contains(allowedExt, strings.ToLower(filepath.Ext(file.Filename))) {
+			ErrorResponse(context, http.StatusBadRequest, "Photo must be jpg, jpeg or png")
+			return
+		}
+
+		if file.Size > maxReturnPhotoSize {
+			ErrorResponse(context, http.StatusBadRequest, "Photo must not be larger than 2 MB")
+			return
+		}
+	}
+
+	var photos []string
+
+	for _, file := range files {
+		uploadedFile := h.storageProvider.Upload("Return", file)
+		photos = append(photos, uploadedFile)
+	}
+
+	returnRequest, err := h.services.Returns.Create(context.Request.Context(), dto.CreateReturnDTO{
+		OrderID:   orderID,
+		UserID:    userID,
+		ProductID: productID,
+		Quantity:  input.Quantity,
+		Reason:    input.Reason,
+		Photos:    photos,
+	})
+	if err != nil {
+		returnErrorResponse(context, err)
+		return
+	}
+
+	createdResponse(context, returnRequest)
+}
+
+// ShipReturn godoc
+// @Summary   Submit return shipment
+// @Tags      user-returns
+// @Accept    json
+// @Produce   json
+// @Param     id     path      string               true  "return request id"
+// @Param     input  body      dto.ShipReturnInput  true  "return shipment"
+// @Success   200  {object}  success
+// @Failure   400  {object}  failure
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/returns/{id}/ship [post]
+func (h *Handler) shipReturn(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	returnID, err := getIdFromPath(context, "id")
+	if err != nil {
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.ShipReturnInput
+	_ = context.ShouldBindJSON(&input)
+
+	err = validate.Struct(input)
+	if err != nil {
+		errorValidationResponse(context, err)
+		return
+	}
+
+	returnRequest, err := h.services.Returns.Ship(context.Request.Context(), returnID, userID, input)
+	if err != nil {
+		returnErrorResponse(context, err)
+		return
+	}
+
+	successResponse(context, returnRequest)
+}
+
+func returnErrorResponse(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrReturnNotFound), errors.Is(err, service.ErrOrderNotFound):
+		ErrorResponse(context, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrReturnNotAllowed):
+		ErrorResponse(context, http.StatusBadRequest, err.Error())
+	default:
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+	}
+}
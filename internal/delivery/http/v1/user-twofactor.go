@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+)
+
+// UserTwoFactorVerify godoc
+// @Summary  Finish a user sign-in with a two-factor code
+// @Tags     user-auth
+// @Accept   json
+// @Produce  json
+// @Param    input  body      dto.TwoFactorVerifyInput  true  "challenge token from sign-in and a TOTP or recovery code"
+// @Success  200    {object}  auth.AuthDetails
+// @Failure  401    {object}  failure
+// @Failure  422    {object}  failure
+// @Failure  500    {object}  failure
+// @Router   /users/auth/2fa/verify [post]
+func (h *Handler) userTwoFactorVerify(context *gin.Context) {
+	h.verifyTwoFactor(context, domain.AccountUser)
+}
+
+// UserTwoFactorSetup godoc
+// @Summary   Start two-factor enrolment
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Success   200  {object}  domain.TwoFactorSetup
+// @Failure   401  {object}  failure
+// @Failure   409  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/2fa/setup [post]
+func (h *Handler) userTwoFactorSetup(context *gin.Context) {
+	h.setupTwoFactor(context, domain.AccountUser)
+}
+
+// UserTwoFactorConfirm godoc
+// @Summary   Enable two-factor authentication
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.TwoFactorCodeInput  true  "code from the authenticator app"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   409    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/2fa/confirm [post]
+func (h *Handler) userTwoFactorConfirm(context *gin.Context) {
+	h.confirmTwoFactor(context, domain.AccountUser)
+}
+
+// UserTwoFactorDisable godoc
+// @Summary   Disable two-factor authentication
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.TwoFactorCodeInput  true  "TOTP or recovery code"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/2fa/disable [post]
+func (h *Handler) userTwoFactorDisable(context *gin.Context) {
+	h.disableTwoFactor(context, domain.AccountUser)
+}
+
+// UserTwoFactorRecoveryCodes godoc
+// @Summary   Replace the two-factor recovery codes
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     input  body      dto.TwoFactorCodeInput  true  "TOTP or recovery code"
+// @Success   200    {object}  success
+// @Failure   400    {object}  failure
+// @Failure   401    {object}  failure
+// @Failure   500    {object}  failure
+// @Security  UserAuth
+// @Router    /users/2fa/recovery-codes [post]
+func (h *Handler) userTwoFactorRecoveryCodes(context *gin.Context) {
+	h.regenerateRecoveryCodes(context, domain.AccountUser)
+}
@@ -1,7 +1,9 @@
 package v1
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
 	"net/http"
 )
 
@@ -11,7 +13,19 @@ func (h *Handler) initUsersRoutes(api *gin.RouterGroup) {
 		authenticated := users.Group("/", h.verifyUser)
 		{
 			authenticated.GET("/account", h.getUserAccount)
+			authenticated.PUT("/account", h.updateUserAccount)
+			authenticated.DELETE("/account", h.deleteUserAccount)
+			authenticated.PUT("/account/avatar", h.updateUserAvatar)
+			authenticated.POST("/account/email", h.changeUserEmail)
+			authenticated.PUT("/account/password", h.changeUserPassword)
+			authenticated.POST("/account/export", h.exportUserAccount)
 			authenticated.GET("/reviews", h.getAllReviewsUser)
+			authenticated.GET("/sessions", h.getUserSessions)
+			authenticated.DELETE("/sessions/:id", h.deleteUserSession)
+			authenticated.POST("/2fa/setup", h.userTwoFactorSetup)
+			authenticated.POST("/2fa/confirm", h.userTwoFactorConfirm)
+			authenticated.POST("/2fa/disable", h.userTwoFactorDisable)
+			authenticated.POST("/2fa/recovery-codes", h.userTwoFactorRecoveryCodes)
 		}
 	}
 }
@@ -69,3 +83,67 @@ func (h *Handler) getUserAccount(context *gin.Context) {
 
 	successResponse(context, userInfo)
 }
+
+// GetUserSessions godoc
+// @Summary   Active sessions of the user
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Success   200  {array}   auth.Session
+// @Failure   401  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/sessions [get]
+func (h *Handler) getUserSessions(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	sessions, err := h.tokenProvider.Sessions(auth.Subject("userID", userID.Hex()))
+	if err != nil {
+		ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	currentSessionID := context.GetString("sessionID")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	successResponse(context, sessions)
+}
+
+// DeleteUserSession godoc
+// @Summary   Revoke a session of the user
+// @Tags      user
+// @Accept    json
+// @Produce   json
+// @Param     id   path      string  true  "session id"
+// @Success   200  {object}  success
+// @Failure   401  {object}  failure
+// @Failure   404  {object}  failure
+// @Failure   500  {object}  failure
+// @Security  UserAuth
+// @Router    /users/sessions/{id} [delete]
+func (h *Handler) deleteUserSession(context *gin.Context) {
+	userID, err := getIdFromRequestContext(context, "userID")
+	if err != nil {
+		ErrorResponse(context, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err = h.tokenProvider.RevokeSession(auth.Subject("userID", userID.Hex()), context.Param("id"))
+	if err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			ErrorResponse(context, http.StatusNotFound, err.Error())
+		} else {
+			ErrorResponse(context, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	var data interface{}
+	successResponse(context, data)
+}
@@ -3,8 +3,22 @@ package domain
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Admin struct {
-	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name     string             `json:"name" bson:"name"`
-	Email    string             `json:"email" bson:"email"`
-	Password string             `json:"password" bson:"password"`
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name"`
+	Email     string             `json:"email" bson:"email"`
+	Password  string             `json:"-" bson:"password"`
+	Roles     []Role             `json:"roles" bson:"roles"`
+	TwoFactor TwoFactor          `json:"twoFactor" bson:"twoFactor,omitempty"`
+}
+
+// HasPermission reports whether any of the admin's roles grants the
+// permission. An admin without roles has no permissions at all.
+func (a Admin) HasPermission(permission Permission) bool {
+	for _, role := range a.Roles {
+		if role.Grants(permission) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,122 @@
+package domain
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	AnalyticsPeriodDay   = "day"
+	AnalyticsPeriodWeek  = "week"
+	AnalyticsPeriodMonth = "month"
+)
+
+// SalesPoint is the gross merchandise value of the orders placed in one
+// period and currency. Totals have no period.
+type SalesPoint struct {
+	Period            string `json:"period,omitempty" bson:"period"`
+	Orders            int64  `json:"orders" bson:"orders"`
+	GMV               Money  `json:"gmv" bson:"gmv"`
+	AverageOrderValue Money  `json:"averageOrderValue" bson:"-"`
+}
+
+type SalesReport struct {
+	Totals []SalesPoint `json:"totals"`
+	Series []SalesPoint `json:"series"`
+}
+
+type TopProduct struct {
+	ProductID primitive.ObjectID `json:"productID" bson:"productID"`
+	StoreID   primitive.ObjectID `json:"storeID" bson:"storeID"`
+	Name      string             `json:"name" bson:"name"`
+	Orders    int64              `json:"orders" bson:"orders"`
+	Quantity  int64              `json:"quantity" bson:"quantity"`
+	Revenue   Money              `json:"revenue" bson:"revenue"`
+}
+
+type TopStore struct {
+	StoreID primitive.ObjectID `json:"storeID" bson:"storeID"`
+	Name    string             `json:"name" bson:"name"`
+	Domain  string             `json:"domain" bson:"domain"`
+	Orders  int64              `json:"orders" bson:"orders"`
+	GMV     Money              `json:"gmv" bson:"gmv"`
+}
+
+// CartConversion counts the carts changed in a range and how many of their
+// owners placed an order in the same range.
+type CartConversion struct {
+	Carts     int64   `json:"carts" bson:"carts"`
+	Converted int64   `json:"converted" bson:"converted"`
+	Rate      float64 `json:"rate" bson:"-"`
+}
+
+type NewUsersPoint struct {
+	Period string `json:"period" bson:"period"`
+	Users  int64  `json:"users" bson:"users"`
+}
+
+type NewUsersReport struct {
+	Total  int64           `json:"total"`
+	Series []NewUsersPoint `json:"series"`
+}
+
+// OrderRates relates the placed orders of a range to those that were
+// cancelled and those that were refunded in full or through a return.
+type OrderRates struct {
+	Orders           int64   `json:"orders" bson:"orders"`
+	Cancelled        int64   `json:"cancelled" bson:"cancelled"`
+	Refunded         int64   `json:"refunded" bson:"refunded"`
+	CancellationRate float64 `json:"cancellationRate" bson:"-"`
+	RefundRate       float64 `json:"refundRate" bson:"-"`
+}
+
+type AnalyticsOverview struct {
+	Sales      []SalesPoint   `json:"sales"`
+	Rates      OrderRates     `json:"rates"`
+	Conversion CartConversion `json:"conversion"`
+	NewUsers   int64          `json:"newUsers"`
+}
+
+// ProductCounter holds the views and cart adds of a product on one calendar
+// day of the analytics timezone, written as 2006-01-02.
+type ProductCounter struct {
+	ProductID primitive.ObjectID `json:"productID" bson:"productID"`
+	StoreID   primitive.ObjectID `json:"storeID" bson:"storeID"`
+	Date      string             `json:"date" bson:"date"`
+	Views     int64              `json:"views" bson:"views"`
+	CartAdds  int64              `json:"cartAdds" bson:"cartAdds"`
+}
+
+type TrafficPoint struct {
+	Period   string `json:"period" bson:"period"`
+	Views    int64  `json:"views" bson:"views"`
+	CartAdds int64  `json:"cartAdds" bson:"cartAdds"`
+}
+
+// StoreSalesPoint is the performance of a store in one period, revenue is the
+// item subtotal per currency. Totals have no period.
+type StoreSalesPoint struct {
+	Period   string  `json:"period,omitempty" bson:"period"`
+	Orders   int64   `json:"orders" bson:"orders"`
+	Units    int64   `json:"units" bson:"units"`
+	Revenue  []Money `json:"revenue" bson:"revenue"`
+	Views    int64   `json:"views" bson:"views"`
+	CartAdds int64   `json:"cartAdds" bson:"cartAdds"`
+}
+
+// ProductPerformance relates the orders of a product to its views and cart
+// adds, conversions are orders per view and per cart add.
+type ProductPerformance struct {
+	ProductID      primitive.ObjectID `json:"productID" bson:"productID"`
+	Name           string             `json:"name" bson:"name"`
+	Orders         int64              `json:"orders" bson:"orders"`
+	Units          int64              `json:"units" bson:"units"`
+	Revenue        []Money            `json:"revenue" bson:"revenue"`
+	Views          int64              `json:"views" bson:"views"`
+	CartAdds       int64              `json:"cartAdds" bson:"cartAdds"`
+	ViewConversion float64            `json:"viewConversion" bson:"-"`
+	CartConversion float64            `json:"cartConversion" bson:"-"`
+}
+
+type StoreAnalytics struct {
+	Totals   StoreSalesPoint      `json:"totals"`
+	Series   []StoreSalesPoint    `json:"series"`
+	Products []ProductPerformance `json:"products"`
+}
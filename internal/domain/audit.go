@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AuditActorAdmin  = "admin"
+	AuditActorSeller = "seller"
+
+	AuditActionPermissionDenied = "permission.denied"
+	AuditActionImpersonate      = "user.impersonate"
+	AuditActionImpersonated     = "user.impersonated_request"
+	AuditActionUserDelete       = "user.delete"
+	AuditActionOrderUpdate      = "order.update"
+	AuditActionCategoryCreate   = "category.create"
+	AuditActionCategoryUpdate   = "category.update"
+	AuditActionCategoryDelete   = "category.delete"
+	AuditActionProductCreate    = "product.create"
+	AuditActionProductUpdate    = "product.update"
+	AuditActionProductDelete    = "product.delete"
+	AuditActionProductPublish   = "product.publish"
+	AuditActionStoreDocument    = "store.document"
+	AuditActionStoreApprove     = "store.approve"
+	AuditActionStoreReject      = "store.reject"
+
+	// Status changes are recorded with the new status appended, e.g.
+	// "user.status.suspended".
+	AuditActionUserStatus  = "user.status"
+	AuditActionStoreStatus = "store.status"
+
+	AuditTargetUser     = "user"
+	AuditTargetStore    = "store"
+	AuditTargetOrder    = "order"
+	AuditTargetCategory = "category"
+	AuditTargetProduct  = "product"
+)
+
+// AuditContext is who made a request and how. It travels with the request
+// context so that services can write audit entries without being told.
+type AuditContext struct {
+	ActorType string             `json:"actorType" bson:"actorType"`
+	ActorID   primitive.ObjectID `json:"actorID" bson:"actorID"`
+	Roles     []Role             `json:"roles,omitempty" bson:"roles,omitempty"`
+	Method    string             `json:"method" bson:"method"`
+	Path      string             `json:"path" bson:"path"`
+	IP        string             `json:"ip" bson:"ip"`
+	RequestID string             `json:"requestID,omitempty" bson:"requestID,omitempty"`
+}
+
+// AuditLog records a security relevant event together with who caused it and
+// the request it happened on.
+type AuditLog struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	AuditContext `bson:",inline"`
+	Action       string             `json:"action" bson:"action"`
+	Permission   Permission         `json:"permission,omitempty" bson:"permission,omitempty"`
+	TargetType   string             `json:"targetType,omitempty" bson:"targetType,omitempty"`
+	TargetID     primitive.ObjectID `json:"targetID,omitempty" bson:"targetID,omitempty"`
+	Reason       string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	Changes      []AuditChange      `json:"changes,omitempty" bson:"changes,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// AuditChange is a field that differs between the entity before and after
+// the action. A created entity has no Before, a deleted one no After.
+type AuditChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before,omitempty" bson:"before,omitempty"`
+	After  interface{} `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+type AuditPage struct {
+	Entries []AuditLog `json:"entries"`
+	Total   int64      `json:"total"`
+	Page    int64      `json:"page"`
+	Limit   int64      `json:"limit"`
+}
@@ -1,14 +1,20 @@
 package domain
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Cart struct {
 	ID         primitive.ObjectID `json:"id" bson:"_id"`
 	UserID     primitive.ObjectID `json:"userID" bson:"userID"`
-	TotalPrice float64            `json:"totalPrice" bson:"-"`
+	TotalPrice Money              `json:"totalPrice" bson:"-"`
+	Tax        []TaxLine          `json:"tax" bson:"-"`
+	TaxTotal   Money              `json:"taxTotal" bson:"-"`
+	GrandTotal Money              `json:"grandTotal" bson:"-"`
 	CartItems  []CartItem         `json:"cartItems" bson:"cartItems"`
+	UpdatedAt  time.Time          `json:"updatedAt" bson:"updatedAt,omitempty"`
 }
 
 type CartItem struct {
@@ -1,7 +1,26 @@
 package dto
 
+import "github.com/sigit14ap/go-commerce/internal/domain"
+
 type SignInDTO struct {
 	Email       string `json:"email"`
 	Password    string `json:"password"`
 	Fingerprint string `json:"fingerprint"`
+	Device      string `json:"device"`
+}
+
+type CreateAdminInput struct {
+	Name     string        `json:"name" validate:"required,max=255"`
+	Email    string        `json:"email" validate:"required,email"`
+	Password string        `json:"password" validate:"required,min=8,max=72"`
+	Roles    []domain.Role `json:"roles" validate:"required,min=1"`
+}
+
+type UpdateAdminInput struct {
+	Name  string        `json:"name" validate:"omitempty,max=255"`
+	Roles []domain.Role `json:"roles" validate:"omitempty,min=1"`
+}
+
+type AdminPasswordInput struct {
+	Password string `json:"password" validate:"required,min=8,max=72"`
 }
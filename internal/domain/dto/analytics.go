@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AnalyticsInput struct {
+	Period   string    `form:"period" validate:"omitempty,oneof=day week month"`
+	From     time.Time `form:"from" time_format:"2006-01-02"`
+	To       time.Time `form:"to" time_format:"2006-01-02"`
+	Timezone string    `form:"timezone" validate:"omitempty,max=64"`
+	Limit    int64     `form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// AnalyticsFilter selects the range [From, To) in absolute time, Period and
+// Timezone decide how the range is split into buckets.
+type AnalyticsFilter struct {
+	StoreID  primitive.ObjectID `json:"storeID"`
+	Period   string             `json:"period"`
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"`
+	Timezone string             `json:"timezone"`
+	Limit    int64              `json:"limit,omitempty"`
+}
+
+type StoreAnalyticsExportInput struct {
+	Report string `form:"report" validate:"omitempty,oneof=series products"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditFilterInput struct {
+	ActorType  string    `form:"actorType" validate:"omitempty,oneof=admin seller"`
+	ActorID    string    `form:"actorID" validate:"omitempty,len=24,hexadecimal"`
+	Action     string    `form:"action" validate:"omitempty,max=64"`
+	TargetType string    `form:"targetType" validate:"omitempty,max=32"`
+	TargetID   string    `form:"targetID" validate:"omitempty,len=24,hexadecimal"`
+	RequestID  string    `form:"requestID" validate:"omitempty,max=64"`
+	From       time.Time `form:"from" time_format:"2006-01-02"`
+	To         time.Time `form:"to" time_format:"2006-01-02"`
+	Page       int64     `form:"page" validate:"omitempty,min=1"`
+	Limit      int64     `form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type AuditFilter struct {
+	ActorType  string
+	ActorID    primitive.ObjectID
+	Action     string
+	TargetType string
+	TargetID   primitive.ObjectID
+	RequestID  string
+	From       time.Time
+	To         time.Time
+	Page       int64
+	Limit      int64
+}
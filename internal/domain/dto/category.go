@@ -6,27 +6,32 @@ type ValidationCategoryDTO struct {
 	Name        string                `form:"name" binding:"required"`
 	Description string                `form:"description" binding:"required"`
 	Icon        *multipart.FileHeader `form:"icon" binding:"required"`
+	TaxExempt   bool                  `form:"tax_exempt"`
 }
 
 type ValidationUpdateCategoryDTO struct {
 	Name        string `form:"name" binding:"required"`
 	Description string `form:"description" binding:"required"`
+	TaxExempt   *bool  `form:"tax_exempt"`
 }
 
 type CreateCategoryDTO struct {
 	Name        string
 	Description string
 	Icon        string
+	TaxExempt   bool
 }
 
 type UpdateCategoryDTO struct {
 	Name        string
 	Description string
 	Icon        string
+	TaxExempt   *bool
 }
 
 type UpdateCategoryInput struct {
 	Name        string
 	Description string
 	Icon        string
+	TaxExempt   *bool
 }
@@ -11,6 +11,18 @@ type CreateOrderDTO struct {
 	OrderItems  []domain.OrderItem `json:"-"`
 	ContactInfo domain.ContactInfo `json:"contactInfo"`
 	UserID      primitive.ObjectID `json:"-"`
+	AddressID   string             `json:"addressID" validate:"required_with=Shipping,omitempty,len=24,hexadecimal"`
+	Shipping    []ShippingChoice   `json:"shipping" validate:"dive"`
+	// SingleStore turns carts with items of several stores away before
+	// any stock is reserved.
+	SingleStore bool `json:"-"`
+}
+
+// ShippingChoice picks one of the quoted shipping options of a store.
+type ShippingChoice struct {
+	StoreID string `json:"storeID" validate:"required,len=24,hexadecimal"`
+	Courier string `json:"courier" validate:"required"`
+	Service string `json:"service" validate:"required"`
 }
 
 type UpdateOrderDTO struct {
@@ -24,7 +36,85 @@ type UpdateOrderInput struct {
 }
 
 type DeliveryCostInput struct {
-	Product []struct {
-		ProductID string `json:"product_id" validate:"required"`
-	} `json:"product" validate:"required"`
+	AddressID string `form:"address_id" validate:"required,len=24,hexadecimal"`
+}
+
+type CancelOrderInput struct {
+	Reason string `json:"reason" validate:"required,oneof=changed_mind wrong_address payment_issue out_of_stock fraud_suspect other"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+type CancelOrderDTO struct {
+	Actor   string
+	ActorID primitive.ObjectID
+	Reason  string
+	Note    string
+}
+
+type OrderTransitionInput struct {
+	Status          string                    `bson:"status"`
+	PaymentIntentID string                    `bson:"paymentIntentID,omitempty"`
+	PaidAt          time.Time                 `bson:"paidAt,omitempty"`
+	Cancellation    *domain.OrderCancellation `bson:"cancellation,omitempty"`
+	Shipment        *domain.OrderShipment     `bson:"shipment,omitempty"`
+	DeliveredAt     time.Time                 `bson:"deliveredAt,omitempty"`
+	CompletedAt     time.Time                 `bson:"completedAt,omitempty"`
+	Event           domain.OrderEvent         `bson:"-"`
+	// Undisputed only applies the transition while the order has no open
+	// return requests.
+	Undisputed bool `bson:"-"`
+}
+
+// OrderPaymentDTO is a payment the provider reports as captured for an order.
+type OrderPaymentDTO struct {
+	OrderID         primitive.ObjectID
+	PaymentIntentID string
+	Amount          domain.Money
+	PaidAt          time.Time
+}
+
+type StoreOrderFilterInput struct {
+	Status string    `form:"status" validate:"omitempty,oneof=reserved paid processing shipped delivered completed cancelled"`
+	From   time.Time `form:"from" time_format:"2006-01-02"`
+	To     time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+type StoreOrderFilter struct {
+	Status string
+	From   time.Time
+	To     time.Time
+}
+
+type ShipOrderInput struct {
+	Courier        string `json:"courier" validate:"required,oneof=jne pos tiki"`
+	Service        string `json:"service"`
+	TrackingNumber string `json:"tracking_number" validate:"required"`
+}
+
+type ThirdPartyWaybillDTO struct {
+	Delivered   bool
+	DeliveredAt time.Time
+	Events      []domain.TrackingEvent
+}
+
+type ThirdPartyCostDTO struct {
+	Courier     string
+	Service     string
+	Description string
+	Cost        domain.Money
+	MinDays     int
+	MaxDays     int
+}
+
+// ShippingParcel is what one store ships for an order, the weight in grams
+// and the total of its items.
+type ShippingParcel struct {
+	StoreID  primitive.ObjectID
+	Weight   int64
+	Subtotal domain.Money
+}
+
+type UpdateTrackingInput struct {
+	Tracking          []domain.TrackingEvent `bson:"tracking"`
+	TrackingUpdatedAt time.Time              `bson:"trackingUpdatedAt"`
 }
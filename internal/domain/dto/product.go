@@ -1,40 +1,69 @@
 package dto
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type CreateProductDTO struct {
 	StoreID     primitive.ObjectID `form:"seller_id" bson:"seller_id"`
 	Name        string             `form:"name" bson:"name"`
 	Description string             `form:"description" bson:"description"`
-	Price       float64            `form:"price" bson:"price"`
+	Price       domain.Money       `form:"price" bson:"price"`
 	CategoryID  primitive.ObjectID `form:"category_id" bson:"category_id"`
 	Images      []string           `form:"images"`
 	Weight      int64              `form:"weight" bson:"weight"`
+	Stock       int64              `form:"stock" bson:"stock"`
+	Draft       bool               `form:"-" bson:"draft"`
 }
 
 type CreateProductInput struct {
-	Name        string  `form:"name" binding:"required"`
-	Description string  `form:"description" binding:"required"`
-	Price       float64 `form:"price" binding:"required"`
-	CategoryID  string  `form:"category_id" binding:"required"`
-	Weight      int64   `form:"weight" binding:"required"`
+	Name        string       `form:"name" binding:"required"`
+	Description string       `form:"description" binding:"required"`
+	Price       domain.Money `form:"price"`
+	CategoryID  string       `form:"category_id" binding:"required"`
+	Weight      int64        `form:"weight" binding:"required"`
+	Stock       *int64       `form:"stock" binding:"required,min=0"`
 }
 
 type UpdateProductDTO struct {
 	StoreID     primitive.ObjectID `form:"seller_id" bson:"seller_id"`
 	Name        string             `form:"name" bson:"name"`
 	Description string             `form:"description" bson:"description"`
-	Price       float64            `form:"price" bson:"price"`
+	Price       domain.Money       `form:"price" bson:"price"`
 	CategoryID  primitive.ObjectID `form:"category_id" bson:"category_id"`
 	Images      []string           `form:"images"`
 	Weight      int64              `form:"weight" bson:"weight"`
+	Stock       *int64             `form:"stock" bson:"stock"`
 }
 
 type UpdateProductInput struct {
-	Name        string   `form:"name" binding:"required"`
-	Description string   `form:"description" binding:"required"`
-	Price       float64  `form:"price" binding:"required"`
-	CategoryID  string   `form:"category_id" binding:"required"`
-	Images      []string `form:"images"`
-	Weight      int64    `form:"weight" binding:"required"`
+	Name        string       `form:"name" binding:"required"`
+	Description string       `form:"description" binding:"required"`
+	Price       domain.Money `form:"price"`
+	CategoryID  string       `form:"category_id" binding:"required"`
+	Images      []string     `form:"images"`
+	Weight      int64        `form:"weight" binding:"required"`
+	Stock       *int64       `form:"stock" binding:"omitempty,min=0"`
+}
+
+type ProductFilterInput struct {
+	Query      string `form:"q" validate:"omitempty,max=100"`
+	CategoryID string `form:"category_id" validate:"omitempty,len=24,hexadecimal"`
+	MinPrice   int64  `form:"min_price" validate:"omitempty,min=0"`
+	MaxPrice   int64  `form:"max_price" validate:"omitempty,min=0"`
+	Sort       string `form:"sort" validate:"omitempty,oneof=newest price_asc price_desc name"`
+	Page       int64  `form:"page" validate:"omitempty,min=1"`
+	Limit      int64  `form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type ProductFilter struct {
+	StoreID    primitive.ObjectID
+	Query      string
+	CategoryID primitive.ObjectID
+	MinPrice   int64
+	MaxPrice   int64
+	Sort       string
+	Page       int64
+	Limit      int64
 }
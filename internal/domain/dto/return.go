@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CreateReturnInput struct {
+	ProductID string `form:"product_id" validate:"required"`
+	Quantity  int64  `form:"quantity" validate:"required,min=1"`
+	Reason    string `form:"reason" validate:"required,max=500"`
+}
+
+type CreateReturnDTO struct {
+	OrderID   primitive.ObjectID
+	UserID    primitive.ObjectID
+	ProductID primitive.ObjectID
+	Quantity  int64
+	Reason    string
+	Photos    []string
+}
+
+type ReviewReturnInput struct {
+	Note string `json:"note" validate:"max=500"`
+}
+
+type ShipReturnInput struct {
+	Courier        string `json:"courier" validate:"required"`
+	TrackingNumber string `json:"tracking_number" validate:"required"`
+}
+
+type ReceiveReturnInput struct {
+	RefundAmount domain.Money `json:"refund_amount"`
+}
+
+type ReturnTransitionInput struct {
+	Status       string                 `bson:"status"`
+	StoreNote    string                 `bson:"storeNote,omitempty"`
+	Shipment     *domain.ReturnShipment `bson:"shipment,omitempty"`
+	RefundAmount domain.Money           `bson:"refundAmount,omitempty"`
+	RefundID     string                 `bson:"refundID,omitempty"`
+	UpdatedAt    time.Time              `bson:"updatedAt"`
+}
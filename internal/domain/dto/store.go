@@ -1,6 +1,11 @@
 package dto
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type StoreRegisterInput struct {
 	Name   string `json:"name" validate:"required,min=5,max=255"`
@@ -8,9 +13,76 @@ type StoreRegisterInput struct {
 }
 
 type StoreRegisterDTO struct {
-	UserID primitive.ObjectID `json:"user_id" bson:"user_id"`
-	Name   string             `json:"name" bson:"name"`
-	Domain string             `json:"domain" bson:"domain"`
+	UserID       primitive.ObjectID       `json:"user_id" bson:"user_id"`
+	Name         string                   `json:"name" bson:"name"`
+	Domain       string                   `json:"domain" bson:"domain"`
+	Verification domain.StoreVerification `json:"verification" bson:"verification"`
+}
+
+type StoreDocumentInput struct {
+	Type string `form:"type" validate:"required,oneof=identity business"`
+}
+
+type StoreFilterInput struct {
+	Verification string `form:"verification" validate:"omitempty,oneof=pending approved rejected"`
+}
+
+type StoreReviewInput struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+type StoreReviewDTO struct {
+	Status     string             `bson:"verification.status"`
+	Note       string             `bson:"verification.note"`
+	ReviewedBy primitive.ObjectID `bson:"verification.reviewed_by"`
+	ReviewedAt time.Time          `bson:"verification.reviewed_at"`
+}
+
+type StoreProfileInput struct {
+	Name        string `json:"name" validate:"required,min=5,max=255"`
+	Description string `json:"description" validate:"max=2000"`
+}
+
+type StoreProfileDTO struct {
+	Name        string `bson:"name"`
+	Description string `bson:"description"`
+}
+
+type StoreImageDTO struct {
+	Logo   string `bson:"logo,omitempty"`
+	Banner string `bson:"banner,omitempty"`
+}
+
+type StoreHoursInput struct {
+	Hours []StoreDayInput `json:"hours" validate:"max=7,dive"`
+}
+
+type StoreDayInput struct {
+	Day   *int   `json:"day" validate:"required,min=0,max=6"`
+	Open  string `json:"open" validate:"required,datetime=15:04"`
+	Close string `json:"close" validate:"required,datetime=15:04"`
+}
+
+type StoreHoursDTO struct {
+	OperatingHours []domain.OperatingHours `bson:"operating_hours"`
+}
+
+type StoreHolidayInput struct {
+	Start   time.Time `json:"start" validate:"required"`
+	End     time.Time `json:"end" validate:"required,gtfield=Start"`
+	Message string    `json:"message" validate:"max=500"`
+}
+
+type StoreHolidayDTO struct {
+	Holiday *domain.StoreHoliday `bson:"holiday"`
+}
+
+type StoreHandlingInput struct {
+	HandlingDays *int `json:"handling_days" validate:"required,min=0,max=30"`
+}
+
+type StoreHandlingDTO struct {
+	HandlingDays int `bson:"handling_days"`
 }
 
 type StoreShipmentInput struct {
@@ -18,7 +90,31 @@ type StoreShipmentInput struct {
 	CityID     string `json:"city_id" validate:"required"`
 }
 
+type StoreTaxInput struct {
+	PricesIncludeTax *bool `json:"prices_include_tax" validate:"required"`
+}
+
+type StoreTaxDTO struct {
+	PricesIncludeTax bool `bson:"prices_include_tax"`
+}
+
 type StoreShipmentDTO struct {
 	ProvinceID primitive.ObjectID `json:"province_id" bson:"province_id"`
 	CityID     primitive.ObjectID `json:"city_id" bson:"city_id"`
 }
+
+type StoreShippingInput struct {
+	Couriers          []StoreCourierInput `json:"couriers" validate:"max=3,unique=Code,dive"`
+	FreeShippingMin   *domain.Money       `json:"free_shipping_min"`
+	HandlingFee       domain.Money        `json:"handling_fee"`
+	ExcludedProvinces []string            `json:"excluded_provinces" validate:"max=50,dive,len=24,hexadecimal"`
+}
+
+type StoreCourierInput struct {
+	Code     string   `json:"code" validate:"required,oneof=jne pos tiki"`
+	Services []string `json:"services" validate:"max=10,dive,required,max=50"`
+}
+
+type StoreShippingDTO struct {
+	Shipping domain.ShippingRules `bson:"shipping"`
+}
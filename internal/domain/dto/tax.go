@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TaxItem struct {
+	StoreID primitive.ObjectID
+	Amount  domain.Money
+	Exempt  bool
+}
+
+type TaxReportInput struct {
+	StoreID string    `form:"store_id" validate:"omitempty,len=24,hexadecimal"`
+	Period  string    `form:"period" validate:"omitempty,oneof=day month"`
+	From    time.Time `form:"from" time_format:"2006-01-02"`
+	To      time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+type TaxReportFilter struct {
+	StoreID  primitive.ObjectID
+	Period   string
+	From     time.Time
+	To       time.Time
+	Timezone string
+}
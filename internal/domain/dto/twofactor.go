@@ -0,0 +1,18 @@
+package dto
+
+type TwoFactorVerifyInput struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorChallengeInput struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type SecuritySettingsInput struct {
+	RequireAdminTwoFactor bool `json:"requireAdminTwoFactor"`
+}
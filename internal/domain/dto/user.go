@@ -11,7 +11,28 @@ type UpdateUserDTO struct {
 }
 
 type UpdateUserInput struct {
-	Name string `json:"name"`
+	Name   string `json:"name"`
+	Phone  string `json:"phone"`
+	Avatar string `json:"avatar"`
+}
+
+type UpdateProfileInput struct {
+	Name  string `json:"name" validate:"omitempty,max=255"`
+	Phone string `json:"phone" validate:"omitempty,min=8,max=20"`
+}
+
+type ChangeEmailInput struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	Password        string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password" validate:"required"`
 }
 
 type SignUpDTO struct {
@@ -19,3 +40,28 @@ type SignUpDTO struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password"  binding:"required"`
 }
+
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type EmailInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type OIDCCallbackInput struct {
+	Code        string `json:"code" validate:"required"`
+	State       string `json:"state" validate:"required"`
+	Fingerprint string `json:"fingerprint"`
+	Device      string `json:"device"`
+}
+
+type UpdateStatusInput struct {
+	Status string `json:"status" validate:"required,oneof=active suspended banned"`
+	Reason string `json:"reason" validate:"max=500"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WalletInput struct {
+	Page  int64 `form:"page" validate:"omitempty,min=1"`
+	Limit int64 `form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type BankAccountInput struct {
+	BankName      string `json:"bank_name" validate:"required,max=100"`
+	AccountNumber string `json:"account_number" validate:"required,numeric,min=5,max=30"`
+	AccountHolder string `json:"account_holder" validate:"required,max=255"`
+}
+
+type PayoutRequestInput struct {
+	Amount domain.Money `json:"amount"`
+}
+
+type PayoutReviewInput struct {
+	Note string `json:"note" validate:"max=500"`
+}
+
+type PayoutFilterInput struct {
+	Status string `form:"status" validate:"omitempty,oneof=requested approved rejected paid"`
+}
+
+type PayoutReviewDTO struct {
+	AdminID primitive.ObjectID
+	Note    string
+}
+
+type PayoutTransitionInput struct {
+	Status    string             `bson:"status"`
+	AdminID   primitive.ObjectID `bson:"adminID,omitempty"`
+	Note      string             `bson:"note,omitempty"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+	PaidAt    time.Time          `bson:"paidAt,omitempty"`
+}
+
+type StoreBankAccountDTO struct {
+	BankAccount domain.BankAccount `bson:"bank_account"`
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invoice is a snapshot of an order taken when the invoice is first issued,
+// later changes to products or the store do not alter it.
+type Invoice struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Number      string             `json:"number" bson:"number"`
+	OrderID     primitive.ObjectID `json:"orderID" bson:"orderID"`
+	OrderNumber string             `json:"orderNumber" bson:"orderNumber"`
+	UserID      primitive.ObjectID `json:"userID" bson:"userID"`
+	StoreID     primitive.ObjectID `json:"storeID" bson:"storeID"`
+	IssuedAt    time.Time          `json:"issuedAt" bson:"issuedAt"`
+	OrderedAt   time.Time          `json:"orderedAt" bson:"orderedAt"`
+	Buyer       ContactInfo        `json:"buyer" bson:"buyer"`
+	Store       InvoiceStore       `json:"store" bson:"store"`
+	Lines       []InvoiceLine      `json:"lines" bson:"lines"`
+	Subtotal    Money              `json:"subtotal" bson:"subtotal"`
+	Shipping    Money              `json:"shipping" bson:"shipping"`
+	Discount    Money              `json:"discount" bson:"discount"`
+	Tax         Money              `json:"tax" bson:"tax"`
+	TaxLines    []TaxLine          `json:"taxLines" bson:"taxLines,omitempty"`
+	Total       Money              `json:"total" bson:"total"`
+	FileKey     string             `json:"-" bson:"fileKey,omitempty"`
+}
+
+type InvoiceStore struct {
+	Name     string `json:"name" bson:"name"`
+	Domain   string `json:"domain" bson:"domain"`
+	City     string `json:"city" bson:"city"`
+	Province string `json:"province" bson:"province"`
+}
+
+type InvoiceLine struct {
+	ProductID primitive.ObjectID `json:"productID" bson:"productID"`
+	Name      string             `json:"name" bson:"name"`
+	Quantity  int64              `json:"quantity" bson:"quantity"`
+	UnitPrice Money              `json:"unitPrice" bson:"unitPrice"`
+	Total     Money              `json:"total" bson:"total"`
+}
@@ -0,0 +1,337 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+type Currency string
+
+const (
+	CurrencyIDR Currency = "IDR"
+	CurrencyUSD Currency = "USD"
+	CurrencySGD Currency = "SGD"
+	CurrencyJPY Currency = "JPY"
+)
+
+// DefaultCurrency is used for amounts that arrive without a currency, such as
+// plain numbers in requests and prices stored before the money type existed.
+const DefaultCurrency = CurrencyIDR
+
+// currencyExponents holds the ISO 4217 number of minor unit digits.
+var currencyExponents = map[Currency]int{
+	CurrencyIDR: 2,
+	CurrencyUSD: 2,
+	CurrencySGD: 2,
+	CurrencyJPY: 0,
+}
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// Exponent returns the number of decimal digits of the minor unit, unknown
+// currencies are treated as having two.
+func (c Currency) Exponent() int {
+	if exponent, ok := currencyExponents[c]; ok {
+		return exponent
+	}
+
+	return 2
+}
+
+// Money is an amount in the minor unit of its currency, 1 IDR is 100 minor units.
+//
+// Arithmetic stays in integers. Operations that scale an amount by a rate round
+// half away from zero to the nearest minor unit. The zero value has no currency
+// and adopts the currency of the amount it is combined with; combining two
+// different currencies is a programming error and panics.
+type Money struct {
+	Amount   int64    `json:"amount" bson:"amount"`
+	Currency Currency `json:"currency" bson:"currency"`
+}
+
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// IDR returns an amount of whole rupiah.
+func IDR(rupiah int64) Money {
+	return Money{Amount: rupiah * pow10(CurrencyIDR.Exponent()), Currency: CurrencyIDR}
+}
+
+// MoneyFromMajor converts an amount in major units, rounding half away from zero.
+func MoneyFromMajor(amount float64, currency Currency) Money {
+	return Money{Amount: int64(math.Round(amount * float64(pow10(currency.Exponent())))), Currency: currency}
+}
+
+// ParseMoney reads a decimal amount in major units such as "15000" or "15000.50".
+// Digits beyond the minor unit are rejected rather than rounded.
+func ParseMoney(s string, currency Currency) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+
+	exponent := currency.Exponent()
+	fraction = strings.TrimRight(fraction, "0")
+	if whole == "" || len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	digits := whole + fraction + strings.Repeat("0", exponent-len(fraction))
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func (m Money) Add(other Money) Money {
+	currency := m.currencyWith(other)
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}
+}
+
+func (m Money) Sub(other Money) Money {
+	currency := m.currencyWith(other)
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Mul multiplies the amount by a quantity.
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Scale multiplies the amount by factor, rounding half away from zero.
+func (m Money) Scale(factor float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * factor)), Currency: m.Currency}
+}
+
+// Min returns the smaller of the two amounts.
+func (m Money) Min(other Money) Money {
+	if m.Cmp(other) <= 0 {
+		return m
+	}
+
+	return other
+}
+
+// Cmp returns -1, 0 or 1 when m is less than, equal to or greater than other.
+func (m Money) Cmp(other Money) int {
+	m.currencyWith(other)
+
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	}
+
+	return 0
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Major returns the amount in major units, only meant for display and
+// external APIs that expect decimals.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / float64(pow10(m.currency().Exponent()))
+}
+
+// Decimal formats the amount in major units without grouping, e.g. "15000.50".
+func (m Money) Decimal() string {
+	exponent := m.currency().Exponent()
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	if exponent == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+
+	unit := pow10(exponent)
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, exponent, amount%unit)
+}
+
+// String formats the amount for people, e.g. "IDR 1,500,000.00".
+func (m Money) String() string {
+	decimal := m.Decimal()
+	sign := ""
+	if strings.HasPrefix(decimal, "-") {
+		sign, decimal = "-", decimal[1:]
+	}
+
+	whole, fraction := decimal, ""
+	if i := strings.IndexByte(decimal, '.'); i >= 0 {
+		whole, fraction = decimal[:i], decimal[i:]
+	}
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+
+	return fmt.Sprintf("%s %s%s%s", m.currency(), sign, grouped.String(), fraction)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   int64    `json:"amount"`
+		Currency Currency `json:"currency"`
+		Display  string   `json:"display"`
+	}{m.Amount, m.currency(), m.String()})
+}
+
+// UnmarshalJSON accepts {"amount": 1500050, "currency": "IDR"} in minor units,
+// or a number or string in major units of the default currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+
+	switch {
+	case trimmed == "null":
+		*m = Money{}
+		return nil
+	case strings.HasPrefix(trimmed, "{"):
+		var value struct {
+			Amount   int64    `json:"amount"`
+			Currency Currency `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+
+		if value.Currency == "" {
+			value.Currency = DefaultCurrency
+		}
+
+		*m = Money{Amount: value.Amount, Currency: Currency(strings.ToUpper(string(value.Currency)))}
+		return nil
+	case strings.HasPrefix(trimmed, `"`):
+		unquoted, err := strconv.Unquote(trimmed)
+		if err != nil {
+			return err
+		}
+		trimmed = unquoted
+	}
+
+	money, err := ParseMoney(trimmed, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
+
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(bson.D{
+		{Key: "amount", Value: m.Amount},
+		{Key: "currency", Value: string(m.currency())},
+	})
+}
+
+// UnmarshalBSONValue reads money documents and, for data written before the
+// money type, plain numbers in major units of the default currency.
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	value := bsoncore.Value{Type: t, Data: data}
+
+	switch t {
+	case bsontype.EmbeddedDocument:
+		var document struct {
+			Amount   int64  `bson:"amount"`
+			Currency string `bson:"currency"`
+		}
+		if err := bson.Unmarshal(data, &document); err != nil {
+			return err
+		}
+
+		*m = Money{Amount: document.Amount, Currency: Currency(document.Currency)}
+		if m.Currency == "" {
+			m.Currency = DefaultCurrency
+		}
+	case bsontype.Double:
+		*m = MoneyFromMajor(value.Double(), DefaultCurrency)
+	case bsontype.Int32:
+		*m = MoneyFromMajor(float64(value.Int32()), DefaultCurrency)
+	case bsontype.Int64:
+		*m = MoneyFromMajor(float64(value.Int64()), DefaultCurrency)
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+	default:
+		return fmt.Errorf("%w: cannot decode %s", ErrInvalidMoney, t)
+	}
+
+	return nil
+}
+
+// SumMoney adds up amounts of the same currency.
+func SumMoney(amounts ...Money) Money {
+	var total Money
+	for _, amount := range amounts {
+		total = total.Add(amount)
+	}
+
+	return total
+}
+
+func (m Money) currency() Currency {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return m.Currency
+}
+
+func (m Money) currencyWith(other Money) Currency {
+	switch {
+	case m.Currency == "":
+		return other.Currency
+	case other.Currency == "" || other.Currency == m.Currency:
+		return m.Currency
+	}
+
+	panic(fmt.Sprintf("money: currency mismatch %s and %s", m.Currency, other.Currency))
+}
+
+func pow10(exponent int) int64 {
+	result := int64(1)
+	for i := 0; i < exponent; i++ {
+		result *= 10
+	}
+
+	return result
+}
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMoneyRounding(t *testing.T) {
+	cases := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{"major half up", MoneyFromMajor(10.005, CurrencyIDR), NewMoney(1001, CurrencyIDR)},
+		{"major half away from zero", MoneyFromMajor(-10.005, CurrencyIDR), NewMoney(-1001, CurrencyIDR)},
+		{"major without minor unit", MoneyFromMajor(1500.5, CurrencyJPY), NewMoney(1501, CurrencyJPY)},
+		{"scale half up", NewMoney(1050, CurrencyIDR).Scale(0.05), NewMoney(53, CurrencyIDR)},
+		{"scale down", NewMoney(1049, CurrencyIDR).Scale(0.05), NewMoney(52, CurrencyIDR)},
+		{"scale negative", NewMoney(-1050, CurrencyIDR).Scale(0.05), NewMoney(-53, CurrencyIDR)},
+		{"whole rupiah", IDR(15000), NewMoney(1500000, CurrencyIDR)},
+		{"zero adopts currency", Money{}.Add(IDR(1)), IDR(1)},
+		{"sum", SumMoney(IDR(1), IDR(2), Money{}), IDR(3)},
+	}
+
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s: got %+v, want %+v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		input    string
+		currency Currency
+		want     Money
+		err      bool
+	}{
+		{"15000", CurrencyIDR, NewMoney(1500000, CurrencyIDR), false},
+		{"15000.5", CurrencyIDR, NewMoney(1500050, CurrencyIDR), false},
+		{" 15000.50 ", CurrencyIDR, NewMoney(1500050, CurrencyIDR), false},
+		{"-0.01", CurrencyIDR, NewMoney(-1, CurrencyIDR), false},
+		{"1.500", CurrencyUSD, NewMoney(150, CurrencyUSD), false},
+		{"1500", CurrencyJPY, NewMoney(1500, CurrencyJPY), false},
+		{"0.001", CurrencyIDR, Money{}, true},
+		{"1.5", CurrencyJPY, Money{}, true},
+		{".5", CurrencyIDR, Money{}, true},
+		{"", CurrencyIDR, Money{}, true},
+		{"1,000", CurrencyIDR, Money{}, true},
+	}
+
+	for _, c := range cases {
+		got, err := ParseMoney(c.input, c.currency)
+		if c.err {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseMoney(%q): err = %v, want %v", c.input, err, ErrInvalidMoney)
+			}
+			continue
+		}
+
+		if err != nil || got != c.want {
+			t.Errorf("ParseMoney(%q) = %+v, %v, want %+v", c.input, got, err, c.want)
+		}
+	}
+}
+
+func TestMoneyFormatting(t *testing.T) {
+	cases := []struct {
+		money   Money
+		decimal string
+		display string
+	}{
+		{NewMoney(150000050, CurrencyIDR), "1500000.50", "IDR 1,500,000.50"},
+		{NewMoney(-1, CurrencyIDR), "-0.01", "IDR -0.01"},
+		{NewMoney(1500, CurrencyJPY), "1500", "JPY 1,500"},
+		{Money{}, "0.00", "IDR 0.00"},
+	}
+
+	for _, c := range cases {
+		if got := c.money.Decimal(); got != c.decimal {
+			t.Errorf("%+v Decimal() = %q, want %q", c.money, got, c.decimal)
+		}
+		if got := c.money.String(); got != c.display {
+			t.Errorf("%+v String() = %q, want %q", c.money, got, c.display)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	cases := []struct {
+		input string
+		want  Money
+	}{
+		{`{"amount": 1500050, "currency": "IDR"}`, NewMoney(1500050, CurrencyIDR)},
+		{`{"amount": 1500, "currency": "usd"}`, NewMoney(1500, CurrencyUSD)},
+		{`{"amount": 1500}`, NewMoney(1500, DefaultCurrency)},
+		{`15000.5`, NewMoney(1500050, DefaultCurrency)},
+		{`"15000"`, NewMoney(1500000, DefaultCurrency)},
+		{`null`, Money{}},
+	}
+
+	for _, c := range cases {
+		var got Money
+		if err := json.Unmarshal([]byte(c.input), &got); err != nil || got != c.want {
+			t.Errorf("unmarshal %s = %+v, %v, want %+v", c.input, got, err, c.want)
+			continue
+		}
+
+		if c.want.IsZero() && c.want.Currency == "" {
+			continue
+		}
+
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("marshal %+v: %v", got, err)
+		}
+
+		var again Money
+		if err = json.Unmarshal(data, &again); err != nil || again != got {
+			t.Errorf("round trip of %s through %s = %+v, %v", c.input, data, again, err)
+		}
+	}
+
+	var invalid Money
+	if err := json.Unmarshal([]byte(`"12.345"`), &invalid); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("unmarshal of too many decimals: err = %v, want %v", err, ErrInvalidMoney)
+	}
+}
+
+func TestMoneyBSON(t *testing.T) {
+	type document struct {
+		Price Money `bson:"price"`
+	}
+
+	for _, money := range []Money{NewMoney(1500050, CurrencyIDR), NewMoney(-1, CurrencyUSD), NewMoney(1500, CurrencyJPY)} {
+		data, err := bson.Marshal(document{Price: money})
+		if err != nil {
+			t.Fatalf("marshal %+v: %v", money, err)
+		}
+
+		var got document
+		if err = bson.Unmarshal(data, &got); err != nil || got.Price != money {
+			t.Errorf("round trip of %+v = %+v, %v", money, got.Price, err)
+		}
+	}
+
+	// Prices stored before the money type were floats in major units.
+	legacy := []struct {
+		value interface{}
+		want  Money
+	}{
+		{15000.5, NewMoney(1500050, DefaultCurrency)},
+		{int32(15000), NewMoney(1500000, DefaultCurrency)},
+		{int64(15000), NewMoney(1500000, DefaultCurrency)},
+		{bson.M{"amount": int64(100)}, NewMoney(100, DefaultCurrency)},
+		{nil, Money{}},
+	}
+
+	for _, c := range legacy {
+		data, err := bson.Marshal(bson.M{"price": c.value})
+		if err != nil {
+			t.Fatalf("marshal %v: %v", c.value, err)
+		}
+
+		var got document
+		if err = bson.Unmarshal(data, &got); err != nil || got.Price != c.want {
+			t.Errorf("decode of %v = %+v, %v, want %+v", c.value, got.Price, err, c.want)
+		}
+	}
+
+	data, _ := bson.Marshal(bson.M{"price": "15000"})
+	var got document
+	if err := bson.Unmarshal(data, &got); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("decode of a string: err = %v, want %v", err, ErrInvalidMoney)
+	}
+}
+
+func TestMoneyCurrencyMismatchPanics(t *testing.T) {
+	cases := []struct {
+		name string
+		op   func()
+	}{
+		{"add", func() { IDR(1).Add(NewMoney(1, CurrencyUSD)) }},
+		{"sub", func() { IDR(1).Sub(NewMoney(1, CurrencyUSD)) }},
+		{"cmp", func() { IDR(1).Cmp(NewMoney(1, CurrencyUSD)) }},
+		{"min", func() { IDR(1).Min(NewMoney(1, CurrencyUSD)) }},
+		{"sum", func() { SumMoney(IDR(1), NewMoney(1, CurrencySGD)) }},
+	}
+
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: mixing currencies did not panic", c.name)
+				}
+			}()
+
+			c.op()
+		}()
+	}
+}
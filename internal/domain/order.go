@@ -6,21 +6,104 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	OrderStatusReserved   = "reserved"
+	OrderStatusPaid       = "paid"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCompleted  = "completed"
+	OrderStatusCancelled  = "cancelled"
+)
+
+const (
+	OrderActorUser   = "user"
+	OrderActorStore  = "store"
+	OrderActorAdmin  = "admin"
+	OrderActorSystem = "system"
+)
+
+const (
+	OrderEventCreated         = "created"
+	OrderEventPaid            = "paid"
+	OrderEventAccepted        = "accepted"
+	OrderEventShipped         = "shipped"
+	OrderEventDelivered       = "delivered"
+	OrderEventCompleted       = "completed"
+	OrderEventCancelled       = "cancelled"
+	OrderEventRefunded        = "refunded"
+	OrderEventReturnRequested = "return_requested"
+	OrderEventReturnApproved  = "return_approved"
+	OrderEventReturnRejected  = "return_rejected"
+	OrderEventReturnShipped   = "return_shipped"
+	OrderEventReturnReceived  = "return_received"
+	OrderEventReturnRefunded  = "return_refunded"
+)
+
+const (
+	CancelReasonChangedMind  = "changed_mind"
+	CancelReasonWrongAddress = "wrong_address"
+	CancelReasonPaymentIssue = "payment_issue"
+	CancelReasonOutOfStock   = "out_of_stock"
+	CancelReasonFraudSuspect = "fraud_suspect"
+	CancelReasonOther        = "other"
+)
+
 type Order struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	OrderID     string             `json:"orderID" bson:"orderID"`
-	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
-	DeliveredAt time.Time          `json:"deliveredAt" bson:"deliveredAt,omitempty"`
-	TotalPrice  float64            `json:"totalPrice" bson:"-"`
-	OrderItems  []OrderItem        `json:"orderItems" bson:"orderItems"`
-	ContactInfo ContactInfo        `json:"contactInfo" bson:"contactInfo"`
-	UserID      primitive.ObjectID `json:"userID" bson:"userID"`
-	Status      string             `json:"status" bson:"status"`
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderID           string             `json:"orderID" bson:"orderID"`
+	CheckoutID        string             `json:"checkoutID,omitempty" bson:"checkoutID,omitempty"`
+	CreatedAt         time.Time          `json:"createdAt" bson:"createdAt"`
+	PaidAt            time.Time          `json:"paidAt" bson:"paidAt,omitempty"`
+	DeliveredAt       time.Time          `json:"deliveredAt" bson:"deliveredAt,omitempty"`
+	CompletedAt       time.Time          `json:"completedAt" bson:"completedAt,omitempty"`
+	SettledAt         time.Time          `json:"settledAt" bson:"settledAt,omitempty"`
+	TotalPrice        Money              `json:"totalPrice" bson:"-"`
+	ShippingCost      Money              `json:"shippingCost" bson:"shippingCost,omitempty"`
+	Discount          Money              `json:"discount" bson:"discount,omitempty"`
+	Tax               []TaxLine          `json:"tax" bson:"tax,omitempty"`
+	TaxTotal          Money              `json:"taxTotal" bson:"taxTotal,omitempty"`
+	GrandTotal        Money              `json:"grandTotal" bson:"grandTotal,omitempty"`
+	OrderItems        []OrderItem        `json:"orderItems" bson:"orderItems"`
+	ContactInfo       ContactInfo        `json:"contactInfo" bson:"contactInfo"`
+	UserID            primitive.ObjectID `json:"userID" bson:"userID"`
+	StoreID           primitive.ObjectID `json:"storeID" bson:"storeID"`
+	Status            string             `json:"status" bson:"status"`
+	PaymentIntentID   string             `json:"-" bson:"paymentIntentID,omitempty"`
+	Cancellation      *OrderCancellation `json:"cancellation,omitempty" bson:"cancellation,omitempty"`
+	Delivery          *OrderDelivery     `json:"delivery,omitempty" bson:"delivery,omitempty"`
+	Shipment          *OrderShipment     `json:"shipment,omitempty" bson:"shipment,omitempty"`
+	Tracking          []TrackingEvent    `json:"tracking,omitempty" bson:"tracking,omitempty"`
+	TrackingUpdatedAt time.Time          `json:"trackingUpdatedAt" bson:"trackingUpdatedAt,omitempty"`
+	Timeline          []OrderEvent       `json:"timeline" bson:"timeline"`
+	// OpenReturns counts the return requests of the order that are not
+	// rejected or refunded yet, the order can't complete while it is set.
+	OpenReturns int `json:"-" bson:"openReturns,omitempty"`
+}
+
+// Checkout groups the orders created from one cart, one order per store.
+type Checkout struct {
+	CheckoutID string  `json:"checkoutID"`
+	Orders     []Order `json:"orders"`
+	GrandTotal Money   `json:"grandTotal"`
+}
+
+// OrderDelivery is the shipping option chosen at checkout, the estimate
+// includes the handling days of the store.
+type OrderDelivery struct {
+	Courier       string    `json:"courier" bson:"courier"`
+	Service       string    `json:"service" bson:"service"`
+	HandlingDays  int       `json:"handlingDays" bson:"handlingDays"`
+	EstimatedFrom time.Time `json:"estimatedFrom" bson:"estimatedFrom"`
+	EstimatedTo   time.Time `json:"estimatedTo" bson:"estimatedTo"`
 }
 
 type OrderItem struct {
 	ProductID primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	StoreID   primitive.ObjectID `json:"storeID" bson:"storeID"`
+	Name      string             `json:"name" bson:"name,omitempty"`
 	Quantity  int64              `json:"quantity" bson:"quantity"`
+	Price     Money              `json:"price" bson:"price"`
 }
 
 type ContactInfo struct {
@@ -30,3 +113,59 @@ type ContactInfo struct {
 	Address      string `json:"address" bson:"address"`
 	OrderComment string `json:"orderComment" bson:"orderComment"`
 }
+
+type OrderCancellation struct {
+	Actor         string             `json:"actor" bson:"actor"`
+	ActorID       primitive.ObjectID `json:"actorID" bson:"actorID"`
+	Reason        string             `json:"reason" bson:"reason"`
+	Note          string             `json:"note" bson:"note"`
+	RefundID      string             `json:"refundID,omitempty" bson:"refundID,omitempty"`
+	RefundPending bool               `json:"refundPending,omitempty" bson:"refundPending,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type OrderEvent struct {
+	Type      string             `json:"type" bson:"type"`
+	Actor     string             `json:"actor" bson:"actor"`
+	ActorID   primitive.ObjectID `json:"actorID" bson:"actorID"`
+	Note      string             `json:"note,omitempty" bson:"note,omitempty"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type OrderShipment struct {
+	Courier        string    `json:"courier" bson:"courier"`
+	Service        string    `json:"service" bson:"service"`
+	TrackingNumber string    `json:"trackingNumber" bson:"trackingNumber"`
+	ShippedAt      time.Time `json:"shippedAt" bson:"shippedAt"`
+}
+
+type PackingSlip struct {
+	OrderID     string         `json:"orderID"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	Recipient   ContactInfo    `json:"recipient"`
+	Shipment    *OrderShipment `json:"shipment,omitempty"`
+	Items       []PackingItem  `json:"items"`
+	TotalWeight int64          `json:"totalWeight"`
+}
+
+type PackingItem struct {
+	ProductID primitive.ObjectID `json:"productID"`
+	Name      string             `json:"name"`
+	Quantity  int64              `json:"quantity"`
+	Weight    int64              `json:"weight"`
+}
+
+type TrackingEvent struct {
+	Code        string    `json:"code" bson:"code"`
+	Description string    `json:"description" bson:"description"`
+	City        string    `json:"city" bson:"city"`
+	OccurredAt  time.Time `json:"occurredAt" bson:"occurredAt"`
+}
+
+type OrderTracking struct {
+	Status      string          `json:"status"`
+	Shipment    *OrderShipment  `json:"shipment"`
+	Events      []TrackingEvent `json:"events"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+	DeliveredAt time.Time       `json:"deliveredAt"`
+}
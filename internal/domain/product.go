@@ -7,12 +7,21 @@ type Product struct {
 	StoreID     primitive.ObjectID `json:"store_id" bson:"store_id"`
 	Name        string             `json:"name" bson:"name"`
 	Description string             `json:"description" bson:"description"`
-	Price       float64            `json:"price" bson:"price"`
+	Price       Money              `json:"price" bson:"price"`
 	TotalRating float64            `json:"total_rating" bson:"-"`
 	CategoryID  primitive.ObjectID `json:"-" bson:"category_id"`
 	Category    Category           `json:"category" bson:"-"`
 	Images      []ProductImage     `json:"images" bson:"images"`
 	Weight      int64              `json:"weight" bson:"weight"`
+	Stock       int64              `json:"stock" bson:"stock"`
+	Draft       bool               `json:"draft" bson:"draft,omitempty"`
+}
+
+type ProductPage struct {
+	Products []Product `json:"products"`
+	Total    int64     `json:"total"`
+	Page     int64     `json:"page"`
+	Limit    int64     `json:"limit"`
 }
 
 type ProductImage struct {
@@ -25,4 +34,5 @@ type Category struct {
 	Name        string             `json:"name" bson:"name"`
 	Description string             `json:"description" bson:"description"`
 	Icon        string             `json:"icon" bson:"icon"`
+	TaxExempt   bool               `json:"tax_exempt" bson:"tax_exempt"`
 }
@@ -0,0 +1,81 @@
+package domain
+
+// Role is a named set of permissions that can be assigned to admin accounts.
+type Role string
+
+// Permission guards a group of admin endpoints, written as resource:action.
+type Permission string
+
+const (
+	RoleSuperAdmin   Role = "super-admin"
+	RoleCatalogAdmin Role = "catalog-admin"
+	RoleSupport      Role = "support"
+	RoleFinance      Role = "finance"
+)
+
+const (
+	PermissionCatalogRead   Permission = "catalog:read"
+	PermissionCatalogWrite  Permission = "catalog:write"
+	PermissionReviewsRead   Permission = "reviews:read"
+	PermissionReviewsWrite  Permission = "reviews:write"
+	PermissionUsersRead     Permission = "users:read"
+	PermissionUsersWrite    Permission = "users:write"
+	PermissionImpersonate   Permission = "users:impersonate"
+	PermissionStoresRead    Permission = "stores:read"
+	PermissionStoresWrite   Permission = "stores:write"
+	PermissionCartsRead     Permission = "carts:read"
+	PermissionCartsWrite    Permission = "carts:write"
+	PermissionOrdersRead    Permission = "orders:read"
+	PermissionOrdersWrite   Permission = "orders:write"
+	PermissionTaxesRead     Permission = "taxes:read"
+	PermissionPayoutsRead   Permission = "payouts:read"
+	PermissionPayoutsWrite  Permission = "payouts:write"
+	PermissionAnalyticsRead Permission = "analytics:read"
+	PermissionAuditRead     Permission = "audit:read"
+	PermissionAdminsManage  Permission = "admins:manage"
+)
+
+// permissionAll is granted to super admins and matches every permission.
+const permissionAll Permission = "*"
+
+var rolePermissions = map[Role][]Permission{
+	RoleSuperAdmin: {permissionAll},
+	RoleCatalogAdmin: {
+		PermissionCatalogRead, PermissionCatalogWrite,
+		PermissionReviewsRead, PermissionReviewsWrite,
+	},
+	RoleSupport: {
+		PermissionCatalogRead, PermissionReviewsRead,
+		PermissionUsersRead, PermissionUsersWrite,
+		PermissionImpersonate,
+		PermissionStoresRead, PermissionStoresWrite,
+		PermissionCartsRead, PermissionCartsWrite,
+		PermissionOrdersRead, PermissionOrdersWrite,
+	},
+	RoleFinance: {
+		PermissionOrdersRead, PermissionTaxesRead,
+		PermissionPayoutsRead, PermissionPayoutsWrite,
+		PermissionAnalyticsRead,
+	},
+}
+
+// Roles lists every role that can be assigned to an admin.
+func Roles() []Role {
+	return []Role{RoleSuperAdmin, RoleCatalogAdmin, RoleSupport, RoleFinance}
+}
+
+func (role Role) IsValid() bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Grants reports whether the role includes the permission.
+func (role Role) Grants(permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permissionAll || granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
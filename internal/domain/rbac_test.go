@@ -0,0 +1,88 @@
+package domain
+
+import "testing"
+
+var allPermissions = []Permission{
+	PermissionCatalogRead, PermissionCatalogWrite,
+	PermissionReviewsRead, PermissionReviewsWrite,
+	PermissionUsersRead, PermissionUsersWrite, PermissionImpersonate,
+	PermissionStoresRead, PermissionStoresWrite,
+	PermissionCartsRead, PermissionCartsWrite,
+	PermissionOrdersRead, PermissionOrdersWrite,
+	PermissionTaxesRead,
+	PermissionPayoutsRead, PermissionPayoutsWrite,
+	PermissionAnalyticsRead,
+	PermissionAuditRead,
+	PermissionAdminsManage,
+}
+
+func TestRolePermissionMatrix(t *testing.T) {
+	granted := map[Role][]Permission{
+		RoleSuperAdmin: allPermissions,
+		RoleCatalogAdmin: {
+			PermissionCatalogRead, PermissionCatalogWrite,
+			PermissionReviewsRead, PermissionReviewsWrite,
+		},
+		RoleSupport: {
+			PermissionCatalogRead, PermissionReviewsRead,
+			PermissionUsersRead, PermissionUsersWrite, PermissionImpersonate,
+			PermissionStoresRead, PermissionStoresWrite,
+			PermissionCartsRead, PermissionCartsWrite,
+			PermissionOrdersRead, PermissionOrdersWrite,
+		},
+		RoleFinance: {
+			PermissionOrdersRead, PermissionTaxesRead,
+			PermissionPayoutsRead, PermissionPayoutsWrite,
+			PermissionAnalyticsRead,
+		},
+	}
+
+	for _, role := range Roles() {
+		want := map[Permission]bool{}
+		for _, permission := range granted[role] {
+			want[permission] = true
+		}
+
+		for _, permission := range allPermissions {
+			if got := role.Grants(permission); got != want[permission] {
+				t.Errorf("%s grants %s = %t, want %t", role, permission, got, want[permission])
+			}
+		}
+	}
+
+	if len(granted) != len(Roles()) {
+		t.Errorf("matrix covers %d roles, Roles lists %d", len(granted), len(Roles()))
+	}
+}
+
+func TestUnknownRoleGrantsNothing(t *testing.T) {
+	role := Role("owner")
+	if role.IsValid() {
+		t.Errorf("%s is valid", role)
+	}
+
+	for _, permission := range allPermissions {
+		if role.Grants(permission) {
+			t.Errorf("%s grants %s", role, permission)
+		}
+	}
+}
+
+func TestAdminHasPermissionOfAnyRole(t *testing.T) {
+	admin := Admin{Roles: []Role{RoleCatalogAdmin, RoleFinance}}
+
+	for permission, want := range map[Permission]bool{
+		PermissionCatalogWrite: true,
+		PermissionPayoutsWrite: true,
+		PermissionUsersRead:    false,
+		PermissionAdminsManage: false,
+	} {
+		if got := admin.HasPermission(permission); got != want {
+			t.Errorf("HasPermission(%s) = %t, want %t", permission, got, want)
+		}
+	}
+
+	if (Admin{}).HasPermission(PermissionCatalogRead) {
+		t.Error("an admin without roles has a permission")
+	}
+}
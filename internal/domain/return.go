@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusShipped   = "shipped"
+	ReturnStatusReceived  = "received"
+	ReturnStatusRefunded  = "refunded"
+	// ReturnStatusRefundPending is a received return whose order has no
+	// captured payment to refund, the buyer is refunded manually.
+	ReturnStatusRefundPending = "refund_pending"
+)
+
+type ReturnRequest struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderID      primitive.ObjectID `json:"orderID" bson:"orderID"`
+	UserID       primitive.ObjectID `json:"userID" bson:"userID"`
+	StoreID      primitive.ObjectID `json:"storeID" bson:"storeID"`
+	ProductID    primitive.ObjectID `json:"productID" bson:"productID"`
+	Quantity     int64              `json:"quantity" bson:"quantity"`
+	Reason       string             `json:"reason" bson:"reason"`
+	Photos       []string           `json:"photos" bson:"photos"`
+	Status       string             `json:"status" bson:"status"`
+	StoreNote    string             `json:"storeNote,omitempty" bson:"storeNote,omitempty"`
+	Shipment     *ReturnShipment    `json:"shipment,omitempty" bson:"shipment,omitempty"`
+	RefundAmount Money              `json:"refundAmount,omitempty" bson:"refundAmount,omitempty"`
+	RefundID     string             `json:"-" bson:"refundID,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt    time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type ReturnShipment struct {
+	Courier        string    `json:"courier" bson:"courier"`
+	TrackingNumber string    `json:"trackingNumber" bson:"trackingNumber"`
+	ShippedAt      time.Time `json:"shippedAt" bson:"shippedAt"`
+}
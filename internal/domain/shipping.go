@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShippingQuote lists the delivery options for the items of one store, the
+// weight is in grams.
+type ShippingQuote struct {
+	StoreID primitive.ObjectID `json:"store_id"`
+	Weight  int64              `json:"weight"`
+	Options []ShippingOption   `json:"options"`
+}
+
+// ShippingOption is a courier service. Cost is what the buyer pays, the
+// courier charge unless the order ships free plus the handling fee of the
+// store. MinDays and MaxDays count from the order and include the handling
+// days of the store.
+type ShippingOption struct {
+	Courier      string `json:"courier"`
+	Service      string `json:"service"`
+	Description  string `json:"description"`
+	Cost         Money  `json:"cost"`
+	HandlingFee  Money  `json:"handling_fee"`
+	FreeShipping bool   `json:"free_shipping"`
+	HandlingDays int    `json:"handling_days"`
+	MinDays      int    `json:"min_days"`
+	MaxDays      int    `json:"max_days"`
+}
+
+// ShippingRules are the delivery settings of a store. A store without
+// couriers ships with every courier and service the platform quotes.
+type ShippingRules struct {
+	Couriers          []CourierServices    `json:"couriers" bson:"couriers,omitempty"`
+	FreeShippingMin   *Money               `json:"free_shipping_min,omitempty" bson:"free_shipping_min,omitempty"`
+	HandlingFee       Money                `json:"handling_fee" bson:"handling_fee"`
+	ExcludedProvinces []primitive.ObjectID `json:"excluded_provinces" bson:"excluded_provinces,omitempty"`
+}
+
+// CourierServices are the services of one courier a store ships with, all
+// of them when Services is empty.
+type CourierServices struct {
+	Code     string   `json:"code" bson:"code"`
+	Services []string `json:"services" bson:"services,omitempty"`
+}
+
+// ShipsWith reports whether the store ships with any service of the courier.
+func (r ShippingRules) ShipsWith(courier string) bool {
+	if len(r.Couriers) == 0 {
+		return true
+	}
+
+	for _, allowed := range r.Couriers {
+		if strings.EqualFold(allowed.Code, courier) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Allows reports whether the store ships with the courier service.
+func (r ShippingRules) Allows(courier string, service string) bool {
+	if len(r.Couriers) == 0 {
+		return true
+	}
+
+	for _, allowed := range r.Couriers {
+		if !strings.EqualFold(allowed.Code, courier) {
+			continue
+		}
+		if len(allowed.Services) == 0 {
+			return true
+		}
+		for _, allowedService := range allowed.Services {
+			if strings.EqualFold(allowedService, service) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Excludes reports whether the store does not ship to the province.
+func (r ShippingRules) Excludes(provinceID primitive.ObjectID) bool {
+	for _, excluded := range r.ExcludedProvinces {
+		if excluded == provinceID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ShipsFree reports whether an order of the given subtotal reaches the free
+// shipping threshold.
+func (r ShippingRules) ShipsFree(subtotal Money) bool {
+	return r.FreeShippingMin != nil && subtotal.Cmp(*r.FreeShippingMin) >= 0
+}
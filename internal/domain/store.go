@@ -1,11 +1,109 @@
 package domain
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// New stores wait for an admin to check their documents before they can
+// sell. Stores registered before verification existed have no verification
+// status and count as approved.
+const (
+	VerificationPending  = "pending"
+	VerificationApproved = "approved"
+	VerificationRejected = "rejected"
+)
+
+const (
+	StoreDocumentIdentity = "identity"
+	StoreDocumentBusiness = "business"
+)
 
 type Store struct {
 	ID                 primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Name               string             `json:"name" bson:"name"`
 	Domain             string             `json:"domain" bson:"domain"`
+	Description        string             `json:"description" bson:"description,omitempty"`
+	Logo               string             `json:"logo" bson:"logo,omitempty"`
+	Banner             string             `json:"banner" bson:"banner,omitempty"`
 	ShipmentCityID     primitive.ObjectID `json:"shipment_city_id" bson:"shipment_city_id"`
 	ShipmentProvinceID primitive.ObjectID `json:"shipment_province_id" bson:"shipment_province_id"`
+	PricesIncludeTax   bool               `json:"prices_include_tax" bson:"prices_include_tax"`
+	BankAccount        *BankAccount       `json:"bank_account,omitempty" bson:"bank_account,omitempty"`
+	Status             string             `json:"status,omitempty" bson:"status,omitempty"`
+	StatusReason       string             `json:"status_reason,omitempty" bson:"status_reason,omitempty"`
+	Verification       StoreVerification  `json:"verification" bson:"verification,omitempty"`
+	OperatingHours     []OperatingHours   `json:"operating_hours" bson:"operating_hours,omitempty"`
+	Holiday            *StoreHoliday      `json:"holiday,omitempty" bson:"holiday,omitempty"`
+	HandlingDays       int                `json:"handling_days" bson:"handling_days,omitempty"`
+	Shipping           ShippingRules      `json:"shipping" bson:"shipping,omitempty"`
+}
+
+// IsApproved reports whether the store passed verification and may publish
+// products.
+func (s Store) IsApproved() bool {
+	return s.Verification.Status == "" || s.Verification.Status == VerificationApproved
+}
+
+// IsListed reports whether buyers can browse the store.
+func (s Store) IsListed() bool {
+	return IsActive(s.Status) && s.IsApproved()
+}
+
+// OnHoliday reports whether the store has paused sales at the given time.
+func (s Store) OnHoliday(now time.Time) bool {
+	return s.Holiday != nil && !now.Before(s.Holiday.Start) && now.Before(s.Holiday.End)
+}
+
+// OperatingHours are the opening times of one weekday, Day 0 is Sunday. Open
+// and Close are HH:MM in the local time of the store.
+type OperatingHours struct {
+	Day   int    `json:"day" bson:"day"`
+	Open  string `json:"open" bson:"open"`
+	Close string `json:"close" bson:"close"`
+}
+
+// StoreHoliday pauses sales from Start until End, buyers see the message
+// instead of being able to order.
+type StoreHoliday struct {
+	Start   time.Time `json:"start" bson:"start"`
+	End     time.Time `json:"end" bson:"end"`
+	Message string    `json:"message" bson:"message"`
+}
+
+type StoreVerification struct {
+	Status     string             `json:"status" bson:"status"`
+	Documents  []StoreDocument    `json:"documents" bson:"documents,omitempty"`
+	Note       string             `json:"note,omitempty" bson:"note,omitempty"`
+	ReviewedBy primitive.ObjectID `json:"reviewed_by,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time         `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+}
+
+// StoreDocument is a verification document. The file sits in private
+// storage under Key and is only handed to admins through a short-lived link.
+// URL is the public location of documents uploaded before that.
+type StoreDocument struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	Type       string             `json:"type" bson:"type"`
+	Key        string             `json:"-" bson:"key,omitempty"`
+	URL        string             `json:"-" bson:"url,omitempty"`
+	UploadedAt time.Time          `json:"uploaded_at" bson:"uploaded_at"`
+}
+
+// Storefront is the public profile of a store that buyers browse.
+type Storefront struct {
+	ID           primitive.ObjectID `json:"id"`
+	Name         string             `json:"name"`
+	Domain       string             `json:"domain"`
+	Description  string             `json:"description"`
+	Logo         string             `json:"logo"`
+	Banner       string             `json:"banner"`
+	Rating       float64            `json:"rating"`
+	ReviewCount  int64              `json:"review_count"`
+	ProductCount int64              `json:"product_count"`
+	City         *City              `json:"city,omitempty"`
+	Hours        []OperatingHours   `json:"operating_hours"`
+	Holiday      *StoreHoliday      `json:"holiday,omitempty"`
+	HandlingDays int                `json:"handling_days"`
 }
@@ -1,5 +1,6 @@
 package domain
 
 type Command struct {
-	Seeds bool
+	Seeds   bool
+	Migrate bool
 }
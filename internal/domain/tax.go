@@ -0,0 +1,51 @@
+package domain
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	TaxPeriodDay   = "day"
+	TaxPeriodMonth = "month"
+)
+
+// TaxLine is the tax charged on a group of items sharing the same rate and
+// pricing mode. Inclusive lines are already contained in the item prices.
+type TaxLine struct {
+	Name      string  `json:"name" bson:"name"`
+	Rate      float64 `json:"rate" bson:"rate"`
+	Inclusive bool    `json:"inclusive" bson:"inclusive"`
+	Base      Money   `json:"base" bson:"base"`
+	Amount    Money   `json:"amount" bson:"amount"`
+}
+
+type TaxReportRow struct {
+	StoreID   primitive.ObjectID `json:"storeID" bson:"storeID"`
+	Period    string             `json:"period" bson:"period"`
+	Name      string             `json:"name" bson:"name"`
+	Rate      float64            `json:"rate" bson:"rate"`
+	Inclusive bool               `json:"inclusive" bson:"inclusive"`
+	Orders    int64              `json:"orders" bson:"orders"`
+	Base      Money              `json:"base" bson:"base"`
+	Amount    Money              `json:"amount" bson:"amount"`
+}
+
+// ExclusiveTax sums the tax that is charged on top of the item prices.
+func ExclusiveTax(lines []TaxLine) Money {
+	var amount Money
+	for _, line := range lines {
+		if !line.Inclusive {
+			amount = amount.Add(line.Amount)
+		}
+	}
+
+	return amount
+}
+
+// TotalTax sums all tax lines, inclusive and exclusive.
+func TotalTax(lines []TaxLine) Money {
+	var amount Money
+	for _, line := range lines {
+		amount = amount.Add(line.Amount)
+	}
+
+	return amount
+}
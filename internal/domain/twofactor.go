@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AccountUser  = "user"
+	AccountAdmin = "admin"
+)
+
+// AccountRef points at a user or an admin account, the two kinds of account
+// that can sign in.
+type AccountRef struct {
+	Kind string
+	ID   primitive.ObjectID
+}
+
+// IDName is the claim the account's ID is carried in.
+func (a AccountRef) IDName() string {
+	if a.Kind == AccountAdmin {
+		return "adminID"
+	}
+
+	return "userID"
+}
+
+// TwoFactor is the TOTP state of an account. Secrets are stored encrypted
+// and recovery codes as SHA-256 hashes.
+type TwoFactor struct {
+	Enabled       bool       `json:"enabled" bson:"enabled"`
+	Secret        string     `json:"-" bson:"secret,omitempty"`
+	PendingSecret string     `json:"-" bson:"pendingSecret,omitempty"`
+	RecoveryCodes []string   `json:"-" bson:"recoveryCodes,omitempty"`
+	EnabledAt     *time.Time `json:"enabledAt,omitempty" bson:"enabledAt,omitempty"`
+}
+
+type TwoFactorAccount struct {
+	Email     string    `bson:"email"`
+	TwoFactor TwoFactor `bson:"twoFactor"`
+}
+
+// TwoFactorSetup is returned when enrolment starts, the URI is meant to be
+// rendered as a QR code.
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningURI"`
+}
+
+// TwoFactorChallenge is returned by sign-in instead of tokens when a second
+// factor is needed. EnrolmentRequired is set for admins who must set up 2FA
+// before they can sign in.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool      `json:"twoFactorRequired"`
+	EnrolmentRequired bool      `json:"enrolmentRequired"`
+	ChallengeToken    string    `json:"challengeToken"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+}
+
+// TwoFactorLogin is a passed challenge, ready to be turned into a session.
+type TwoFactorLogin struct {
+	Account       AccountRef
+	Fingerprint   string
+	Device        string
+	RecoveryCodes []string
+}
+
+type SecuritySettings struct {
+	RequireAdminTwoFactor bool      `json:"requireAdminTwoFactor" bson:"requireAdminTwoFactor"`
+	UpdatedAt             time.Time `json:"updatedAt" bson:"updatedAt"`
+}
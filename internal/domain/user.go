@@ -1,12 +1,48 @@
 package domain
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Users and stores are active unless an admin suspended or banned them. A
+// suspension is meant to be lifted, a ban is not.
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusBanned    = "banned"
+)
+
+// IsActive reports whether an account with the status may be used, accounts
+// created before statuses existed have none and are active.
+func IsActive(status string) bool {
+	return status == "" || status == StatusActive
+}
 
 type User struct {
-	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name     string             `json:"name" bson:"name"`
-	Email    string             `json:"email" bson:"email"`
-	Password string             `json:"-" bson:"password"`
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name            string             `json:"name" bson:"name"`
+	Email           string             `json:"email" bson:"email"`
+	PendingEmail    string             `json:"pendingEmail,omitempty" bson:"pendingEmail,omitempty"`
+	Phone           string             `json:"phone" bson:"phone,omitempty"`
+	Avatar          string             `json:"avatar" bson:"avatar,omitempty"`
+	Password        string             `json:"-" bson:"password"`
+	EmailVerified   bool               `json:"emailVerified" bson:"emailVerified"`
+	EmailVerifiedAt *time.Time         `json:"emailVerifiedAt,omitempty" bson:"emailVerifiedAt,omitempty"`
+	Identities      []ExternalIdentity `json:"identities,omitempty" bson:"identities,omitempty"`
+	TwoFactor       TwoFactor          `json:"twoFactor" bson:"twoFactor,omitempty"`
+	Status          string             `json:"status,omitempty" bson:"status,omitempty"`
+	StatusReason    string             `json:"statusReason,omitempty" bson:"statusReason,omitempty"`
+}
+
+// ExternalIdentity links an account at an OpenID Connect provider to a user.
+type ExternalIdentity struct {
+	Provider string    `json:"provider" bson:"provider"`
+	Issuer   string    `json:"issuer" bson:"issuer"`
+	Subject  string    `json:"-" bson:"subject"`
+	Email    string    `json:"email" bson:"email"`
+	LinkedAt time.Time `json:"linkedAt" bson:"linkedAt"`
 }
 
 type LoginUser struct {
@@ -17,6 +53,20 @@ type LoginUser struct {
 }
 
 type UserInfo struct {
-	Name  string `json:"name" bson:"name"`
-	Email string `json:"email" bson:"email"`
+	Name          string `json:"name" bson:"name"`
+	Email         string `json:"email" bson:"email"`
+	PendingEmail  string `json:"pendingEmail,omitempty" bson:"pendingEmail,omitempty"`
+	EmailVerified bool   `json:"emailVerified" bson:"emailVerified"`
+	Phone         string `json:"phone" bson:"phone,omitempty"`
+	Avatar        string `json:"avatar" bson:"avatar,omitempty"`
+}
+
+// AccountExport is everything the shop keeps about a user, handed out on
+// request as a JSON archive.
+type AccountExport struct {
+	ExportedAt time.Time `json:"exportedAt"`
+	Account    User      `json:"account"`
+	Addresses  []Address `json:"addresses"`
+	Orders     []Order   `json:"orders"`
+	Reviews    []Review  `json:"reviews"`
 }
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	LedgerAccountClearing   = "platform:clearing"
+	LedgerAccountCommission = "platform:commission"
+	LedgerAccountPayout     = "platform:payout"
+)
+
+const (
+	LedgerTransactionSettlement = "order_settlement"
+	LedgerTransactionPayout     = "payout"
+)
+
+const (
+	LedgerEntrySettlement = "settlement"
+	LedgerEntryRefund     = "refund"
+	LedgerEntryCommission = "commission"
+	LedgerEntryPayout     = "payout"
+)
+
+const (
+	PayoutStatusRequested = "requested"
+	PayoutStatusApproved  = "approved"
+	PayoutStatusRejected  = "rejected"
+	PayoutStatusPaid      = "paid"
+)
+
+// LedgerTransaction is an immutable double-entry record, the amounts of its
+// entries always sum to zero. Credits are positive and debits are negative.
+type LedgerTransaction struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Type        string             `json:"type" bson:"type"`
+	Reference   primitive.ObjectID `json:"reference" bson:"reference"`
+	StoreID     primitive.ObjectID `json:"storeID" bson:"storeID"`
+	Entries     []LedgerEntry      `json:"entries" bson:"entries"`
+	Description string             `json:"description" bson:"description"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type LedgerEntry struct {
+	Account string `json:"account" bson:"account"`
+	Type    string `json:"type" bson:"type"`
+	Amount  Money  `json:"amount" bson:"amount"`
+}
+
+type StatementLine struct {
+	TransactionID primitive.ObjectID `json:"transactionID" bson:"_id"`
+	Type          string             `json:"type" bson:"type"`
+	Reference     primitive.ObjectID `json:"reference" bson:"reference"`
+	Description   string             `json:"description" bson:"description"`
+	Amount        Money              `json:"amount" bson:"amount"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type Statement struct {
+	Lines []StatementLine `json:"lines"`
+	Total int64           `json:"total"`
+	Page  int64           `json:"page"`
+	Limit int64           `json:"limit"`
+}
+
+type Wallet struct {
+	Balance   Money     `json:"balance"`
+	Pending   Money     `json:"pending"`
+	Available Money     `json:"available"`
+	Statement Statement `json:"statement"`
+}
+
+type Payout struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	StoreID     primitive.ObjectID `json:"storeID" bson:"storeID"`
+	Amount      Money              `json:"amount" bson:"amount"`
+	BankAccount BankAccount        `json:"bankAccount" bson:"bankAccount"`
+	Status      string             `json:"status" bson:"status"`
+	AdminID     primitive.ObjectID `json:"adminID,omitempty" bson:"adminID,omitempty"`
+	Note        string             `json:"note,omitempty" bson:"note,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	PaidAt      time.Time          `json:"paidAt" bson:"paidAt,omitempty"`
+}
+
+type BankAccount struct {
+	BankName      string `json:"bankName" bson:"bankName"`
+	AccountNumber string `json:"accountNumber" bson:"accountNumber"`
+	AccountHolder string `json:"accountHolder" bson:"accountHolder"`
+}
+
+func StoreLedgerAccount(storeID primitive.ObjectID) string {
+	return "store:" + storeID.Hex()
+}
@@ -61,6 +61,11 @@ func (repo *AddressesRepo) Delete(ctx context.Context, userID primitive.ObjectID
 	return err
 }
 
+func (repo *AddressesRepo) DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := repo.db.DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
+
 func NewAddressesRepo(db *mongo.Database) *AddressesRepo {
 	collection := db.Collection(addressesCollection)
 	indexModel := mongo.IndexModel{
@@ -2,14 +2,47 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// superAdminsLockID is the lock taken while super admins are demoted or
+// deleted, so two removals can't both pass the last super admin check.
+const superAdminsLockID = "super-admins"
+
+var ErrAdminsLocked = errors.New("another admin change is in progress")
+
 type AdminsRepo struct {
-	db *mongo.Collection
+	db    *mongo.Collection
+	locks *mongo.Collection
+}
+
+func (a AdminsRepo) FindAll(ctx context.Context) ([]domain.Admin, error) {
+	cursor, err := a.db.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"email": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var admins []domain.Admin
+	err = cursor.All(ctx, &admins)
+	return admins, err
+}
+
+func (a AdminsRepo) FindByID(ctx context.Context, adminID primitive.ObjectID) (domain.Admin, error) {
+	result := a.db.FindOne(ctx, bson.M{"_id": adminID})
+
+	var admin domain.Admin
+	err := result.Decode(&admin)
+
+	return admin, err
 }
 
 func (a AdminsRepo) FindByCredentials(ctx context.Context, email string) (domain.Admin, error) {
@@ -21,8 +54,111 @@ func (a AdminsRepo) FindByCredentials(ctx context.Context, email string) (domain
 	return admin, err
 }
 
+func (a AdminsRepo) CountByRole(ctx context.Context, role domain.Role) (int64, error) {
+	return a.db.CountDocuments(ctx, bson.M{"roles": role})
+}
+
+func (a AdminsRepo) Create(ctx context.Context, admin domain.Admin) (domain.Admin, error) {
+	admin.ID = primitive.NewObjectID()
+	_, err := a.db.InsertOne(ctx, admin)
+	return admin, err
+}
+
+func (a AdminsRepo) Update(ctx context.Context, adminID primitive.ObjectID, adminInput dto.UpdateAdminInput) (domain.Admin, error) {
+	updateQuery := bson.M{}
+
+	if adminInput.Name != "" {
+		updateQuery["name"] = adminInput.Name
+	}
+
+	if len(adminInput.Roles) > 0 {
+		updateQuery["roles"] = adminInput.Roles
+	}
+
+	result := a.db.FindOneAndUpdate(ctx, bson.M{"_id": adminID}, bson.M{"$set": updateQuery},
+		options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+	var admin domain.Admin
+	err := result.Decode(&admin)
+
+	return admin, err
+}
+
+func (a AdminsRepo) UpdatePassword(ctx context.Context, adminID primitive.ObjectID, passwordHash string) error {
+	result, err := a.db.UpdateOne(ctx, bson.M{"_id": adminID}, bson.M{"$set": bson.M{"password": passwordHash}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (a AdminsRepo) Delete(ctx context.Context, adminID primitive.ObjectID) error {
+	result, err := a.db.DeleteOne(ctx, bson.M{"_id": adminID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// LockSuperAdmins holds the super admins lock until it is unlocked or ttl has
+// passed. The returned token unlocks it.
+func (a AdminsRepo) LockSuperAdmins(ctx context.Context, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := primitive.NewObjectID().Hex()
+
+	// An expired lock matches and is taken over, a held one doesn't and the
+	// upsert collides with it on _id.
+	_, err := a.locks.UpdateOne(ctx,
+		bson.M{"_id": superAdminsLockID, "expiresAt": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"token": token, "expiresAt": now.Add(ttl)}},
+		options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return "", ErrAdminsLocked
+	}
+
+	return token, err
+}
+
+// UnlockSuperAdmins releases the super admins lock if it is still held by
+// token.
+func (a AdminsRepo) UnlockSuperAdmins(ctx context.Context, token string) error {
+	_, err := a.locks.DeleteOne(ctx, bson.M{"_id": superAdminsLockID, "token": token})
+	return err
+}
+
 func NewAdminsRepo(db *mongo.Database) *AdminsRepo {
+	collection := db.Collection(adminsCollection)
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := collection.Indexes().CreateOne(context.Background(), indexModel)
+	if err != nil {
+		log.Fatalf("unable to create admins collection index, %v", err)
+	}
+
+	locks := db.Collection(adminLocksCollection)
+	_, err = locks.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Fatalf("unable to create admin lock collection index, %v", err)
+	}
+
 	return &AdminsRepo{
-		db: db.Collection(adminsCollection),
+		db:    collection,
+		locks: locks,
 	}
 }
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AnalyticsRepo runs the aggregations behind the admin dashboard. An order
+// counts as placed once it left the reserved status, GMV leaves out the
+// cancelled ones.
+type AnalyticsRepo struct {
+	orders *mongo.Collection
+	carts  *mongo.Collection
+	users  *mongo.Collection
+}
+
+func (a *AnalyticsRepo) Sales(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.SalesPoint, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: soldMatch(filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"period":   periodOf("$createdAt", filter),
+				"currency": "$grandTotal.currency",
+			},
+			"orders": bson.M{"$sum": 1},
+			"gmv":    bson.M{"$sum": "$grandTotal.amount"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":    0,
+			"period": "$_id.period",
+			"orders": 1,
+			"gmv":    bson.M{"amount": "$gmv", "currency": "$_id.currency"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "period", Value: 1}, {Key: "gmv.currency", Value: 1}}}},
+	}
+
+	points := []domain.SalesPoint{}
+	err := a.aggregate(ctx, a.orders, pipeline, &points)
+	return points, err
+}
+
+func (a *AnalyticsRepo) TopProducts(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.TopProduct, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: soldMatch(filter)}},
+		{{Key: "$unwind", Value: "$orderItems"}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"productID": "$orderItems._id",
+				"currency":  "$orderItems.price.currency",
+			},
+			"storeID":  bson.M{"$first": "$storeID"},
+			"name":     bson.M{"$last": "$orderItems.name"},
+			"orders":   bson.M{"$sum": 1},
+			"quantity": bson.M{"$sum": "$orderItems.quantity"},
+			"revenue": bson.M{"$sum": bson.M{
+				"$multiply": bson.A{"$orderItems.price.amount", "$orderItems.quantity"},
+			}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "quantity", Value: -1}}}},
+		{{Key: "$limit", Value: filter.Limit}},
+		{{Key: "$project", Value: bson.M{
+			"_id":       0,
+			"productID": "$_id.productID",
+			"storeID":   1,
+			"name":      1,
+			"orders":    1,
+			"quantity":  1,
+			"revenue":   bson.M{"amount": "$revenue", "currency": "$_id.currency"},
+		}}},
+	}
+
+	products := []domain.TopProduct{}
+	err := a.aggregate(ctx, a.orders, pipeline, &products)
+	return products, err
+}
+
+func (a *AnalyticsRepo) TopStores(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.TopStore, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: soldMatch(filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"storeID":  "$storeID",
+				"currency": "$grandTotal.currency",
+			},
+			"orders": bson.M{"$sum": 1},
+			"gmv":    bson.M{"$sum": "$grandTotal.amount"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "gmv", Value: -1}, {Key: "orders", Value: -1}}}},
+		{{Key: "$limit", Value: filter.Limit}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         storesCollection,
+			"localField":   "_id.storeID",
+			"foreignField": "_id",
+			"as":           "store",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$store", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":     0,
+			"storeID": "$_id.storeID",
+			"name":    "$store.name",
+			"domain":  "$store.domain",
+			"orders":  1,
+			"gmv":     bson.M{"amount": "$gmv", "currency": "$_id.currency"},
+		}}},
+	}
+
+	stores := []domain.TopStore{}
+	err := a.aggregate(ctx, a.orders, pipeline, &stores)
+	return stores, err
+}
+
+// CartConversion looks at the carts changed in the range and counts those
+// whose owner placed an order in the same range.
+func (a *AnalyticsRepo) CartConversion(ctx context.Context, filter dto.AnalyticsFilter) (domain.CartConversion, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"updatedAt": rangeMatch(filter)}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": ordersCollection,
+			"let":  bson.M{"userID": "$userID"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"status":    bson.M{"$ne": domain.OrderStatusReserved},
+					"createdAt": rangeMatch(filter),
+					"$expr":     bson.M{"$eq": bson.A{"$userID", "$$userID"}},
+				}},
+				bson.M{"$limit": 1},
+				bson.M{"$project": bson.M{"_id": 1}},
+			},
+			"as": "orders",
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"carts": bson.M{"$sum": 1},
+			"converted": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$gt": bson.A{bson.M{"$size": "$orders"}, 0}}, 1, 0},
+			}},
+		}}},
+	}
+
+	conversions := []domain.CartConversion{}
+	if err := a.aggregate(ctx, a.carts, pipeline, &conversions); err != nil || len(conversions) == 0 {
+		return domain.CartConversion{}, err
+	}
+
+	return conversions[0], nil
+}
+
+// NewUsers buckets sign-ups by the creation time held in the object id, users
+// have no separate creation date.
+func (a *AnalyticsRepo) NewUsers(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.NewUsersPoint, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": bson.M{
+			"$gte": primitive.NewObjectIDFromTimestamp(filter.From),
+			"$lt":  primitive.NewObjectIDFromTimestamp(filter.To),
+		}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   periodOf(bson.M{"$toDate": "$_id"}, filter),
+			"users": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "period": "$_id", "users": 1}}},
+		{{Key: "$sort", Value: bson.D{{Key: "period", Value: 1}}}},
+	}
+
+	points := []domain.NewUsersPoint{}
+	err := a.aggregate(ctx, a.users, pipeline, &points)
+	return points, err
+}
+
+func (a *AnalyticsRepo) OrderRates(ctx context.Context, filter dto.AnalyticsFilter) (domain.OrderRates, error) {
+	refundEvents := bson.A{domain.OrderEventRefunded, domain.OrderEventReturnRefunded}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"status":    bson.M{"$ne": domain.OrderStatusReserved},
+			"createdAt": rangeMatch(filter),
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    nil,
+			"orders": bson.M{"$sum": 1},
+			"cancelled": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$eq": bson.A{"$status", domain.OrderStatusCancelled}}, 1, 0},
+			}},
+			"refunded": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$setIntersection": bson.A{
+					bson.M{"$ifNull": bson.A{"$timeline.type", bson.A{}}}, refundEvents,
+				}}}, 0}}, 1, 0},
+			}},
+		}}},
+	}
+
+	rates := []domain.OrderRates{}
+	if err := a.aggregate(ctx, a.orders, pipeline, &rates); err != nil || len(rates) == 0 {
+		return domain.OrderRates{}, err
+	}
+
+	return rates[0], nil
+}
+
+// StoreSales sums the orders of the store per period, revenue is the item
+// subtotal so shipping and tax collected by the store are left out.
+func (a *AnalyticsRepo) StoreSales(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.StoreSalesPoint, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: soldMatch(filter)}},
+		{{Key: "$project", Value: bson.M{
+			"period":   periodOf("$createdAt", filter),
+			"currency": bson.M{"$arrayElemAt": bson.A{"$orderItems.price.currency", 0}},
+			"units":    bson.M{"$sum": "$orderItems.quantity"},
+			"revenue": bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": "$orderItems",
+				"as":    "item",
+				"in":    bson.M{"$multiply": bson.A{"$$item.price.amount", "$$item.quantity"}},
+			}}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"period": "$period", "currency": "$currency"},
+			"orders":  bson.M{"$sum": 1},
+			"units":   bson.M{"$sum": "$units"},
+			"revenue": bson.M{"$sum": "$revenue"},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$_id.period",
+			"orders":  bson.M{"$sum": "$orders"},
+			"units":   bson.M{"$sum": "$units"},
+			"revenue": bson.M{"$push": bson.M{"amount": "$revenue", "currency": "$_id.currency"}},
+		}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "period": "$_id", "orders": 1, "units": 1, "revenue": 1}}},
+		{{Key: "$sort", Value: bson.D{{Key: "period", Value: 1}}}},
+	}
+
+	points := []domain.StoreSalesPoint{}
+	err := a.aggregate(ctx, a.orders, pipeline, &points)
+	return points, err
+}
+
+// StoreProducts sums the orders of the store per product.
+func (a *AnalyticsRepo) StoreProducts(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.ProductPerformance, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: soldMatch(filter)}},
+		{{Key: "$unwind", Value: "$orderItems"}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"productID": "$orderItems._id",
+				"currency":  "$orderItems.price.currency",
+			},
+			"name":   bson.M{"$last": "$orderItems.name"},
+			"orders": bson.M{"$sum": 1},
+			"units":  bson.M{"$sum": "$orderItems.quantity"},
+			"revenue": bson.M{"$sum": bson.M{
+				"$multiply": bson.A{"$orderItems.price.amount", "$orderItems.quantity"},
+			}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$_id.productID",
+			"name":    bson.M{"$last": "$name"},
+			"orders":  bson.M{"$sum": "$orders"},
+			"units":   bson.M{"$sum": "$units"},
+			"revenue": bson.M{"$push": bson.M{"amount": "$revenue", "currency": "$_id.currency"}},
+		}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "productID": "$_id", "name": 1, "orders": 1, "units": 1, "revenue": 1}}},
+	}
+
+	products := []domain.ProductPerformance{}
+	err := a.aggregate(ctx, a.orders, pipeline, &products)
+	return products, err
+}
+
+func (a *AnalyticsRepo) aggregate(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline,
+	results interface{}) error {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, results)
+}
+
+func soldMatch(filter dto.AnalyticsFilter) bson.M {
+	match := bson.M{
+		"status":    bson.M{"$nin": []string{domain.OrderStatusReserved, domain.OrderStatusCancelled}},
+		"createdAt": rangeMatch(filter),
+	}
+
+	if !filter.StoreID.IsZero() {
+		match["storeID"] = filter.StoreID
+	}
+
+	return match
+}
+
+func rangeMatch(filter dto.AnalyticsFilter) bson.M {
+	return bson.M{"$gte": filter.From, "$lt": filter.To}
+}
+
+// periodOf labels a date with its day, ISO week or month in the timezone of
+// the filter.
+func periodOf(date interface{}, filter dto.AnalyticsFilter) bson.M {
+	format := "%Y-%m-%d"
+	switch filter.Period {
+	case domain.AnalyticsPeriodWeek:
+		format = "%G-W%V"
+	case domain.AnalyticsPeriodMonth:
+		format = "%Y-%m"
+	}
+
+	return bson.M{"$dateToString": bson.M{
+		"format":   format,
+		"date":     date,
+		"timezone": filter.Timezone,
+	}}
+}
+
+func NewAnalyticsRepo(db *mongo.Database) *AnalyticsRepo {
+	return &AnalyticsRepo{
+		orders: db.Collection(ordersCollection),
+		carts:  db.Collection(cartsCollection),
+		users:  db.Collection(usersCollection),
+	}
+}
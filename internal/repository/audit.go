@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepo struct {
+	db *mongo.Collection
+}
+
+func (a *AuditRepo) Insert(ctx context.Context, entry domain.AuditLog) (domain.AuditLog, error) {
+	entry.ID = primitive.NewObjectID()
+	_, err := a.db.InsertOne(ctx, entry)
+
+	return entry, err
+}
+
+// Find pages through the entries matching the filter, newest first.
+func (a *AuditRepo) Find(ctx context.Context, filter dto.AuditFilter) ([]domain.AuditLog, int64, error) {
+	query := bson.M{}
+	if filter.ActorType != "" {
+		query["actorType"] = filter.ActorType
+	}
+	if !filter.ActorID.IsZero() {
+		query["actorID"] = filter.ActorID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.TargetType != "" {
+		query["targetType"] = filter.TargetType
+	}
+	if !filter.TargetID.IsZero() {
+		query["targetID"] = filter.TargetID
+	}
+	if filter.RequestID != "" {
+		query["requestID"] = filter.RequestID
+	}
+
+	createdAt := bson.M{}
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		createdAt["$lt"] = filter.To
+	}
+	if len(createdAt) > 0 {
+		query["createdAt"] = createdAt
+	}
+
+	total, err := a.db.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip((filter.Page - 1) * filter.Limit).
+		SetLimit(filter.Limit)
+
+	cursor, err := a.db.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := []domain.AuditLog{}
+	err = cursor.All(ctx, &entries)
+
+	return entries, total, err
+}
+
+// DeleteBefore removes the entries that are past the retention period.
+func (a *AuditRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := a.db.DeleteMany(ctx, bson.M{"createdAt": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+func NewAuditRepo(db *mongo.Database) *AuditRepo {
+	// The before and after values of changes are free-form, decoding nested
+	// documents as maps keeps them the JSON objects they were written as.
+	registry := bson.NewRegistryBuilder().
+		RegisterTypeMapEntry(bsontype.EmbeddedDocument, reflect.TypeOf(bson.M{})).
+		Build()
+	collection := db.Collection(auditCollection, options.Collection().SetRegistry(registry))
+
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "actorID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "targetType", Value: 1}, {Key: "targetID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "requestID", Value: 1}}},
+	}
+
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
+	if err != nil {
+		log.Fatalf("unable to create audit collection index, %v", err)
+	}
+
+	return &AuditRepo{
+		db: collection,
+	}
+}
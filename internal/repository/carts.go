@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
@@ -83,21 +84,24 @@ func (c *CartsRepo) AddCartItem(ctx context.Context, cartItem domain.CartItem, u
 	_ = result.Decode(&cartData)
 
 	if len(cartData.CartItems) == 0 {
-		_, err := c.db.UpdateOne(ctx, bson.M{"userID": userID}, bson.M{"$addToSet": bson.M{"cartItems": cartItem}})
+		_, err := c.db.UpdateOne(ctx, bson.M{"userID": userID}, bson.M{
+			"$addToSet": bson.M{"cartItems": cartItem},
+			"$set":      bson.M{"updatedAt": time.Now()},
+		})
 		return cartItem, err
 	} else {
 		item := cartData.CartItems[0]
 
 		quantity := item.Quantity + cartItem.Quantity
 
-		updateOptions := bson.M{"$set": bson.M{"cartItems.$.quantity": quantity}}
+		updateOptions := bson.M{"$set": bson.M{"cartItems.$.quantity": quantity, "updatedAt": time.Now()}}
 		_, err := c.db.UpdateOne(ctx, bson.M{"userID": userID, "cartItems.productID": cartItem.ProductID}, updateOptions)
 		return cartItem, err
 	}
 }
 
 func (c *CartsRepo) UpdateCartItem(ctx context.Context, cartItem domain.CartItem, userID primitive.ObjectID) (domain.CartItem, error) {
-	updateOptions := bson.M{"$set": bson.M{"cartItems.$.quantity": cartItem.Quantity}}
+	updateOptions := bson.M{"$set": bson.M{"cartItems.$.quantity": cartItem.Quantity, "updatedAt": time.Now()}}
 	_, err := c.db.UpdateOne(ctx, bson.M{"userID": userID, "cartItems.productID": cartItem.ProductID}, updateOptions)
 	return cartItem, err
 }
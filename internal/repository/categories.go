@@ -54,6 +54,10 @@ func (repo CategoriesRepo) Update(ctx context.Context, categoryInput dto.UpdateC
 		updateQuery["icon"] = categoryInput.Icon
 	}
 
+	if categoryInput.TaxExempt != nil {
+		updateQuery["tax_exempt"] = *categoryInput.TaxExempt
+	}
+
 	_, err := repo.db.UpdateOne(ctx, bson.M{"_id": categoryID}, bson.M{"$set": updateQuery})
 	findResult := repo.db.FindOne(ctx, bson.M{"_id": categoryID})
 
@@ -12,4 +12,15 @@ const (
 	provincesCollection  = "provinces"
 	citiesCollection     = "cities"
 	storesCollection     = "stores"
+	returnsCollection    = "returns"
+	ledgerCollection     = "ledger"
+	payoutsCollection    = "payouts"
+	invoicesCollection   = "invoices"
+	auditCollection      = "audit_logs"
+	settingsCollection   = "settings"
+
+	invoiceCountersCollection = "invoice_counters"
+	productStatsCollection    = "product_stats"
+	payoutLocksCollection     = "payout_locks"
+	adminLocksCollection      = "admin_locks"
 )
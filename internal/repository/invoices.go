@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrInvoiceExists = errors.New("invoice already issued for order")
+
+type InvoicesRepo struct {
+	db       *mongo.Collection
+	counters *mongo.Collection
+}
+
+func (i *InvoicesRepo) FindByOrderID(ctx context.Context, orderID primitive.ObjectID) (domain.Invoice, error) {
+	result := i.db.FindOne(ctx, bson.M{"orderID": orderID})
+
+	var invoice domain.Invoice
+	err := result.Decode(&invoice)
+
+	return invoice, err
+}
+
+// Create stores an invoice without a number yet, only one invoice can exist per order.
+func (i *InvoicesRepo) Create(ctx context.Context, invoice domain.Invoice) (domain.Invoice, error) {
+	invoice.ID = primitive.NewObjectID()
+	_, err := i.db.InsertOne(ctx, invoice)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.Invoice{}, ErrInvoiceExists
+	}
+
+	return invoice, err
+}
+
+// AssignNumber sets the invoice number unless one has already been assigned.
+func (i *InvoicesRepo) AssignNumber(ctx context.Context, invoiceID primitive.ObjectID, number string) (bool, error) {
+	result, err := i.db.UpdateOne(ctx, bson.M{"_id": invoiceID, "number": ""}, bson.M{"$set": bson.M{"number": number}})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// SetFileKey records where the PDF is kept in private storage.
+func (i *InvoicesRepo) SetFileKey(ctx context.Context, invoiceID primitive.ObjectID, fileKey string) error {
+	_, err := i.db.UpdateOne(ctx, bson.M{"_id": invoiceID}, bson.M{"$set": bson.M{"fileKey": fileKey}})
+	return err
+}
+
+// NextSequence returns the next invoice sequence of the store in the given period.
+func (i *InvoicesRepo) NextSequence(ctx context.Context, storeID primitive.ObjectID, period string) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	result := i.counters.FindOneAndUpdate(ctx,
+		bson.M{"storeID": storeID, "period": period},
+		bson.M{"$inc": bson.M{"sequence": 1}},
+		opts)
+
+	var counter struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	err := result.Decode(&counter)
+
+	return counter.Sequence, err
+}
+
+func NewInvoicesRepo(db *mongo.Database) *InvoicesRepo {
+	collection := db.Collection(invoicesCollection)
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "orderID", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "storeID", Value: 1}, {Key: "issuedAt", Value: -1}}},
+	}
+
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
+	if err != nil {
+		log.Fatalf("unable to create invoice collection index, %v", err)
+	}
+
+	counters := db.Collection(invoiceCountersCollection)
+	_, err = counters.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "storeID", Value: 1}, {Key: "period", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatalf("unable to create invoice counter collection index, %v", err)
+	}
+
+	return &InvoicesRepo{
+		db:       collection,
+		counters: counters,
+	}
+}
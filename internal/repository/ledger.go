@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrLedgerDuplicate = errors.New("ledger transaction already recorded")
+
+type LedgerRepo struct {
+	db *mongo.Collection
+}
+
+func (l *LedgerRepo) Insert(ctx context.Context, transaction domain.LedgerTransaction) (domain.LedgerTransaction, error) {
+	transaction.ID = primitive.NewObjectID()
+	_, err := l.db.InsertOne(ctx, transaction)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.LedgerTransaction{}, ErrLedgerDuplicate
+	}
+
+	return transaction, err
+}
+
+// Balance sums the entries of the account in the given currency.
+func (l *LedgerRepo) Balance(ctx context.Context, account string, currency domain.Currency) (domain.Money, error) {
+	filter := bson.M{"entries.account": account, "entries.amount.currency": currency}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "balance": bson.M{"$sum": "$entries.amount.amount"}}}},
+	}
+
+	balance := domain.NewMoney(0, currency)
+	cursor, err := l.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return balance, err
+	}
+
+	var result []struct {
+		Balance int64 `bson:"balance"`
+	}
+	if err = cursor.All(ctx, &result); err != nil || len(result) == 0 {
+		return balance, err
+	}
+
+	balance.Amount = result[0].Balance
+	return balance, nil
+}
+
+// Statement lists the entries posted to the account, newest first.
+func (l *LedgerRepo) Statement(ctx context.Context, account string, page int64, limit int64) ([]domain.StatementLine, int64, error) {
+	filter := bson.M{"entries.account": account}
+
+	total, err := l.db.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$skip", Value: (page - 1) * limit}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$match", Value: filter}},
+		{{Key: "$project", Value: bson.M{
+			"type":        "$entries.type",
+			"amount":      "$entries.amount",
+			"reference":   1,
+			"description": 1,
+			"createdAt":   1,
+		}}},
+	}
+
+	cursor, err := l.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	lines := []domain.StatementLine{}
+	err = cursor.All(ctx, &lines)
+	return lines, total, err
+}
+
+func NewLedgerRepo(db *mongo.Database) *LedgerRepo {
+	collection := db.Collection(ledgerCollection)
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "type", Value: 1}, {Key: "reference", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "entries.account", Value: 1}, {Key: "createdAt", Value: -1}}},
+	}
+
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
+	if err != nil {
+		log.Fatalf("unable to create ledger collection index, %v", err)
+	}
+
+	return &LedgerRepo{
+		db: collection,
+	}
+}
@@ -2,14 +2,20 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var ErrOrderStatusConflict = errors.New("order status has changed")
+
 type OrdersRepo struct {
 	db *mongo.Collection
 }
@@ -45,6 +51,156 @@ func (p *OrdersRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID
 	return orderArray, err
 }
 
+func (p *OrdersRepo) FindByCheckoutID(ctx context.Context, checkoutID string, userID primitive.ObjectID) ([]domain.Order, error) {
+	cursor, err := p.db.Find(ctx, bson.M{"checkoutID": checkoutID, "userID": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	var orderArray []domain.Order
+	err = cursor.All(ctx, &orderArray)
+	return orderArray, err
+}
+
+func (p *OrdersRepo) FindByStoreID(ctx context.Context, storeID primitive.ObjectID, filter dto.StoreOrderFilter) ([]domain.Order, error) {
+	query := bson.M{"storeID": storeID}
+
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	createdAt := bson.M{}
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		createdAt["$lt"] = filter.To.Add(24 * time.Hour)
+	}
+	if len(createdAt) > 0 {
+		query["createdAt"] = createdAt
+	}
+
+	cursor, err := p.db.Find(ctx, query, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	orderArray := []domain.Order{}
+	err = cursor.All(ctx, &orderArray)
+	return orderArray, err
+}
+
+func (p *OrdersRepo) FindByStatus(ctx context.Context, status string) ([]domain.Order, error) {
+	cursor, err := p.db.Find(ctx, bson.M{"status": status})
+	if err != nil {
+		return nil, err
+	}
+
+	orderArray := []domain.Order{}
+	err = cursor.All(ctx, &orderArray)
+	return orderArray, err
+}
+
+func (p *OrdersRepo) FindUnsettled(ctx context.Context) ([]domain.Order, error) {
+	cursor, err := p.db.Find(ctx, bson.M{
+		"status":    domain.OrderStatusCompleted,
+		"settledAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orderArray := []domain.Order{}
+	err = cursor.All(ctx, &orderArray)
+	return orderArray, err
+}
+
+func (p *OrdersRepo) FindRefundPending(ctx context.Context) ([]domain.Order, error) {
+	cursor, err := p.db.Find(ctx, bson.M{
+		"status":                     domain.OrderStatusCancelled,
+		"cancellation.refundPending": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orderArray := []domain.Order{}
+	err = cursor.All(ctx, &orderArray)
+	return orderArray, err
+}
+
+// TaxReport sums the tax lines of placed orders per store, period and rate.
+// Reserved and cancelled orders are left out.
+func (p *OrdersRepo) TaxReport(ctx context.Context, filter dto.TaxReportFilter) ([]domain.TaxReportRow, error) {
+	match := bson.M{
+		"status": bson.M{"$nin": []string{domain.OrderStatusReserved, domain.OrderStatusCancelled}},
+		"tax.0":  bson.M{"$exists": true},
+	}
+
+	if !filter.StoreID.IsZero() {
+		match["storeID"] = filter.StoreID
+	}
+
+	createdAt := bson.M{}
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		createdAt["$lt"] = filter.To.Add(24 * time.Hour)
+	}
+	if len(createdAt) > 0 {
+		match["createdAt"] = createdAt
+	}
+
+	format := "%Y-%m"
+	if filter.Period == domain.TaxPeriodDay {
+		format = "%Y-%m-%d"
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$tax"}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"storeID": "$storeID",
+				"period": bson.M{"$dateToString": bson.M{
+					"format":   format,
+					"date":     "$createdAt",
+					"timezone": filter.Timezone,
+				}},
+				"name":      "$tax.name",
+				"rate":      "$tax.rate",
+				"inclusive": "$tax.inclusive",
+				"currency":  "$tax.base.currency",
+			},
+			"orders": bson.M{"$sum": 1},
+			"base":   bson.M{"$sum": "$tax.base.amount"},
+			"amount": bson.M{"$sum": "$tax.amount.amount"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":       0,
+			"storeID":   "$_id.storeID",
+			"period":    "$_id.period",
+			"name":      "$_id.name",
+			"rate":      "$_id.rate",
+			"inclusive": "$_id.inclusive",
+			"orders":    1,
+			"base":      bson.M{"amount": "$base", "currency": "$_id.currency"},
+			"amount":    bson.M{"amount": "$amount", "currency": "$_id.currency"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "period", Value: 1}, {Key: "storeID", Value: 1}, {Key: "rate", Value: -1}}}},
+	}
+
+	cursor, err := p.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []domain.TaxReportRow{}
+	err = cursor.All(ctx, &rows)
+	return rows, err
+}
+
 func (p *OrdersRepo) Create(ctx context.Context, order domain.Order) (domain.Order, error) {
 	order.ID = primitive.NewObjectID()
 	_, err := p.db.InsertOne(ctx, order)
@@ -71,13 +227,89 @@ func (p *OrdersRepo) Update(ctx context.Context, productInput dto.UpdateOrderInp
 	return order, err
 }
 
+func (p *OrdersRepo) Transition(ctx context.Context, orderID primitive.ObjectID, fromStatuses []string, transition dto.OrderTransitionInput) (domain.Order, error) {
+	update := bson.M{"$set": transition}
+	if transition.Event.Type != "" {
+		update["$push"] = bson.M{"timeline": transition.Event}
+	}
+
+	filter := bson.M{"_id": orderID, "status": bson.M{"$in": fromStatuses}}
+	if transition.Undisputed {
+		filter["openReturns"] = bson.M{"$not": bson.M{"$gt": 0}}
+	}
+
+	result, err := p.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.Order{}, ErrOrderStatusConflict
+	}
+
+	return p.FindByID(ctx, orderID)
+}
+
+// OpenReturn counts a new return request of a delivered order. It fails with
+// ErrOrderStatusConflict once the order is no longer delivered, so a return
+// and the completion of its order can't both succeed.
+func (p *OrdersRepo) OpenReturn(ctx context.Context, orderID primitive.ObjectID) error {
+	result, err := p.db.UpdateOne(ctx,
+		bson.M{"_id": orderID, "status": domain.OrderStatusDelivered},
+		bson.M{"$inc": bson.M{"openReturns": 1}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrOrderStatusConflict
+	}
+
+	return nil
+}
+
+func (p *OrdersRepo) CloseReturn(ctx context.Context, orderID primitive.ObjectID) error {
+	_, err := p.db.UpdateOne(ctx,
+		bson.M{"_id": orderID, "openReturns": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"openReturns": -1}})
+	return err
+}
+
+func (p *OrdersRepo) UpdateTracking(ctx context.Context, orderID primitive.ObjectID, tracking dto.UpdateTrackingInput) error {
+	_, err := p.db.UpdateOne(ctx, bson.M{"_id": orderID}, bson.M{"$set": tracking})
+	return err
+}
+
+func (p *OrdersRepo) AddEvent(ctx context.Context, orderID primitive.ObjectID, event domain.OrderEvent) error {
+	_, err := p.db.UpdateOne(ctx, bson.M{"_id": orderID}, bson.M{"$push": bson.M{"timeline": event}})
+	return err
+}
+
+func (p *OrdersRepo) MarkSettled(ctx context.Context, orderID primitive.ObjectID, settledAt time.Time) error {
+	_, err := p.db.UpdateOne(ctx, bson.M{"_id": orderID}, bson.M{"$set": bson.M{"settledAt": settledAt}})
+	return err
+}
+
 func (p *OrdersRepo) Delete(ctx context.Context, orderID primitive.ObjectID) error {
 	_, err := p.db.DeleteOne(ctx, bson.M{"_id": orderID})
 	return err
 }
 
 func NewOrdersRepo(db *mongo.Database) *OrdersRepo {
+	collection := db.Collection(ordersCollection)
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "storeID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "checkoutID", Value: 1}}},
+	}
+
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
+	if err != nil {
+		log.Fatalf("unable to create order collection index, %v", err)
+	}
+
 	return &OrdersRepo{
-		db: db.Collection(ordersCollection),
+		db: collection,
 	}
 }
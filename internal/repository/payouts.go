@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrPayoutStatusConflict = errors.New("payout status has changed")
+	ErrPayoutLocked         = errors.New("another payout of the store is being requested")
+)
+
+type PayoutsRepo struct {
+	db    *mongo.Collection
+	locks *mongo.Collection
+}
+
+func (p *PayoutsRepo) FindByID(ctx context.Context, payoutID primitive.ObjectID) (domain.Payout, error) {
+	result := p.db.FindOne(ctx, bson.M{"_id": payoutID})
+
+	var payout domain.Payout
+	err := result.Decode(&payout)
+
+	return payout, err
+}
+
+func (p *PayoutsRepo) FindByStoreID(ctx context.Context, storeID primitive.ObjectID) ([]domain.Payout, error) {
+	return p.find(ctx, bson.M{"storeID": storeID})
+}
+
+func (p *PayoutsRepo) FindAll(ctx context.Context, status string) ([]domain.Payout, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	return p.find(ctx, filter)
+}
+
+func (p *PayoutsRepo) find(ctx context.Context, filter bson.M) ([]domain.Payout, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := p.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	payouts := []domain.Payout{}
+	err = cursor.All(ctx, &payouts)
+	return payouts, err
+}
+
+// PendingAmount sums payouts of the store that have been requested but not yet paid or rejected.
+func (p *PayoutsRepo) PendingAmount(ctx context.Context, storeID primitive.ObjectID, currency domain.Currency) (domain.Money, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"storeID":         storeID,
+			"amount.currency": currency,
+			"status":          bson.M{"$in": []string{domain.PayoutStatusRequested, domain.PayoutStatusApproved}},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "amount": bson.M{"$sum": "$amount.amount"}}}},
+	}
+
+	pending := domain.NewMoney(0, currency)
+	cursor, err := p.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return pending, err
+	}
+
+	var result []struct {
+		Amount int64 `bson:"amount"`
+	}
+	if err = cursor.All(ctx, &result); err != nil || len(result) == 0 {
+		return pending, err
+	}
+
+	pending.Amount = result[0].Amount
+	return pending, nil
+}
+
+// Lock holds the payout lock of the store until it is unlocked or ttl has
+// passed, so balance checks and payout requests of a store don't interleave.
+// The returned token unlocks it.
+func (p *PayoutsRepo) Lock(ctx context.Context, storeID primitive.ObjectID, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := primitive.NewObjectID().Hex()
+
+	// An expired lock matches and is taken over, a held one doesn't and the
+	// upsert collides with it on _id.
+	_, err := p.locks.UpdateOne(ctx,
+		bson.M{"_id": storeID, "expiresAt": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"token": token, "expiresAt": now.Add(ttl)}},
+		options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return "", ErrPayoutLocked
+	}
+
+	return token, err
+}
+
+// Unlock releases the payout lock of the store if it is still held by token.
+func (p *PayoutsRepo) Unlock(ctx context.Context, storeID primitive.ObjectID, token string) error {
+	_, err := p.locks.DeleteOne(ctx, bson.M{"_id": storeID, "token": token})
+	return err
+}
+
+func (p *PayoutsRepo) Create(ctx context.Context, payout domain.Payout) (domain.Payout, error) {
+	payout.ID = primitive.NewObjectID()
+	_, err := p.db.InsertOne(ctx, payout)
+	return payout, err
+}
+
+func (p *PayoutsRepo) Transition(ctx context.Context, payoutID primitive.ObjectID, fromStatuses []string, transition dto.PayoutTransitionInput) (domain.Payout, error) {
+	result, err := p.db.UpdateOne(ctx, bson.M{"_id": payoutID, "status": bson.M{"$in": fromStatuses}},
+		bson.M{"$set": transition})
+	if err != nil {
+		return domain.Payout{}, err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.Payout{}, ErrPayoutStatusConflict
+	}
+
+	return p.FindByID(ctx, payoutID)
+}
+
+func NewPayoutsRepo(db *mongo.Database) *PayoutsRepo {
+	collection := db.Collection(payoutsCollection)
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "storeID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+	}
+
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
+	if err != nil {
+		log.Fatalf("unable to create payout collection index, %v", err)
+	}
+
+	locks := db.Collection(payoutLocksCollection)
+	_, err = locks.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Fatalf("unable to create payout lock collection index, %v", err)
+	}
+
+	return &PayoutsRepo{
+		db:    collection,
+		locks: locks,
+	}
+}
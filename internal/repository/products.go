@@ -2,12 +2,18 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
 )
 
+var ErrInsufficientStock = errors.New("insufficient product stock")
+
 type ProductsRepo struct {
 	db *mongo.Collection
 }
@@ -34,6 +40,100 @@ func (p ProductsRepo) FindAll(ctx context.Context) ([]domain.Product, error) {
 	return productArray, err
 }
 
+// FindListed lists the published products that do not belong to any of the
+// stores.
+func (p ProductsRepo) FindListed(ctx context.Context, storeIDs []primitive.ObjectID) ([]domain.Product, error) {
+	filter := bson.M{"draft": bson.M{"$ne": true}}
+	if len(storeIDs) > 0 {
+		filter["store_id"] = bson.M{"$nin": storeIDs}
+	}
+
+	cursor, err := p.db.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var productArray []domain.Product
+	err = cursor.All(ctx, &productArray)
+	return productArray, err
+}
+
+// FindPage pages through the published products matching the filter.
+func (p ProductsRepo) FindPage(ctx context.Context, filter dto.ProductFilter) ([]domain.Product, int64, error) {
+	query := bson.M{"draft": bson.M{"$ne": true}}
+	if !filter.StoreID.IsZero() {
+		query["store_id"] = filter.StoreID
+	}
+	if !filter.CategoryID.IsZero() {
+		query["category_id"] = filter.CategoryID
+	}
+	if filter.Query != "" {
+		query["name"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Query), Options: "i"}
+	}
+
+	price := bson.M{}
+	if filter.MinPrice > 0 {
+		price["$gte"] = filter.MinPrice
+	}
+	if filter.MaxPrice > 0 {
+		price["$lte"] = filter.MaxPrice
+	}
+	if len(price) > 0 {
+		query["price.amount"] = price
+	}
+
+	total, err := p.db.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort := bson.D{{Key: "_id", Value: -1}}
+	switch filter.Sort {
+	case "price_asc":
+		sort = bson.D{{Key: "price.amount", Value: 1}, {Key: "_id", Value: -1}}
+	case "price_desc":
+		sort = bson.D{{Key: "price.amount", Value: -1}, {Key: "_id", Value: -1}}
+	case "name":
+		sort = bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: -1}}
+	}
+
+	findOptions := options.Find().
+		SetSort(sort).
+		SetSkip((filter.Page - 1) * filter.Limit).
+		SetLimit(filter.Limit)
+
+	cursor, err := p.db.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	products := []domain.Product{}
+	err = cursor.All(ctx, &products)
+
+	return products, total, err
+}
+
+// ListedIDs lists the ids of the published products of the store.
+func (p ProductsRepo) ListedIDs(ctx context.Context, storeID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := p.db.Find(ctx, bson.M{"store_id": storeID, "draft": bson.M{"$ne": true}},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var products []domain.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]primitive.ObjectID, len(products))
+	for i, product := range products {
+		productIDs[i] = product.ID
+	}
+
+	return productIDs, nil
+}
+
 func (p ProductsRepo) FindByID(ctx context.Context, productID primitive.ObjectID) (domain.Product, error) {
 	result := p.db.FindOne(ctx, bson.M{"_id": productID})
 
@@ -80,8 +180,57 @@ func (p ProductsRepo) Update(ctx context.Context, product domain.Product, produc
 	return result, err
 }
 
+func (p ProductsRepo) SetDraft(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID, draft bool) (domain.Product, error) {
+	result, err := p.db.UpdateOne(ctx, bson.M{"_id": productID, "store_id": storeID},
+		bson.M{"$set": bson.M{"draft": draft}})
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.Product{}, mongo.ErrNoDocuments
+	}
+
+	return p.FindByID(ctx, productID)
+}
+
 func (p ProductsRepo) Delete(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID) error {
-	_, err := p.db.DeleteOne(ctx, bson.M{"_id": productID, "storeID": storeID})
+	_, err := p.db.DeleteOne(ctx, bson.M{"_id": productID, "store_id": storeID})
+	return err
+}
+
+// SetStock replaces the stock a store counted, reservations made before
+// are not added back.
+func (p ProductsRepo) SetStock(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID, stock int64) (domain.Product, error) {
+	result, err := p.db.UpdateOne(ctx, bson.M{"_id": productID, "store_id": storeID},
+		bson.M{"$set": bson.M{"stock": stock}})
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.Product{}, mongo.ErrNoDocuments
+	}
+
+	return p.FindByID(ctx, productID)
+}
+
+func (p ProductsRepo) ReserveStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error {
+	result, err := p.db.UpdateOne(ctx, bson.M{"_id": productID, "stock": bson.M{"$gte": quantity}},
+		bson.M{"$inc": bson.M{"stock": -quantity}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrInsufficientStock
+	}
+
+	return nil
+}
+
+func (p ProductsRepo) ReleaseStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error {
+	_, err := p.db.UpdateOne(ctx, bson.M{"_id": productID}, bson.M{"$inc": bson.M{"stock": quantity}})
 	return err
 }
 
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const statsDateLayout = "2006-01-02"
+
+// ProductStatsRepo keeps one document per product and day with its views and
+// cart adds.
+type ProductStatsRepo struct {
+	db *mongo.Collection
+}
+
+func (p *ProductStatsRepo) Increment(ctx context.Context, counters []domain.ProductCounter) error {
+	if len(counters) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(counters))
+	for _, counter := range counters {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"productID": counter.ProductID, "date": counter.Date}).
+			SetUpdate(bson.M{
+				"$inc":         bson.M{"views": counter.Views, "cartAdds": counter.CartAdds},
+				"$setOnInsert": bson.M{"storeID": counter.StoreID},
+			}).
+			SetUpsert(true))
+	}
+
+	_, err := p.db.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Traffic sums the counters of the store per period. The counter days are
+// read as days of the filter timezone.
+func (p *ProductStatsRepo) Traffic(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.TrafficPoint, error) {
+	match, err := statsMatch(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id": periodOf(bson.M{"$dateFromString": bson.M{
+				"dateString": "$date",
+				"format":     "%Y-%m-%d",
+				"timezone":   filter.Timezone,
+			}}, filter),
+			"views":    bson.M{"$sum": "$views"},
+			"cartAdds": bson.M{"$sum": "$cartAdds"},
+		}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "period": "$_id", "views": 1, "cartAdds": 1}}},
+		{{Key: "$sort", Value: bson.D{{Key: "period", Value: 1}}}},
+	}
+
+	cursor, err := p.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	points := []domain.TrafficPoint{}
+	err = cursor.All(ctx, &points)
+	return points, err
+}
+
+// Products sums the counters of the store per product, with the current
+// product name.
+func (p *ProductStatsRepo) Products(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.ProductPerformance, error) {
+	match, err := statsMatch(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$productID",
+			"views":    bson.M{"$sum": "$views"},
+			"cartAdds": bson.M{"$sum": "$cartAdds"},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         productsCollection,
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "product",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$product", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":       0,
+			"productID": "$_id",
+			"name":      "$product.name",
+			"views":     1,
+			"cartAdds":  1,
+		}}},
+	}
+
+	cursor, err := p.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	products := []domain.ProductPerformance{}
+	err = cursor.All(ctx, &products)
+	return products, err
+}
+
+func statsMatch(filter dto.AnalyticsFilter) (bson.M, error) {
+	location, err := time.LoadLocation(filter.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{
+		"storeID": filter.StoreID,
+		"date": bson.M{
+			"$gte": filter.From.In(location).Format(statsDateLayout),
+			"$lt":  filter.To.In(location).Format(statsDateLayout),
+		},
+	}, nil
+}
+
+func NewProductStatsRepo(db *mongo.Database) *ProductStatsRepo {
+	collection := db.Collection(productStatsCollection)
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "productID", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "storeID", Value: 1}, {Key: "date", Value: 1}}},
+	}
+
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
+	if err != nil {
+		log.Fatalf("unable to create product stats collection index, %v", err)
+	}
+
+	return &ProductStatsRepo{
+		db: collection,
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
@@ -14,20 +15,35 @@ type Users interface {
 	FindByID(ctx context.Context, userID primitive.ObjectID) (domain.User, error)
 	FindByCredentials(ctx context.Context, email string, password string) (domain.LoginUser, error)
 	FindUserInfo(ctx context.Context, userID primitive.ObjectID) (domain.UserInfo, error)
+	FindByEmail(ctx context.Context, email string) (domain.User, error)
+	FindByIdentity(ctx context.Context, issuer string, subject string) (domain.User, error)
 	Create(ctx context.Context, user domain.User) (domain.User, error)
+	AddIdentity(ctx context.Context, userID primitive.ObjectID, identity domain.ExternalIdentity) error
 	Update(ctx context.Context, userInput dto.UpdateUserInput,
 		userID primitive.ObjectID) (domain.User, error)
+	MarkEmailVerified(ctx context.Context, userID primitive.ObjectID, verifiedAt time.Time) error
+	UpdatePassword(ctx context.Context, userID primitive.ObjectID, passwordHash string) error
+	UpdateStatus(ctx context.Context, userID primitive.ObjectID, status string, reason string) error
+	SetPendingEmail(ctx context.Context, userID primitive.ObjectID, email string) error
+	ChangeEmail(ctx context.Context, userID primitive.ObjectID, email string, verifiedAt time.Time) error
 	Delete(ctx context.Context, userID primitive.ObjectID) error
 }
 
 type Products interface {
 	GetBySellerID(ctx context.Context, storeID primitive.ObjectID) ([]domain.Product, error)
 	FindAll(ctx context.Context) ([]domain.Product, error)
+	FindListed(ctx context.Context, storeIDs []primitive.ObjectID) ([]domain.Product, error)
+	FindPage(ctx context.Context, filter dto.ProductFilter) ([]domain.Product, int64, error)
+	ListedIDs(ctx context.Context, storeID primitive.ObjectID) ([]primitive.ObjectID, error)
 	FindByID(ctx context.Context, productID primitive.ObjectID) (domain.Product, error)
 	Create(ctx context.Context, product domain.Product) (domain.Product, error)
 	Update(ctx context.Context, product domain.Product,
 		productID primitive.ObjectID) (domain.Product, error)
+	SetDraft(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID, draft bool) (domain.Product, error)
 	Delete(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID) error
+	SetStock(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID, stock int64) (domain.Product, error)
+	ReserveStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error
+	ReleaseStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error
 }
 
 type Reviews interface {
@@ -38,10 +54,21 @@ type Reviews interface {
 	Create(ctx context.Context, review domain.Review) (domain.Review, error)
 	Delete(ctx context.Context, reviewID primitive.ObjectID) error
 	DeleteByProductID(ctx context.Context, productID primitive.ObjectID) error
+	AnonymizeByUserID(ctx context.Context, userID primitive.ObjectID) error
+	Summary(ctx context.Context, productIDs []primitive.ObjectID) (float64, int64, error)
 }
 
 type Admins interface {
+	FindAll(ctx context.Context) ([]domain.Admin, error)
+	FindByID(ctx context.Context, adminID primitive.ObjectID) (domain.Admin, error)
 	FindByCredentials(ctx context.Context, email string) (domain.Admin, error)
+	CountByRole(ctx context.Context, role domain.Role) (int64, error)
+	Create(ctx context.Context, admin domain.Admin) (domain.Admin, error)
+	Update(ctx context.Context, adminID primitive.ObjectID, adminInput dto.UpdateAdminInput) (domain.Admin, error)
+	UpdatePassword(ctx context.Context, adminID primitive.ObjectID, passwordHash string) error
+	Delete(ctx context.Context, adminID primitive.ObjectID) error
+	LockSuperAdmins(ctx context.Context, ttl time.Duration) (string, error)
+	UnlockSuperAdmins(ctx context.Context, token string) error
 }
 
 type Carts interface {
@@ -63,12 +90,35 @@ type Orders interface {
 	FindAll(ctx context.Context) ([]domain.Order, error)
 	FindByID(ctx context.Context, orderID primitive.ObjectID) (domain.Order, error)
 	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]domain.Order, error)
+	FindByCheckoutID(ctx context.Context, checkoutID string, userID primitive.ObjectID) ([]domain.Order, error)
+	FindByStoreID(ctx context.Context, storeID primitive.ObjectID, filter dto.StoreOrderFilter) ([]domain.Order, error)
+	FindByStatus(ctx context.Context, status string) ([]domain.Order, error)
+	FindUnsettled(ctx context.Context) ([]domain.Order, error)
+	FindRefundPending(ctx context.Context) ([]domain.Order, error)
+	TaxReport(ctx context.Context, filter dto.TaxReportFilter) ([]domain.TaxReportRow, error)
 	Create(ctx context.Context, order domain.Order) (domain.Order, error)
 	Update(ctx context.Context, orderInput dto.UpdateOrderInput,
 		orderID primitive.ObjectID) (domain.Order, error)
+	Transition(ctx context.Context, orderID primitive.ObjectID, fromStatuses []string,
+		transition dto.OrderTransitionInput) (domain.Order, error)
+	UpdateTracking(ctx context.Context, orderID primitive.ObjectID, tracking dto.UpdateTrackingInput) error
+	AddEvent(ctx context.Context, orderID primitive.ObjectID, event domain.OrderEvent) error
+	OpenReturn(ctx context.Context, orderID primitive.ObjectID) error
+	CloseReturn(ctx context.Context, orderID primitive.ObjectID) error
+	MarkSettled(ctx context.Context, orderID primitive.ObjectID, settledAt time.Time) error
 	Delete(ctx context.Context, orderID primitive.ObjectID) error
 }
 
+type Returns interface {
+	FindByID(ctx context.Context, returnID primitive.ObjectID) (domain.ReturnRequest, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]domain.ReturnRequest, error)
+	FindByStoreID(ctx context.Context, storeID primitive.ObjectID) ([]domain.ReturnRequest, error)
+	FindByOrderID(ctx context.Context, orderID primitive.ObjectID) ([]domain.ReturnRequest, error)
+	Create(ctx context.Context, returnRequest domain.ReturnRequest) (domain.ReturnRequest, error)
+	Transition(ctx context.Context, returnID primitive.ObjectID, fromStatuses []string,
+		transition dto.ReturnTransitionInput) (domain.ReturnRequest, error)
+}
+
 type Categories interface {
 	FindAll(ctx context.Context) ([]domain.Category, error)
 	FindByID(ctx context.Context, categoryID primitive.ObjectID) (domain.Category, error)
@@ -96,13 +146,94 @@ type Addresses interface {
 	Create(ctx context.Context, address dto.AddressDTO) (domain.Address, error)
 	Update(ctx context.Context, userID primitive.ObjectID, addressID primitive.ObjectID, address dto.AddressDTO) (domain.Address, error)
 	Delete(ctx context.Context, userID primitive.ObjectID, addressID primitive.ObjectID) error
+	DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error
 }
 
 type Stores interface {
+	FindByID(ctx context.Context, storeID primitive.ObjectID) (domain.Store, error)
 	FindByUserID(ctx context.Context, userID primitive.ObjectID) (domain.Store, error)
 	FindByDomain(ctx context.Context, domainStore string) (domain.Store, error)
 	Create(ctx context.Context, store dto.StoreRegisterDTO) (domain.Store, error)
 	UpdateShipment(ctx context.Context, storeID primitive.ObjectID, shipment dto.StoreShipmentDTO) (domain.Store, error)
+	UpdateBankAccount(ctx context.Context, storeID primitive.ObjectID, bankAccount dto.StoreBankAccountDTO) (domain.Store, error)
+	UpdateTax(ctx context.Context, storeID primitive.ObjectID, tax dto.StoreTaxDTO) (domain.Store, error)
+	UpdateStatus(ctx context.Context, storeID primitive.ObjectID, status string, reason string) (domain.Store, error)
+	FindInactiveIDs(ctx context.Context) ([]primitive.ObjectID, error)
+	FindAll(ctx context.Context, verification string) ([]domain.Store, error)
+	AddDocument(ctx context.Context, storeID primitive.ObjectID, document domain.StoreDocument) (domain.Store, error)
+	Review(ctx context.Context, storeID primitive.ObjectID, review dto.StoreReviewDTO) (domain.Store, error)
+	UpdateProfile(ctx context.Context, storeID primitive.ObjectID, profile dto.StoreProfileDTO) (domain.Store, error)
+	UpdateImage(ctx context.Context, storeID primitive.ObjectID, image dto.StoreImageDTO) (domain.Store, error)
+	UpdateHours(ctx context.Context, storeID primitive.ObjectID, hours dto.StoreHoursDTO) (domain.Store, error)
+	UpdateHoliday(ctx context.Context, storeID primitive.ObjectID, holiday dto.StoreHolidayDTO) (domain.Store, error)
+	UpdateHandling(ctx context.Context, storeID primitive.ObjectID, handling dto.StoreHandlingDTO) (domain.Store, error)
+	UpdateShipping(ctx context.Context, storeID primitive.ObjectID, shipping dto.StoreShippingDTO) (domain.Store, error)
+}
+
+// Ledger is append-only, transactions are never updated or deleted once written.
+type Ledger interface {
+	Insert(ctx context.Context, transaction domain.LedgerTransaction) (domain.LedgerTransaction, error)
+	Balance(ctx context.Context, account string, currency domain.Currency) (domain.Money, error)
+	Statement(ctx context.Context, account string, page int64, limit int64) ([]domain.StatementLine, int64, error)
+}
+
+type Invoices interface {
+	FindByOrderID(ctx context.Context, orderID primitive.ObjectID) (domain.Invoice, error)
+	Create(ctx context.Context, invoice domain.Invoice) (domain.Invoice, error)
+	AssignNumber(ctx context.Context, invoiceID primitive.ObjectID, number string) (bool, error)
+	SetFileKey(ctx context.Context, invoiceID primitive.ObjectID, fileKey string) error
+	NextSequence(ctx context.Context, storeID primitive.ObjectID, period string) (int64, error)
+}
+
+// Audit is append-only like the ledger, entries are only deleted once they
+// are past the retention period.
+type Audit interface {
+	Insert(ctx context.Context, entry domain.AuditLog) (domain.AuditLog, error)
+	Find(ctx context.Context, filter dto.AuditFilter) ([]domain.AuditLog, int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type TwoFactor interface {
+	Find(ctx context.Context, account domain.AccountRef) (domain.TwoFactorAccount, error)
+	SetPendingSecret(ctx context.Context, account domain.AccountRef, secret string) error
+	Enable(ctx context.Context, account domain.AccountRef, secret string, recoveryCodes []string, enabledAt time.Time) error
+	SetRecoveryCodes(ctx context.Context, account domain.AccountRef, recoveryCodes []string) error
+	Disable(ctx context.Context, account domain.AccountRef) error
+	UseRecoveryCode(ctx context.Context, account domain.AccountRef, codeHash string) (bool, error)
+}
+
+type Settings interface {
+	Security(ctx context.Context) (domain.SecuritySettings, error)
+	UpdateSecurity(ctx context.Context, settings domain.SecuritySettings) (domain.SecuritySettings, error)
+}
+
+type Payouts interface {
+	FindByID(ctx context.Context, payoutID primitive.ObjectID) (domain.Payout, error)
+	FindByStoreID(ctx context.Context, storeID primitive.ObjectID) ([]domain.Payout, error)
+	FindAll(ctx context.Context, status string) ([]domain.Payout, error)
+	PendingAmount(ctx context.Context, storeID primitive.ObjectID, currency domain.Currency) (domain.Money, error)
+	Lock(ctx context.Context, storeID primitive.ObjectID, ttl time.Duration) (string, error)
+	Unlock(ctx context.Context, storeID primitive.ObjectID, token string) error
+	Create(ctx context.Context, payout domain.Payout) (domain.Payout, error)
+	Transition(ctx context.Context, payoutID primitive.ObjectID, fromStatuses []string,
+		transition dto.PayoutTransitionInput) (domain.Payout, error)
+}
+
+type Analytics interface {
+	Sales(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.SalesPoint, error)
+	TopProducts(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.TopProduct, error)
+	TopStores(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.TopStore, error)
+	CartConversion(ctx context.Context, filter dto.AnalyticsFilter) (domain.CartConversion, error)
+	NewUsers(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.NewUsersPoint, error)
+	OrderRates(ctx context.Context, filter dto.AnalyticsFilter) (domain.OrderRates, error)
+	StoreSales(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.StoreSalesPoint, error)
+	StoreProducts(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.ProductPerformance, error)
+}
+
+type ProductStats interface {
+	Increment(ctx context.Context, counters []domain.ProductCounter) error
+	Traffic(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.TrafficPoint, error)
+	Products(ctx context.Context, filter dto.AnalyticsFilter) ([]domain.ProductPerformance, error)
 }
 
 type Repositories struct {
@@ -116,6 +247,15 @@ type Repositories struct {
 	Areas      Areas
 	Addresses  Addresses
 	Stores     Stores
+	Returns    Returns
+	Ledger     Ledger
+	Payouts    Payouts
+	Invoices   Invoices
+	Audit      Audit
+	TwoFactor  TwoFactor
+	Settings   Settings
+	Analytics  Analytics
+	Stats      ProductStats
 }
 
 func NewRepositories(db *mongo.Database) *Repositories {
@@ -130,5 +270,14 @@ func NewRepositories(db *mongo.Database) *Repositories {
 		Areas:      NewAreasRepo(db),
 		Addresses:  NewAddressesRepo(db),
 		Stores:     NewStoresRepo(db),
+		Returns:    NewReturnsRepo(db),
+		Ledger:     NewLedgerRepo(db),
+		Payouts:    NewPayoutsRepo(db),
+		Invoices:   NewInvoicesRepo(db),
+		Audit:      NewAuditRepo(db),
+		TwoFactor:  NewTwoFactorRepo(db),
+		Settings:   NewSettingsRepo(db),
+		Analytics:  NewAnalyticsRepo(db),
+		Stats:      NewProductStatsRepo(db),
 	}
 }
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrReturnStatusConflict = errors.New("return status has changed")
+
+type ReturnsRepo struct {
+	db *mongo.Collection
+}
+
+func (r *ReturnsRepo) FindByID(ctx context.Context, returnID primitive.ObjectID) (domain.ReturnRequest, error) {
+	result := r.db.FindOne(ctx, bson.M{"_id": returnID})
+
+	var returnRequest domain.ReturnRequest
+	err := result.Decode(&returnRequest)
+
+	return returnRequest, err
+}
+
+func (r *ReturnsRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]domain.ReturnRequest, error) {
+	return r.find(ctx, bson.M{"userID": userID})
+}
+
+func (r *ReturnsRepo) FindByStoreID(ctx context.Context, storeID primitive.ObjectID) ([]domain.ReturnRequest, error) {
+	return r.find(ctx, bson.M{"storeID": storeID})
+}
+
+func (r *ReturnsRepo) FindByOrderID(ctx context.Context, orderID primitive.ObjectID) ([]domain.ReturnRequest, error) {
+	return r.find(ctx, bson.M{"orderID": orderID})
+}
+
+func (r *ReturnsRepo) find(ctx context.Context, filter bson.M) ([]domain.ReturnRequest, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	returns := []domain.ReturnRequest{}
+	err = cursor.All(ctx, &returns)
+	return returns, err
+}
+
+func (r *ReturnsRepo) Create(ctx context.Context, returnRequest domain.ReturnRequest) (domain.ReturnRequest, error) {
+	returnRequest.ID = primitive.NewObjectID()
+	_, err := r.db.InsertOne(ctx, returnRequest)
+	return returnRequest, err
+}
+
+func (r *ReturnsRepo) Transition(ctx context.Context, returnID primitive.ObjectID, fromStatuses []string, transition dto.ReturnTransitionInput) (domain.ReturnRequest, error) {
+	result, err := r.db.UpdateOne(ctx, bson.M{"_id": returnID, "status": bson.M{"$in": fromStatuses}},
+		bson.M{"$set": transition})
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ReturnRequest{}, ErrReturnStatusConflict
+	}
+
+	return r.FindByID(ctx, returnID)
+}
+
+func NewReturnsRepo(db *mongo.Database) *ReturnsRepo {
+	collection := db.Collection(returnsCollection)
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "orderID", Value: 1}}},
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "storeID", Value: 1}, {Key: "createdAt", Value: -1}}},
+	}
+
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
+	if err != nil {
+		log.Fatalf("unable to create return collection index, %v", err)
+	}
+
+	return &ReturnsRepo{
+		db: collection,
+	}
+}
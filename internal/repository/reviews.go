@@ -71,6 +71,46 @@ func (r ReviewsRepo) DeleteByProductID(ctx context.Context, productID primitive.
 	return err
 }
 
+// AnonymizeByUserID detaches the reviews of a user, the text and rating stay
+// so product ratings do not change.
+func (r ReviewsRepo) AnonymizeByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.db.UpdateMany(ctx, bson.M{"userID": userID},
+		bson.M{"$set": bson.M{"userID": primitive.NilObjectID, "anonymized": true}})
+	return err
+}
+
+// Summary returns the average rating and the number of reviews of the
+// products together.
+func (r ReviewsRepo) Summary(ctx context.Context, productIDs []primitive.ObjectID) (float64, int64, error) {
+	if len(productIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"productID": bson.M{"$in": productIDs}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    nil,
+			"rating": bson.M{"$avg": "$rating"},
+			"count":  bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var results []struct {
+		Rating float64 `bson:"rating"`
+		Count  int64   `bson:"count"`
+	}
+	if err = cursor.All(ctx, &results); err != nil || len(results) == 0 {
+		return 0, 0, err
+	}
+
+	return results[0].Rating, results[0].Count, nil
+}
+
 func NewReviewsRepo(db *mongo.Database) *ReviewsRepo {
 	return &ReviewsRepo{
 		db: db.Collection(reviewsCollection),
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const securitySettingsID = "security"
+
+type SettingsRepo struct {
+	db *mongo.Collection
+}
+
+// Security returns the stored security settings, or the defaults when they
+// were never saved.
+func (s *SettingsRepo) Security(ctx context.Context) (domain.SecuritySettings, error) {
+	result := s.db.FindOne(ctx, bson.M{"_id": securitySettingsID})
+
+	var settings domain.SecuritySettings
+	err := result.Decode(&settings)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.SecuritySettings{}, nil
+	}
+
+	return settings, err
+}
+
+func (s *SettingsRepo) UpdateSecurity(ctx context.Context, settings domain.SecuritySettings) (domain.SecuritySettings, error) {
+	_, err := s.db.UpdateOne(ctx, bson.M{"_id": securitySettingsID}, bson.M{"$set": settings},
+		options.Update().SetUpsert(true))
+
+	return settings, err
+}
+
+func NewSettingsRepo(db *mongo.Database) *SettingsRepo {
+	return &SettingsRepo{
+		db: db.Collection(settingsCollection),
+	}
+}
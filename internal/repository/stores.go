@@ -16,6 +16,15 @@ type StoresRepo struct {
 	db *mongo.Collection
 }
 
+func (repo *StoresRepo) FindByID(ctx context.Context, storeID primitive.ObjectID) (domain.Store, error) {
+	result := repo.db.FindOne(ctx, bson.M{"_id": storeID})
+
+	var store domain.Store
+	err := result.Decode(&store)
+
+	return store, err
+}
+
 func (repo *StoresRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) (domain.Store, error) {
 	result := repo.db.FindOne(ctx, bson.M{"user_id": userID})
 
@@ -60,6 +69,176 @@ func (repo *StoresRepo) UpdateShipment(ctx context.Context, storeID primitive.Ob
 	return store, err
 }
 
+func (repo *StoresRepo) UpdateBankAccount(ctx context.Context, storeID primitive.ObjectID, bankAccount dto.StoreBankAccountDTO) (domain.Store, error) {
+	_, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID}, bson.M{"$set": bankAccount})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	result := repo.db.FindOne(ctx, bson.M{"_id": storeID})
+
+	var store domain.Store
+	err = result.Decode(&store)
+
+	return store, err
+}
+
+func (repo *StoresRepo) UpdateTax(ctx context.Context, storeID primitive.ObjectID, tax dto.StoreTaxDTO) (domain.Store, error) {
+	_, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID}, bson.M{"$set": tax})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
+func (repo *StoresRepo) UpdateStatus(ctx context.Context, storeID primitive.ObjectID, status string, reason string) (domain.Store, error) {
+	result, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID},
+		bson.M{"$set": bson.M{"status": status, "status_reason": reason}})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.Store{}, mongo.ErrNoDocuments
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
+// FindInactiveIDs lists the stores that are suspended or banned.
+func (repo *StoresRepo) FindInactiveIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	cursor, err := repo.db.Find(ctx,
+		bson.M{"status": bson.M{"$in": bson.A{domain.StatusSuspended, domain.StatusBanned}}},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var stores []domain.Store
+	if err = cursor.All(ctx, &stores); err != nil {
+		return nil, err
+	}
+
+	storeIDs := make([]primitive.ObjectID, len(stores))
+	for i, store := range stores {
+		storeIDs[i] = store.ID
+	}
+
+	return storeIDs, nil
+}
+
+// FindAll lists the stores, filtered by verification status when one is
+// given. Stores without a status are listed as approved.
+func (repo *StoresRepo) FindAll(ctx context.Context, verification string) ([]domain.Store, error) {
+	filter := bson.M{}
+	switch verification {
+	case "":
+	case domain.VerificationApproved:
+		filter["verification.status"] = bson.M{"$in": bson.A{domain.VerificationApproved, nil}}
+	default:
+		filter["verification.status"] = verification
+	}
+
+	cursor, err := repo.db.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var stores []domain.Store
+	err = cursor.All(ctx, &stores)
+	return stores, err
+}
+
+// AddDocument attaches the document to the store and puts it back in the
+// review queue.
+func (repo *StoresRepo) AddDocument(ctx context.Context, storeID primitive.ObjectID, document domain.StoreDocument) (domain.Store, error) {
+	result, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID}, bson.M{
+		"$push": bson.M{"verification.documents": document},
+		"$set":  bson.M{"verification.status": domain.VerificationPending},
+	})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.Store{}, mongo.ErrNoDocuments
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
+// Review records the decision on a pending store, a store that is not
+// pending is reported as not found.
+func (repo *StoresRepo) Review(ctx context.Context, storeID primitive.ObjectID, review dto.StoreReviewDTO) (domain.Store, error) {
+	result, err := repo.db.UpdateOne(ctx,
+		bson.M{"_id": storeID, "verification.status": domain.VerificationPending},
+		bson.M{"$set": review})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.Store{}, mongo.ErrNoDocuments
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
+func (repo *StoresRepo) UpdateProfile(ctx context.Context, storeID primitive.ObjectID, profile dto.StoreProfileDTO) (domain.Store, error) {
+	_, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID}, bson.M{"$set": profile})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
+func (repo *StoresRepo) UpdateImage(ctx context.Context, storeID primitive.ObjectID, image dto.StoreImageDTO) (domain.Store, error) {
+	_, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID}, bson.M{"$set": image})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
+func (repo *StoresRepo) UpdateHours(ctx context.Context, storeID primitive.ObjectID, hours dto.StoreHoursDTO) (domain.Store, error) {
+	_, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID}, bson.M{"$set": hours})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
+func (repo *StoresRepo) UpdateHoliday(ctx context.Context, storeID primitive.ObjectID, holiday dto.StoreHolidayDTO) (domain.Store, error) {
+	_, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID}, bson.M{"$set": holiday})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
+func (repo *StoresRepo) UpdateHandling(ctx context.Context, storeID primitive.ObjectID, handling dto.StoreHandlingDTO) (domain.Store, error) {
+	_, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID}, bson.M{"$set": handling})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
+func (repo *StoresRepo) UpdateShipping(ctx context.Context, storeID primitive.ObjectID, shipping dto.StoreShippingDTO) (domain.Store, error) {
+	_, err := repo.db.UpdateOne(ctx, bson.M{"_id": storeID}, bson.M{"$set": shipping})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	return repo.FindByID(ctx, storeID)
+}
+
 func NewStoresRepo(db *mongo.Database) *StoresRepo {
 	collection := db.Collection(storesCollection)
 	indexModel := mongo.IndexModel{
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TwoFactorRepo keeps the twoFactor field of users and admins, the account
+// kind decides which collection is used.
+type TwoFactorRepo struct {
+	users  *mongo.Collection
+	admins *mongo.Collection
+}
+
+func (t *TwoFactorRepo) Find(ctx context.Context, account domain.AccountRef) (domain.TwoFactorAccount, error) {
+	result := t.collection(account).FindOne(ctx, bson.M{"_id": account.ID},
+		options.FindOne().SetProjection(bson.M{"email": 1, "twoFactor": 1}))
+
+	var twoFactorAccount domain.TwoFactorAccount
+	err := result.Decode(&twoFactorAccount)
+
+	return twoFactorAccount, err
+}
+
+func (t *TwoFactorRepo) SetPendingSecret(ctx context.Context, account domain.AccountRef, secret string) error {
+	return t.update(ctx, account, bson.M{}, bson.M{"$set": bson.M{"twoFactor.pendingSecret": secret}})
+}
+
+// Enable promotes the pending secret. The secret is matched so a setup
+// restarted in the meantime can not be confirmed with the old code.
+func (t *TwoFactorRepo) Enable(ctx context.Context, account domain.AccountRef, secret string,
+	recoveryCodes []string, enabledAt time.Time) error {
+	return t.update(ctx, account, bson.M{"twoFactor.pendingSecret": secret}, bson.M{
+		"$set": bson.M{
+			"twoFactor.enabled":       true,
+			"twoFactor.secret":        secret,
+			"twoFactor.recoveryCodes": recoveryCodes,
+			"twoFactor.enabledAt":     enabledAt,
+		},
+		"$unset": bson.M{"twoFactor.pendingSecret": ""},
+	})
+}
+
+func (t *TwoFactorRepo) SetRecoveryCodes(ctx context.Context, account domain.AccountRef, recoveryCodes []string) error {
+	return t.update(ctx, account, bson.M{"twoFactor.enabled": true},
+		bson.M{"$set": bson.M{"twoFactor.recoveryCodes": recoveryCodes}})
+}
+
+func (t *TwoFactorRepo) Disable(ctx context.Context, account domain.AccountRef) error {
+	return t.update(ctx, account, bson.M{}, bson.M{"$set": bson.M{"twoFactor": domain.TwoFactor{}}})
+}
+
+// UseRecoveryCode removes the hash from the account and reports whether it
+// was there, so each recovery code works once even under concurrent use.
+func (t *TwoFactorRepo) UseRecoveryCode(ctx context.Context, account domain.AccountRef, codeHash string) (bool, error) {
+	result, err := t.collection(account).UpdateOne(ctx,
+		bson.M{"_id": account.ID, "twoFactor.recoveryCodes": codeHash},
+		bson.M{"$pull": bson.M{"twoFactor.recoveryCodes": codeHash}})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+func (t *TwoFactorRepo) update(ctx context.Context, account domain.AccountRef, filter bson.M, update bson.M) error {
+	filter["_id"] = account.ID
+	result, err := t.collection(account).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (t *TwoFactorRepo) collection(account domain.AccountRef) *mongo.Collection {
+	if account.Kind == domain.AccountAdmin {
+		return t.admins
+	}
+
+	return t.users
+}
+
+func NewTwoFactorRepo(db *mongo.Database) *TwoFactorRepo {
+	return &TwoFactorRepo{
+		users:  db.Collection(usersCollection),
+		admins: db.Collection(adminsCollection),
+	}
+}
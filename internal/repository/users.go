@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
@@ -18,11 +19,18 @@ type UsersRepo struct {
 
 func NewUsersRepo(db *mongo.Database) *UsersRepo {
 	collection := db.Collection(usersCollection)
-	indexModel := mongo.IndexModel{
-		Keys:    bson.M{"email": 1},
-		Options: options.Index().SetUnique(true),
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"email": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "identities.issuer", Value: 1}, {Key: "identities.subject", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}),
+		},
 	}
-	_, err := collection.Indexes().CreateOne(context.Background(), indexModel)
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
 	if err != nil {
 		log.Fatalf("unable to create user collection index, %v", err)
 	}
@@ -63,7 +71,8 @@ func (u UsersRepo) FindByCredentials(ctx context.Context, email string, password
 
 func (u *UsersRepo) FindUserInfo(ctx context.Context, userID primitive.ObjectID) (domain.UserInfo, error) {
 	result := u.db.FindOne(ctx, bson.M{"_id": userID},
-		options.FindOne().SetProjection(bson.M{"email": 1, "name": 1}))
+		options.FindOne().SetProjection(bson.M{"email": 1, "name": 1, "emailVerified": 1, "pendingEmail": 1,
+			"phone": 1, "avatar": 1}))
 
 	var userInfo domain.UserInfo
 	err := result.Decode(&userInfo)
@@ -71,6 +80,102 @@ func (u *UsersRepo) FindUserInfo(ctx context.Context, userID primitive.ObjectID)
 	return userInfo, err
 }
 
+func (u UsersRepo) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+	result := u.db.FindOne(ctx, bson.M{"email": email})
+
+	var user domain.User
+	err := result.Decode(&user)
+
+	return user, err
+}
+
+func (u UsersRepo) FindByIdentity(ctx context.Context, issuer string, subject string) (domain.User, error) {
+	result := u.db.FindOne(ctx, bson.M{"identities": bson.M{"$elemMatch": bson.M{"issuer": issuer, "subject": subject}}})
+
+	var user domain.User
+	err := result.Decode(&user)
+
+	return user, err
+}
+
+func (u UsersRepo) AddIdentity(ctx context.Context, userID primitive.ObjectID, identity domain.ExternalIdentity) error {
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$push": bson.M{"identities": identity}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (u UsersRepo) MarkEmailVerified(ctx context.Context, userID primitive.ObjectID, verifiedAt time.Time) error {
+	_, err := u.db.UpdateOne(ctx, bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"emailVerified": true, "emailVerifiedAt": verifiedAt}})
+	return err
+}
+
+func (u UsersRepo) UpdateStatus(ctx context.Context, userID primitive.ObjectID, status string, reason string) error {
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"status": status, "statusReason": reason}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (u UsersRepo) UpdatePassword(ctx context.Context, userID primitive.ObjectID, passwordHash string) error {
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"password": passwordHash}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (u UsersRepo) SetPendingEmail(ctx context.Context, userID primitive.ObjectID, email string) error {
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"pendingEmail": email}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// ChangeEmail replaces the email with the pending one. It only matches while
+// the email is still the pending one, so a link for an address the user has
+// changed their mind about does nothing.
+func (u UsersRepo) ChangeEmail(ctx context.Context, userID primitive.ObjectID, email string, verifiedAt time.Time) error {
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": userID, "pendingEmail": email}, bson.M{
+		"$set":   bson.M{"email": email, "emailVerified": true, "emailVerifiedAt": verifiedAt},
+		"$unset": bson.M{"pendingEmail": ""},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
 func (u UsersRepo) Create(ctx context.Context, user domain.User) (domain.User, error) {
 	user.ID = primitive.NewObjectID()
 	_, err := u.db.InsertOne(ctx, user)
@@ -84,6 +189,14 @@ func (u UsersRepo) Update(ctx context.Context, userInput dto.UpdateUserInput, us
 		updateQuery["name"] = userInput.Name
 	}
 
+	if userInput.Phone != "" {
+		updateQuery["phone"] = userInput.Phone
+	}
+
+	if userInput.Avatar != "" {
+		updateQuery["avatar"] = userInput.Avatar
+	}
+
 	_, err := u.db.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": updateQuery})
 	findResult := u.db.FindOne(ctx, bson.M{"_id": userID})
 
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
+	"github.com/sigit14ap/go-commerce/pkg/mailer"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrWrongPassword        = errors.New("password does not match")
+	ErrEmailTaken           = errors.New("email address is already in use")
+	ErrEmailUnchanged       = errors.New("this is already the email address of the account")
+	ErrAccountHasStore      = errors.New("the account owns a store, close the store before deleting the account")
+	ErrAccountHasOpenOrders = errors.New("the account has orders that are not completed or cancelled yet")
+)
+
+// AccountService is the self-service side of a user account: the profile,
+// email and password changes, the data export and the deletion.
+type AccountService struct {
+	users          repository.Users
+	reviews        repository.Reviews
+	addresses      repository.Addresses
+	orders         repository.Orders
+	carts          repository.Carts
+	stores         repository.Stores
+	tokens         auth.OneTimeTokenProvider
+	mailer         mailer.Mailer
+	frontendURL    string
+	changeEmailTTL time.Duration
+}
+
+func NewAccountService(repos *repository.Repositories, tokens auth.OneTimeTokenProvider, mailer mailer.Mailer,
+	frontendURL string, changeEmailTTL time.Duration) *AccountService {
+	return &AccountService{
+		users:          repos.Users,
+		reviews:        repos.Reviews,
+		addresses:      repos.Addresses,
+		orders:         repos.Orders,
+		carts:          repos.Carts,
+		stores:         repos.Stores,
+		tokens:         tokens,
+		mailer:         mailer,
+		frontendURL:    frontendURL,
+		changeEmailTTL: changeEmailTTL,
+	}
+}
+
+func (a *AccountService) UpdateProfile(ctx context.Context, userID primitive.ObjectID, input dto.UpdateProfileInput) (domain.User, error) {
+	return a.users.Update(ctx, dto.UpdateUserInput{
+		Name:  strings.TrimSpace(input.Name),
+		Phone: strings.TrimSpace(input.Phone),
+	}, userID)
+}
+
+func (a *AccountService) UpdateAvatar(ctx context.Context, userID primitive.ObjectID, avatarURL string) (domain.User, error) {
+	return a.users.Update(ctx, dto.UpdateUserInput{Avatar: avatarURL}, userID)
+}
+
+// RequestEmailChange keeps the new address as pending and mails a
+// confirmation link to it. The account keeps signing in with the current
+// address until the link is used, and the current address is told about
+// the change.
+func (a *AccountService) RequestEmailChange(ctx context.Context, userID primitive.ObjectID, input dto.ChangeEmailInput) error {
+	user, err := a.checkPassword(ctx, userID, input.Password)
+	if err != nil {
+		return err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if email == user.Email {
+		return ErrEmailUnchanged
+	}
+
+	_, err = a.users.FindByEmail(ctx, email)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	if err = a.users.SetPendingEmail(ctx, userID, email); err != nil {
+		return err
+	}
+
+	token, err := a.tokens.Issue(auth.PurposeChangeEmail, userID.Hex()+":"+email, a.changeEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	err = a.mailer.Send(mailer.Message{
+		To:      email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm that you want to use this address for your account with the link below. "+
+			"It expires in %s.\n\n%s\n", user.Name, expiresIn(a.changeEmailTTL),
+			a.frontendURL+"/confirm-email?token="+token),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = a.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nA change of the email address of your account to %s was requested. "+
+			"If this was not you, change your password now.\n", user.Name, email),
+	})
+	if err != nil {
+		log.Errorf("failed to notify user %s of an email change: %v", userID.Hex(), err)
+	}
+
+	return nil
+}
+
+func (a *AccountService) ConfirmEmailChange(ctx context.Context, token string) (primitive.ObjectID, error) {
+	subject, err := a.tokens.Consume(auth.PurposeChangeEmail, token)
+	if errors.Is(err, auth.ErrInvalidOneTimeToken) {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	parts := strings.SplitN(subject, ":", 2)
+	userID, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil || len(parts) != 2 {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+
+	err = a.users.ChangeEmail(ctx, userID, parts[1], time.Now())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return primitive.NilObjectID, ErrEmailTaken
+	}
+
+	return userID, err
+}
+
+func (a *AccountService) ChangePassword(ctx context.Context, userID primitive.ObjectID, input dto.ChangePasswordInput) error {
+	if _, err := a.checkPassword(ctx, userID, input.CurrentPassword); err != nil {
+		return err
+	}
+
+	hashPassword, err := HashPassword(input.Password)
+	if err != nil {
+		return err
+	}
+
+	return a.users.UpdatePassword(ctx, userID, hashPassword)
+}
+
+func (a *AccountService) Export(ctx context.Context, userID primitive.ObjectID) (domain.AccountExport, error) {
+	user, err := a.users.FindByID(ctx, userID)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+
+	addresses, err := a.addresses.FindAll(ctx, userID)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+
+	orders, err := a.orders.FindByUserID(ctx, userID)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+
+	reviews, err := a.reviews.FindByUserID(ctx, userID)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+
+	if orders == nil {
+		orders = []domain.Order{}
+	}
+	if reviews == nil {
+		reviews = []domain.Review{}
+	}
+
+	return domain.AccountExport{
+		ExportedAt: time.Now(),
+		Account:    user,
+		Addresses:  addresses,
+		Orders:     orders,
+		Reviews:    reviews,
+	}, nil
+}
+
+// Delete removes the account, its addresses and its cart. Reviews stay
+// without the user, and orders are kept untouched for accounting. Accounts
+// that own a store or still have orders in progress can not be deleted.
+func (a *AccountService) Delete(ctx context.Context, userID primitive.ObjectID, password string) error {
+	if _, err := a.checkPassword(ctx, userID, password); err != nil {
+		return err
+	}
+
+	_, err := a.stores.FindByUserID(ctx, userID)
+	if err == nil {
+		return ErrAccountHasStore
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	orders, err := a.orders.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if order.Status != domain.OrderStatusCompleted && order.Status != domain.OrderStatusCancelled {
+			return ErrAccountHasOpenOrders
+		}
+	}
+
+	if err = a.reviews.AnonymizeByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	if err = a.addresses.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	cart, err := a.carts.FindByID(ctx, userID)
+	if err == nil {
+		err = a.carts.Delete(ctx, cart.ID)
+	}
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	return a.users.Delete(ctx, userID)
+}
+
+func (a *AccountService) checkPassword(ctx context.Context, userID primitive.ObjectID, password string) (domain.User, error) {
+	user, err := a.users.FindByID(ctx, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	// accounts created through an identity provider have no password until
+	// one is set with the reset link
+	if user.Password == "" || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return domain.User{}, ErrWrongPassword
+	}
+
+	return user, nil
+}
@@ -2,28 +2,188 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
 	"github.com/sigit14ap/go-commerce/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const adminLockTTL = 30 * time.Second
+
+var (
+	ErrInvalidRole     = errors.New("invalid admin role")
+	ErrLastSuperAdmin  = errors.New("the last super admin can not be removed")
+	ErrAdminSelfDelete = errors.New("admins can not delete their own account")
+	ErrAdminsBusy      = errors.New("another admin role change is in progress, try again")
 )
 
 type AdminsService struct {
 	repo repository.Admins
 }
 
+func NewAdminsService(repo repository.Admins) *AdminsService {
+	return &AdminsService{
+		repo: repo,
+	}
+}
+
+func (a *AdminsService) FindAll(ctx context.Context) ([]domain.Admin, error) {
+	return a.repo.FindAll(ctx)
+}
+
+func (a *AdminsService) FindByID(ctx context.Context, adminID primitive.ObjectID) (domain.Admin, error) {
+	return a.repo.FindByID(ctx, adminID)
+}
+
+func (a *AdminsService) FindByEmail(ctx context.Context, email string) (domain.Admin, error) {
+	return a.repo.FindByCredentials(ctx, email)
+}
+
 func (a *AdminsService) FindByCredentials(ctx context.Context, signInDTO dto.SignInDTO) (domain.Admin, error) {
 	return a.repo.FindByCredentials(ctx, signInDTO.Email)
 }
 
-func NewAdminsService(repo repository.Admins) *AdminsService {
-	return &AdminsService{
-		repo: repo,
+// Create stores a new admin, the password is hashed the same way as user
+// passwords.
+func (a *AdminsService) Create(ctx context.Context, adminInput dto.CreateAdminInput) (domain.Admin, error) {
+	if err := validateRoles(adminInput.Roles); err != nil {
+		return domain.Admin{}, err
+	}
+
+	hashPassword, err := HashPassword(adminInput.Password)
+	if err != nil {
+		return domain.Admin{}, err
+	}
+
+	return a.repo.Create(ctx, domain.Admin{
+		Name:     adminInput.Name,
+		Email:    adminInput.Email,
+		Password: hashPassword,
+		Roles:    adminInput.Roles,
+	})
+}
+
+// Update changes the name and roles of an admin. Taking the super admin role
+// away from the last super admin is refused so the admin accounts can still
+// be managed afterwards.
+func (a *AdminsService) Update(ctx context.Context, adminID primitive.ObjectID, adminInput dto.UpdateAdminInput) (domain.Admin, error) {
+	if err := validateRoles(adminInput.Roles); err != nil {
+		return domain.Admin{}, err
+	}
+
+	if len(adminInput.Roles) == 0 {
+		return a.repo.Update(ctx, adminID, adminInput)
+	}
+
+	var admin domain.Admin
+	err := a.withSuperAdminsLocked(ctx, func() error {
+		current, err := a.repo.FindByID(ctx, adminID)
+		if err != nil {
+			return err
+		}
+
+		if !hasRole(adminInput.Roles, domain.RoleSuperAdmin) {
+			if err = a.checkNotLastSuperAdmin(ctx, current); err != nil {
+				return err
+			}
+		}
+
+		admin, err = a.repo.Update(ctx, adminID, adminInput)
+		return err
+	})
+
+	return admin, err
+}
+
+func (a *AdminsService) ResetPassword(ctx context.Context, adminID primitive.ObjectID, password string) error {
+	hashPassword, err := HashPassword(password)
+	if err != nil {
+		return err
 	}
+
+	return a.repo.UpdatePassword(ctx, adminID, hashPassword)
+}
+
+func (a *AdminsService) Delete(ctx context.Context, adminID primitive.ObjectID) error {
+	return a.withSuperAdminsLocked(ctx, func() error {
+		admin, err := a.repo.FindByID(ctx, adminID)
+		if err != nil {
+			return err
+		}
+
+		if err = a.checkNotLastSuperAdmin(ctx, admin); err != nil {
+			return err
+		}
+
+		return a.repo.Delete(ctx, adminID)
+	})
 }
 
 func (a *AdminsService) CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// withSuperAdminsLocked runs change while holding the super admins lock, so the
+// last super admin check and the write that follows it can't interleave with
+// another role change or delete.
+func (a *AdminsService) withSuperAdminsLocked(ctx context.Context, change func() error) error {
+	token, err := a.repo.LockSuperAdmins(ctx, adminLockTTL)
+	if errors.Is(err, repository.ErrAdminsLocked) {
+		return ErrAdminsBusy
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := a.repo.UnlockSuperAdmins(ctx, token); err != nil {
+			log.Errorf("failed to unlock super admins: %v", err)
+		}
+	}()
+
+	return change()
+}
+
+func (a *AdminsService) checkNotLastSuperAdmin(ctx context.Context, admin domain.Admin) error {
+	if !hasRole(admin.Roles, domain.RoleSuperAdmin) {
+		return nil
+	}
+
+	count, err := a.repo.CountByRole(ctx, domain.RoleSuperAdmin)
+	if err != nil {
+		return err
+	}
+
+	if count <= 1 {
+		return ErrLastSuperAdmin
+	}
+
+	return nil
+}
+
+func validateRoles(roles []domain.Role) error {
+	for _, role := range roles {
+		if !role.IsValid() {
+			return fmt.Errorf("%w: %s", ErrInvalidRole, role)
+		}
+	}
+
+	return nil
+}
+
+func hasRole(roles []domain.Role, role domain.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryAdmins only implements what updating and deleting admins use.
+type memoryAdmins struct {
+	repository.Admins
+	mu     sync.Mutex
+	admins map[primitive.ObjectID]domain.Admin
+	locked bool
+}
+
+func newMemoryAdmins(admins ...domain.Admin) *memoryAdmins {
+	repo := &memoryAdmins{admins: map[primitive.ObjectID]domain.Admin{}}
+	for _, admin := range admins {
+		repo.admins[admin.ID] = admin
+	}
+
+	return repo
+}
+
+func (a *memoryAdmins) FindByID(ctx context.Context, adminID primitive.ObjectID) (domain.Admin, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	admin, ok := a.admins[adminID]
+	if !ok {
+		return domain.Admin{}, mongo.ErrNoDocuments
+	}
+
+	return admin, nil
+}
+
+func (a *memoryAdmins) CountByRole(ctx context.Context, role domain.Role) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var count int64
+	for _, admin := range a.admins {
+		if hasRole(admin.Roles, role) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (a *memoryAdmins) Update(ctx context.Context, adminID primitive.ObjectID, adminInput dto.UpdateAdminInput) (domain.Admin, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	admin, ok := a.admins[adminID]
+	if !ok {
+		return domain.Admin{}, mongo.ErrNoDocuments
+	}
+
+	if adminInput.Name != "" {
+		admin.Name = adminInput.Name
+	}
+	if len(adminInput.Roles) > 0 {
+		admin.Roles = adminInput.Roles
+	}
+
+	a.admins[adminID] = admin
+	return admin, nil
+}
+
+func (a *memoryAdmins) Delete(ctx context.Context, adminID primitive.ObjectID) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.admins[adminID]; !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	delete(a.admins, adminID)
+	return nil
+}
+
+func (a *memoryAdmins) LockSuperAdmins(ctx context.Context, ttl time.Duration) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.locked {
+		return "", repository.ErrAdminsLocked
+	}
+
+	a.locked = true
+	return "token", nil
+}
+
+func (a *memoryAdmins) UnlockSuperAdmins(ctx context.Context, token string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.locked = false
+	return nil
+}
+
+func superAdmin() domain.Admin {
+	return domain.Admin{ID: primitive.NewObjectID(), Roles: []domain.Role{domain.RoleSuperAdmin}}
+}
+
+func TestLastSuperAdminIsKept(t *testing.T) {
+	ctx := context.Background()
+	first, second := superAdmin(), superAdmin()
+	repo := newMemoryAdmins(first, second)
+	admins := NewAdminsService(repo)
+
+	demote := dto.UpdateAdminInput{Roles: []domain.Role{domain.RoleSupport}}
+	if _, err := admins.Update(ctx, first.ID, demote); err != nil {
+		t.Fatalf("demoting one of two super admins: %v", err)
+	}
+
+	if _, err := admins.Update(ctx, second.ID, demote); !errors.Is(err, ErrLastSuperAdmin) {
+		t.Errorf("demoting the last super admin err = %v, want %v", err, ErrLastSuperAdmin)
+	}
+	if err := admins.Delete(ctx, second.ID); !errors.Is(err, ErrLastSuperAdmin) {
+		t.Errorf("deleting the last super admin err = %v, want %v", err, ErrLastSuperAdmin)
+	}
+
+	rename := dto.UpdateAdminInput{Name: "Root"}
+	if _, err := admins.Update(ctx, second.ID, rename); err != nil {
+		t.Errorf("renaming the last super admin: %v", err)
+	}
+	if err := admins.Delete(ctx, first.ID); err != nil {
+		t.Errorf("deleting a support admin: %v", err)
+	}
+
+	if repo.locked {
+		t.Error("super admins are still locked")
+	}
+}
+
+func TestConcurrentSuperAdminDeletesKeepOne(t *testing.T) {
+	ctx := context.Background()
+	first, second := superAdmin(), superAdmin()
+	repo := newMemoryAdmins(first, second)
+	admins := NewAdminsService(repo)
+
+	results := make(chan error, 2)
+	for _, admin := range []domain.Admin{first, second} {
+		go func(adminID primitive.ObjectID) {
+			for {
+				err := admins.Delete(ctx, adminID)
+				if !errors.Is(err, ErrAdminsBusy) {
+					results <- err
+					return
+				}
+			}
+		}(admin.ID)
+	}
+
+	var refused int
+	for i := 0; i < 2; i++ {
+		if err := <-results; errors.Is(err, ErrLastSuperAdmin) {
+			refused++
+		} else if err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	}
+
+	if count, _ := repo.CountByRole(ctx, domain.RoleSuperAdmin); count != 1 || refused != 1 {
+		t.Errorf("%d super admins left and %d deletes refused, want 1 and 1", count, refused)
+	}
+}
+
+func TestSuperAdminChangeWhileLocked(t *testing.T) {
+	ctx := context.Background()
+	first, second := superAdmin(), superAdmin()
+	repo := newMemoryAdmins(first, second)
+	repo.locked = true
+	admins := NewAdminsService(repo)
+
+	if err := admins.Delete(ctx, first.ID); !errors.Is(err, ErrAdminsBusy) {
+		t.Errorf("Delete err = %v, want %v", err, ErrAdminsBusy)
+	}
+
+	demote := dto.UpdateAdminInput{Roles: []domain.Role{domain.RoleFinance}}
+	if _, err := admins.Update(ctx, first.ID, demote); !errors.Is(err, ErrAdminsBusy) {
+		t.Errorf("Update err = %v, want %v", err, ErrAdminsBusy)
+	}
+
+	if count, _ := repo.CountByRole(ctx, domain.RoleSuperAdmin); count != 2 {
+		t.Errorf("%d super admins left, want 2", count)
+	}
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultAnalyticsLimit = 10
+
+var (
+	ErrInvalidTimezone  = errors.New("unknown timezone")
+	ErrInvalidDateRange = errors.New("invalid date range")
+)
+
+// AnalyticsService answers the admin dashboard. Dates of a request are whole
+// days in its timezone, both ends included, and every answer is cached in
+// Redis for a few minutes since the aggregations scan whole collections.
+type AnalyticsService struct {
+	repo        repository.Analytics
+	stats       repository.ProductStats
+	redisClient *redis.Client
+	timezone    string
+	defaultDays int
+	maxDays     int
+	cacheTTL    time.Duration
+}
+
+func NewAnalyticsService(repo repository.Analytics, stats repository.ProductStats, redisClient *redis.Client,
+	timezone string, defaultDays int, maxDays int, cacheTTL time.Duration) *AnalyticsService {
+	return &AnalyticsService{
+		repo:        repo,
+		stats:       stats,
+		redisClient: redisClient,
+		timezone:    timezone,
+		defaultDays: defaultDays,
+		maxDays:     maxDays,
+		cacheTTL:    cacheTTL,
+	}
+}
+
+func (a *AnalyticsService) Sales(ctx context.Context, input dto.AnalyticsInput) (domain.SalesReport, error) {
+	filter, err := a.filter(input, false)
+	if err != nil {
+		return domain.SalesReport{}, err
+	}
+
+	var report domain.SalesReport
+	err = a.cached("sales", filter, &report, func() (interface{}, error) {
+		series, err := a.repo.Sales(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		return domain.SalesReport{Totals: salesTotals(series), Series: withAverages(series)}, nil
+	})
+
+	return report, err
+}
+
+func (a *AnalyticsService) TopProducts(ctx context.Context, input dto.AnalyticsInput) ([]domain.TopProduct, error) {
+	filter, err := a.filter(input, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []domain.TopProduct
+	err = a.cached("top-products", filter, &products, func() (interface{}, error) {
+		return a.repo.TopProducts(ctx, filter)
+	})
+
+	return products, err
+}
+
+func (a *AnalyticsService) TopStores(ctx context.Context, input dto.AnalyticsInput) ([]domain.TopStore, error) {
+	filter, err := a.filter(input, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var stores []domain.TopStore
+	err = a.cached("top-stores", filter, &stores, func() (interface{}, error) {
+		return a.repo.TopStores(ctx, filter)
+	})
+
+	return stores, err
+}
+
+func (a *AnalyticsService) CartConversion(ctx context.Context, input dto.AnalyticsInput) (domain.CartConversion, error) {
+	filter, err := a.filter(input, false)
+	if err != nil {
+		return domain.CartConversion{}, err
+	}
+
+	var conversion domain.CartConversion
+	err = a.cached("conversion", filter, &conversion, func() (interface{}, error) {
+		conversion, err := a.repo.CartConversion(ctx, filter)
+		conversion.Rate = ratio(conversion.Converted, conversion.Carts)
+		return conversion, err
+	})
+
+	return conversion, err
+}
+
+func (a *AnalyticsService) NewUsers(ctx context.Context, input dto.AnalyticsInput) (domain.NewUsersReport, error) {
+	filter, err := a.filter(input, false)
+	if err != nil {
+		return domain.NewUsersReport{}, err
+	}
+
+	var report domain.NewUsersReport
+	err = a.cached("new-users", filter, &report, func() (interface{}, error) {
+		series, err := a.repo.NewUsers(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		report := domain.NewUsersReport{Series: series}
+		for _, point := range series {
+			report.Total += point.Users
+		}
+
+		return report, nil
+	})
+
+	return report, err
+}
+
+func (a *AnalyticsService) OrderRates(ctx context.Context, input dto.AnalyticsInput) (domain.OrderRates, error) {
+	filter, err := a.filter(input, false)
+	if err != nil {
+		return domain.OrderRates{}, err
+	}
+
+	var rates domain.OrderRates
+	err = a.cached("rates", filter, &rates, func() (interface{}, error) {
+		rates, err := a.repo.OrderRates(ctx, filter)
+		rates.CancellationRate = ratio(rates.Cancelled, rates.Orders)
+		rates.RefundRate = ratio(rates.Refunded, rates.Orders)
+		return rates, err
+	})
+
+	return rates, err
+}
+
+// Overview puts the totals of the other reports together, each of them comes
+// from its own cache entry.
+func (a *AnalyticsService) Overview(ctx context.Context, input dto.AnalyticsInput) (domain.AnalyticsOverview, error) {
+	sales, err := a.Sales(ctx, input)
+	if err != nil {
+		return domain.AnalyticsOverview{}, err
+	}
+
+	rates, err := a.OrderRates(ctx, input)
+	if err != nil {
+		return domain.AnalyticsOverview{}, err
+	}
+
+	conversion, err := a.CartConversion(ctx, input)
+	if err != nil {
+		return domain.AnalyticsOverview{}, err
+	}
+
+	newUsers, err := a.NewUsers(ctx, input)
+	if err != nil {
+		return domain.AnalyticsOverview{}, err
+	}
+
+	return domain.AnalyticsOverview{
+		Sales:      sales.Totals,
+		Rates:      rates,
+		Conversion: conversion,
+		NewUsers:   newUsers.Total,
+	}, nil
+}
+
+// Store reports the sales of one store next to the views and cart adds of
+// its products. Views and cart adds reach it once they are flushed from Redis.
+func (a *AnalyticsService) Store(ctx context.Context, storeID primitive.ObjectID, input dto.AnalyticsInput) (domain.StoreAnalytics, error) {
+	filter, err := a.filter(input, false)
+	if err != nil {
+		return domain.StoreAnalytics{}, err
+	}
+	filter.StoreID = storeID
+
+	var analytics domain.StoreAnalytics
+	err = a.cached("store", filter, &analytics, func() (interface{}, error) {
+		return a.storeAnalytics(ctx, filter)
+	})
+
+	return analytics, err
+}
+
+func (a *AnalyticsService) storeAnalytics(ctx context.Context, filter dto.AnalyticsFilter) (domain.StoreAnalytics, error) {
+	series, err := a.repo.StoreSales(ctx, filter)
+	if err != nil {
+		return domain.StoreAnalytics{}, err
+	}
+
+	traffic, err := a.stats.Traffic(ctx, filter)
+	if err != nil {
+		return domain.StoreAnalytics{}, err
+	}
+
+	sold, err := a.repo.StoreProducts(ctx, filter)
+	if err != nil {
+		return domain.StoreAnalytics{}, err
+	}
+
+	viewed, err := a.stats.Products(ctx, filter)
+	if err != nil {
+		return domain.StoreAnalytics{}, err
+	}
+
+	series = mergeTraffic(series, traffic)
+	products := mergeProductStats(sold, viewed)
+
+	return domain.StoreAnalytics{
+		Totals:   storeTotals(series),
+		Series:   series,
+		Products: products,
+	}, nil
+}
+
+// filter turns the requested days into an absolute range. Without dates it
+// covers the last defaultDays days up to today.
+func (a *AnalyticsService) filter(input dto.AnalyticsInput, limited bool) (dto.AnalyticsFilter, error) {
+	timezone := input.Timezone
+	if timezone == "" {
+		timezone = a.timezone
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return dto.AnalyticsFilter{}, fmt.Errorf("%w: %s", ErrInvalidTimezone, timezone)
+	}
+
+	to := input.To
+	if to.IsZero() {
+		to = time.Now().In(location)
+	}
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+
+	from := to.AddDate(0, 0, -a.defaultDays)
+	if !input.From.IsZero() {
+		from = time.Date(input.From.Year(), input.From.Month(), input.From.Day(), 0, 0, 0, 0, location)
+	}
+
+	if !from.Before(to) {
+		return dto.AnalyticsFilter{}, fmt.Errorf("%w: from is after to", ErrInvalidDateRange)
+	}
+	if a.maxDays > 0 && from.AddDate(0, 0, a.maxDays).Before(to) {
+		return dto.AnalyticsFilter{}, fmt.Errorf("%w: the range is longer than %d days", ErrInvalidDateRange, a.maxDays)
+	}
+
+	filter := dto.AnalyticsFilter{
+		Period:   input.Period,
+		From:     from,
+		To:       to,
+		Timezone: location.String(),
+	}
+
+	if filter.Period == "" {
+		filter.Period = domain.AnalyticsPeriodDay
+	}
+
+	if limited {
+		filter.Limit = input.Limit
+		if filter.Limit == 0 {
+			filter.Limit = defaultAnalyticsLimit
+		}
+	}
+
+	return filter, nil
+}
+
+// cached decodes the cached answer for the report and filter into result, or
+// loads and caches it. Redis errors only cost the cache.
+func (a *AnalyticsService) cached(report string, filter dto.AnalyticsFilter, result interface{},
+	load func() (interface{}, error)) error {
+	params, err := json.Marshal(filter)
+	if err != nil {
+		return err
+	}
+
+	hash := sha1.Sum(params)
+	key := fmt.Sprintf("analytics:%s:%s", report, hex.EncodeToString(hash[:]))
+
+	cached, err := a.redisClient.Get(key).Bytes()
+	if err == nil && json.Unmarshal(cached, result) == nil {
+		return nil
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Errorf("analytics cache %s: %s", key, err)
+	}
+
+	value, err := load()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err = a.redisClient.Set(key, data, a.cacheTTL).Err(); err != nil {
+		log.Errorf("analytics cache %s: %s", key, err)
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+func salesTotals(series []domain.SalesPoint) []domain.SalesPoint {
+	totals := []domain.SalesPoint{}
+	index := map[domain.Currency]int{}
+	for _, point := range series {
+		i, ok := index[point.GMV.Currency]
+		if !ok {
+			i = len(totals)
+			index[point.GMV.Currency] = i
+			totals = append(totals, domain.SalesPoint{GMV: domain.NewMoney(0, point.GMV.Currency)})
+		}
+
+		totals[i].Orders += point.Orders
+		totals[i].GMV = totals[i].GMV.Add(point.GMV)
+	}
+
+	return withAverages(totals)
+}
+
+func withAverages(points []domain.SalesPoint) []domain.SalesPoint {
+	for i := range points {
+		if points[i].Orders > 0 {
+			points[i].AverageOrderValue = points[i].GMV.Scale(1 / float64(points[i].Orders))
+		}
+	}
+
+	return points
+}
+
+func mergeTraffic(series []domain.StoreSalesPoint, traffic []domain.TrafficPoint) []domain.StoreSalesPoint {
+	index := make(map[string]int, len(series))
+	for i, point := range series {
+		index[point.Period] = i
+	}
+
+	for _, point := range traffic {
+		i, ok := index[point.Period]
+		if !ok {
+			i = len(series)
+			index[point.Period] = i
+			series = append(series, domain.StoreSalesPoint{Period: point.Period, Revenue: []domain.Money{}})
+		}
+
+		series[i].Views += point.Views
+		series[i].CartAdds += point.CartAdds
+	}
+
+	sort.Slice(series, func(i, j int) bool { return series[i].Period < series[j].Period })
+	return series
+}
+
+func mergeProductStats(sold []domain.ProductPerformance, viewed []domain.ProductPerformance) []domain.ProductPerformance {
+	index := make(map[primitive.ObjectID]int, len(sold))
+	for i, product := range sold {
+		index[product.ProductID] = i
+	}
+
+	for _, product := range viewed {
+		i, ok := index[product.ProductID]
+		if !ok {
+			i = len(sold)
+			index[product.ProductID] = i
+			sold = append(sold, domain.ProductPerformance{
+				ProductID: product.ProductID,
+				Name:      product.Name,
+				Revenue:   []domain.Money{},
+			})
+		}
+
+		sold[i].Views += product.Views
+		sold[i].CartAdds += product.CartAdds
+	}
+
+	for i := range sold {
+		sold[i].ViewConversion = ratio(sold[i].Orders, sold[i].Views)
+		sold[i].CartConversion = ratio(sold[i].Orders, sold[i].CartAdds)
+	}
+
+	sort.SliceStable(sold, func(i, j int) bool {
+		if sold[i].Units != sold[j].Units {
+			return sold[i].Units > sold[j].Units
+		}
+		return sold[i].Views > sold[j].Views
+	})
+
+	return sold
+}
+
+func storeTotals(series []domain.StoreSalesPoint) domain.StoreSalesPoint {
+	totals := domain.StoreSalesPoint{Revenue: []domain.Money{}}
+	index := map[domain.Currency]int{}
+
+	for _, point := range series {
+		totals.Orders += point.Orders
+		totals.Units += point.Units
+		totals.Views += point.Views
+		totals.CartAdds += point.CartAdds
+
+		for _, revenue := range point.Revenue {
+			i, ok := index[revenue.Currency]
+			if !ok {
+				i = len(totals.Revenue)
+				index[revenue.Currency] = i
+				totals.Revenue = append(totals.Revenue, domain.NewMoney(0, revenue.Currency))
+			}
+
+			totals.Revenue[i] = totals.Revenue[i].Add(revenue)
+		}
+	}
+
+	return totals
+}
+
+// ratio returns part of whole rounded to four decimals, zero for an empty whole.
+func ratio(part int64, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+
+	return math.Round(float64(part)/float64(whole)*10000) / 10000
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+)
+
+func TestAnalyticsFilter(t *testing.T) {
+	analytics := NewAnalyticsService(nil, nil, nil, "Asia/Jakarta", 30, 366, time.Minute)
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+
+	filter, err := analytics.filter(dto.AnalyticsInput{
+		From: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2022, 3, 31, 0, 0, 0, 0, time.UTC),
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := time.Date(2022, 3, 1, 0, 0, 0, 0, jakarta); !filter.From.Equal(want) {
+		t.Errorf("from = %s, want %s", filter.From, want)
+	}
+	if want := time.Date(2022, 4, 1, 0, 0, 0, 0, jakarta); !filter.To.Equal(want) {
+		t.Errorf("to = %s, want %s", filter.To, want)
+	}
+	if filter.Period != domain.AnalyticsPeriodDay || filter.Timezone != "Asia/Jakarta" || filter.Limit != 0 {
+		t.Errorf("filter = %+v", filter)
+	}
+
+	filter, err = analytics.filter(dto.AnalyticsInput{Timezone: "UTC"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days := filter.To.Sub(filter.From); days != 30*24*time.Hour || filter.Limit != defaultAnalyticsLimit {
+		t.Errorf("default range = %s, limit %d", days, filter.Limit)
+	}
+
+	errorTests := []struct {
+		input dto.AnalyticsInput
+		want  error
+	}{
+		{dto.AnalyticsInput{Timezone: "Mars/Olympus"}, ErrInvalidTimezone},
+		{dto.AnalyticsInput{
+			From: time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+		}, ErrInvalidDateRange},
+		{dto.AnalyticsInput{
+			From: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, ErrInvalidDateRange},
+	}
+
+	for _, test := range errorTests {
+		if _, err = analytics.filter(test.input, false); !errors.Is(err, test.want) {
+			t.Errorf("filter(%+v) error = %v, want %v", test.input, err, test.want)
+		}
+	}
+}
+
+func TestSalesTotals(t *testing.T) {
+	totals := salesTotals([]domain.SalesPoint{
+		{Period: "2022-03-01", Orders: 2, GMV: domain.IDR(30000)},
+		{Period: "2022-03-01", Orders: 1, GMV: domain.NewMoney(1000, domain.CurrencyUSD)},
+		{Period: "2022-03-02", Orders: 1, GMV: domain.IDR(15000)},
+	})
+
+	if len(totals) != 2 {
+		t.Fatalf("totals = %+v", totals)
+	}
+	if totals[0].Orders != 3 || totals[0].GMV != domain.IDR(45000) || totals[0].AverageOrderValue != domain.IDR(15000) {
+		t.Errorf("IDR total = %+v", totals[0])
+	}
+	if totals[1].Orders != 1 || totals[1].AverageOrderValue != domain.NewMoney(1000, domain.CurrencyUSD) {
+		t.Errorf("USD total = %+v", totals[1])
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultAuditLimit = 20
+
+type auditContextKey struct{}
+
+// WithAuditContext attaches the request's audit context to ctx.
+func WithAuditContext(ctx context.Context, auditContext domain.AuditContext) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, auditContext)
+}
+
+// WithAuditActor adds the signed in actor to the audit context of ctx.
+func WithAuditActor(ctx context.Context, actorType string, actorID primitive.ObjectID, roles []domain.Role) context.Context {
+	auditContext := AuditContextFrom(ctx)
+	auditContext.ActorType = actorType
+	auditContext.ActorID = actorID
+	auditContext.Roles = roles
+
+	return WithAuditContext(ctx, auditContext)
+}
+
+func AuditContextFrom(ctx context.Context) domain.AuditContext {
+	auditContext, _ := ctx.Value(auditContextKey{}).(domain.AuditContext)
+	return auditContext
+}
+
+type AuditService struct {
+	repo      repository.Audit
+	retention time.Duration
+}
+
+func NewAuditService(repo repository.Audit, retention time.Duration) *AuditService {
+	return &AuditService{
+		repo:      repo,
+		retention: retention,
+	}
+}
+
+// Record appends an entry to the audit log, stamping it with the current time.
+// Whatever the entry leaves out about the actor and the request is taken
+// from the audit context of ctx.
+func (a *AuditService) Record(ctx context.Context, entry domain.AuditLog) error {
+	auditContext := AuditContextFrom(ctx)
+	if entry.ActorType == "" {
+		entry.ActorType = auditContext.ActorType
+		entry.ActorID = auditContext.ActorID
+		entry.Roles = auditContext.Roles
+	}
+	if entry.Method == "" {
+		entry.Method = auditContext.Method
+		entry.Path = auditContext.Path
+		entry.IP = auditContext.IP
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = auditContext.RequestID
+	}
+
+	entry.CreatedAt = time.Now()
+	_, err := a.repo.Insert(ctx, entry)
+
+	return err
+}
+
+// Track is the hook services call after changing an entity. It records what
+// changed between before and after, either may be nil for creates and
+// deletes. The change has already happened, so failures are only logged.
+func (a *AuditService) Track(ctx context.Context, action string, targetType string, targetID primitive.ObjectID,
+	before interface{}, after interface{}) {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		log.Errorf("failed to diff %s %s for the audit log: %s", targetType, targetID.Hex(), err)
+	}
+
+	// An update that left everything as it was is not worth an entry.
+	if err == nil && len(changes) == 0 && before != nil && after != nil {
+		return
+	}
+
+	err = a.Record(ctx, domain.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+	})
+	if err != nil {
+		log.Errorf("failed to audit %s of %s %s: %s", action, targetType, targetID.Hex(), err)
+	}
+}
+
+func (a *AuditService) Find(ctx context.Context, input dto.AuditFilterInput) (domain.AuditPage, error) {
+	filter := dto.AuditFilter{
+		ActorType:  input.ActorType,
+		Action:     input.Action,
+		TargetType: input.TargetType,
+		RequestID:  input.RequestID,
+		From:       input.From,
+		Page:       input.Page,
+		Limit:      input.Limit,
+	}
+
+	// Invalid IDs were turned away by validation already.
+	if input.ActorID != "" {
+		filter.ActorID, _ = primitive.ObjectIDFromHex(input.ActorID)
+	}
+	if input.TargetID != "" {
+		filter.TargetID, _ = primitive.ObjectIDFromHex(input.TargetID)
+	}
+
+	// To names the last day to include.
+	if !input.To.IsZero() {
+		filter.To = input.To.AddDate(0, 0, 1)
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = defaultAuditLimit
+	}
+
+	entries, total, err := a.repo.Find(ctx, filter)
+	if err != nil {
+		return domain.AuditPage{}, err
+	}
+
+	return domain.AuditPage{
+		Entries: entries,
+		Total:   total,
+		Page:    filter.Page,
+		Limit:   filter.Limit,
+	}, nil
+}
+
+// Purge deletes the entries older than the retention period, a retention of
+// zero keeps them forever.
+func (a *AuditService) Purge(ctx context.Context) (int64, error) {
+	if a.retention <= 0 {
+		return 0, nil
+	}
+
+	return a.repo.DeleteBefore(ctx, time.Now().Add(-a.retention))
+}
+
+// auditChanges compares the JSON form of two versions of an entity, so
+// fields hidden from the API such as password hashes never reach the log.
+func auditChanges(before interface{}, after interface{}) ([]domain.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(beforeFields)+len(afterFields))
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+	for name := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []domain.AuditChange
+	for _, name := range names {
+		if name == "id" {
+			continue
+		}
+
+		beforeValue, afterValue := beforeFields[name], afterFields[name]
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+
+		changes = append(changes, domain.AuditChange{
+			Field:  name,
+			Before: beforeValue,
+			After:  afterValue,
+		})
+	}
+
+	return changes, nil
+}
+
+func auditFields(entity interface{}) (map[string]interface{}, error) {
+	if entity == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	err = json.Unmarshal(data, &fields)
+
+	return fields, err
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAuditChanges(t *testing.T) {
+	userID := primitive.NewObjectID()
+	before := domain.User{ID: userID, Name: "Budi", Email: "budi@example.com", Password: "hash-1"}
+	after := before
+	after.Status = domain.StatusSuspended
+	after.Password = "hash-2"
+
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || changes[0].Field != "status" || changes[0].Before != nil ||
+		changes[0].After != domain.StatusSuspended {
+		t.Errorf("changes = %+v", changes)
+	}
+
+	changes, err = auditChanges(nil, domain.Category{ID: primitive.NewObjectID(), Name: "Books"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := map[string]bool{}
+	for _, change := range changes {
+		if change.Before != nil {
+			t.Errorf("created %s has a before value %v", change.Field, change.Before)
+		}
+		fields[change.Field] = true
+	}
+	if !fields["name"] || fields["id"] {
+		t.Errorf("created fields = %v", fields)
+	}
+}
+
+func TestWithAuditActor(t *testing.T) {
+	adminID := primitive.NewObjectID()
+	ctx := WithAuditContext(context.Background(), domain.AuditContext{Method: "PUT", Path: "/orders/1", RequestID: "req-1"})
+	ctx = WithAuditActor(ctx, domain.AuditActorAdmin, adminID, []domain.Role{domain.RoleSupport})
+
+	auditContext := AuditContextFrom(ctx)
+	if auditContext.ActorID != adminID || auditContext.ActorType != domain.AuditActorAdmin ||
+		auditContext.RequestID != "req-1" || auditContext.Method != "PUT" || len(auditContext.Roles) != 1 {
+		t.Errorf("audit context = %+v", auditContext)
+	}
+}
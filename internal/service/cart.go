@@ -13,6 +13,7 @@ import (
 type CartService struct {
 	repo           repository.Carts
 	productService Products
+	taxService     Taxes
 }
 
 func (c *CartService) FindAll(ctx context.Context) ([]domain.Cart, error) {
@@ -22,7 +23,7 @@ func (c *CartService) FindAll(ctx context.Context) ([]domain.Cart, error) {
 	}
 
 	for i, cart := range carts {
-		var totalPrice float64
+		var totalPrice domain.Money
 		for _, cartItem := range cart.CartItems {
 			product, err := c.productService.FindByID(ctx, cartItem.ProductID)
 
@@ -30,7 +31,7 @@ func (c *CartService) FindAll(ctx context.Context) ([]domain.Cart, error) {
 				return nil, fmt.Errorf("Product no longer exists in stock")
 			}
 
-			totalPrice += product.Price * float64(cartItem.Quantity)
+			totalPrice = totalPrice.Add(product.Price.Mul(cartItem.Quantity))
 		}
 
 		carts[i].TotalPrice = totalPrice
@@ -45,7 +46,8 @@ func (c *CartService) FindByID(ctx context.Context, userID primitive.ObjectID) (
 		return domain.Cart{}, err
 	}
 
-	var totalPrice float64
+	var totalPrice domain.Money
+	var taxItems []dto.TaxItem
 	for _, cartItem := range cart.CartItems {
 		product, err := c.productService.FindByID(ctx, cartItem.ProductID)
 
@@ -53,11 +55,25 @@ func (c *CartService) FindByID(ctx context.Context, userID primitive.ObjectID) (
 			return domain.Cart{}, fmt.Errorf("Product no longer in stock")
 		}
 
-		totalPrice += product.Price * float64(cartItem.Quantity)
+		amount := product.Price.Mul(cartItem.Quantity)
+		totalPrice = totalPrice.Add(amount)
+		taxItems = append(taxItems, dto.TaxItem{
+			StoreID: product.StoreID,
+			Amount:  amount,
+			Exempt:  product.Category.TaxExempt,
+		})
 	}
 
 	cart.TotalPrice = totalPrice
 
+	cart.Tax, err = c.taxService.Calculate(ctx, taxItems)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	cart.TaxTotal = domain.TotalTax(cart.Tax)
+	cart.GrandTotal = totalPrice.Add(domain.ExclusiveTax(cart.Tax))
+
 	return cart, nil
 }
 
@@ -93,7 +109,7 @@ func (c *CartService) FindCartItems(ctx context.Context, userID primitive.Object
 
 func (c *CartService) AddCartItem(ctx context.Context, cartItem domain.CartItem, userID primitive.ObjectID) (domain.CartItem, error) {
 
-	product, err := c.productService.FindByID(ctx, cartItem.ProductID)
+	product, err := c.productService.FindPurchasableByID(ctx, cartItem.ProductID)
 
 	if err != nil {
 		return domain.CartItem{}, err
@@ -106,7 +122,7 @@ func (c *CartService) AddCartItem(ctx context.Context, cartItem domain.CartItem,
 }
 
 func (c *CartService) UpdateCartItem(ctx context.Context, cartItem domain.CartItem, userID primitive.ObjectID) (domain.CartItem, error) {
-	product, err := c.productService.FindByID(ctx, cartItem.ProductID)
+	product, err := c.productService.FindPurchasableByID(ctx, cartItem.ProductID)
 
 	if err != nil {
 		return domain.CartItem{}, err
@@ -142,9 +158,10 @@ func (c *CartService) Delete(ctx context.Context, cartID primitive.ObjectID) err
 	return c.repo.Delete(ctx, cartID)
 }
 
-func NewCartsService(repo repository.Carts, productsService Products) *CartService {
+func NewCartsService(repo repository.Carts, productsService Products, taxService Taxes) *CartService {
 	return &CartService{
 		repo:           repo,
 		productService: productsService,
+		taxService:     taxService,
 	}
 }
@@ -9,7 +9,8 @@ import (
 )
 
 type CategoriesService struct {
-	repo repository.Categories
+	repo  repository.Categories
+	audit Audit
 }
 
 func (service *CategoriesService) FindAll(ctx context.Context) ([]domain.Category, error) {
@@ -31,28 +32,61 @@ func (service *CategoriesService) FindByID(ctx context.Context, categoryID primi
 }
 
 func (service *CategoriesService) Create(ctx context.Context, category dto.CreateCategoryDTO) (domain.Category, error) {
-	return service.repo.Create(ctx, domain.Category{
+	created, err := service.repo.Create(ctx, domain.Category{
 		Name:        category.Name,
 		Description: category.Description,
 		Icon:        category.Icon,
+		TaxExempt:   category.TaxExempt,
 	})
+	if err != nil {
+		return domain.Category{}, err
+	}
+
+	service.audit.Track(ctx, domain.AuditActionCategoryCreate, domain.AuditTargetCategory, created.ID, nil, created)
+
+	return created, nil
 }
 
 func (service *CategoriesService) Update(ctx context.Context, categoryDTO dto.UpdateCategoryDTO, categoryID primitive.ObjectID) (domain.Category, error) {
-	return service.repo.Update(ctx, dto.UpdateCategoryInput{
+	before, err := service.repo.FindByID(ctx, categoryID)
+	if err != nil {
+		return domain.Category{}, err
+	}
+
+	category, err := service.repo.Update(ctx, dto.UpdateCategoryInput{
 		Name:        categoryDTO.Name,
 		Description: categoryDTO.Description,
 		Icon:        categoryDTO.Icon,
+		TaxExempt:   categoryDTO.TaxExempt,
 	}, categoryID)
+	if err != nil {
+		return domain.Category{}, err
+	}
+
+	service.audit.Track(ctx, domain.AuditActionCategoryUpdate, domain.AuditTargetCategory, categoryID, before, category)
+
+	return category, nil
 }
 
 func (service *CategoriesService) Delete(ctx context.Context, categoryID primitive.ObjectID) error {
-	err := service.repo.Delete(ctx, categoryID)
-	return err
+	before, err := service.repo.FindByID(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+
+	err = service.repo.Delete(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+
+	service.audit.Track(ctx, domain.AuditActionCategoryDelete, domain.AuditTargetCategory, categoryID, before, nil)
+
+	return nil
 }
 
-func NewCategoriesService(repo repository.Categories) *CategoriesService {
+func NewCategoriesService(repo repository.Categories, audit Audit) *CategoriesService {
 	return &CategoriesService{
-		repo: repo,
+		repo:  repo,
+		audit: audit,
 	}
 }
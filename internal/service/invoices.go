@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"github.com/sigit14ap/go-commerce/pkg/pdf"
+	"github.com/sigit14ap/go-commerce/pkg/storage"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrInvoiceNotAvailable = errors.New("invoice is not available for this order")
+
+const invoicePeriodLayout = "200601"
+
+// invoicedStatuses are the statuses of orders that have been paid for.
+var invoicedStatuses = []string{
+	domain.OrderStatusPaid,
+	domain.OrderStatusProcessing,
+	domain.OrderStatusShipped,
+	domain.OrderStatusDelivered,
+	domain.OrderStatusCompleted,
+}
+
+type InvoicesService struct {
+	repo            repository.Invoices
+	ordersService   Orders
+	productsService Products
+	storesService   Stores
+	areasService    Areas
+	storage         storage.StorageProvider
+}
+
+func NewInvoicesService(repo repository.Invoices, ordersService Orders, productsService Products, storesService Stores,
+	areasService Areas, storage storage.StorageProvider) *InvoicesService {
+	return &InvoicesService{
+		repo:            repo,
+		ordersService:   ordersService,
+		productsService: productsService,
+		storesService:   storesService,
+		areasService:    areasService,
+		storage:         storage,
+	}
+}
+
+// Invoice returns the invoice of the order together with its PDF. The invoice
+// is issued on first request, later requests render the same snapshot again.
+func (i *InvoicesService) Invoice(ctx context.Context, orderID primitive.ObjectID, actor string, actorID primitive.ObjectID) (domain.Invoice, []byte, error) {
+	order, err := i.ordersService.FindByID(ctx, orderID)
+	if err != nil || !canActOnOrder(order, actor, actorID) {
+		return domain.Invoice{}, nil, ErrOrderNotFound
+	}
+
+	invoice, err := i.repo.FindByOrderID(ctx, orderID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		invoice, err = i.issue(ctx, order)
+	}
+	if err != nil {
+		return domain.Invoice{}, nil, err
+	}
+
+	if invoice.Number == "" {
+		invoice, err = i.assignNumber(ctx, invoice)
+		if err != nil {
+			return domain.Invoice{}, nil, err
+		}
+	}
+
+	document := renderInvoice(invoice)
+
+	if invoice.FileKey == "" {
+		invoice.FileKey = i.store(ctx, invoice, document)
+	}
+
+	return invoice, document, nil
+}
+
+// issue snapshots a paid order into an invoice, unpaid orders get none.
+func (i *InvoicesService) issue(ctx context.Context, order domain.Order) (domain.Invoice, error) {
+	if order.Status == domain.OrderStatusCancelled {
+		return domain.Invoice{}, fmt.Errorf("%w: order has been cancelled", ErrInvoiceNotAvailable)
+	}
+
+	if !containsStatus(invoicedStatuses, order.Status) {
+		return domain.Invoice{}, fmt.Errorf("%w: order has not been paid", ErrInvoiceNotAvailable)
+	}
+
+	store, err := i.storesService.FindByID(ctx, order.StoreID)
+	if err != nil {
+		return domain.Invoice{}, err
+	}
+
+	invoice := domain.Invoice{
+		OrderID:     order.ID,
+		OrderNumber: order.OrderID,
+		UserID:      order.UserID,
+		StoreID:     order.StoreID,
+		IssuedAt:    time.Now(),
+		OrderedAt:   order.CreatedAt,
+		Buyer:       order.ContactInfo,
+		Store: domain.InvoiceStore{
+			Name:   store.Name,
+			Domain: store.Domain,
+		},
+		Lines:    make([]domain.InvoiceLine, 0, len(order.OrderItems)),
+		Shipping: order.ShippingCost,
+		Discount: order.Discount,
+		Tax:      order.TaxTotal,
+		TaxLines: order.Tax,
+	}
+
+	if city, err := i.areasService.FindCity(ctx, store.ShipmentCityID); err == nil {
+		invoice.Store.City = city.Name
+	}
+
+	if province, err := i.areasService.FindProvince(ctx, store.ShipmentProvinceID); err == nil {
+		invoice.Store.Province = province.Name
+	}
+
+	for _, orderItem := range order.OrderItems {
+		name := orderItem.Name
+		if name == "" {
+			name = i.productName(ctx, orderItem.ProductID)
+		}
+
+		lineTotal := orderItem.Price.Mul(orderItem.Quantity)
+		invoice.Lines = append(invoice.Lines, domain.InvoiceLine{
+			ProductID: orderItem.ProductID,
+			Name:      name,
+			Quantity:  orderItem.Quantity,
+			UnitPrice: orderItem.Price,
+			Total:     lineTotal,
+		})
+		invoice.Subtotal = invoice.Subtotal.Add(lineTotal)
+	}
+
+	invoice.Total = invoice.Subtotal.Add(invoice.Shipping).Sub(invoice.Discount).Add(domain.ExclusiveTax(invoice.TaxLines))
+
+	created, err := i.repo.Create(ctx, invoice)
+	if errors.Is(err, repository.ErrInvoiceExists) {
+		return i.repo.FindByOrderID(ctx, order.ID)
+	}
+
+	return created, err
+}
+
+// assignNumber gives the invoice the next number in the sequence of its store
+// and month. Only one concurrent request wins the assignment.
+func (i *InvoicesService) assignNumber(ctx context.Context, invoice domain.Invoice) (domain.Invoice, error) {
+	store, err := i.storesService.FindByID(ctx, invoice.StoreID)
+	if err != nil {
+		return domain.Invoice{}, err
+	}
+
+	period := invoice.IssuedAt.Format(invoicePeriodLayout)
+	sequence, err := i.repo.NextSequence(ctx, invoice.StoreID, period)
+	if err != nil {
+		return domain.Invoice{}, err
+	}
+
+	number := fmt.Sprintf("INV/%s/%s/%05d", strings.ToUpper(store.Domain), period, sequence)
+	_, err = i.repo.AssignNumber(ctx, invoice.ID, number)
+	if err != nil {
+		return domain.Invoice{}, err
+	}
+
+	return i.repo.FindByOrderID(ctx, invoice.OrderID)
+}
+
+// store keeps a copy of the PDF in private storage, buyers and stores get it
+// through the authenticated invoice endpoints only. A failed upload is
+// retried on the next request.
+func (i *InvoicesService) store(ctx context.Context, invoice domain.Invoice, document []byte) string {
+	fileKey, err := i.storage.PutPrivate("Invoice", InvoiceFilename(invoice), document, "application/pdf")
+	if err != nil {
+		log.Warnf("failed to store invoice %s: %v", invoice.Number, err)
+		return ""
+	}
+
+	err = i.repo.SetFileKey(ctx, invoice.ID, fileKey)
+	if err != nil {
+		log.Warnf("failed to save invoice %s file key: %v", invoice.Number, err)
+	}
+
+	return fileKey
+}
+
+func (i *InvoicesService) productName(ctx context.Context, productID primitive.ObjectID) string {
+	product, err := i.productsService.FindByID(ctx, productID)
+	if err != nil {
+		return "Product " + productID.Hex()
+	}
+
+	return product.Name
+}
+
+func InvoiceFilename(invoice domain.Invoice) string {
+	return strings.ReplaceAll(invoice.Number, "/", "-") + ".pdf"
+}
+
+type invoiceTotal struct {
+	label  string
+	amount domain.Money
+}
+
+func renderInvoice(invoice domain.Invoice) []byte {
+	const (
+		left        = 50.0
+		right       = pdf.PageWidth - 50
+		qtyColumn   = 340.0
+		priceColumn = 440.0
+		bottom      = 760.0
+	)
+
+	document := pdf.NewDocument("Invoice " + invoice.Number)
+	document.AddPage()
+
+	document.Text(left, 70, pdf.FontBold, 20, "INVOICE")
+	document.TextRight(right, 62, pdf.FontBold, 10, invoice.Number)
+	document.TextRight(right, 76, pdf.FontRegular, 9, "Issued "+invoice.IssuedAt.Format("02 Jan 2006"))
+	document.TextRight(right, 88, pdf.FontRegular, 9, "Order "+invoice.OrderNumber)
+
+	document.Text(left, 120, pdf.FontBold, 10, "Sold by")
+	document.Text(left, 134, pdf.FontRegular, 9, invoice.Store.Name)
+	document.Text(left, 146, pdf.FontRegular, 9, invoice.Store.Domain)
+	document.Text(left, 158, pdf.FontRegular, 9, strings.Trim(invoice.Store.City+", "+invoice.Store.Province, ", "))
+
+	buyer := invoice.Buyer
+	document.Text(300, 120, pdf.FontBold, 10, "Bill to")
+	document.Text(300, 134, pdf.FontRegular, 9, strings.TrimSpace(buyer.Name+" "+buyer.Surname))
+	document.Text(300, 146, pdf.FontRegular, 9, buyer.PhoneNumber)
+	document.Text(300, 158, pdf.FontRegular, 9, buyer.Address)
+
+	header := func(y float64) {
+		document.Text(left, y, pdf.FontBold, 9, "Product")
+		document.TextRight(qtyColumn, y, pdf.FontBold, 9, "Qty")
+		document.TextRight(priceColumn, y, pdf.FontBold, 9, "Unit price")
+		document.TextRight(right, y, pdf.FontBold, 9, "Amount")
+		document.Line(left, y+5, right, y+5)
+	}
+
+	y := 200.0
+	header(y)
+
+	for _, line := range invoice.Lines {
+		y += 18
+		if y > bottom {
+			document.AddPage()
+			y = 70
+			header(y)
+			y += 18
+		}
+
+		document.Text(left, y, pdf.FontRegular, 9, truncate(line.Name, 50))
+		document.TextRight(qtyColumn, y, pdf.FontRegular, 9, fmt.Sprintf("%d", line.Quantity))
+		document.TextRight(priceColumn, y, pdf.FontRegular, 9, line.UnitPrice.String())
+		document.TextRight(right, y, pdf.FontRegular, 9, line.Total.String())
+	}
+
+	if y+100 > bottom {
+		document.AddPage()
+		y = 50
+	}
+
+	y += 12
+	document.Line(left, y, right, y)
+
+	totals := []invoiceTotal{
+		{"Subtotal", invoice.Subtotal},
+		{"Shipping", invoice.Shipping},
+		{"Discount", invoice.Discount.Neg()},
+	}
+
+	for _, taxLine := range invoice.TaxLines {
+		label := taxLine.Name
+		if taxLine.Inclusive {
+			label += " (included)"
+		}
+
+		totals = append(totals, invoiceTotal{label, taxLine.Amount})
+	}
+
+	if len(invoice.TaxLines) == 0 {
+		totals = append(totals, invoiceTotal{"Tax", invoice.Tax})
+	}
+
+	for _, total := range totals {
+		y += 16
+		if y > bottom {
+			document.AddPage()
+			y = 70
+		}
+
+		document.TextRight(priceColumn, y, pdf.FontRegular, 9, total.label)
+		document.TextRight(right, y, pdf.FontRegular, 9, total.amount.String())
+	}
+
+	y += 20
+	document.TextRight(priceColumn, y, pdf.FontBold, 11, "Total")
+	document.TextRight(right, y, pdf.FontBold, 11, invoice.Total.String())
+
+	return document.Bytes()
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+
+	return string(runes[:length-3]) + "..."
+}
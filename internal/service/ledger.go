@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+)
+
+// settlementTransaction credits the store with the order value, including tax
+// charged on top of the prices and the shipping the store pays the courier
+// with, and debits the refunds already paid out on it and the platform
+// commission on the goods that were not refunded.
+func settlementTransaction(order domain.Order, refunded domain.Money, commissionRate float64, createdAt time.Time) domain.LedgerTransaction {
+	storeAccount := domain.StoreLedgerAccount(order.StoreID)
+	goods := orderTotal(order.OrderItems).Add(domain.ExclusiveTax(order.Tax))
+	gross := goods.Add(order.ShippingCost)
+	refunded = refunded.Min(gross)
+	commission := goods.Sub(refunded.Min(goods)).Scale(commissionRate)
+
+	entries := []domain.LedgerEntry{
+		{Account: domain.LedgerAccountClearing, Type: domain.LedgerEntrySettlement, Amount: gross.Neg()},
+		{Account: storeAccount, Type: domain.LedgerEntrySettlement, Amount: gross},
+	}
+
+	if refunded.IsPositive() {
+		entries = append(entries,
+			domain.LedgerEntry{Account: storeAccount, Type: domain.LedgerEntryRefund, Amount: refunded.Neg()},
+			domain.LedgerEntry{Account: domain.LedgerAccountClearing, Type: domain.LedgerEntryRefund, Amount: refunded},
+		)
+	}
+
+	if commission.IsPositive() {
+		entries = append(entries,
+			domain.LedgerEntry{Account: storeAccount, Type: domain.LedgerEntryCommission, Amount: commission.Neg()},
+			domain.LedgerEntry{Account: domain.LedgerAccountCommission, Type: domain.LedgerEntryCommission, Amount: commission},
+		)
+	}
+
+	return domain.LedgerTransaction{
+		Type:        domain.LedgerTransactionSettlement,
+		Reference:   order.ID,
+		StoreID:     order.StoreID,
+		Entries:     entries,
+		Description: fmt.Sprintf("Settlement of order %s", order.OrderID),
+		CreatedAt:   createdAt,
+	}
+}
+
+func payoutTransaction(payout domain.Payout, createdAt time.Time) domain.LedgerTransaction {
+	amount := payout.Amount
+
+	return domain.LedgerTransaction{
+		Type:      domain.LedgerTransactionPayout,
+		Reference: payout.ID,
+		StoreID:   payout.StoreID,
+		Entries: []domain.LedgerEntry{
+			{Account: domain.StoreLedgerAccount(payout.StoreID), Type: domain.LedgerEntryPayout, Amount: amount.Neg()},
+			{Account: domain.LedgerAccountPayout, Type: domain.LedgerEntryPayout, Amount: amount},
+		},
+		Description: fmt.Sprintf("Payout to %s %s", payout.BankAccount.BankName, payout.BankAccount.AccountNumber),
+		CreatedAt:   createdAt,
+	}
+}
+
+// validateTransaction enforces the double-entry invariants: at least two
+// non-zero entries in a single currency whose amounts sum to exactly zero.
+func validateTransaction(transaction domain.LedgerTransaction) error {
+	if len(transaction.Entries) < 2 {
+		return fmt.Errorf("%w: transaction needs at least two entries", ErrLedgerUnbalanced)
+	}
+
+	currency := transaction.Entries[0].Amount.Currency
+	var sum int64
+	for _, entry := range transaction.Entries {
+		if entry.Account == "" {
+			return fmt.Errorf("%w: entry without account", ErrLedgerUnbalanced)
+		}
+
+		if entry.Amount.IsZero() {
+			return fmt.Errorf("%w: zero amount entry on %s", ErrLedgerUnbalanced, entry.Account)
+		}
+
+		if entry.Amount.Currency != currency {
+			return fmt.Errorf("%w: mixed currencies %s and %s", ErrLedgerUnbalanced, currency, entry.Amount.Currency)
+		}
+
+		sum += entry.Amount.Amount
+	}
+
+	if sum != 0 {
+		return fmt.Errorf("%w: entries sum to %d", ErrLedgerUnbalanced, sum)
+	}
+
+	return nil
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryLedger struct {
+	transactions []domain.LedgerTransaction
+}
+
+func (l *memoryLedger) Insert(ctx context.Context, transaction domain.LedgerTransaction) (domain.LedgerTransaction, error) {
+	for _, existing := range l.transactions {
+		if existing.Type == transaction.Type && existing.Reference == transaction.Reference {
+			return domain.LedgerTransaction{}, repository.ErrLedgerDuplicate
+		}
+	}
+
+	transaction.ID = primitive.NewObjectID()
+	l.transactions = append(l.transactions, transaction)
+	return transaction, nil
+}
+
+func (l *memoryLedger) Balance(ctx context.Context, account string, currency domain.Currency) (domain.Money, error) {
+	balance := domain.NewMoney(0, currency)
+	for _, transaction := range l.transactions {
+		for _, entry := range transaction.Entries {
+			if entry.Account == account && entry.Amount.Currency == currency {
+				balance = balance.Add(entry.Amount)
+			}
+		}
+	}
+
+	return balance, nil
+}
+
+func (l *memoryLedger) Statement(ctx context.Context, account string, page int64, limit int64) ([]domain.StatementLine, int64, error) {
+	return nil, 0, nil
+}
+
+type memoryPayouts struct {
+	payouts map[primitive.ObjectID]domain.Payout
+	locked  map[primitive.ObjectID]bool
+}
+
+func (p *memoryPayouts) FindByID(ctx context.Context, payoutID primitive.ObjectID) (domain.Payout, error) {
+	payout, ok := p.payouts[payoutID]
+	if !ok {
+		return domain.Payout{}, errors.New("not found")
+	}
+
+	return payout, nil
+}
+
+func (p *memoryPayouts) FindByStoreID(ctx context.Context, storeID primitive.ObjectID) ([]domain.Payout, error) {
+	return nil, nil
+}
+
+func (p *memoryPayouts) FindAll(ctx context.Context, status string) ([]domain.Payout, error) {
+	return nil, nil
+}
+
+func (p *memoryPayouts) PendingAmount(ctx context.Context, storeID primitive.ObjectID, currency domain.Currency) (domain.Money, error) {
+	pending := domain.NewMoney(0, currency)
+	for _, payout := range p.payouts {
+		if payout.StoreID == storeID && (payout.Status == domain.PayoutStatusRequested || payout.Status == domain.PayoutStatusApproved) {
+			pending = pending.Add(payout.Amount)
+		}
+	}
+
+	return pending, nil
+}
+
+func (p *memoryPayouts) Lock(ctx context.Context, storeID primitive.ObjectID, ttl time.Duration) (string, error) {
+	if p.locked[storeID] {
+		return "", repository.ErrPayoutLocked
+	}
+
+	p.locked[storeID] = true
+	return storeID.Hex(), nil
+}
+
+func (p *memoryPayouts) Unlock(ctx context.Context, storeID primitive.ObjectID, token string) error {
+	delete(p.locked, storeID)
+	return nil
+}
+
+func (p *memoryPayouts) Create(ctx context.Context, payout domain.Payout) (domain.Payout, error) {
+	payout.ID = primitive.NewObjectID()
+	p.payouts[payout.ID] = payout
+	return payout, nil
+}
+
+func (p *memoryPayouts) Transition(ctx context.Context, payoutID primitive.ObjectID, fromStatuses []string, transition dto.PayoutTransitionInput) (domain.Payout, error) {
+	payout, ok := p.payouts[payoutID]
+	if !ok || !containsStatus(fromStatuses, payout.Status) {
+		return domain.Payout{}, repository.ErrPayoutStatusConflict
+	}
+
+	payout.Status = transition.Status
+	p.payouts[payoutID] = payout
+	return payout, nil
+}
+
+type memoryReturns struct {
+	repository.Returns
+	returns []domain.ReturnRequest
+}
+
+func (r *memoryReturns) FindByOrderID(ctx context.Context, orderID primitive.ObjectID) ([]domain.ReturnRequest, error) {
+	return r.returns, nil
+}
+
+func newTestWallets(returns ...domain.ReturnRequest) (*WalletsService, *memoryLedger) {
+	ledger := &memoryLedger{}
+	payouts := &memoryPayouts{payouts: map[primitive.ObjectID]domain.Payout{}, locked: map[primitive.ObjectID]bool{}}
+
+	return NewWalletsService(ledger, payouts, &memoryReturns{returns: returns}, 0.05), ledger
+}
+
+func testOrder(storeID primitive.ObjectID) domain.Order {
+	return domain.Order{
+		ID:      primitive.NewObjectID(),
+		OrderID: "test-order",
+		StoreID: storeID,
+		Status:  domain.OrderStatusCompleted,
+		OrderItems: []domain.OrderItem{
+			{ProductID: primitive.NewObjectID(), StoreID: storeID, Quantity: 3, Price: idr(3333)},
+			{ProductID: primitive.NewObjectID(), StoreID: storeID, Quantity: 1, Price: idr(1001)},
+		},
+	}
+}
+
+// idr returns an amount in sen, the minor unit of the rupiah.
+func idr(sen int64) domain.Money {
+	return domain.NewMoney(sen, domain.CurrencyIDR)
+}
+
+func assertLedgerBalanced(t *testing.T, ledger *memoryLedger) {
+	t.Helper()
+
+	var total int64
+	for _, transaction := range ledger.transactions {
+		if err := validateTransaction(transaction); err != nil {
+			t.Fatalf("transaction %s: %v", transaction.Type, err)
+		}
+
+		for _, entry := range transaction.Entries {
+			total += entry.Amount.Amount
+		}
+	}
+
+	if total != 0 {
+		t.Fatalf("ledger does not balance, accounts sum to %d cents", total)
+	}
+}
+
+func TestSettlementTransactionBalances(t *testing.T) {
+	order := testOrder(primitive.NewObjectID())
+
+	for _, refunded := range []domain.Money{{}, idr(1001), idr(3333), idr(100000)} {
+		transaction := settlementTransaction(order, refunded, 0.05, time.Now())
+		if err := validateTransaction(transaction); err != nil {
+			t.Errorf("refunded %s: %v", refunded, err)
+		}
+	}
+}
+
+func TestValidateTransactionRejectsUnbalanced(t *testing.T) {
+	transactions := []domain.LedgerTransaction{
+		{Entries: []domain.LedgerEntry{{Account: domain.LedgerAccountClearing, Amount: idr(1000)}}},
+		{Entries: []domain.LedgerEntry{
+			{Account: domain.LedgerAccountClearing, Amount: idr(-1000)},
+			{Account: domain.LedgerAccountCommission, Amount: idr(999)},
+		}},
+		{Entries: []domain.LedgerEntry{
+			{Account: domain.LedgerAccountClearing, Amount: idr(0)},
+			{Account: domain.LedgerAccountCommission, Amount: idr(0)},
+		}},
+		{Entries: []domain.LedgerEntry{
+			{Account: domain.LedgerAccountClearing, Amount: idr(-1000)},
+			{Account: domain.LedgerAccountCommission, Amount: domain.NewMoney(1000, domain.CurrencyUSD)},
+		}},
+	}
+
+	for i, transaction := range transactions {
+		if err := validateTransaction(transaction); !errors.Is(err, ErrLedgerUnbalanced) {
+			t.Errorf("transaction %d: expected ErrLedgerUnbalanced, got %v", i, err)
+		}
+	}
+}
+
+func TestSettleOrderCreditsNetOfRefundsAndCommission(t *testing.T) {
+	storeID := primitive.NewObjectID()
+	order := testOrder(storeID)
+	wallets, ledger := newTestWallets(
+		domain.ReturnRequest{Status: domain.ReturnStatusRefunded, RefundAmount: idr(1001)},
+		domain.ReturnRequest{Status: domain.ReturnStatusRejected, RefundAmount: idr(3333)},
+	)
+
+	if err := wallets.SettleOrder(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+
+	// gross 110.00, refunded 10.01, commission 5% of 99.99 rounds to 5.00
+	balance, _ := ledger.Balance(context.Background(), domain.StoreLedgerAccount(storeID), domain.CurrencyIDR)
+	if balance != idr(9499) {
+		t.Errorf("expected store balance 94.99, got %s", balance)
+	}
+
+	commission, _ := ledger.Balance(context.Background(), domain.LedgerAccountCommission, domain.CurrencyIDR)
+	if commission != idr(500) {
+		t.Errorf("expected commission 5.00, got %s", commission)
+	}
+
+	assertLedgerBalanced(t, ledger)
+}
+
+func TestSettleOrderCreditsShippingWithoutCommission(t *testing.T) {
+	storeID := primitive.NewObjectID()
+	order := testOrder(storeID)
+	order.ShippingCost = idr(1500)
+	wallets, ledger := newTestWallets()
+
+	if err := wallets.SettleOrder(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+
+	// goods 110.00 and shipping 15.00, commission 5% of the goods is 5.50
+	balance, _ := ledger.Balance(context.Background(), domain.StoreLedgerAccount(storeID), domain.CurrencyIDR)
+	if balance != idr(11950) {
+		t.Errorf("expected store balance 119.50, got %s", balance)
+	}
+
+	assertLedgerBalanced(t, ledger)
+}
+
+func TestSettleOrderIsIdempotent(t *testing.T) {
+	storeID := primitive.NewObjectID()
+	order := testOrder(storeID)
+	wallets, ledger := newTestWallets()
+
+	for i := 0; i < 2; i++ {
+		if err := wallets.SettleOrder(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(ledger.transactions) != 1 {
+		t.Fatalf("expected one settlement, got %d", len(ledger.transactions))
+	}
+}
+
+func TestPayoutsNeverExceedBalance(t *testing.T) {
+	ctx := context.Background()
+	storeID := primitive.NewObjectID()
+	store := domain.Store{
+		ID:          storeID,
+		BankAccount: &domain.BankAccount{BankName: "BCA", AccountNumber: "1234567890", AccountHolder: "Store"},
+	}
+	wallets, ledger := newTestWallets()
+
+	if _, err := wallets.RequestPayout(ctx, store, idr(100)); !errors.Is(err, ErrPayoutNotAllowed) {
+		t.Fatalf("expected payout from empty wallet to be refused, got %v", err)
+	}
+
+	if err := wallets.SettleOrder(ctx, testOrder(storeID)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 110.00 settled less 5.50 commission leaves 104.50 available
+	first, err := wallets.RequestPayout(ctx, store, idr(10000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = wallets.RequestPayout(ctx, store, idr(451)); !errors.Is(err, ErrPayoutNotAllowed) {
+		t.Fatalf("expected pending payout to hold the balance, got %v", err)
+	}
+
+	if _, err = wallets.MarkPayoutPaid(ctx, first.ID, dto.PayoutReviewDTO{}); !errors.Is(err, ErrPayoutNotAllowed) {
+		t.Fatalf("expected unapproved payout to be refused, got %v", err)
+	}
+
+	if _, err = wallets.ApprovePayout(ctx, first.ID, dto.PayoutReviewDTO{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = wallets.MarkPayoutPaid(ctx, first.ID, dto.PayoutReviewDTO{}); err != nil {
+		t.Fatal(err)
+	}
+
+	wallet, err := wallets.FindWallet(ctx, storeID, 1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wallet.Balance != idr(450) || !wallet.Pending.IsZero() || wallet.Available != idr(450) {
+		t.Errorf("unexpected wallet after payout: %+v", wallet)
+	}
+
+	assertLedgerBalanced(t, ledger)
+}
+
+func TestPayoutRequestsOfAStoreDontInterleave(t *testing.T) {
+	ctx := context.Background()
+	storeID := primitive.NewObjectID()
+	store := domain.Store{
+		ID:          storeID,
+		BankAccount: &domain.BankAccount{BankName: "BCA", AccountNumber: "1234567890", AccountHolder: "Store"},
+	}
+	wallets, _ := newTestWallets()
+	if err := wallets.SettleOrder(ctx, testOrder(storeID)); err != nil {
+		t.Fatal(err)
+	}
+
+	payouts := wallets.payouts.(*memoryPayouts)
+	token, _ := payouts.Lock(ctx, storeID, payoutLockTTL)
+
+	if _, err := wallets.RequestPayout(ctx, store, idr(1000)); !errors.Is(err, ErrPayoutNotAllowed) {
+		t.Fatalf("expected request during another request to be refused, got %v", err)
+	}
+
+	_ = payouts.Unlock(ctx, storeID, token)
+	if _, err := wallets.RequestPayout(ctx, store, idr(1000)); err != nil {
+		t.Fatalf("request after unlock: %v", err)
+	}
+	if payouts.locked[storeID] {
+		t.Error("request left the payouts of the store locked")
+	}
+}
+
+func TestWalletRequiresBankAccountForPayout(t *testing.T) {
+	wallets, _ := newTestWallets()
+
+	_, err := wallets.RequestPayout(context.Background(), domain.Store{ID: primitive.NewObjectID()}, idr(1000))
+	if !errors.Is(err, ErrPayoutNotAllowed) {
+		t.Fatalf("expected ErrPayoutNotAllowed, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// LockoutService locks accounts progressively after failed sign-ins. Once
+// the failures reach the threshold every further failure locks the account
+// for twice as long as the previous one, up to the maximum. Failures are
+// forgotten after a successful sign-in or once none happened for the reset
+// period.
+type LockoutService struct {
+	redisClient *redis.Client
+	threshold   int64
+	base        time.Duration
+	max         time.Duration
+	reset       time.Duration
+}
+
+func NewLockoutService(redisClient *redis.Client, threshold int, base time.Duration, max time.Duration,
+	reset time.Duration) *LockoutService {
+	return &LockoutService{
+		redisClient: redisClient,
+		threshold:   int64(threshold),
+		base:        base,
+		max:         max,
+		reset:       reset,
+	}
+}
+
+// Locked returns how long the account is still locked, zero when it is not.
+func (l *LockoutService) Locked(ctx context.Context, kind string, email string) (time.Duration, error) {
+	ttl, err := l.redisClient.PTTL(lockedKey(kind, email)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// PTTL is negative when the key does not exist
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+// Failed counts a failed sign-in and returns how long the account is locked
+// because of it, zero when it is not locked yet.
+func (l *LockoutService) Failed(ctx context.Context, kind string, email string) (time.Duration, error) {
+	pipe := l.redisClient.TxPipeline()
+	failures := pipe.Incr(failuresKey(kind, email))
+	pipe.Expire(failuresKey(kind, email), l.reset)
+	if _, err := pipe.Exec(); err != nil {
+		return 0, err
+	}
+
+	duration := l.duration(failures.Val())
+	if duration == 0 {
+		return 0, nil
+	}
+
+	err := l.redisClient.Set(lockedKey(kind, email), failures.Val(), duration).Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return duration, nil
+}
+
+func (l *LockoutService) Succeeded(ctx context.Context, kind string, email string) error {
+	return l.redisClient.Del(failuresKey(kind, email), lockedKey(kind, email)).Err()
+}
+
+func (l *LockoutService) duration(failures int64) time.Duration {
+	if l.threshold <= 0 || failures < l.threshold {
+		return 0
+	}
+
+	duration := l.base
+	for i := l.threshold; i < failures && duration < l.max; i++ {
+		duration *= 2
+	}
+
+	if duration > l.max {
+		return l.max
+	}
+
+	return duration
+}
+
+func failuresKey(kind string, email string) string {
+	return fmt.Sprintf("lockout:failures:%s:%s", kind, strings.ToLower(strings.TrimSpace(email)))
+}
+
+func lockedKey(kind string, email string) string {
+	return fmt.Sprintf("lockout:locked:%s:%s", kind, strings.ToLower(strings.TrimSpace(email)))
+}
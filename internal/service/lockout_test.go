@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	lockout := NewLockoutService(nil, 5, time.Minute, time.Hour, 24*time.Hour)
+
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{11, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := lockout.duration(test.failures); got != test.want {
+			t.Errorf("duration(%d) = %s, want %s", test.failures, got, test.want)
+		}
+	}
+}
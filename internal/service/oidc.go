@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"github.com/sigit14ap/go-commerce/pkg/oidc"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrOIDCState              = errors.New("invalid or expired login state")
+	ErrOIDCEmailNotVerified   = errors.New("the identity provider has not verified the email address")
+	ErrOIDCAccountNotVerified = errors.New("an account with this email exists but is not verified, " +
+		"verify it or reset its password before signing in with the identity provider")
+)
+
+// oidcState is kept in Redis between the redirect to the provider and the
+// callback, keyed by the state parameter.
+type oidcState struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"codeVerifier"`
+}
+
+type OIDCService struct {
+	repo        repository.Users
+	provider    oidc.Provider
+	redisClient *redis.Client
+	stateTTL    time.Duration
+}
+
+func NewOIDCService(repo repository.Users, provider oidc.Provider, redisClient *redis.Client, stateTTL time.Duration) *OIDCService {
+	return &OIDCService{
+		repo:        repo,
+		provider:    provider,
+		redisClient: redisClient,
+		stateTTL:    stateTTL,
+	}
+}
+
+// Authorize starts a login: it stores a fresh state, nonce and PKCE verifier
+// and returns the provider URL to send the browser to, along with the state.
+// The state has to be kept in the browser that started the login, Login only
+// accepts a callback from that browser.
+func (o *OIDCService) Authorize(ctx context.Context) (string, string, error) {
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier, challenge, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := o.provider.AuthCodeURL(state, nonce, challenge)
+	if err != nil {
+		return "", "", err
+	}
+
+	stateJson, err := json.Marshal(oidcState{Nonce: nonce, CodeVerifier: verifier})
+	if err != nil {
+		return "", "", err
+	}
+
+	err = o.redisClient.Set(oidcStateKey(state), stateJson, o.stateTTL).Err()
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+// Login finishes a login started by Authorize. browserState is the state kept
+// by the browser presenting the callback, it has to match so a callback
+// carrying someone else's code can't sign the browser into their account.
+// The user is found by the linked identity first, then by the verified email
+// address, in which case the identity is linked to the account. Unknown
+// addresses get a new account.
+func (o *OIDCService) Login(ctx context.Context, state string, browserState string, code string) (domain.User, error) {
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return domain.User{}, ErrOIDCState
+	}
+
+	stored, err := o.consumeState(state)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	token, err := o.provider.Exchange(code, stored.CodeVerifier)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	identity, err := o.provider.VerifyIDToken(token.IDToken, stored.Nonce)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := o.repo.FindByIdentity(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.User{}, err
+	}
+
+	if !identity.EmailVerified || identity.Email == "" {
+		return domain.User{}, ErrOIDCEmailNotVerified
+	}
+
+	now := time.Now()
+	externalIdentity := domain.ExternalIdentity{
+		Provider: o.provider.Name(),
+		Issuer:   identity.Issuer,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		LinkedAt: now,
+	}
+
+	user, err = o.repo.FindByEmail(ctx, identity.Email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return o.repo.Create(ctx, domain.User{
+			Name:            identity.Name,
+			Email:           identity.Email,
+			EmailVerified:   true,
+			EmailVerifiedAt: &now,
+			Identities:      []domain.ExternalIdentity{externalIdentity},
+		})
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	// linking to an unverified account would hand it to whoever registered
+	// the address first
+	if !user.EmailVerified {
+		return domain.User{}, ErrOIDCAccountNotVerified
+	}
+
+	if err = o.repo.AddIdentity(ctx, user.ID, externalIdentity); err != nil {
+		return domain.User{}, err
+	}
+
+	user.Identities = append(user.Identities, externalIdentity)
+	return user, nil
+}
+
+func (o *OIDCService) consumeState(state string) (oidcState, error) {
+	if state == "" {
+		return oidcState{}, ErrOIDCState
+	}
+
+	pipeline := o.redisClient.TxPipeline()
+	get := pipeline.Get(oidcStateKey(state))
+	pipeline.Del(oidcStateKey(state))
+	_, err := pipeline.Exec()
+	if errors.Is(err, redis.Nil) {
+		return oidcState{}, ErrOIDCState
+	}
+	if err != nil {
+		return oidcState{}, err
+	}
+
+	var stored oidcState
+	if err = json.Unmarshal([]byte(get.Val()), &stored); err != nil {
+		return oidcState{}, err
+	}
+
+	return stored, nil
+}
+
+func oidcStateKey(state string) string {
+	return "oidc:state:" + state
+}
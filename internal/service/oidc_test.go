@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLoginRequiresStateOfTheBrowser(t *testing.T) {
+	// No provider or Redis: a callback from another browser is refused
+	// before the state is looked up or the code exchanged.
+	oidcService := NewOIDCService(nil, nil, nil, 0)
+
+	tests := []struct {
+		name         string
+		state        string
+		browserState string
+	}{
+		{"no cookie", "state-of-the-attacker", ""},
+		{"other login", "state-of-the-attacker", "state-of-the-victim"},
+		{"no state", "", ""},
+	}
+
+	for _, test := range tests {
+		_, err := oidcService.Login(context.Background(), test.state, test.browserState, "code")
+		if !errors.Is(err, ErrOIDCState) {
+			t.Errorf("%s: err = %v, want %v", test.name, err, ErrOIDCState)
+		}
+	}
+}
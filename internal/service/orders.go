@@ -2,22 +2,68 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
 	"github.com/sigit14ap/go-commerce/internal/repository"
+	log "github.com/sirupsen/logrus"
 	"github.com/twinj/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderNotCancellable = errors.New("order can no longer be cancelled")
+	ErrOrderInvalidStatus  = errors.New("order is not in the expected status")
+	ErrOrderDisputed       = errors.New("order has an open return request")
+	ErrPaymentMismatch     = errors.New("captured amount does not match the order total")
+	ErrCheckoutNotFound    = errors.New("checkout not found")
+	ErrOrderMultipleStores = errors.New("cart has items of several stores, check out with /users/checkouts")
+)
+
+var openReturnStatuses = []string{
+	domain.ReturnStatusRequested,
+	domain.ReturnStatusApproved,
+	domain.ReturnStatusShipped,
+	domain.ReturnStatusReceived,
+}
+
+var cancellableStatuses = map[string][]string{
+	domain.OrderActorUser: {
+		domain.OrderStatusReserved,
+		domain.OrderStatusPaid,
+		domain.OrderStatusProcessing,
+	},
+	domain.OrderActorStore: {
+		domain.OrderStatusPaid,
+		domain.OrderStatusProcessing,
+	},
+	domain.OrderActorAdmin: {
+		domain.OrderStatusReserved,
+		domain.OrderStatusPaid,
+		domain.OrderStatusProcessing,
+		domain.OrderStatusShipped,
+	},
+}
+
 type OrdersService struct {
-	repo           repository.Orders
-	productService Products
-	cartService    Carts
+	repo            repository.Orders
+	returnsRepo     repository.Returns
+	productService  Products
+	cartService     Carts
+	paymentService  Payment
+	walletService   Wallets
+	taxService      Taxes
+	shippingService Shipping
+	audit           Audit
 }
 
+// FindAll returns every order, totals are taken from the prices snapshotted
+// at checkout so later price changes or deleted products don't affect them.
 func (p *OrdersService) FindAll(ctx context.Context) ([]domain.Order, error) {
 	orders, err := p.repo.FindAll(ctx)
 	if err != nil {
@@ -25,86 +71,747 @@ func (p *OrdersService) FindAll(ctx context.Context) ([]domain.Order, error) {
 	}
 
 	for i, order := range orders {
-		var totalPrice float64
+		orders[i].TotalPrice = orderTotal(order.OrderItems)
+	}
+
+	return orders, nil
+}
+
+func (p *OrdersService) FindByID(ctx context.Context, orderID primitive.ObjectID) (domain.Order, error) {
+	order, err := p.repo.FindByID(ctx, orderID)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	order.TotalPrice = orderTotal(order.OrderItems)
+
+	return order, nil
+}
+
+func (p *OrdersService) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]domain.Order, error) {
+	return p.repo.FindByUserID(ctx, userID)
+}
+
+// FindCheckout returns the orders the user created from one cart.
+func (p *OrdersService) FindCheckout(ctx context.Context, checkoutID string, userID primitive.ObjectID) (domain.Checkout, error) {
+	orders, err := p.repo.FindByCheckoutID(ctx, checkoutID, userID)
+	if err != nil {
+		return domain.Checkout{}, err
+	}
+
+	if len(orders) == 0 {
+		return domain.Checkout{}, ErrCheckoutNotFound
+	}
 
-		for _, orderItem := range order.OrderItems {
-			product, err := p.productService.FindByID(ctx, orderItem.ProductID)
+	return newCheckout(checkoutID, orders), nil
+}
 
-			if err != nil {
-				return nil, err
-			}
+// Create reserves stock for the ordered items and splits them into one order
+// per store under a shared checkout. Either every order is created or none,
+// orders created before a failure are removed and all stock is released.
+func (p *OrdersService) Create(ctx context.Context, orderDTO dto.CreateOrderDTO) (domain.Checkout, error) {
+	var reserved []domain.OrderItem
+	var storeIDs []primitive.ObjectID
+	storeItems := map[primitive.ObjectID][]domain.OrderItem{}
+	storeTaxItems := map[primitive.ObjectID][]dto.TaxItem{}
+	storeWeights := map[primitive.ObjectID]int64{}
 
-			totalPrice += product.Price * float64(orderItem.Quantity)
+	for _, orderItem := range orderDTO.OrderItems {
+		product, err := p.productService.FindPurchasableByID(ctx, orderItem.ProductID)
+		if errors.Is(err, ErrStoreOnHoliday) {
+			p.releaseStock(ctx, reserved)
+			return domain.Checkout{}, err
+		}
+		if err != nil {
+			p.releaseStock(ctx, reserved)
+			return domain.Checkout{}, fmt.Errorf("Product no longer exist in stock")
 		}
 
-		orders[i].TotalPrice = totalPrice
+		if orderDTO.SingleStore && len(storeIDs) > 0 && storeIDs[0] != product.StoreID {
+			p.releaseStock(ctx, reserved)
+			return domain.Checkout{}, ErrOrderMultipleStores
+		}
+
+		err = p.productService.ReserveStock(ctx, orderItem.ProductID, orderItem.Quantity)
+		if err != nil {
+			p.releaseStock(ctx, reserved)
+			return domain.Checkout{}, fmt.Errorf("Product %s is out of stock", product.Name)
+		}
+
+		orderItem.StoreID = product.StoreID
+		orderItem.Name = product.Name
+		orderItem.Price = product.Price
+		reserved = append(reserved, orderItem)
+
+		if _, ok := storeItems[product.StoreID]; !ok {
+			storeIDs = append(storeIDs, product.StoreID)
+		}
+		storeItems[product.StoreID] = append(storeItems[product.StoreID], orderItem)
+		storeWeights[product.StoreID] += product.Weight * orderItem.Quantity
+		storeTaxItems[product.StoreID] = append(storeTaxItems[product.StoreID], dto.TaxItem{
+			StoreID: product.StoreID,
+			Amount:  product.Price.Mul(orderItem.Quantity),
+			Exempt:  product.Category.TaxExempt,
+		})
+	}
+
+	storeTax := map[primitive.ObjectID][]domain.TaxLine{}
+	for _, storeID := range storeIDs {
+		taxLines, err := p.taxService.Calculate(ctx, storeTaxItems[storeID])
+		if err != nil {
+			p.releaseStock(ctx, reserved)
+			return domain.Checkout{}, err
+		}
+
+		storeTax[storeID] = taxLines
+	}
+
+	createdAt := time.Now()
+	checkoutID := uuid.NewV4().String()
+
+	storeShipping, err := p.shippingChoices(ctx, orderDTO, storeItems, storeWeights, createdAt)
+	if err != nil {
+		p.releaseStock(ctx, reserved)
+		return domain.Checkout{}, err
+	}
+
+	orders := make([]domain.Order, 0, len(storeIDs))
+
+	for _, storeID := range storeIDs {
+		itemsTotal := orderTotal(storeItems[storeID])
+		shipping := storeShipping[storeID]
+		order, err := p.repo.Create(ctx, domain.Order{
+			OrderID:      uuid.NewV4().String(),
+			CheckoutID:   checkoutID,
+			CreatedAt:    createdAt,
+			OrderItems:   storeItems[storeID],
+			ContactInfo:  orderDTO.ContactInfo,
+			TotalPrice:   itemsTotal,
+			ShippingCost: shipping.cost,
+			Tax:          storeTax[storeID],
+			TaxTotal:     domain.TotalTax(storeTax[storeID]),
+			GrandTotal:   itemsTotal.Add(domain.ExclusiveTax(storeTax[storeID])).Add(shipping.cost),
+			UserID:       orderDTO.UserID,
+			StoreID:      storeID,
+			Status:       domain.OrderStatusReserved,
+			Delivery:     shipping.delivery,
+			Timeline: []domain.OrderEvent{{
+				Type:      domain.OrderEventCreated,
+				Actor:     domain.OrderActorUser,
+				ActorID:   orderDTO.UserID,
+				CreatedAt: createdAt,
+			}},
+		})
+		if err != nil {
+			p.discardOrders(ctx, orders)
+			p.releaseStock(ctx, reserved)
+			return domain.Checkout{}, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return newCheckout(checkoutID, orders), nil
+}
+
+// discardOrders removes the orders of a checkout that couldn't be completed,
+// their stock is released by the caller.
+func (p *OrdersService) discardOrders(ctx context.Context, orders []domain.Order) {
+	for _, order := range orders {
+		err := p.repo.Delete(ctx, order.ID)
+		if err != nil {
+			log.Errorf("failed to discard order %s of an incomplete checkout: %v", order.ID.Hex(), err)
+		}
+	}
+}
+
+func newCheckout(checkoutID string, orders []domain.Order) domain.Checkout {
+	checkout := domain.Checkout{CheckoutID: checkoutID, Orders: orders}
+	for _, order := range orders {
+		checkout.GrandTotal = checkout.GrandTotal.Add(order.GrandTotal)
+	}
+
+	return checkout
+}
+
+type orderShipping struct {
+	cost     domain.Money
+	delivery *domain.OrderDelivery
+}
+
+// shippingChoices prices the shipping option picked for each store under
+// the shipping rules of the store and turns its estimate into delivery
+// dates. Stores without a choice ship at no charge as before.
+func (p *OrdersService) shippingChoices(ctx context.Context, orderDTO dto.CreateOrderDTO,
+	storeItems map[primitive.ObjectID][]domain.OrderItem, storeWeights map[primitive.ObjectID]int64,
+	createdAt time.Time) (map[primitive.ObjectID]orderShipping, error) {
+	storeShipping := map[primitive.ObjectID]orderShipping{}
+	if len(orderDTO.Shipping) == 0 {
+		return storeShipping, nil
+	}
+
+	// Invalid IDs were turned away by validation already.
+	addressID, _ := primitive.ObjectIDFromHex(orderDTO.AddressID)
+
+	for _, choice := range orderDTO.Shipping {
+		storeID, _ := primitive.ObjectIDFromHex(choice.StoreID)
+		weight, ok := storeWeights[storeID]
+		if !ok {
+			return nil, ErrShippingUnavailable
+		}
+
+		parcel := dto.ShippingParcel{
+			StoreID:  storeID,
+			Weight:   weight,
+			Subtotal: orderTotal(storeItems[storeID]),
+		}
+		option, err := p.shippingService.Option(ctx, orderDTO.UserID, addressID, parcel, choice.Courier, choice.Service)
+		if err != nil {
+			return nil, err
+		}
+
+		storeShipping[storeID] = orderShipping{
+			cost: option.Cost,
+			delivery: &domain.OrderDelivery{
+				Courier:       option.Courier,
+				Service:       option.Service,
+				HandlingDays:  option.HandlingDays,
+				EstimatedFrom: createdAt.AddDate(0, 0, option.MinDays),
+				EstimatedTo:   createdAt.AddDate(0, 0, option.MaxDays),
+			},
+		}
+	}
+
+	return storeShipping, nil
+}
+
+func (p *OrdersService) FindByStoreID(ctx context.Context, storeID primitive.ObjectID, filter dto.StoreOrderFilter) ([]domain.Order, error) {
+	orders, err := p.repo.FindByStoreID(ctx, storeID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, order := range orders {
+		orders[i].TotalPrice = orderTotal(order.OrderItems)
 	}
 
 	return orders, nil
 }
 
-func (p *OrdersService) FindByID(ctx context.Context, orderID primitive.ObjectID) (domain.Order, error) {
+func (p *OrdersService) FindByStore(ctx context.Context, orderID primitive.ObjectID, storeID primitive.ObjectID) (domain.Order, error) {
 	order, err := p.repo.FindByID(ctx, orderID)
+	if err != nil || !canActOnOrder(order, domain.OrderActorStore, storeID) {
+		return domain.Order{}, ErrOrderNotFound
+	}
+
+	order.TotalPrice = orderTotal(order.OrderItems)
+
+	return order, nil
+}
+
+func (p *OrdersService) FindByStatus(ctx context.Context, status string) ([]domain.Order, error) {
+	return p.repo.FindByStatus(ctx, status)
+}
+
+func (p *OrdersService) FindTracking(ctx context.Context, orderID primitive.ObjectID, userID primitive.ObjectID) (domain.OrderTracking, error) {
+	order, err := p.repo.FindByID(ctx, orderID)
+	if err != nil || !canActOnOrder(order, domain.OrderActorUser, userID) {
+		return domain.OrderTracking{}, ErrOrderNotFound
+	}
+
+	tracking := domain.OrderTracking{
+		Status:      order.Status,
+		Shipment:    order.Shipment,
+		Events:      order.Tracking,
+		UpdatedAt:   order.TrackingUpdatedAt,
+		DeliveredAt: order.DeliveredAt,
+	}
+	if tracking.Events == nil {
+		tracking.Events = []domain.TrackingEvent{}
+	}
+
+	return tracking, nil
+}
+
+// UpdateTracking stores the courier tracking events of a shipped order
+// and marks it delivered once the courier reports delivery.
+func (p *OrdersService) UpdateTracking(ctx context.Context, order domain.Order, waybill dto.ThirdPartyWaybillDTO) (domain.Order, error) {
+	err := p.repo.UpdateTracking(ctx, order.ID, dto.UpdateTrackingInput{
+		Tracking:          waybill.Events,
+		TrackingUpdatedAt: time.Now(),
+	})
 	if err != nil {
 		return domain.Order{}, err
 	}
 
-	var totalPrice float64
-	for _, orderItem := range order.OrderItems {
-		product, err := p.productService.FindByID(ctx, orderItem.ProductID)
+	if !waybill.Delivered {
+		return p.repo.FindByID(ctx, order.ID)
+	}
 
-		if err != nil {
-			return domain.Order{}, err
+	deliveredAt := waybill.DeliveredAt
+	if deliveredAt.IsZero() {
+		deliveredAt = time.Now()
+	}
+
+	order, err = p.repo.Transition(ctx, order.ID, []string{domain.OrderStatusShipped}, dto.OrderTransitionInput{
+		Status:      domain.OrderStatusDelivered,
+		DeliveredAt: deliveredAt,
+		Event: domain.OrderEvent{
+			Type:      domain.OrderEventDelivered,
+			Actor:     domain.OrderActorSystem,
+			CreatedAt: time.Now(),
+		},
+	})
+	if errors.Is(err, repository.ErrOrderStatusConflict) {
+		return domain.Order{}, ErrOrderInvalidStatus
+	}
+
+	return order, err
+}
+
+// Complete closes a delivered order, either confirmed by the buyer or by the
+// system once the confirmation period has passed, and settles it to the store.
+// Orders with an open return request are disputed and stay delivered. Returns
+// requested while the order completes are counted on the order, so the
+// transition only applies while none is open.
+func (p *OrdersService) Complete(ctx context.Context, orderID primitive.ObjectID, actor string, actorID primitive.ObjectID) (domain.Order, error) {
+	returns, err := p.returnsRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	for _, returnRequest := range returns {
+		if containsStatus(openReturnStatuses, returnRequest.Status) {
+			return domain.Order{}, ErrOrderDisputed
 		}
+	}
 
-		totalPrice += product.Price * float64(orderItem.Quantity)
+	order, err := p.transitionAs(ctx, orderID, actor, actorID, []string{domain.OrderStatusDelivered}, dto.OrderTransitionInput{
+		Status:      domain.OrderStatusCompleted,
+		CompletedAt: time.Now(),
+		Event: domain.OrderEvent{
+			Type: domain.OrderEventCompleted,
+		},
+		Undisputed: true,
+	})
+	if errors.Is(err, ErrOrderInvalidStatus) {
+		current, findErr := p.repo.FindByID(ctx, orderID)
+		if findErr == nil && current.Status == domain.OrderStatusDelivered && current.OpenReturns > 0 {
+			return domain.Order{}, ErrOrderDisputed
+		}
+	}
+	if err != nil {
+		return domain.Order{}, err
 	}
 
-	order.TotalPrice = totalPrice
+	err = p.Settle(ctx, order)
+	if err != nil {
+		return order, fmt.Errorf("order completed but settlement failed: %w", err)
+	}
 
-	return order, err
+	return p.repo.FindByID(ctx, orderID)
 }
 
-func (p *OrdersService) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]domain.Order, error) {
-	return p.repo.FindByUserID(ctx, userID)
+// Settle credits the store ledger for a completed order. It is safe to retry.
+func (p *OrdersService) Settle(ctx context.Context, order domain.Order) error {
+	if order.Status != domain.OrderStatusCompleted {
+		return ErrOrderInvalidStatus
+	}
+
+	err := p.walletService.SettleOrder(ctx, order)
+	if err != nil {
+		return err
+	}
+
+	return p.repo.MarkSettled(ctx, order.ID, time.Now())
 }
 
-func (p *OrdersService) Create(ctx context.Context, orderDTO dto.CreateOrderDTO) (domain.Order, error) {
-	var totalPrice float64
-	for _, orderItem := range orderDTO.OrderItems {
+func (p *OrdersService) FindUnsettled(ctx context.Context) ([]domain.Order, error) {
+	return p.repo.FindUnsettled(ctx)
+}
+
+func (p *OrdersService) Accept(ctx context.Context, orderID primitive.ObjectID, storeID primitive.ObjectID) (domain.Order, error) {
+	return p.transitionAs(ctx, orderID, domain.OrderActorStore, storeID, []string{domain.OrderStatusPaid}, dto.OrderTransitionInput{
+		Status: domain.OrderStatusProcessing,
+		Event: domain.OrderEvent{
+			Type: domain.OrderEventAccepted,
+		},
+	})
+}
+
+func (p *OrdersService) Ship(ctx context.Context, orderID primitive.ObjectID, storeID primitive.ObjectID, shipInput dto.ShipOrderInput) (domain.Order, error) {
+	shipment := domain.OrderShipment{
+		Courier:        shipInput.Courier,
+		Service:        shipInput.Service,
+		TrackingNumber: shipInput.TrackingNumber,
+		ShippedAt:      time.Now(),
+	}
+
+	return p.transitionAs(ctx, orderID, domain.OrderActorStore, storeID, []string{domain.OrderStatusProcessing}, dto.OrderTransitionInput{
+		Status:   domain.OrderStatusShipped,
+		Shipment: &shipment,
+		Event: domain.OrderEvent{
+			Type: domain.OrderEventShipped,
+			Note: fmt.Sprintf("%s %s", shipment.Courier, shipment.TrackingNumber),
+		},
+	})
+}
+
+func (p *OrdersService) PackingSlip(ctx context.Context, orderID primitive.ObjectID, storeID primitive.ObjectID) (domain.PackingSlip, error) {
+	order, err := p.FindByStore(ctx, orderID, storeID)
+	if err != nil {
+		return domain.PackingSlip{}, err
+	}
+
+	slip := domain.PackingSlip{
+		OrderID:   order.OrderID,
+		CreatedAt: order.CreatedAt,
+		Recipient: order.ContactInfo,
+		Shipment:  order.Shipment,
+		Items:     make([]domain.PackingItem, 0, len(order.OrderItems)),
+	}
+
+	for _, orderItem := range order.OrderItems {
 		product, err := p.productService.FindByID(ctx, orderItem.ProductID)
 		if err != nil {
-			return domain.Order{}, fmt.Errorf("Product no longer exist in stock")
+			return domain.PackingSlip{}, err
 		}
-		totalPrice += product.Price * float64(orderItem.Quantity)
+
+		slip.Items = append(slip.Items, domain.PackingItem{
+			ProductID: orderItem.ProductID,
+			Name:      product.Name,
+			Quantity:  orderItem.Quantity,
+			Weight:    product.Weight * orderItem.Quantity,
+		})
+		slip.TotalWeight += product.Weight * orderItem.Quantity
 	}
 
-	return p.repo.Create(ctx, domain.Order{
-		OrderID:     uuid.NewV4().String(),
-		CreatedAt:   time.Now(),
-		OrderItems:  orderDTO.OrderItems,
-		ContactInfo: orderDTO.ContactInfo,
-		TotalPrice:  totalPrice,
-		UserID:      orderDTO.UserID,
-		Status:      "reserved",
-	})
+	return slip, nil
+}
+
+func (p *OrdersService) transitionAs(ctx context.Context, orderID primitive.ObjectID, actor string, actorID primitive.ObjectID, fromStatuses []string, transition dto.OrderTransitionInput) (domain.Order, error) {
+	order, err := p.repo.FindByID(ctx, orderID)
+	if err != nil || !canActOnOrder(order, actor, actorID) {
+		return domain.Order{}, ErrOrderNotFound
+	}
+
+	transition.Event.Actor = actor
+	transition.Event.ActorID = actorID
+	transition.Event.CreatedAt = time.Now()
+
+	order, err = p.repo.Transition(ctx, orderID, fromStatuses, transition)
+	if errors.Is(err, repository.ErrOrderStatusConflict) {
+		return domain.Order{}, ErrOrderInvalidStatus
+	}
+
+	return order, err
+}
+
+func orderTotal(orderItems []domain.OrderItem) domain.Money {
+	var totalPrice domain.Money
+	for _, orderItem := range orderItems {
+		totalPrice = totalPrice.Add(orderItem.Price.Mul(orderItem.Quantity))
+	}
+
+	return totalPrice
 }
 
 func (p *OrdersService) Update(ctx context.Context, orderDTO dto.UpdateOrderDTO, orderID primitive.ObjectID) (domain.Order, error) {
-	return p.repo.Update(ctx, dto.UpdateOrderInput{
+	before, err := p.repo.FindByID(ctx, orderID)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	order, err := p.repo.Update(ctx, dto.UpdateOrderInput{
 		DeliveredAt: orderDTO.DeliveredAt,
 		Status:      orderDTO.Status,
 	}, orderID)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	p.audit.Track(ctx, domain.AuditActionOrderUpdate, domain.AuditTargetOrder, orderID, before, order)
+
+	return order, nil
+}
+
+// Cancel cancels a single order and refunds what it was charged. Checkouts of
+// several stores are split into one order per store, cancelling one of them
+// leaves the orders of the other stores as they are.
+func (p *OrdersService) Cancel(ctx context.Context, orderID primitive.ObjectID, cancelDTO dto.CancelOrderDTO) (domain.Order, error) {
+	order, err := p.repo.FindByID(ctx, orderID)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if !canActOnOrder(order, cancelDTO.Actor, cancelDTO.ActorID) {
+		return domain.Order{}, ErrOrderNotFound
+	}
+
+	allowedStatuses := cancellableStatuses[cancelDTO.Actor]
+	if !containsStatus(allowedStatuses, order.Status) {
+		return domain.Order{}, ErrOrderNotCancellable
+	}
+
+	cancellation := domain.OrderCancellation{
+		Actor:         cancelDTO.Actor,
+		ActorID:       cancelDTO.ActorID,
+		Reason:        cancelDTO.Reason,
+		Note:          cancelDTO.Note,
+		RefundPending: order.PaymentIntentID != "",
+		CreatedAt:     time.Now(),
+	}
+
+	cancelled, err := p.repo.Transition(ctx, orderID, allowedStatuses, dto.OrderTransitionInput{
+		Status:       domain.OrderStatusCancelled,
+		Cancellation: &cancellation,
+		Event: domain.OrderEvent{
+			Type:      domain.OrderEventCancelled,
+			Actor:     cancelDTO.Actor,
+			ActorID:   cancelDTO.ActorID,
+			Note:      cancelDTO.Reason,
+			CreatedAt: cancellation.CreatedAt,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderStatusConflict) {
+			return domain.Order{}, ErrOrderNotCancellable
+		}
+		return domain.Order{}, err
+	}
+
+	p.releaseStock(ctx, order.OrderItems)
+
+	if !cancellation.RefundPending {
+		return cancelled, nil
+	}
+
+	// The order stays cancelled with its refund pending when the refund
+	// fails, the completion job retries it.
+	refunded, err := p.RefundCancelled(ctx, cancelled)
+	if err != nil {
+		log.Errorf("failed to refund cancelled order %s: %v", cancelled.ID.Hex(), err)
+		return cancelled, nil
+	}
+
+	return refunded, nil
+}
+
+// RefundCancelled refunds the payment of a cancelled order whose refund is
+// pending and records the refund on the cancellation. The refund is what
+// the order was charged, orders captured before grand totals were stored
+// are refunded in full, so the provider refuses a second refund should two
+// attempts overlap.
+func (p *OrdersService) RefundCancelled(ctx context.Context, order domain.Order) (domain.Order, error) {
+	if order.Status != domain.OrderStatusCancelled || order.Cancellation == nil || !order.Cancellation.RefundPending {
+		return domain.Order{}, ErrOrderInvalidStatus
+	}
+
+	refundID, err := p.paymentService.Refund(ctx, order, order.GrandTotal)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	cancellation := *order.Cancellation
+	cancellation.RefundID = refundID
+	cancellation.RefundPending = false
+
+	return p.repo.Transition(ctx, order.ID, []string{domain.OrderStatusCancelled}, dto.OrderTransitionInput{
+		Status:       domain.OrderStatusCancelled,
+		Cancellation: &cancellation,
+		Event: domain.OrderEvent{
+			Type:      domain.OrderEventRefunded,
+			Actor:     cancellation.Actor,
+			ActorID:   cancellation.ActorID,
+			Note:      refundID,
+			CreatedAt: time.Now(),
+		},
+	})
+}
+
+func (p *OrdersService) FindRefundPending(ctx context.Context) ([]domain.Order, error) {
+	return p.repo.FindRefundPending(ctx)
+}
+
+// CancelCheckout cancels every order of a checkout the buyer can still
+// cancel, orders that went too far with their store are left alone.
+func (p *OrdersService) CancelCheckout(ctx context.Context, checkoutID string, cancelDTO dto.CancelOrderDTO) (domain.Checkout, error) {
+	orders, err := p.repo.FindByCheckoutID(ctx, checkoutID, cancelDTO.ActorID)
+	if err != nil {
+		return domain.Checkout{}, err
+	}
+
+	if len(orders) == 0 {
+		return domain.Checkout{}, ErrCheckoutNotFound
+	}
+
+	var cancelErr error
+	cancelled := 0
+	for i, order := range orders {
+		if !containsStatus(cancellableStatuses[cancelDTO.Actor], order.Status) {
+			continue
+		}
+
+		order, err = p.Cancel(ctx, order.ID, cancelDTO)
+		if errors.Is(err, ErrOrderNotCancellable) {
+			continue
+		}
+		if err != nil && cancelErr == nil {
+			cancelErr = err
+		}
+		if order.Status == domain.OrderStatusCancelled {
+			orders[i] = order
+			cancelled++
+		}
+	}
+
+	if cancelled == 0 && cancelErr == nil {
+		return domain.Checkout{}, ErrOrderNotCancellable
+	}
+
+	return newCheckout(checkoutID, orders), cancelErr
+}
+
+// Capture records a payment the provider captured and moves the order from
+// reserved to paid in one conditional update. Payment providers retry their
+// notifications, so capturing the same payment again returns the order as
+// it is. A payment that cannot be applied, because the amount is wrong or
+// the order was cancelled or paid meanwhile, is refunded.
+func (p *OrdersService) Capture(ctx context.Context, payment dto.OrderPaymentDTO) (domain.Order, error) {
+	order, err := p.repo.FindByID(ctx, payment.OrderID)
+	if err != nil {
+		return domain.Order{}, ErrOrderNotFound
+	}
+
+	if order.PaymentIntentID == payment.PaymentIntentID {
+		return order, nil
+	}
+
+	if order.GrandTotal.IsPositive() && payment.Amount != order.GrandTotal {
+		mismatch := fmt.Errorf("%w: captured %s for %s", ErrPaymentMismatch, payment.Amount, order.GrandTotal)
+		return domain.Order{}, p.refundUnapplied(ctx, order, payment, mismatch)
+	}
+
+	paid, err := p.repo.Transition(ctx, order.ID, []string{domain.OrderStatusReserved}, dto.OrderTransitionInput{
+		Status:          domain.OrderStatusPaid,
+		PaymentIntentID: payment.PaymentIntentID,
+		PaidAt:          payment.PaidAt,
+		Event: domain.OrderEvent{
+			Type:      domain.OrderEventPaid,
+			Actor:     domain.OrderActorSystem,
+			Note:      payment.PaymentIntentID,
+			CreatedAt: time.Now(),
+		},
+	})
+	if !errors.Is(err, repository.ErrOrderStatusConflict) {
+		return paid, err
+	}
+
+	order, err = p.repo.FindByID(ctx, order.ID)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	return domain.Order{}, p.refundUnapplied(ctx, order, payment, ErrOrderInvalidStatus)
+}
+
+// refundUnapplied hands back a payment that was not applied to the order and
+// returns reason. The refund is recorded on the timeline under the payment
+// intent, so a retried notification does not refund twice.
+func (p *OrdersService) refundUnapplied(ctx context.Context, order domain.Order, payment dto.OrderPaymentDTO, reason error) error {
+	for _, event := range order.Timeline {
+		if event.Type == domain.OrderEventRefunded && strings.HasPrefix(event.Note, payment.PaymentIntentID+" ") {
+			return reason
+		}
+	}
+
+	order.PaymentIntentID = payment.PaymentIntentID
+	refundID, err := p.paymentService.Refund(ctx, order, domain.Money{})
+	if err != nil {
+		return fmt.Errorf("payment %s was not applied and could not be refunded: %w", payment.PaymentIntentID, err)
+	}
+
+	err = p.repo.AddEvent(ctx, order.ID, domain.OrderEvent{
+		Type:      domain.OrderEventRefunded,
+		Actor:     domain.OrderActorSystem,
+		Note:      fmt.Sprintf("%s refunded as %s", payment.PaymentIntentID, refundID),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return reason
+}
+
+func (p *OrdersService) AddEvent(ctx context.Context, orderID primitive.ObjectID, event domain.OrderEvent) error {
+	return p.repo.AddEvent(ctx, orderID, event)
+}
+
+// OpenReturn holds a delivered order open for a new return request, it fails
+// with ErrOrderInvalidStatus once the order left delivered.
+func (p *OrdersService) OpenReturn(ctx context.Context, orderID primitive.ObjectID) error {
+	err := p.repo.OpenReturn(ctx, orderID)
+	if errors.Is(err, repository.ErrOrderStatusConflict) {
+		return ErrOrderInvalidStatus
+	}
+
+	return err
+}
+
+// CloseReturn releases the order once one of its return requests is rejected
+// or refunded.
+func (p *OrdersService) CloseReturn(ctx context.Context, orderID primitive.ObjectID) error {
+	return p.repo.CloseReturn(ctx, orderID)
+}
+
+func (p *OrdersService) releaseStock(ctx context.Context, orderItems []domain.OrderItem) {
+	for _, orderItem := range orderItems {
+		err := p.productService.ReleaseStock(ctx, orderItem.ProductID, orderItem.Quantity)
+		if err != nil {
+			log.Errorf("failed to release stock of product %s: %v", orderItem.ProductID.Hex(), err)
+		}
+	}
+}
+
+func canActOnOrder(order domain.Order, actor string, actorID primitive.ObjectID) bool {
+	switch actor {
+	case domain.OrderActorUser:
+		return order.UserID == actorID
+	case domain.OrderActorStore:
+		return order.StoreID == actorID
+	case domain.OrderActorAdmin, domain.OrderActorSystem:
+		return true
+	}
+
+	return false
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (p *OrdersService) Delete(ctx context.Context, orderID primitive.ObjectID) error {
 	return p.repo.Delete(ctx, orderID)
 }
 
-func NewOrdersService(repo repository.Orders, productService Products, cartService Carts) *OrdersService {
+func NewOrdersService(repo repository.Orders, returnsRepo repository.Returns, productService Products, cartService Carts,
+	paymentService Payment, walletService Wallets, taxService Taxes, shippingService Shipping, audit Audit) *OrdersService {
 	return &OrdersService{
-		repo:           repo,
-		productService: productService,
-		cartService:    cartService,
+		repo:            repo,
+		returnsRepo:     returnsRepo,
+		productService:  productService,
+		cartService:     cartService,
+		paymentService:  paymentService,
+		walletService:   walletService,
+		taxService:      taxService,
+		shippingService: shippingService,
+		audit:           audit,
 	}
 }
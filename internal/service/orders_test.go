@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryOrders only implements the lookups and updates the tests use.
+type memoryOrders struct {
+	repository.Orders
+	orders map[primitive.ObjectID]domain.Order
+	// failCreate fails creating orders once this many were created.
+	failCreate int
+}
+
+func (o *memoryOrders) Create(ctx context.Context, order domain.Order) (domain.Order, error) {
+	if o.failCreate > 0 && len(o.orders) >= o.failCreate {
+		return domain.Order{}, errors.New("write failed")
+	}
+
+	order.ID = primitive.NewObjectID()
+	o.orders[order.ID] = order
+	return order, nil
+}
+
+func (o *memoryOrders) FindByCheckoutID(ctx context.Context, checkoutID string, userID primitive.ObjectID) ([]domain.Order, error) {
+	var orders []domain.Order
+	for _, order := range o.orders {
+		if order.CheckoutID == checkoutID && order.UserID == userID {
+			orders = append(orders, order)
+		}
+	}
+
+	return orders, nil
+}
+
+func (o *memoryOrders) Delete(ctx context.Context, orderID primitive.ObjectID) error {
+	delete(o.orders, orderID)
+	return nil
+}
+
+func (o *memoryOrders) FindByID(ctx context.Context, orderID primitive.ObjectID) (domain.Order, error) {
+	order, ok := o.orders[orderID]
+	if !ok {
+		return domain.Order{}, mongo.ErrNoDocuments
+	}
+
+	return order, nil
+}
+
+func (o *memoryOrders) Transition(ctx context.Context, orderID primitive.ObjectID, fromStatuses []string, transition dto.OrderTransitionInput) (domain.Order, error) {
+	order, ok := o.orders[orderID]
+	if !ok || !containsStatus(fromStatuses, order.Status) || (transition.Undisputed && order.OpenReturns > 0) {
+		return domain.Order{}, repository.ErrOrderStatusConflict
+	}
+
+	order.Status = transition.Status
+	if transition.PaymentIntentID != "" {
+		order.PaymentIntentID = transition.PaymentIntentID
+		order.PaidAt = transition.PaidAt
+	}
+	if transition.Cancellation != nil {
+		order.Cancellation = transition.Cancellation
+	}
+	if !transition.CompletedAt.IsZero() {
+		order.CompletedAt = transition.CompletedAt
+	}
+	if transition.Event.Type != "" {
+		order.Timeline = append(order.Timeline, transition.Event)
+	}
+
+	o.orders[orderID] = order
+	return order, nil
+}
+
+func (o *memoryOrders) AddEvent(ctx context.Context, orderID primitive.ObjectID, event domain.OrderEvent) error {
+	order := o.orders[orderID]
+	order.Timeline = append(order.Timeline, event)
+	o.orders[orderID] = order
+	return nil
+}
+
+func (o *memoryOrders) OpenReturn(ctx context.Context, orderID primitive.ObjectID) error {
+	order, ok := o.orders[orderID]
+	if !ok || order.Status != domain.OrderStatusDelivered {
+		return repository.ErrOrderStatusConflict
+	}
+
+	order.OpenReturns++
+	o.orders[orderID] = order
+	return nil
+}
+
+func (o *memoryOrders) CloseReturn(ctx context.Context, orderID primitive.ObjectID) error {
+	order, ok := o.orders[orderID]
+	if ok && order.OpenReturns > 0 {
+		order.OpenReturns--
+		o.orders[orderID] = order
+	}
+
+	return nil
+}
+
+type recordedRefund struct {
+	paymentIntentID string
+	amount          domain.Money
+}
+
+// memoryPayment records refunds instead of calling the payment provider.
+type memoryPayment struct {
+	Payment
+	refunds []recordedRefund
+	// failRefunds fails this many refunds before refunds go through.
+	failRefunds int
+}
+
+func (p *memoryPayment) Refund(ctx context.Context, order domain.Order, amount domain.Money) (string, error) {
+	if p.failRefunds > 0 {
+		p.failRefunds--
+		return "", errors.New("payment provider unavailable")
+	}
+
+	p.refunds = append(p.refunds, recordedRefund{paymentIntentID: order.PaymentIntentID, amount: amount})
+	return "re_test", nil
+}
+
+// memoryStock counts the stock reserved by orders and put back by cancellations.
+type memoryStock struct {
+	Products
+	products map[primitive.ObjectID]domain.Product
+	reserved map[primitive.ObjectID]int64
+	released map[primitive.ObjectID]int64
+}
+
+func (s *memoryStock) FindPurchasableByID(ctx context.Context, productID primitive.ObjectID) (domain.Product, error) {
+	product, ok := s.products[productID]
+	if !ok {
+		return domain.Product{}, mongo.ErrNoDocuments
+	}
+
+	return product, nil
+}
+
+func (s *memoryStock) ReserveStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error {
+	s.reserved[productID] += quantity
+	return nil
+}
+
+func (s *memoryStock) ReleaseStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error {
+	s.released[productID] += quantity
+	return nil
+}
+
+// untaxed charges no tax on orders.
+type untaxed struct {
+	Taxes
+}
+
+func (untaxed) Calculate(ctx context.Context, items []dto.TaxItem) ([]domain.TaxLine, error) {
+	return nil, nil
+}
+
+func newTestOrders(orders ...domain.Order) (*OrdersService, *memoryOrders, *memoryPayment, *memoryStock) {
+	repo := &memoryOrders{orders: map[primitive.ObjectID]domain.Order{}}
+	for _, order := range orders {
+		repo.orders[order.ID] = order
+	}
+
+	payment := &memoryPayment{}
+	stock := &memoryStock{
+		products: map[primitive.ObjectID]domain.Product{},
+		reserved: map[primitive.ObjectID]int64{},
+		released: map[primitive.ObjectID]int64{},
+	}
+
+	return &OrdersService{repo: repo, productService: stock, paymentService: payment, taxService: untaxed{}}, repo, payment, stock
+}
+
+// cartOfStores stocks one product per store and orders one of each.
+func cartOfStores(stock *memoryStock, stores int) dto.CreateOrderDTO {
+	orderDTO := dto.CreateOrderDTO{UserID: primitive.NewObjectID()}
+	for i := 0; i < stores; i++ {
+		product := domain.Product{ID: primitive.NewObjectID(), StoreID: primitive.NewObjectID(), Price: idr(10000)}
+		stock.products[product.ID] = product
+		orderDTO.OrderItems = append(orderDTO.OrderItems, domain.OrderItem{ProductID: product.ID, Quantity: 1})
+	}
+
+	return orderDTO
+}
+
+func reservedOrder() domain.Order {
+	return domain.Order{
+		ID:         primitive.NewObjectID(),
+		UserID:     primitive.NewObjectID(),
+		StoreID:    primitive.NewObjectID(),
+		Status:     domain.OrderStatusReserved,
+		GrandTotal: idr(125000),
+		OrderItems: []domain.OrderItem{{ProductID: primitive.NewObjectID(), Quantity: 2, Price: idr(50000)}},
+	}
+}
+
+func capturedPayment(order domain.Order) dto.OrderPaymentDTO {
+	return dto.OrderPaymentDTO{
+		OrderID:         order.ID,
+		PaymentIntentID: "pi_test",
+		Amount:          order.GrandTotal,
+		PaidAt:          time.Now(),
+	}
+}
+
+func TestCaptureMarksOrderPaid(t *testing.T) {
+	order := reservedOrder()
+	orders, repo, payment, _ := newTestOrders(order)
+
+	paid, err := orders.Capture(context.Background(), capturedPayment(order))
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if paid.Status != domain.OrderStatusPaid || paid.PaymentIntentID != "pi_test" || paid.PaidAt.IsZero() {
+		t.Errorf("order = %+v, want paid with payment intent and paid at", paid)
+	}
+
+	// A retried notification leaves the order alone.
+	again, err := orders.Capture(context.Background(), capturedPayment(order))
+	if err != nil || again.Status != domain.OrderStatusPaid {
+		t.Errorf("retried Capture = %s, %v, want paid order", again.Status, err)
+	}
+	if len(repo.orders[order.ID].Timeline) != 1 || len(payment.refunds) != 0 {
+		t.Errorf("retried capture changed the order: %+v, refunds %v", repo.orders[order.ID].Timeline, payment.refunds)
+	}
+}
+
+func TestCaptureRefundsUnappliedPayments(t *testing.T) {
+	wrongAmount := reservedOrder()
+	cancelled := reservedOrder()
+	cancelled.Status = domain.OrderStatusCancelled
+	orders, repo, payment, _ := newTestOrders(wrongAmount, cancelled)
+
+	underpaid := capturedPayment(wrongAmount)
+	underpaid.Amount = idr(100000)
+	_, err := orders.Capture(context.Background(), underpaid)
+	if !errors.Is(err, ErrPaymentMismatch) {
+		t.Errorf("err = %v, want %v", err, ErrPaymentMismatch)
+	}
+	if repo.orders[wrongAmount.ID].Status != domain.OrderStatusReserved {
+		t.Errorf("status = %s, want order still awaiting payment", repo.orders[wrongAmount.ID].Status)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err = orders.Capture(context.Background(), capturedPayment(cancelled))
+		if !errors.Is(err, ErrOrderInvalidStatus) {
+			t.Errorf("err = %v, want %v", err, ErrOrderInvalidStatus)
+		}
+	}
+
+	if len(payment.refunds) != 2 {
+		t.Fatalf("refunds = %v, want the underpayment and the late payment refunded once each", payment.refunds)
+	}
+	for _, refund := range payment.refunds {
+		if refund.paymentIntentID != "pi_test" || !refund.amount.IsZero() {
+			t.Errorf("refund = %+v, want full refund of pi_test", refund)
+		}
+	}
+}
+
+func TestCancelPaidOrderRefundsPayment(t *testing.T) {
+	order := reservedOrder()
+	orders, repo, payment, stock := newTestOrders(order)
+
+	_, err := orders.Capture(context.Background(), capturedPayment(order))
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+
+	cancelled, err := orders.Cancel(context.Background(), order.ID, dto.CancelOrderDTO{
+		Actor:   domain.OrderActorUser,
+		ActorID: order.UserID,
+		Reason:  domain.CancelReasonChangedMind,
+	})
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	if cancelled.Status != domain.OrderStatusCancelled || cancelled.Cancellation == nil || cancelled.Cancellation.RefundID != "re_test" {
+		t.Errorf("order = %+v, want cancelled with refund", cancelled)
+	}
+	if len(payment.refunds) != 1 || payment.refunds[0].paymentIntentID != "pi_test" || payment.refunds[0].amount != order.GrandTotal {
+		t.Errorf("refunds = %+v, want %s refunded from pi_test", payment.refunds, order.GrandTotal)
+	}
+	if stock.released[order.OrderItems[0].ProductID] != 2 {
+		t.Errorf("released stock = %v, want 2", stock.released)
+	}
+
+	timeline := repo.orders[order.ID].Timeline
+	if last := timeline[len(timeline)-1]; last.Type != domain.OrderEventRefunded {
+		t.Errorf("last event = %s, want %s", last.Type, domain.OrderEventRefunded)
+	}
+}
+
+func TestCancelKeepsFailedRefundPending(t *testing.T) {
+	order := reservedOrder()
+	orders, repo, payment, _ := newTestOrders(order)
+
+	_, err := orders.Capture(context.Background(), capturedPayment(order))
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+
+	payment.failRefunds = 1
+	cancelled, err := orders.Cancel(context.Background(), order.ID, dto.CancelOrderDTO{
+		Actor:   domain.OrderActorUser,
+		ActorID: order.UserID,
+		Reason:  domain.CancelReasonChangedMind,
+	})
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if cancelled.Status != domain.OrderStatusCancelled || cancelled.Cancellation == nil || !cancelled.Cancellation.RefundPending {
+		t.Fatalf("order = %+v, want cancelled with its refund pending", cancelled)
+	}
+	if len(payment.refunds) != 0 {
+		t.Fatalf("refunds = %v, want none after the provider failed", payment.refunds)
+	}
+
+	refunded, err := orders.RefundCancelled(context.Background(), repo.orders[order.ID])
+	if err != nil {
+		t.Fatalf("RefundCancelled: %v", err)
+	}
+	if refunded.Cancellation.RefundPending || refunded.Cancellation.RefundID != "re_test" {
+		t.Errorf("cancellation = %+v, want refunded", refunded.Cancellation)
+	}
+	if refunded.Cancellation.Reason != domain.CancelReasonChangedMind {
+		t.Errorf("reason = %q, want the cancellation kept", refunded.Cancellation.Reason)
+	}
+
+	_, err = orders.RefundCancelled(context.Background(), repo.orders[order.ID])
+	if !errors.Is(err, ErrOrderInvalidStatus) {
+		t.Errorf("second RefundCancelled err = %v, want %v", err, ErrOrderInvalidStatus)
+	}
+	if len(payment.refunds) != 1 || payment.refunds[0].amount != order.GrandTotal {
+		t.Errorf("refunds = %+v, want %s refunded once", payment.refunds, order.GrandTotal)
+	}
+}
+
+func TestCancelReservedOrderDoesNotRefund(t *testing.T) {
+	order := reservedOrder()
+	orders, _, payment, _ := newTestOrders(order)
+
+	cancelled, err := orders.Cancel(context.Background(), order.ID, dto.CancelOrderDTO{
+		Actor:   domain.OrderActorUser,
+		ActorID: order.UserID,
+		Reason:  domain.CancelReasonChangedMind,
+	})
+	if err != nil || cancelled.Status != domain.OrderStatusCancelled {
+		t.Fatalf("Cancel = %s, %v, want cancelled", cancelled.Status, err)
+	}
+	if len(payment.refunds) != 0 {
+		t.Errorf("refunds = %v, want none for an unpaid order", payment.refunds)
+	}
+}
+
+func TestCreateSplitsCartIntoCheckout(t *testing.T) {
+	orders, repo, _, stock := newTestOrders()
+
+	checkout, err := orders.Create(context.Background(), cartOfStores(stock, 2))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if len(checkout.Orders) != 2 || len(repo.orders) != 2 || checkout.GrandTotal != idr(20000) {
+		t.Fatalf("checkout = %+v, want two orders totalling %v", checkout, idr(20000))
+	}
+	for _, order := range checkout.Orders {
+		if order.CheckoutID == "" || order.CheckoutID != checkout.CheckoutID {
+			t.Errorf("order checkout = %q, want %q", order.CheckoutID, checkout.CheckoutID)
+		}
+	}
+}
+
+func TestCreateDiscardsCreatedOrdersOnFailure(t *testing.T) {
+	orders, repo, _, stock := newTestOrders()
+	repo.failCreate = 1
+
+	_, err := orders.Create(context.Background(), cartOfStores(stock, 2))
+	if err == nil {
+		t.Fatal("Create succeeded, want the failed write")
+	}
+
+	if len(repo.orders) != 0 {
+		t.Errorf("orders = %v, want the created order discarded", repo.orders)
+	}
+	for productID, quantity := range stock.reserved {
+		if stock.released[productID] != quantity {
+			t.Errorf("product %s released %d of %d reserved", productID.Hex(), stock.released[productID], quantity)
+		}
+	}
+}
+
+func TestCreateSingleStoreRejectsMixedCart(t *testing.T) {
+	orders, repo, _, stock := newTestOrders()
+	orderDTO := cartOfStores(stock, 2)
+	orderDTO.SingleStore = true
+
+	_, err := orders.Create(context.Background(), orderDTO)
+	if !errors.Is(err, ErrOrderMultipleStores) {
+		t.Fatalf("err = %v, want %v", err, ErrOrderMultipleStores)
+	}
+
+	if len(repo.orders) != 0 {
+		t.Errorf("orders = %v, want none", repo.orders)
+	}
+	for productID, quantity := range stock.reserved {
+		if stock.released[productID] != quantity {
+			t.Errorf("product %s released %d of %d reserved", productID.Hex(), stock.released[productID], quantity)
+		}
+	}
+}
+
+func TestCancelCheckoutSkipsOrdersPastCancellation(t *testing.T) {
+	reserved := reservedOrder()
+	reserved.CheckoutID = "checkout"
+	shipped := reservedOrder()
+	shipped.CheckoutID = reserved.CheckoutID
+	shipped.UserID = reserved.UserID
+	shipped.Status = domain.OrderStatusShipped
+	orders, repo, _, _ := newTestOrders(reserved, shipped)
+
+	checkout, err := orders.CancelCheckout(context.Background(), reserved.CheckoutID, dto.CancelOrderDTO{
+		Actor:   domain.OrderActorUser,
+		ActorID: reserved.UserID,
+		Reason:  domain.CancelReasonChangedMind,
+	})
+	if err != nil {
+		t.Fatalf("CancelCheckout: %v", err)
+	}
+
+	if len(checkout.Orders) != 2 {
+		t.Fatalf("checkout orders = %d, want 2", len(checkout.Orders))
+	}
+	if repo.orders[reserved.ID].Status != domain.OrderStatusCancelled {
+		t.Errorf("reserved order = %s, want cancelled", repo.orders[reserved.ID].Status)
+	}
+	if repo.orders[shipped.ID].Status != domain.OrderStatusShipped {
+		t.Errorf("shipped order = %s, want left shipped", repo.orders[shipped.ID].Status)
+	}
+
+	_, err = orders.CancelCheckout(context.Background(), reserved.CheckoutID, dto.CancelOrderDTO{
+		Actor:   domain.OrderActorUser,
+		ActorID: reserved.UserID,
+		Reason:  domain.CancelReasonChangedMind,
+	})
+	if !errors.Is(err, ErrOrderNotCancellable) {
+		t.Errorf("second CancelCheckout err = %v, want %v", err, ErrOrderNotCancellable)
+	}
+}
+
+func TestFindByIDTotalsSnapshottedPrices(t *testing.T) {
+	order := reservedOrder()
+	orders, _, _, _ := newTestOrders(order)
+
+	// The product is gone from the catalogue, the order keeps its price.
+	found, err := orders.FindByID(context.Background(), order.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if found.TotalPrice != idr(100000) {
+		t.Errorf("total = %v, want %v", found.TotalPrice, idr(100000))
+	}
+}
@@ -1,30 +1,76 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
 	"github.com/stripe/stripe-go/v72"
 	"github.com/stripe/stripe-go/v72/paymentlink"
 	"github.com/stripe/stripe-go/v72/price"
 	"github.com/stripe/stripe-go/v72/product"
+	"github.com/stripe/stripe-go/v72/refund"
+	"github.com/stripe/stripe-go/v72/webhook"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var ErrInvalidPaymentEvent = errors.New("invalid payment event")
+
+// paymentOrderKey is the metadata key that ties a payment link, and the
+// checkout sessions Stripe creates from it, to an order.
+const paymentOrderKey = "order_id"
+
 type PaymentService struct {
+	webhookSecret string
 }
 
-func (p *PaymentService) GetPaymentLink(order domain.Order) (string, error) {
-	linkParamsList := make([]*stripe.PaymentLinkLineItemParams, len(order.OrderItems))
-	for i, orderItem := range order.OrderItems {
-		productPrice := p.GetProductPrice(orderItem.ProductID)
-		linkParamsList[i] = &stripe.PaymentLinkLineItemParams{
-			Price:    stripe.String(productPrice.ID),
-			Quantity: stripe.Int64(orderItem.Quantity),
+// GetPaymentLink charges the prices snapshotted on the order, its exclusive
+// tax and its shipping cost, so the captured amount matches the grand total.
+func (p *PaymentService) GetPaymentLink(ctx context.Context, order domain.Order) (string, error) {
+	linkParamsList := make([]*stripe.PaymentLinkLineItemParams, 0, len(order.OrderItems)+2)
+	for _, orderItem := range order.OrderItems {
+		name := orderItem.Name
+		if name == "" {
+			name = fmt.Sprintf("Product %s", orderItem.ProductID.Hex())
+		}
+
+		lineItem, err := oneOffLineItem(name, orderItem.Price, orderItem.Quantity)
+		if err != nil {
+			return "", err
+		}
+		linkParamsList = append(linkParamsList, lineItem)
+	}
+
+	charges := []struct {
+		name   string
+		amount domain.Money
+	}{
+		{"Tax", domain.ExclusiveTax(order.Tax)},
+		{"Shipping", order.ShippingCost},
+	}
+	for _, charge := range charges {
+		if !charge.amount.IsPositive() {
+			continue
+		}
+
+		lineItem, err := oneOffLineItem(charge.name, charge.amount, 1)
+		if err != nil {
+			return "", err
 		}
+		linkParamsList = append(linkParamsList, lineItem)
 	}
 
 	params := &stripe.PaymentLinkParams{
 		LineItems: linkParamsList,
 	}
+	params.AddMetadata(paymentOrderKey, order.ID.Hex())
+	params.Context = ctx
+
 	paymentLink, err := paymentlink.New(params)
 	if err != nil {
 		return "", err
@@ -33,8 +79,66 @@ func (p *PaymentService) GetPaymentLink(order domain.Order) (string, error) {
 	return paymentLink.URL, nil
 }
 
+// CapturedPayment verifies a Stripe webhook and reads the order payment it
+// reports. Events other than a paid checkout session are not captures and
+// return false.
+func (p *PaymentService) CapturedPayment(payload []byte, signature string) (dto.OrderPaymentDTO, bool, error) {
+	if p.webhookSecret == "" {
+		return dto.OrderPaymentDTO{}, false, fmt.Errorf("%w: webhook secret is not configured", ErrInvalidPaymentEvent)
+	}
+
+	event, err := webhook.ConstructEvent(payload, signature, p.webhookSecret)
+	if err != nil {
+		return dto.OrderPaymentDTO{}, false, fmt.Errorf("%w: %v", ErrInvalidPaymentEvent, err)
+	}
+
+	if event.Type != "checkout.session.completed" && event.Type != "checkout.session.async_payment_succeeded" {
+		return dto.OrderPaymentDTO{}, false, nil
+	}
+
+	var session stripe.CheckoutSession
+	err = json.Unmarshal(event.Data.Raw, &session)
+	if err != nil {
+		return dto.OrderPaymentDTO{}, false, fmt.Errorf("%w: %v", ErrInvalidPaymentEvent, err)
+	}
+
+	if session.PaymentStatus != stripe.CheckoutSessionPaymentStatusPaid || session.PaymentIntent == nil {
+		return dto.OrderPaymentDTO{}, false, nil
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(session.Metadata[paymentOrderKey])
+	if err != nil {
+		return dto.OrderPaymentDTO{}, false, fmt.Errorf("%w: session %s has no order", ErrInvalidPaymentEvent, session.ID)
+	}
+
+	return dto.OrderPaymentDTO{
+		OrderID:         orderID,
+		PaymentIntentID: session.PaymentIntent.ID,
+		Amount:          domain.NewMoney(session.AmountTotal, domain.Currency(strings.ToUpper(string(session.Currency)))),
+		PaidAt:          time.Unix(event.Created, 0),
+	}, true, nil
+}
+
+// Refund refunds the order payment, a zero amount refunds it in full.
+func (p *PaymentService) Refund(ctx context.Context, order domain.Order, amount domain.Money) (string, error) {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(order.PaymentIntentID),
+	}
+	if amount.IsPositive() {
+		params.Amount = stripe.Int64(amount.Amount)
+	}
+	params.Context = ctx
+
+	result, err := refund.New(params)
+	if err != nil {
+		return "", err
+	}
+
+	return result.ID, nil
+}
+
 func (p *PaymentService) GetProductPrice(productID primitive.ObjectID) *stripe.Price {
-	params := &stripe.PriceListParams{Product: stripe.String(productID.Hex())}
+	params := &stripe.PriceListParams{Product: stripe.String(productID.Hex()), Active: stripe.Bool(true)}
 	iterator := price.List(params)
 	iterator.Next()
 	return iterator.Price()
@@ -51,13 +155,7 @@ func (p *PaymentService) CreateProduct(domainProduct domain.Product) error {
 		return err
 	}
 
-	priceParams := &stripe.PriceParams{
-		Currency:          stripe.String(string(stripe.CurrencyRUB)),
-		Product:           stripe.String(stripeProduct.ID),
-		UnitAmountDecimal: stripe.Float64(domainProduct.Price * 100),
-	}
-
-	_, err = price.New(priceParams)
+	_, err = price.New(productPriceParams(stripeProduct.ID, domainProduct.Price))
 	if err != nil {
 		return err
 	}
@@ -66,18 +164,23 @@ func (p *PaymentService) CreateProduct(domainProduct domain.Product) error {
 }
 
 func (p *PaymentService) UpdateProduct(domainProduct domain.Product) error {
-	params := &stripe.PriceListParams{Product: stripe.String(domainProduct.ID.Hex())}
-	iterator := price.List(params)
-	iterator.Next()
-	productPrice := iterator.Price()
-	_, err := price.Update(productPrice.ID, &stripe.PriceParams{
-		UnitAmountDecimal: stripe.Float64(domainProduct.Price * 100),
-	})
-	if err != nil {
-		return err
+	// Stripe prices are immutable, a changed price replaces the active one.
+	productPrice := p.GetProductPrice(domainProduct.ID)
+	if productPrice == nil || productPrice.UnitAmount != domainProduct.Price.Amount || string(productPrice.Currency) != *stripeCurrency(domainProduct.Price) {
+		_, err := price.New(productPriceParams(domainProduct.ID.Hex(), domainProduct.Price))
+		if err != nil {
+			return err
+		}
+
+		if productPrice != nil {
+			_, err = price.Update(productPrice.ID, &stripe.PriceParams{Active: stripe.Bool(false)})
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	_, err = product.Update(domainProduct.ID.Hex(), &stripe.ProductParams{
+	_, err := product.Update(domainProduct.ID.Hex(), &stripe.ProductParams{
 		Name:        stripe.String(domainProduct.Name),
 		Description: stripe.String(domainProduct.Description),
 	})
@@ -90,6 +193,49 @@ func (p *PaymentService) DeleteProduct(productID primitive.ObjectID) error {
 	return err
 }
 
-func NewPaymentService() *PaymentService {
-	return &PaymentService{}
+// NewPaymentService configures the Stripe client with the secret key, an
+// empty key leaves payments unavailable.
+func NewPaymentService(secretKey string, webhookSecret string) *PaymentService {
+	if secretKey != "" {
+		stripe.Key = secretKey
+	}
+
+	return &PaymentService{
+		webhookSecret: webhookSecret,
+	}
+}
+
+func oneOffLineItem(name string, amount domain.Money, quantity int64) (*stripe.PaymentLinkLineItemParams, error) {
+	linePrice, err := price.New(&stripe.PriceParams{
+		Currency:    stripeCurrency(amount),
+		ProductData: &stripe.PriceProductDataParams{Name: stripe.String(name)},
+		UnitAmount:  stripe.Int64(amount.Amount),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &stripe.PaymentLinkLineItemParams{
+		Price:    stripe.String(linePrice.ID),
+		Quantity: stripe.Int64(quantity),
+	}, nil
+}
+
+func productPriceParams(productID string, amount domain.Money) *stripe.PriceParams {
+	return &stripe.PriceParams{
+		Currency:   stripeCurrency(amount),
+		Product:    stripe.String(productID),
+		UnitAmount: stripe.Int64(amount.Amount),
+	}
+}
+
+// stripeCurrency returns the lower case ISO code Stripe expects. Stripe counts
+// amounts in the same minor units as domain.Money.
+func stripeCurrency(amount domain.Money) *string {
+	currency := amount.Currency
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
+	return stripe.String(strings.ToLower(string(currency)))
 }
@@ -2,16 +2,23 @@ package service
 
 import (
 	"context"
+	"errors"
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
 	"github.com/sigit14ap/go-commerce/internal/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
 )
 
+const defaultProductLimit = 20
+
 type ProductsService struct {
 	repo              repository.Products
+	storesRepo        repository.Stores
 	reviewsService    Reviews
 	categoriesService Categories
+	audit             Audit
 }
 
 func (p *ProductsService) GetBySellerID(ctx context.Context, storeID primitive.ObjectID) ([]domain.Product, error) {
@@ -41,6 +48,70 @@ func (p *ProductsService) FindAll(ctx context.Context) ([]domain.Product, error)
 		return nil, err
 	}
 
+	return p.withDetails(ctx, products)
+}
+
+// FindListed lists the products shoppers can see, drafts and the products of
+// suspended and banned stores are left out.
+func (p *ProductsService) FindListed(ctx context.Context) ([]domain.Product, error) {
+	inactiveStoreIDs, err := p.storesRepo.FindInactiveIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := p.repo.FindListed(ctx, inactiveStoreIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.withDetails(ctx, products)
+}
+
+// FindStorePage pages through the published products of a store.
+func (p *ProductsService) FindStorePage(ctx context.Context, storeID primitive.ObjectID, input dto.ProductFilterInput) (domain.ProductPage, error) {
+	filter := dto.ProductFilter{
+		StoreID:  storeID,
+		Query:    input.Query,
+		MinPrice: input.MinPrice,
+		MaxPrice: input.MaxPrice,
+		Sort:     input.Sort,
+		Page:     input.Page,
+		Limit:    input.Limit,
+	}
+
+	// Invalid IDs were turned away by validation already.
+	if input.CategoryID != "" {
+		filter.CategoryID, _ = primitive.ObjectIDFromHex(input.CategoryID)
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = defaultProductLimit
+	}
+
+	products, total, err := p.repo.FindPage(ctx, filter)
+	if err != nil {
+		return domain.ProductPage{}, err
+	}
+
+	products, err = p.withDetails(ctx, products)
+	if err != nil {
+		return domain.ProductPage{}, err
+	}
+
+	return domain.ProductPage{
+		Products: products,
+		Total:    total,
+		Page:     filter.Page,
+		Limit:    filter.Limit,
+	}, nil
+}
+
+func (p *ProductsService) withDetails(ctx context.Context, products []domain.Product) ([]domain.Product, error) {
+	var err error
+
 	for i, product := range products {
 		products[i].TotalRating, err = p.reviewsService.GetTotalReviewRating(ctx, product.ID)
 		if err != nil {
@@ -72,6 +143,50 @@ func (p *ProductsService) FindByID(ctx context.Context, productID primitive.Obje
 	return product, err
 }
 
+// FindListedByID finds a product shoppers can see, a draft or a product of a
+// suspended or banned store is reported as not found.
+func (p *ProductsService) FindListedByID(ctx context.Context, productID primitive.ObjectID) (domain.Product, error) {
+	product, _, err := p.findListed(ctx, productID)
+	return product, err
+}
+
+// FindPurchasableByID finds a product shoppers can put in their cart and
+// order, products of a store on holiday are listed but cannot be bought.
+func (p *ProductsService) FindPurchasableByID(ctx context.Context, productID primitive.ObjectID) (domain.Product, error) {
+	product, store, err := p.findListed(ctx, productID)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	if store.OnHoliday(time.Now()) {
+		return domain.Product{}, storeHolidayError(store)
+	}
+
+	return product, nil
+}
+
+func (p *ProductsService) findListed(ctx context.Context, productID primitive.ObjectID) (domain.Product, domain.Store, error) {
+	product, err := p.FindByID(ctx, productID)
+	if err != nil {
+		return domain.Product{}, domain.Store{}, err
+	}
+
+	if product.Draft {
+		return domain.Product{}, domain.Store{}, mongo.ErrNoDocuments
+	}
+
+	store, err := p.storesRepo.FindByID(ctx, product.StoreID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.Product{}, domain.Store{}, err
+	}
+
+	if !domain.IsActive(store.Status) {
+		return domain.Product{}, domain.Store{}, mongo.ErrNoDocuments
+	}
+
+	return product, store, nil
+}
+
 func (p *ProductsService) Create(ctx context.Context, product dto.CreateProductDTO) (domain.Product, error) {
 
 	var images []domain.ProductImage
@@ -90,8 +205,16 @@ func (p *ProductsService) Create(ctx context.Context, product dto.CreateProductD
 		CategoryID:  product.CategoryID,
 		Images:      images,
 		Weight:      product.Weight,
+		Stock:       product.Stock,
+		Draft:       product.Draft,
 	})
 
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	p.audit.Track(ctx, domain.AuditActionProductCreate, domain.AuditTargetProduct, result.ID, nil, result)
+
 	result.Category, err = p.categoriesService.FindByID(ctx, product.CategoryID)
 	if err != nil {
 		return domain.Product{}, err
@@ -110,7 +233,17 @@ func (p *ProductsService) Update(ctx context.Context, productDTO dto.UpdateProdu
 		})
 	}
 
-	return p.repo.Update(ctx, domain.Product{
+	// A store can only change its own products.
+	before, err := p.repo.FindByID(ctx, productID)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	if before.StoreID != productDTO.StoreID {
+		return domain.Product{}, mongo.ErrNoDocuments
+	}
+
+	product, err := p.repo.Update(ctx, domain.Product{
 		StoreID:     productDTO.StoreID,
 		Name:        productDTO.Name,
 		Description: productDTO.Description,
@@ -119,20 +252,78 @@ func (p *ProductsService) Update(ctx context.Context, productDTO dto.UpdateProdu
 		Images:      images,
 		Weight:      productDTO.Weight,
 	}, productID)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	if productDTO.Stock != nil {
+		product, err = p.repo.SetStock(ctx, productID, productDTO.StoreID, *productDTO.Stock)
+		if err != nil {
+			return domain.Product{}, err
+		}
+	}
+
+	p.audit.Track(ctx, domain.AuditActionProductUpdate, domain.AuditTargetProduct, productID, before, product)
+
+	return product, nil
+}
+
+// Publish lists a draft product, only approved stores may publish.
+func (p *ProductsService) Publish(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID) (domain.Product, error) {
+	before, err := p.repo.FindByID(ctx, productID)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	if before.StoreID != storeID {
+		return domain.Product{}, mongo.ErrNoDocuments
+	}
+
+	product, err := p.repo.SetDraft(ctx, productID, storeID, false)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	p.audit.Track(ctx, domain.AuditActionProductPublish, domain.AuditTargetProduct, productID, before, product)
+
+	return product, nil
 }
 
 func (p *ProductsService) Delete(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID) error {
-	err := p.repo.Delete(ctx, productID, storeID)
+	before, err := p.repo.FindByID(ctx, productID)
 	if err != nil {
 		return err
 	}
+
+	if before.StoreID != storeID {
+		return mongo.ErrNoDocuments
+	}
+
+	err = p.repo.Delete(ctx, productID, storeID)
+	if err != nil {
+		return err
+	}
+
+	p.audit.Track(ctx, domain.AuditActionProductDelete, domain.AuditTargetProduct, productID, before, nil)
+
 	return p.reviewsService.DeleteByProductID(ctx, productID)
 }
 
-func NewProductsService(repo repository.Products, reviewsService Reviews, categoriesService Categories) *ProductsService {
+func (p *ProductsService) ReserveStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error {
+	return p.repo.ReserveStock(ctx, productID, quantity)
+}
+
+func (p *ProductsService) ReleaseStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error {
+	return p.repo.ReleaseStock(ctx, productID, quantity)
+}
+
+func NewProductsService(repo repository.Products, storesRepo repository.Stores, reviewsService Reviews,
+	categoriesService Categories, audit Audit) *ProductsService {
 	return &ProductsService{
 		repo:              repo,
+		storesRepo:        storesRepo,
 		reviewsService:    reviewsService,
 		categoriesService: categoriesService,
+		audit:             audit,
 	}
 }
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	productStatsPendingKey  = "productstats:pending"
+	productStatsFlushingKey = "productstats:flushing"
+	productStatsLockKey     = "productstats:flush:lock"
+
+	productStatsView    = "view"
+	productStatsCartAdd = "cart"
+)
+
+// releaseProductStatsLock deletes the flush lock only while it still holds
+// the token of the flush that took it, so a flush that outlived the lock TTL
+// does not release the lock of the instance that flushes after it.
+var releaseProductStatsLock = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// ProductStatsService counts product views and cart adds with a single
+// HINCRBY per event. Flush moves the counts to Mongo in one bulk write, the
+// pending hash is renamed first so events counted meanwhile wait for the
+// next flush.
+type ProductStatsService struct {
+	repo        repository.ProductStats
+	redisClient *redis.Client
+	location    *time.Location
+	lockTTL     time.Duration
+}
+
+func NewProductStatsService(repo repository.ProductStats, redisClient *redis.Client, timezone string,
+	lockTTL time.Duration) *ProductStatsService {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Warnf("unknown analytics timezone %q, product stats use UTC days", timezone)
+		location = time.UTC
+	}
+
+	return &ProductStatsService{
+		repo:        repo,
+		redisClient: redisClient,
+		location:    location,
+		lockTTL:     lockTTL,
+	}
+}
+
+func (p *ProductStatsService) RecordView(ctx context.Context, product domain.Product) error {
+	return p.record(productStatsView, product)
+}
+
+func (p *ProductStatsService) RecordCartAdd(ctx context.Context, product domain.Product) error {
+	return p.record(productStatsCartAdd, product)
+}
+
+func (p *ProductStatsService) record(event string, product domain.Product) error {
+	field := strings.Join([]string{
+		event,
+		product.ID.Hex(),
+		product.StoreID.Hex(),
+		time.Now().In(p.location).Format("2006-01-02"),
+	}, ":")
+
+	return p.redisClient.HIncrBy(productStatsPendingKey, field, 1).Err()
+}
+
+// Flush writes the pending counts to Mongo. Only one instance flushes at a
+// time, and a batch left behind by a failed flush is written before the next
+// one is taken.
+func (p *ProductStatsService) Flush(ctx context.Context) error {
+	token, err := randomToken(16)
+	if err != nil {
+		return err
+	}
+
+	locked, err := p.redisClient.SetNX(productStatsLockKey, token, p.lockTTL).Result()
+	if err != nil || !locked {
+		return err
+	}
+	defer releaseProductStatsLock.Run(p.redisClient, []string{productStatsLockKey}, token)
+
+	if err = p.flushBatch(ctx); err != nil {
+		return err
+	}
+
+	err = p.redisClient.Rename(productStatsPendingKey, productStatsFlushingKey).Err()
+	if err != nil {
+		// nothing was counted since the last flush
+		if strings.Contains(err.Error(), "no such key") {
+			return nil
+		}
+		return err
+	}
+
+	return p.flushBatch(ctx)
+}
+
+func (p *ProductStatsService) flushBatch(ctx context.Context) error {
+	fields, err := p.redisClient.HGetAll(productStatsFlushingKey).Result()
+	if err != nil || len(fields) == 0 {
+		return err
+	}
+
+	counters, err := productCounters(fields)
+	if err != nil {
+		return err
+	}
+
+	if err = p.repo.Increment(ctx, counters); err != nil {
+		return err
+	}
+
+	return p.redisClient.Del(productStatsFlushingKey).Err()
+}
+
+// productCounters turns the hash fields event:productID:storeID:date into one
+// counter per product and day.
+func productCounters(fields map[string]string) ([]domain.ProductCounter, error) {
+	counters := []domain.ProductCounter{}
+	index := map[string]int{}
+
+	for field, value := range fields {
+		parts := strings.Split(field, ":")
+		if len(parts) != 4 {
+			log.Warnf("product stats: skipping malformed field %q", field)
+			continue
+		}
+
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("product stats field %s: %w", field, err)
+		}
+
+		productID, err := primitive.ObjectIDFromHex(parts[1])
+		if err != nil {
+			log.Warnf("product stats: skipping malformed field %q", field)
+			continue
+		}
+
+		storeID, _ := primitive.ObjectIDFromHex(parts[2])
+
+		key := parts[1] + ":" + parts[3]
+		i, ok := index[key]
+		if !ok {
+			i = len(counters)
+			index[key] = i
+			counters = append(counters, domain.ProductCounter{ProductID: productID, StoreID: storeID, Date: parts[3]})
+		}
+
+		switch parts[0] {
+		case productStatsView:
+			counters[i].Views += count
+		case productStatsCartAdd:
+			counters[i].CartAdds += count
+		}
+	}
+
+	return counters, nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestProductCounters(t *testing.T) {
+	productID := primitive.NewObjectID()
+	storeID := primitive.NewObjectID()
+	prefix := productID.Hex() + ":" + storeID.Hex() + ":"
+
+	counters, err := productCounters(map[string]string{
+		"view:" + prefix + "2022-03-01": "12",
+		"cart:" + prefix + "2022-03-01": "3",
+		"view:" + prefix + "2022-03-02": "5",
+		"view:malformed":                "1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(counters) != 2 {
+		t.Fatalf("counters = %+v", counters)
+	}
+
+	for _, counter := range counters {
+		if counter.ProductID != productID || counter.StoreID != storeID {
+			t.Errorf("counter ids = %+v", counter)
+		}
+
+		switch counter.Date {
+		case "2022-03-01":
+			if counter.Views != 12 || counter.CartAdds != 3 {
+				t.Errorf("2022-03-01 = %+v", counter)
+			}
+		case "2022-03-02":
+			if counter.Views != 5 || counter.CartAdds != 0 {
+				t.Errorf("2022-03-02 = %+v", counter)
+			}
+		default:
+			t.Errorf("unexpected date %q", counter.Date)
+		}
+	}
+
+	if _, err = productCounters(map[string]string{"view:" + prefix + "2022-03-01": "x"}); err == nil {
+		t.Error("expected an error for a count that is not a number")
+	}
+}
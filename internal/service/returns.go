@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrReturnNotFound   = errors.New("return request not found")
+	ErrReturnNotAllowed = errors.New("return request not allowed")
+)
+
+type ReturnsService struct {
+	repo           repository.Returns
+	ordersService  Orders
+	productService Products
+	paymentService Payment
+	returnWindow   time.Duration
+}
+
+func NewReturnsService(repo repository.Returns, ordersService Orders, productService Products, paymentService Payment, returnWindow time.Duration) *ReturnsService {
+	return &ReturnsService{
+		repo:           repo,
+		ordersService:  ordersService,
+		productService: productService,
+		paymentService: paymentService,
+		returnWindow:   returnWindow,
+	}
+}
+
+func (r *ReturnsService) FindByID(ctx context.Context, returnID primitive.ObjectID) (domain.ReturnRequest, error) {
+	return r.repo.FindByID(ctx, returnID)
+}
+
+func (r *ReturnsService) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]domain.ReturnRequest, error) {
+	return r.repo.FindByUserID(ctx, userID)
+}
+
+func (r *ReturnsService) FindByStoreID(ctx context.Context, storeID primitive.ObjectID) ([]domain.ReturnRequest, error) {
+	return r.repo.FindByStoreID(ctx, storeID)
+}
+
+func (r *ReturnsService) Create(ctx context.Context, returnDTO dto.CreateReturnDTO) (domain.ReturnRequest, error) {
+	order, err := r.ordersService.FindByID(ctx, returnDTO.OrderID)
+	if err != nil || order.UserID != returnDTO.UserID {
+		return domain.ReturnRequest{}, ErrOrderNotFound
+	}
+
+	if order.Status != domain.OrderStatusDelivered || order.DeliveredAt.IsZero() {
+		return domain.ReturnRequest{}, fmt.Errorf("%w: order has not been delivered", ErrReturnNotAllowed)
+	}
+
+	if time.Since(order.DeliveredAt) > r.returnWindow {
+		return domain.ReturnRequest{}, fmt.Errorf("%w: return window has closed", ErrReturnNotAllowed)
+	}
+
+	orderItem, ok := findOrderItem(order, returnDTO.ProductID)
+	if !ok {
+		return domain.ReturnRequest{}, fmt.Errorf("%w: product is not part of the order", ErrReturnNotAllowed)
+	}
+
+	existing, err := r.repo.FindByOrderID(ctx, order.ID)
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	returnedQuantity := returnDTO.Quantity
+	for _, returnRequest := range existing {
+		if returnRequest.ProductID == returnDTO.ProductID && returnRequest.Status != domain.ReturnStatusRejected {
+			returnedQuantity += returnRequest.Quantity
+		}
+	}
+
+	if returnedQuantity > orderItem.Quantity {
+		return domain.ReturnRequest{}, fmt.Errorf("%w: quantity exceeds ordered quantity", ErrReturnNotAllowed)
+	}
+
+	// Counting the return on the order first keeps the order from completing
+	// while the return is created.
+	err = r.ordersService.OpenReturn(ctx, order.ID)
+	if errors.Is(err, ErrOrderInvalidStatus) {
+		return domain.ReturnRequest{}, fmt.Errorf("%w: order has not been delivered", ErrReturnNotAllowed)
+	}
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	now := time.Now()
+	returnRequest, err := r.repo.Create(ctx, domain.ReturnRequest{
+		OrderID:   order.ID,
+		UserID:    returnDTO.UserID,
+		StoreID:   orderItem.StoreID,
+		ProductID: returnDTO.ProductID,
+		Quantity:  returnDTO.Quantity,
+		Reason:    returnDTO.Reason,
+		Photos:    returnDTO.Photos,
+		Status:    domain.ReturnStatusRequested,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		if closeErr := r.ordersService.CloseReturn(ctx, order.ID); closeErr != nil {
+			log.Errorf("failed to close the return of order %s: %v", order.ID.Hex(), closeErr)
+		}
+		return domain.ReturnRequest{}, err
+	}
+
+	err = r.addOrderEvent(ctx, returnRequest, domain.OrderEventReturnRequested, domain.OrderActorUser, returnDTO.UserID, returnDTO.Reason)
+
+	return returnRequest, err
+}
+
+func (r *ReturnsService) Approve(ctx context.Context, returnID primitive.ObjectID, storeID primitive.ObjectID, note string) (domain.ReturnRequest, error) {
+	return r.review(ctx, returnID, storeID, note, domain.ReturnStatusApproved, domain.OrderEventReturnApproved)
+}
+
+func (r *ReturnsService) Reject(ctx context.Context, returnID primitive.ObjectID, storeID primitive.ObjectID, note string) (domain.ReturnRequest, error) {
+	return r.review(ctx, returnID, storeID, note, domain.ReturnStatusRejected, domain.OrderEventReturnRejected)
+}
+
+func (r *ReturnsService) review(ctx context.Context, returnID primitive.ObjectID, storeID primitive.ObjectID, note string, status string, eventType string) (domain.ReturnRequest, error) {
+	returnRequest, err := r.repo.FindByID(ctx, returnID)
+	if err != nil || returnRequest.StoreID != storeID {
+		return domain.ReturnRequest{}, ErrReturnNotFound
+	}
+
+	returnRequest, err = r.transition(ctx, returnID, []string{domain.ReturnStatusRequested}, dto.ReturnTransitionInput{
+		Status:    status,
+		StoreNote: note,
+	})
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	if status == domain.ReturnStatusRejected {
+		err = r.ordersService.CloseReturn(ctx, returnRequest.OrderID)
+		if err != nil {
+			return domain.ReturnRequest{}, err
+		}
+	}
+
+	err = r.addOrderEvent(ctx, returnRequest, eventType, domain.OrderActorStore, storeID, note)
+
+	return returnRequest, err
+}
+
+func (r *ReturnsService) Ship(ctx context.Context, returnID primitive.ObjectID, userID primitive.ObjectID, shipInput dto.ShipReturnInput) (domain.ReturnRequest, error) {
+	returnRequest, err := r.repo.FindByID(ctx, returnID)
+	if err != nil || returnRequest.UserID != userID {
+		return domain.ReturnRequest{}, ErrReturnNotFound
+	}
+
+	returnRequest, err = r.transition(ctx, returnID, []string{domain.ReturnStatusApproved}, dto.ReturnTransitionInput{
+		Status: domain.ReturnStatusShipped,
+		Shipment: &domain.ReturnShipment{
+			Courier:        shipInput.Courier,
+			TrackingNumber: shipInput.TrackingNumber,
+			ShippedAt:      time.Now(),
+		},
+	})
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	note := fmt.Sprintf("%s %s", shipInput.Courier, shipInput.TrackingNumber)
+	err = r.addOrderEvent(ctx, returnRequest, domain.OrderEventReturnShipped, domain.OrderActorUser, userID, note)
+
+	return returnRequest, err
+}
+
+// Receive marks the returned goods as received by the store, puts them back in
+// stock and refunds the buyer, a zero refund amount refunds the full value of
+// the returned items. Orders without a captured payment can't be refunded
+// through the payment provider, their refund is left pending for a manual payout.
+// A refund the provider fails leaves the return received for Refund to retry.
+func (r *ReturnsService) Receive(ctx context.Context, returnID primitive.ObjectID, storeID primitive.ObjectID, refundAmount domain.Money) (domain.ReturnRequest, error) {
+	returnRequest, err := r.repo.FindByID(ctx, returnID)
+	if err != nil || returnRequest.StoreID != storeID {
+		return domain.ReturnRequest{}, ErrReturnNotFound
+	}
+
+	order, err := r.ordersService.FindByID(ctx, returnRequest.OrderID)
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	orderItem, _ := findOrderItem(order, returnRequest.ProductID)
+	itemsValue := orderItem.Price.Mul(returnRequest.Quantity)
+
+	if refundAmount.IsZero() {
+		refundAmount = itemsValue
+	}
+
+	if refundAmount.IsNegative() || refundAmount.Currency != itemsValue.Currency {
+		return domain.ReturnRequest{}, fmt.Errorf("%w: invalid refund amount", ErrReturnNotAllowed)
+	}
+
+	if refundAmount.Cmp(itemsValue) > 0 {
+		return domain.ReturnRequest{}, fmt.Errorf("%w: refund exceeds value of returned items", ErrReturnNotAllowed)
+	}
+
+	// The refund amount is kept with the received return, so a refund that
+	// failed is retried with the amount the store chose.
+	returnRequest, err = r.transition(ctx, returnID, []string{domain.ReturnStatusShipped}, dto.ReturnTransitionInput{
+		Status:       domain.ReturnStatusReceived,
+		RefundAmount: refundAmount,
+	})
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	err = r.addOrderEvent(ctx, returnRequest, domain.OrderEventReturnReceived, domain.OrderActorStore, storeID, "")
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	return r.refund(ctx, returnRequest, order, storeID)
+}
+
+// Refund retries the refund of a received return whose refund failed.
+func (r *ReturnsService) Refund(ctx context.Context, returnID primitive.ObjectID, storeID primitive.ObjectID) (domain.ReturnRequest, error) {
+	returnRequest, err := r.repo.FindByID(ctx, returnID)
+	if err != nil || returnRequest.StoreID != storeID {
+		return domain.ReturnRequest{}, ErrReturnNotFound
+	}
+
+	if returnRequest.Status != domain.ReturnStatusReceived {
+		return domain.ReturnRequest{}, fmt.Errorf("%w: return is not awaiting a refund", ErrReturnNotAllowed)
+	}
+
+	order, err := r.ordersService.FindByID(ctx, returnRequest.OrderID)
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	return r.refund(ctx, returnRequest, order, storeID)
+}
+
+// refund refunds a received return and restocks its items. Orders without a
+// captured payment leave the refund to be made manually.
+func (r *ReturnsService) refund(ctx context.Context, returnRequest domain.ReturnRequest, order domain.Order, storeID primitive.ObjectID) (domain.ReturnRequest, error) {
+	refundAmount := returnRequest.RefundAmount
+
+	if order.PaymentIntentID == "" {
+		pending, err := r.transition(ctx, returnRequest.ID, []string{domain.ReturnStatusReceived}, dto.ReturnTransitionInput{
+			Status:       domain.ReturnStatusRefundPending,
+			RefundAmount: refundAmount,
+		})
+		if err != nil {
+			return domain.ReturnRequest{}, err
+		}
+
+		err = r.ordersService.CloseReturn(ctx, pending.OrderID)
+		if err != nil {
+			return domain.ReturnRequest{}, err
+		}
+
+		note := fmt.Sprintf("%s to be refunded manually", refundAmount)
+		err = r.addOrderEvent(ctx, pending, domain.OrderEventReturnRefunded, domain.OrderActorStore, storeID, note)
+		if err != nil {
+			return domain.ReturnRequest{}, err
+		}
+
+		return pending, r.restock(ctx, pending)
+	}
+
+	refundID, err := r.paymentService.Refund(ctx, order, refundAmount)
+	if err != nil {
+		return returnRequest, fmt.Errorf("return received but refund failed, retry the refund: %w", err)
+	}
+
+	returnRequest, err = r.transition(ctx, returnRequest.ID, []string{domain.ReturnStatusReceived}, dto.ReturnTransitionInput{
+		Status:       domain.ReturnStatusRefunded,
+		RefundAmount: refundAmount,
+		RefundID:     refundID,
+	})
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	err = r.ordersService.CloseReturn(ctx, returnRequest.OrderID)
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	note := refundAmount.String()
+	err = r.addOrderEvent(ctx, returnRequest, domain.OrderEventReturnRefunded, domain.OrderActorStore, storeID, note)
+	if err != nil {
+		return domain.ReturnRequest{}, err
+	}
+
+	return returnRequest, r.restock(ctx, returnRequest)
+}
+
+func (r *ReturnsService) restock(ctx context.Context, returnRequest domain.ReturnRequest) error {
+	err := r.productService.ReleaseStock(ctx, returnRequest.ProductID, returnRequest.Quantity)
+	if err != nil {
+		return fmt.Errorf("return refunded but restock failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ReturnsService) transition(ctx context.Context, returnID primitive.ObjectID, fromStatuses []string, transition dto.ReturnTransitionInput) (domain.ReturnRequest, error) {
+	transition.UpdatedAt = time.Now()
+
+	returnRequest, err := r.repo.Transition(ctx, returnID, fromStatuses, transition)
+	if errors.Is(err, repository.ErrReturnStatusConflict) {
+		return domain.ReturnRequest{}, fmt.Errorf("%w: return request is not in the expected state", ErrReturnNotAllowed)
+	}
+
+	return returnRequest, err
+}
+
+func (r *ReturnsService) addOrderEvent(ctx context.Context, returnRequest domain.ReturnRequest, eventType string, actor string, actorID primitive.ObjectID, note string) error {
+	return r.ordersService.AddEvent(ctx, returnRequest.OrderID, domain.OrderEvent{
+		Type:      eventType,
+		Actor:     actor,
+		ActorID:   actorID,
+		Note:      note,
+		CreatedAt: time.Now(),
+	})
+}
+
+func findOrderItem(order domain.Order, productID primitive.ObjectID) (domain.OrderItem, bool) {
+	for _, orderItem := range order.OrderItems {
+		if orderItem.ProductID == productID {
+			return orderItem, true
+		}
+	}
+
+	return domain.OrderItem{}, false
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryReturnRequests only implements the lookups and updates the tests use.
+type memoryReturnRequests struct {
+	repository.Returns
+	returns map[primitive.ObjectID]domain.ReturnRequest
+}
+
+func (r *memoryReturnRequests) FindByID(ctx context.Context, returnID primitive.ObjectID) (domain.ReturnRequest, error) {
+	returnRequest, ok := r.returns[returnID]
+	if !ok {
+		return domain.ReturnRequest{}, mongo.ErrNoDocuments
+	}
+
+	return returnRequest, nil
+}
+
+func (r *memoryReturnRequests) FindByOrderID(ctx context.Context, orderID primitive.ObjectID) ([]domain.ReturnRequest, error) {
+	var returns []domain.ReturnRequest
+	for _, returnRequest := range r.returns {
+		if returnRequest.OrderID == orderID {
+			returns = append(returns, returnRequest)
+		}
+	}
+
+	return returns, nil
+}
+
+func (r *memoryReturnRequests) Create(ctx context.Context, returnRequest domain.ReturnRequest) (domain.ReturnRequest, error) {
+	returnRequest.ID = primitive.NewObjectID()
+	r.returns[returnRequest.ID] = returnRequest
+	return returnRequest, nil
+}
+
+func (r *memoryReturnRequests) Transition(ctx context.Context, returnID primitive.ObjectID, fromStatuses []string, transition dto.ReturnTransitionInput) (domain.ReturnRequest, error) {
+	returnRequest, ok := r.returns[returnID]
+	if !ok || !containsStatus(fromStatuses, returnRequest.Status) {
+		return domain.ReturnRequest{}, repository.ErrReturnStatusConflict
+	}
+
+	returnRequest.Status = transition.Status
+	if !transition.RefundAmount.IsZero() {
+		returnRequest.RefundAmount = transition.RefundAmount
+		returnRequest.RefundID = transition.RefundID
+	}
+
+	r.returns[returnID] = returnRequest
+	return returnRequest, nil
+}
+
+// memoryOrderLookup serves orders to the returns service.
+type memoryOrderLookup struct {
+	Orders
+	repo *memoryOrders
+}
+
+func (o *memoryOrderLookup) FindByID(ctx context.Context, orderID primitive.ObjectID) (domain.Order, error) {
+	return o.repo.FindByID(ctx, orderID)
+}
+
+func (o *memoryOrderLookup) AddEvent(ctx context.Context, orderID primitive.ObjectID, event domain.OrderEvent) error {
+	return o.repo.AddEvent(ctx, orderID, event)
+}
+
+func (o *memoryOrderLookup) OpenReturn(ctx context.Context, orderID primitive.ObjectID) error {
+	err := o.repo.OpenReturn(ctx, orderID)
+	if errors.Is(err, repository.ErrOrderStatusConflict) {
+		return ErrOrderInvalidStatus
+	}
+
+	return err
+}
+
+func (o *memoryOrderLookup) CloseReturn(ctx context.Context, orderID primitive.ObjectID) error {
+	return o.repo.CloseReturn(ctx, orderID)
+}
+
+func shippedReturn(order domain.Order) domain.ReturnRequest {
+	orderItem := order.OrderItems[0]
+
+	return domain.ReturnRequest{
+		ID:        primitive.NewObjectID(),
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		StoreID:   order.StoreID,
+		ProductID: orderItem.ProductID,
+		Quantity:  orderItem.Quantity,
+		Status:    domain.ReturnStatusShipped,
+	}
+}
+
+func newTestReturns(order domain.Order, returnRequest domain.ReturnRequest) (*ReturnsService, *memoryReturnRequests, *memoryPayment, *memoryStock) {
+	_, orders, payment, stock := newTestOrders(order)
+	repo := &memoryReturnRequests{returns: map[primitive.ObjectID]domain.ReturnRequest{returnRequest.ID: returnRequest}}
+
+	returns := NewReturnsService(repo, &memoryOrderLookup{repo: orders}, stock, payment, 7*24*time.Hour)
+	return returns, repo, payment, stock
+}
+
+func TestReceiveRefundsCapturedPayment(t *testing.T) {
+	order := reservedOrder()
+	order.Status = domain.OrderStatusDelivered
+	order.PaymentIntentID = "pi_test"
+	returnRequest := shippedReturn(order)
+	returns, _, payment, stock := newTestReturns(order, returnRequest)
+
+	received, err := returns.Receive(context.Background(), returnRequest.ID, order.StoreID, domain.Money{})
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if received.Status != domain.ReturnStatusRefunded || received.RefundAmount != idr(100000) {
+		t.Errorf("return = %s %v, want refunded %v", received.Status, received.RefundAmount, idr(100000))
+	}
+	if len(payment.refunds) != 1 || payment.refunds[0].amount != idr(100000) {
+		t.Errorf("refunds = %v, want one refund of %v", payment.refunds, idr(100000))
+	}
+	if stock.released[returnRequest.ProductID] != returnRequest.Quantity {
+		t.Errorf("restocked %d, want %d", stock.released[returnRequest.ProductID], returnRequest.Quantity)
+	}
+}
+
+func TestReceiveWithoutPaymentLeavesRefundPending(t *testing.T) {
+	order := reservedOrder()
+	order.Status = domain.OrderStatusDelivered
+	returnRequest := shippedReturn(order)
+	returns, _, payment, stock := newTestReturns(order, returnRequest)
+
+	received, err := returns.Receive(context.Background(), returnRequest.ID, order.StoreID, idr(40000))
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if received.Status != domain.ReturnStatusRefundPending || received.RefundAmount != idr(40000) {
+		t.Errorf("return = %s %v, want refund pending of %v", received.Status, received.RefundAmount, idr(40000))
+	}
+	if len(payment.refunds) != 0 {
+		t.Errorf("refunds = %v, want none without a captured payment", payment.refunds)
+	}
+	if stock.released[returnRequest.ProductID] != returnRequest.Quantity {
+		t.Errorf("restocked %d, want %d", stock.released[returnRequest.ProductID], returnRequest.Quantity)
+	}
+	if containsStatus(openReturnStatuses, received.Status) {
+		t.Errorf("status %s still disputes the order", received.Status)
+	}
+}
+
+func TestRefundRetriesFailedReturnRefund(t *testing.T) {
+	order := reservedOrder()
+	order.Status = domain.OrderStatusDelivered
+	order.PaymentIntentID = "pi_test"
+	returnRequest := shippedReturn(order)
+	returns, repo, payment, stock := newTestReturns(order, returnRequest)
+
+	payment.failRefunds = 1
+	_, err := returns.Receive(context.Background(), returnRequest.ID, order.StoreID, idr(40000))
+	if err == nil {
+		t.Fatal("Receive succeeded, want the refund failure")
+	}
+	if received := repo.returns[returnRequest.ID]; received.Status != domain.ReturnStatusReceived || received.RefundAmount != idr(40000) {
+		t.Fatalf("return = %s %v, want received with the refund amount kept", received.Status, received.RefundAmount)
+	}
+	if stock.released[returnRequest.ProductID] != 0 {
+		t.Errorf("restocked %d before the refund", stock.released[returnRequest.ProductID])
+	}
+
+	_, err = returns.Refund(context.Background(), returnRequest.ID, primitive.NewObjectID())
+	if !errors.Is(err, ErrReturnNotFound) {
+		t.Errorf("Refund by another store err = %v, want %v", err, ErrReturnNotFound)
+	}
+
+	refunded, err := returns.Refund(context.Background(), returnRequest.ID, order.StoreID)
+	if err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if refunded.Status != domain.ReturnStatusRefunded || refunded.RefundAmount != idr(40000) {
+		t.Errorf("return = %s %v, want refunded %v", refunded.Status, refunded.RefundAmount, idr(40000))
+	}
+	if len(payment.refunds) != 1 || payment.refunds[0].amount != idr(40000) {
+		t.Errorf("refunds = %v, want one refund of %v", payment.refunds, idr(40000))
+	}
+	if stock.released[returnRequest.ProductID] != returnRequest.Quantity {
+		t.Errorf("restocked %d, want %d", stock.released[returnRequest.ProductID], returnRequest.Quantity)
+	}
+
+	_, err = returns.Refund(context.Background(), returnRequest.ID, order.StoreID)
+	if !errors.Is(err, ErrReturnNotAllowed) {
+		t.Errorf("second Refund err = %v, want %v", err, ErrReturnNotAllowed)
+	}
+}
+
+func TestOpenReturnKeepsOrderFromCompleting(t *testing.T) {
+	order := reservedOrder()
+	order.Status = domain.OrderStatusDelivered
+	order.DeliveredAt = time.Now()
+	returns, repo, _, _ := newTestReturns(order, domain.ReturnRequest{ID: primitive.NewObjectID()})
+	orderRepo := returns.ordersService.(*memoryOrderLookup).repo
+	orders := &OrdersService{repo: orderRepo, returnsRepo: repo}
+
+	returnRequest, err := returns.Create(context.Background(), dto.CreateReturnDTO{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		ProductID: order.OrderItems[0].ProductID,
+		Quantity:  1,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// A return created after Complete looked for open returns still stops the
+	// order from completing.
+	delete(repo.returns, returnRequest.ID)
+	_, err = orders.Complete(context.Background(), order.ID, domain.OrderActorSystem, primitive.NilObjectID)
+	if !errors.Is(err, ErrOrderDisputed) {
+		t.Fatalf("Complete err = %v, want %v", err, ErrOrderDisputed)
+	}
+	if status := orderRepo.orders[order.ID].Status; status != domain.OrderStatusDelivered {
+		t.Fatalf("status = %s, want delivered", status)
+	}
+
+	repo.returns[returnRequest.ID] = returnRequest
+	_, err = returns.Reject(context.Background(), returnRequest.ID, returnRequest.StoreID, "")
+	if err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+	if open := orderRepo.orders[order.ID].OpenReturns; open != 0 {
+		t.Fatalf("open returns = %d after rejecting, want 0", open)
+	}
+}
+
+func TestCreateReturnRejectsCompletedOrder(t *testing.T) {
+	order := reservedOrder()
+	order.Status = domain.OrderStatusDelivered
+	order.DeliveredAt = time.Now()
+	returns, repo, _, _ := newTestReturns(order, domain.ReturnRequest{ID: primitive.NewObjectID()})
+	orderRepo := returns.ordersService.(*memoryOrderLookup).repo
+
+	// The order completes between the lookup of Create and the return being
+	// counted on it.
+	returns.ordersService = &completingOrderLookup{memoryOrderLookup: returns.ordersService.(*memoryOrderLookup)}
+
+	_, err := returns.Create(context.Background(), dto.CreateReturnDTO{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		ProductID: order.OrderItems[0].ProductID,
+		Quantity:  1,
+	})
+	if !errors.Is(err, ErrReturnNotAllowed) {
+		t.Fatalf("Create err = %v, want %v", err, ErrReturnNotAllowed)
+	}
+	if len(repo.returns) != 1 || orderRepo.orders[order.ID].OpenReturns != 0 {
+		t.Errorf("returns = %d, open returns = %d, want no return created", len(repo.returns), orderRepo.orders[order.ID].OpenReturns)
+	}
+}
+
+// completingOrderLookup completes the order right after Create looked it up.
+type completingOrderLookup struct {
+	*memoryOrderLookup
+}
+
+func (o *completingOrderLookup) FindByID(ctx context.Context, orderID primitive.ObjectID) (domain.Order, error) {
+	order, err := o.memoryOrderLookup.FindByID(ctx, orderID)
+	if err == nil {
+		completed := order
+		completed.Status = domain.OrderStatusCompleted
+		o.repo.orders[orderID] = completed
+	}
+
+	return order, err
+}
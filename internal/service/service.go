@@ -2,11 +2,18 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-redis/redis/v7"
+	"github.com/sigit14ap/go-commerce/internal/config"
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
 	"github.com/sigit14ap/go-commerce/internal/repository"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
+	"github.com/sigit14ap/go-commerce/pkg/courier"
+	"github.com/sigit14ap/go-commerce/pkg/mailer"
+	"github.com/sigit14ap/go-commerce/pkg/oidc"
+	"github.com/sigit14ap/go-commerce/pkg/storage"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -20,16 +27,39 @@ type Users interface {
 		userID primitive.ObjectID) (domain.User, error)
 	Delete(ctx context.Context, userID primitive.ObjectID) error
 	CheckPasswordHash(password, hash string) bool
+	ResendVerification(ctx context.Context, email string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, password string) (primitive.ObjectID, error)
+	UpdateStatus(ctx context.Context, userID primitive.ObjectID, input dto.UpdateStatusInput) (domain.User, error)
+	CheckStatus(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type Account interface {
+	UpdateProfile(ctx context.Context, userID primitive.ObjectID, input dto.UpdateProfileInput) (domain.User, error)
+	UpdateAvatar(ctx context.Context, userID primitive.ObjectID, avatarURL string) (domain.User, error)
+	RequestEmailChange(ctx context.Context, userID primitive.ObjectID, input dto.ChangeEmailInput) error
+	ConfirmEmailChange(ctx context.Context, token string) (primitive.ObjectID, error)
+	ChangePassword(ctx context.Context, userID primitive.ObjectID, input dto.ChangePasswordInput) error
+	Export(ctx context.Context, userID primitive.ObjectID) (domain.AccountExport, error)
+	Delete(ctx context.Context, userID primitive.ObjectID, password string) error
 }
 
 type Products interface {
 	GetBySellerID(ctx context.Context, storeID primitive.ObjectID) ([]domain.Product, error)
 	FindAll(ctx context.Context) ([]domain.Product, error)
+	FindListed(ctx context.Context) ([]domain.Product, error)
 	FindByID(ctx context.Context, productID primitive.ObjectID) (domain.Product, error)
+	FindListedByID(ctx context.Context, productID primitive.ObjectID) (domain.Product, error)
+	FindPurchasableByID(ctx context.Context, productID primitive.ObjectID) (domain.Product, error)
+	FindStorePage(ctx context.Context, storeID primitive.ObjectID, input dto.ProductFilterInput) (domain.ProductPage, error)
 	Create(ctx context.Context, productDTO dto.CreateProductDTO) (domain.Product, error)
 	Update(ctx context.Context, productDTO dto.UpdateProductDTO,
 		productID primitive.ObjectID) (domain.Product, error)
+	Publish(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID) (domain.Product, error)
 	Delete(ctx context.Context, productID primitive.ObjectID, storeID primitive.ObjectID) error
+	ReserveStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error
+	ReleaseStock(ctx context.Context, productID primitive.ObjectID, quantity int64) error
 }
 
 type Reviews interface {
@@ -44,7 +74,14 @@ type Reviews interface {
 }
 
 type Admins interface {
+	FindAll(ctx context.Context) ([]domain.Admin, error)
+	FindByID(ctx context.Context, adminID primitive.ObjectID) (domain.Admin, error)
+	FindByEmail(ctx context.Context, email string) (domain.Admin, error)
 	FindByCredentials(ctx context.Context, signInDTO dto.SignInDTO) (domain.Admin, error)
+	Create(ctx context.Context, adminInput dto.CreateAdminInput) (domain.Admin, error)
+	Update(ctx context.Context, adminID primitive.ObjectID, adminInput dto.UpdateAdminInput) (domain.Admin, error)
+	ResetPassword(ctx context.Context, adminID primitive.ObjectID, password string) error
+	Delete(ctx context.Context, adminID primitive.ObjectID) error
 	CheckPasswordHash(password, hash string) bool
 }
 
@@ -67,14 +104,36 @@ type Orders interface {
 	FindAll(ctx context.Context) ([]domain.Order, error)
 	FindByID(ctx context.Context, orderID primitive.ObjectID) (domain.Order, error)
 	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]domain.Order, error)
-	Create(ctx context.Context, orderDTO dto.CreateOrderDTO) (domain.Order, error)
+	FindByStoreID(ctx context.Context, storeID primitive.ObjectID, filter dto.StoreOrderFilter) ([]domain.Order, error)
+	FindByStore(ctx context.Context, orderID primitive.ObjectID, storeID primitive.ObjectID) (domain.Order, error)
+	FindByStatus(ctx context.Context, status string) ([]domain.Order, error)
+	FindTracking(ctx context.Context, orderID primitive.ObjectID, userID primitive.ObjectID) (domain.OrderTracking, error)
+	FindCheckout(ctx context.Context, checkoutID string, userID primitive.ObjectID) (domain.Checkout, error)
+	Create(ctx context.Context, orderDTO dto.CreateOrderDTO) (domain.Checkout, error)
 	Update(ctx context.Context, orderDTO dto.UpdateOrderDTO,
 		orderID primitive.ObjectID) (domain.Order, error)
+	Cancel(ctx context.Context, orderID primitive.ObjectID, cancelDTO dto.CancelOrderDTO) (domain.Order, error)
+	CancelCheckout(ctx context.Context, checkoutID string, cancelDTO dto.CancelOrderDTO) (domain.Checkout, error)
+	RefundCancelled(ctx context.Context, order domain.Order) (domain.Order, error)
+	FindRefundPending(ctx context.Context) ([]domain.Order, error)
+	Capture(ctx context.Context, payment dto.OrderPaymentDTO) (domain.Order, error)
+	Accept(ctx context.Context, orderID primitive.ObjectID, storeID primitive.ObjectID) (domain.Order, error)
+	Ship(ctx context.Context, orderID primitive.ObjectID, storeID primitive.ObjectID, shipInput dto.ShipOrderInput) (domain.Order, error)
+	PackingSlip(ctx context.Context, orderID primitive.ObjectID, storeID primitive.ObjectID) (domain.PackingSlip, error)
+	UpdateTracking(ctx context.Context, order domain.Order, waybill dto.ThirdPartyWaybillDTO) (domain.Order, error)
+	Complete(ctx context.Context, orderID primitive.ObjectID, actor string, actorID primitive.ObjectID) (domain.Order, error)
+	Settle(ctx context.Context, order domain.Order) error
+	FindUnsettled(ctx context.Context) ([]domain.Order, error)
+	AddEvent(ctx context.Context, orderID primitive.ObjectID, event domain.OrderEvent) error
+	OpenReturn(ctx context.Context, orderID primitive.ObjectID) error
+	CloseReturn(ctx context.Context, orderID primitive.ObjectID) error
 	Delete(ctx context.Context, orderID primitive.ObjectID) error
 }
 
 type Payment interface {
-	GetPaymentLink(ctx context.Context, orderID primitive.ObjectID) (string, error)
+	GetPaymentLink(ctx context.Context, order domain.Order) (string, error)
+	Refund(ctx context.Context, order domain.Order, amount domain.Money) (string, error)
+	CapturedPayment(payload []byte, signature string) (dto.OrderPaymentDTO, bool, error)
 }
 
 type Categories interface {
@@ -107,14 +166,118 @@ type Addresses interface {
 }
 
 type Stores interface {
+	FindByID(ctx context.Context, storeID primitive.ObjectID) (domain.Store, error)
 	FindByUserID(ctx context.Context, userID primitive.ObjectID) (domain.Store, error)
 	FindByDomain(ctx context.Context, domainStore string) (domain.Store, error)
 	Create(ctx context.Context, store dto.StoreRegisterDTO) (domain.Store, error)
 	UpdateShipment(ctx context.Context, storeID primitive.ObjectID, shipment dto.StoreShipmentDTO) (domain.Store, error)
+	UpdateBankAccount(ctx context.Context, storeID primitive.ObjectID, bankAccount domain.BankAccount) (domain.Store, error)
+	UpdateTax(ctx context.Context, storeID primitive.ObjectID, pricesIncludeTax bool) (domain.Store, error)
+	UpdateStatus(ctx context.Context, storeID primitive.ObjectID, input dto.UpdateStatusInput) (domain.Store, error)
+	FindAll(ctx context.Context, filter dto.StoreFilterInput) ([]domain.Store, error)
+	AddDocument(ctx context.Context, storeID primitive.ObjectID, documentType string, key string) (domain.Store, error)
+	FindDocument(ctx context.Context, storeID primitive.ObjectID, documentID primitive.ObjectID) (domain.StoreDocument, error)
+	Approve(ctx context.Context, storeID primitive.ObjectID, adminID primitive.ObjectID, note string) (domain.Store, error)
+	Reject(ctx context.Context, storeID primitive.ObjectID, adminID primitive.ObjectID, note string) (domain.Store, error)
+	FindListedByDomain(ctx context.Context, domainStore string) (domain.Store, error)
+	Storefront(ctx context.Context, domainStore string) (domain.Storefront, error)
+	UpdateProfile(ctx context.Context, storeID primitive.ObjectID, input dto.StoreProfileInput) (domain.Store, error)
+	UpdateImage(ctx context.Context, storeID primitive.ObjectID, image dto.StoreImageDTO) (domain.Store, error)
+	UpdateHours(ctx context.Context, storeID primitive.ObjectID, input dto.StoreHoursInput) (domain.Store, error)
+	UpdateHoliday(ctx context.Context, storeID primitive.ObjectID, holiday *domain.StoreHoliday) (domain.Store, error)
+	UpdateHandling(ctx context.Context, storeID primitive.ObjectID, handlingDays int) (domain.Store, error)
+	UpdateShipping(ctx context.Context, storeID primitive.ObjectID, rules domain.ShippingRules) (domain.Store, error)
+}
+
+type Shipping interface {
+	Quote(ctx context.Context, userID primitive.ObjectID, addressID primitive.ObjectID) ([]domain.ShippingQuote, error)
+	Option(ctx context.Context, userID primitive.ObjectID, addressID primitive.ObjectID, parcel dto.ShippingParcel,
+		courier string, service string) (domain.ShippingOption, error)
+}
+
+type Taxes interface {
+	Calculate(ctx context.Context, items []dto.TaxItem) ([]domain.TaxLine, error)
+	Report(ctx context.Context, filter dto.TaxReportFilter) ([]domain.TaxReportRow, error)
+}
+
+type Invoices interface {
+	Invoice(ctx context.Context, orderID primitive.ObjectID, actor string, actorID primitive.ObjectID) (domain.Invoice, []byte, error)
+}
+
+type Wallets interface {
+	SettleOrder(ctx context.Context, order domain.Order) error
+	FindWallet(ctx context.Context, storeID primitive.ObjectID, page int64, limit int64) (domain.Wallet, error)
+	FindPayouts(ctx context.Context, status string) ([]domain.Payout, error)
+	FindPayoutsByStore(ctx context.Context, storeID primitive.ObjectID) ([]domain.Payout, error)
+	RequestPayout(ctx context.Context, store domain.Store, amount domain.Money) (domain.Payout, error)
+	ApprovePayout(ctx context.Context, payoutID primitive.ObjectID, review dto.PayoutReviewDTO) (domain.Payout, error)
+	RejectPayout(ctx context.Context, payoutID primitive.ObjectID, review dto.PayoutReviewDTO) (domain.Payout, error)
+	MarkPayoutPaid(ctx context.Context, payoutID primitive.ObjectID, review dto.PayoutReviewDTO) (domain.Payout, error)
+}
+
+type Returns interface {
+	FindByID(ctx context.Context, returnID primitive.ObjectID) (domain.ReturnRequest, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]domain.ReturnRequest, error)
+	FindByStoreID(ctx context.Context, storeID primitive.ObjectID) ([]domain.ReturnRequest, error)
+	Create(ctx context.Context, returnDTO dto.CreateReturnDTO) (domain.ReturnRequest, error)
+	Approve(ctx context.Context, returnID primitive.ObjectID, storeID primitive.ObjectID, note string) (domain.ReturnRequest, error)
+	Reject(ctx context.Context, returnID primitive.ObjectID, storeID primitive.ObjectID, note string) (domain.ReturnRequest, error)
+	Ship(ctx context.Context, returnID primitive.ObjectID, userID primitive.ObjectID, shipInput dto.ShipReturnInput) (domain.ReturnRequest, error)
+	Receive(ctx context.Context, returnID primitive.ObjectID, storeID primitive.ObjectID, refundAmount domain.Money) (domain.ReturnRequest, error)
+	Refund(ctx context.Context, returnID primitive.ObjectID, storeID primitive.ObjectID) (domain.ReturnRequest, error)
+}
+
+type OIDC interface {
+	Authorize(ctx context.Context) (string, string, error)
+	Login(ctx context.Context, state string, browserState string, code string) (domain.User, error)
+}
+
+type Audit interface {
+	Record(ctx context.Context, entry domain.AuditLog) error
+	Track(ctx context.Context, action string, targetType string, targetID primitive.ObjectID,
+		before interface{}, after interface{})
+	Find(ctx context.Context, input dto.AuditFilterInput) (domain.AuditPage, error)
+	Purge(ctx context.Context) (int64, error)
+}
+
+type TwoFactor interface {
+	Challenge(ctx context.Context, account domain.AccountRef, fingerprint string, device string) (*domain.TwoFactorChallenge, error)
+	SetupWithChallenge(ctx context.Context, kind string, token string) (domain.TwoFactorSetup, error)
+	VerifyChallenge(ctx context.Context, kind string, input dto.TwoFactorVerifyInput) (domain.TwoFactorLogin, error)
+	Setup(ctx context.Context, account domain.AccountRef) (domain.TwoFactorSetup, error)
+	Confirm(ctx context.Context, account domain.AccountRef, code string) ([]string, error)
+	Disable(ctx context.Context, account domain.AccountRef, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, account domain.AccountRef, code string) ([]string, error)
+	SecuritySettings(ctx context.Context) (domain.SecuritySettings, error)
+	UpdateSecuritySettings(ctx context.Context, input dto.SecuritySettingsInput) (domain.SecuritySettings, error)
+}
+
+type Lockout interface {
+	Locked(ctx context.Context, kind string, email string) (time.Duration, error)
+	Failed(ctx context.Context, kind string, email string) (time.Duration, error)
+	Succeeded(ctx context.Context, kind string, email string) error
+}
+
+type Analytics interface {
+	Sales(ctx context.Context, input dto.AnalyticsInput) (domain.SalesReport, error)
+	TopProducts(ctx context.Context, input dto.AnalyticsInput) ([]domain.TopProduct, error)
+	TopStores(ctx context.Context, input dto.AnalyticsInput) ([]domain.TopStore, error)
+	CartConversion(ctx context.Context, input dto.AnalyticsInput) (domain.CartConversion, error)
+	NewUsers(ctx context.Context, input dto.AnalyticsInput) (domain.NewUsersReport, error)
+	OrderRates(ctx context.Context, input dto.AnalyticsInput) (domain.OrderRates, error)
+	Overview(ctx context.Context, input dto.AnalyticsInput) (domain.AnalyticsOverview, error)
+	Store(ctx context.Context, storeID primitive.ObjectID, input dto.AnalyticsInput) (domain.StoreAnalytics, error)
+}
+
+type ProductStats interface {
+	RecordView(ctx context.Context, product domain.Product) error
+	RecordCartAdd(ctx context.Context, product domain.Product) error
+	Flush(ctx context.Context) error
 }
 
 type Services struct {
 	Users      Users
+	Account    Account
 	Products   Products
 	Reviews    Reviews
 	Admins     Admins
@@ -125,29 +288,76 @@ type Services struct {
 	Areas      Areas
 	Addresses  Addresses
 	Stores     Stores
+	Returns    Returns
+	Wallets    Wallets
+	Invoices   Invoices
+	Taxes      Taxes
+	Audit      Audit
+	OIDC       OIDC
+	TwoFactor  TwoFactor
+	Lockout    Lockout
+	Analytics  Analytics
+	Stats      ProductStats
+	Shipping   Shipping
 }
 
 type Deps struct {
-	Repos       *repository.Repositories
-	Services    *Services
-	RedisClient *redis.Client
+	Repos           *repository.Repositories
+	Services        *Services
+	RedisClient     *redis.Client
+	Config          *config.Config
+	StorageProvider storage.StorageProvider
+	Mailer          mailer.Mailer
+	SecretBox       *auth.SecretBox
+	Courier         courier.CourierProvider
 }
 
 func NewServices(deps Deps) *Services {
+	auditService := NewAuditService(deps.Repos.Audit, time.Duration(deps.Config.Audit.RetentionDays)*24*time.Hour)
 	reviewsService := NewReviewsService(deps.Repos.Reviews, deps.RedisClient)
-	CategoriesService := NewCategoriesService(deps.Repos.Categories)
-	productsService := NewProductsService(deps.Repos.Products, reviewsService, CategoriesService)
+	CategoriesService := NewCategoriesService(deps.Repos.Categories, auditService)
+	productsService := NewProductsService(deps.Repos.Products, deps.Repos.Stores, reviewsService, CategoriesService,
+		auditService)
 	adminsService := NewAdminsService(deps.Repos.Admins)
-	cartsService := NewCartsService(deps.Repos.Carts, productsService)
-	usersService := NewUsersService(deps.Repos.Users, cartsService)
-	ordersService := NewOrdersService(deps.Repos.Orders, productsService, cartsService)
+	storeService := NewStoresService(deps.Repos.Stores, deps.Repos.Products, deps.Repos.Reviews, deps.Repos.Areas,
+		auditService)
+	taxService := NewTaxService(deps.Config.Tax.Name, deps.Config.Tax.Rate, deps.Config.Tax.Timezone, storeService, deps.Repos.Orders)
+	cartsService := NewCartsService(deps.Repos.Carts, productsService, taxService)
+	oneTimeTokens := auth.NewOneTimeTokens(deps.Config, deps.RedisClient)
+	usersService := NewUsersService(deps.Repos.Users, cartsService, oneTimeTokens, deps.Mailer, deps.Config.Account.FrontendURL,
+		time.Duration(deps.Config.Account.VerifyEmailTokenMinutes)*time.Minute,
+		time.Duration(deps.Config.Account.ResetPasswordTokenMinutes)*time.Minute, auditService)
+	accountService := NewAccountService(deps.Repos, oneTimeTokens, deps.Mailer, deps.Config.Account.FrontendURL,
+		time.Duration(deps.Config.Account.VerifyEmailTokenMinutes)*time.Minute)
+	paymentService := NewPaymentService(deps.Config.Payment.StripeKey, deps.Config.Payment.WebhookSecret)
+	walletsService := NewWalletsService(deps.Repos.Ledger, deps.Repos.Payouts, deps.Repos.Returns, deps.Config.Wallet.CommissionRate)
 	areaService := NewAreasService(deps.Repos.Areas)
 	addressService := NewAddressesService(deps.Repos.Addresses, areaService)
-	storeService := NewStoresService(deps.Repos.Stores)
-	// paymentService := NewPaymentService(ordersService, productsService)
+	shippingService := NewShippingService(deps.Repos.Stores, deps.Repos.Areas, cartsService, addressService, deps.Courier)
+	ordersService := NewOrdersService(deps.Repos.Orders, deps.Repos.Returns, productsService, cartsService,
+		paymentService, walletsService, taxService, shippingService, auditService)
+	returnWindow := time.Duration(deps.Config.Order.ReturnWindowDays) * 24 * time.Hour
+	returnsService := NewReturnsService(deps.Repos.Returns, ordersService, productsService, paymentService, returnWindow)
+	oidcService := NewOIDCService(deps.Repos.Users, oidc.NewClient(deps.Config), deps.RedisClient,
+		time.Duration(deps.Config.OIDC.StateMinutes)*time.Minute)
+	twoFactorService := NewTwoFactorService(deps.Repos.TwoFactor, deps.Repos.Settings, deps.RedisClient, deps.SecretBox,
+		deps.Config.TwoFactor.Issuer, time.Duration(deps.Config.TwoFactor.ChallengeMinutes)*time.Minute,
+		deps.Config.TwoFactor.MaxAttempts, deps.Config.TwoFactor.RecoveryCodes)
+	lockout := deps.Config.RateLimit.Lockout
+	lockoutService := NewLockoutService(deps.RedisClient, lockout.Threshold,
+		time.Duration(lockout.BaseSeconds)*time.Second, time.Duration(lockout.MaxMinutes)*time.Minute,
+		time.Duration(lockout.ResetHours)*time.Hour)
+	analytics := deps.Config.Analytics
+	analyticsService := NewAnalyticsService(deps.Repos.Analytics, deps.Repos.Stats, deps.RedisClient,
+		analytics.Timezone, analytics.DefaultDays, analytics.MaxDays, time.Duration(analytics.CacheMinutes)*time.Minute)
+	productStatsService := NewProductStatsService(deps.Repos.Stats, deps.RedisClient, analytics.Timezone,
+		time.Duration(analytics.FlushMinutes)*time.Minute)
+	invoicesService := NewInvoicesService(deps.Repos.Invoices, ordersService, productsService, storeService,
+		areaService, deps.StorageProvider)
 
 	return &Services{
 		Users:      usersService,
+		Account:    accountService,
 		Products:   productsService,
 		Reviews:    reviewsService,
 		Admins:     adminsService,
@@ -157,6 +367,17 @@ func NewServices(deps Deps) *Services {
 		Areas:      areaService,
 		Addresses:  addressService,
 		Stores:     storeService,
-		// Payment:  paymentService,
+		Returns:    returnsService,
+		Payment:    paymentService,
+		Wallets:    walletsService,
+		Invoices:   invoicesService,
+		Taxes:      taxService,
+		Audit:      auditService,
+		OIDC:       oidcService,
+		TwoFactor:  twoFactorService,
+		Lockout:    lockoutService,
+		Analytics:  analyticsService,
+		Stats:      productStatsService,
+		Shipping:   shippingService,
 	}
 }
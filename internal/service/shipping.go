@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"github.com/sigit14ap/go-commerce/pkg/courier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrShippingUnavailable = errors.New("shipping option is not available")
+	ErrNoShipmentOrigin    = errors.New("store has not set its shipment origin")
+	ErrShippingExcluded    = errors.New("store does not ship to the province of the address")
+)
+
+// shippingCouriers are the couriers quoted for stores that did not pick
+// their own.
+var shippingCouriers = []string{"jne", "pos", "tiki"}
+
+type ShippingService struct {
+	storesRepo       repository.Stores
+	areasRepo        repository.Areas
+	cartService      Carts
+	addressesService Addresses
+	courier          courier.CourierProvider
+}
+
+func NewShippingService(storesRepo repository.Stores, areasRepo repository.Areas, cartService Carts,
+	addressesService Addresses, courierProvider courier.CourierProvider) *ShippingService {
+	return &ShippingService{
+		storesRepo:       storesRepo,
+		areasRepo:        areasRepo,
+		cartService:      cartService,
+		addressesService: addressesService,
+		courier:          courierProvider,
+	}
+}
+
+// Quote lists the shipping options of each store in the cart of the user to
+// one of the user's addresses.
+func (s *ShippingService) Quote(ctx context.Context, userID primitive.ObjectID, addressID primitive.ObjectID) ([]domain.ShippingQuote, error) {
+	address, err := s.addressesService.Find(ctx, userID, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	cartItems, err := s.cartService.FindCartItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var storeIDs []primitive.ObjectID
+	parcels := map[primitive.ObjectID]dto.ShippingParcel{}
+	for _, cartItem := range cartItems {
+		storeID := cartItem.Product.StoreID
+		parcel, ok := parcels[storeID]
+		if !ok {
+			storeIDs = append(storeIDs, storeID)
+			parcel.StoreID = storeID
+		}
+		parcel.Weight += cartItem.Product.Weight * cartItem.Quantity
+		parcel.Subtotal = parcel.Subtotal.Add(cartItem.Product.Price.Mul(cartItem.Quantity))
+		parcels[storeID] = parcel
+	}
+
+	quotes := make([]domain.ShippingQuote, 0, len(storeIDs))
+	for _, storeID := range storeIDs {
+		options, err := s.options(ctx, parcels[storeID], address)
+		if err != nil {
+			return nil, err
+		}
+
+		quotes = append(quotes, domain.ShippingQuote{
+			StoreID: storeID,
+			Weight:  parcels[storeID].Weight,
+			Options: options,
+		})
+	}
+
+	return quotes, nil
+}
+
+// Option prices the chosen courier service again, so checkout charges what
+// the courier quotes rather than what the client sent.
+func (s *ShippingService) Option(ctx context.Context, userID primitive.ObjectID, addressID primitive.ObjectID,
+	parcel dto.ShippingParcel, courierCode string, service string) (domain.ShippingOption, error) {
+	address, err := s.addressesService.Find(ctx, userID, addressID)
+	if err != nil {
+		return domain.ShippingOption{}, err
+	}
+
+	options, err := s.options(ctx, parcel, address)
+	if err != nil {
+		return domain.ShippingOption{}, err
+	}
+
+	for _, option := range options {
+		if option.Courier == courierCode && option.Service == service {
+			return option, nil
+		}
+	}
+
+	return domain.ShippingOption{}, ErrShippingUnavailable
+}
+
+// options quotes the couriers and services the store ships with, priced by
+// the shipping rules of the store.
+func (s *ShippingService) options(ctx context.Context, parcel dto.ShippingParcel, address domain.Address) ([]domain.ShippingOption, error) {
+	store, err := s.storesRepo.FindByID(ctx, parcel.StoreID)
+	if err != nil {
+		return nil, err
+	}
+
+	if store.OnHoliday(time.Now()) {
+		return nil, storeHolidayError(store)
+	}
+
+	if store.Shipping.Excludes(address.ProvinceID) {
+		return nil, fmt.Errorf("%w: %s", ErrShippingExcluded, store.Name)
+	}
+
+	if store.ShipmentCityID.IsZero() {
+		return nil, ErrNoShipmentOrigin
+	}
+
+	origin, err := s.areasRepo.FindCity(ctx, store.ShipmentCityID)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := s.areasRepo.FindCity(ctx, address.CityID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Couriers do not quote parcels without weight.
+	weight := parcel.Weight
+	if weight < 1 {
+		weight = 1
+	}
+
+	options := []domain.ShippingOption{}
+	for _, courierCode := range shippingCouriers {
+		if !store.Shipping.ShipsWith(courierCode) {
+			continue
+		}
+
+		costs, err := s.courier.GetDeliveryCost(origin.ThirdPartyID, destination.ThirdPartyID, weight, courierCode)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cost := range costs {
+			if !store.Shipping.Allows(cost.Courier, cost.Service) {
+				continue
+			}
+			options = append(options, shippingOption(cost, store, parcel.Subtotal))
+		}
+	}
+
+	return options, nil
+}
+
+// shippingOption charges the courier cost, waived once the subtotal reaches
+// the free shipping threshold, plus the handling fee of the store, and adds
+// the days the store takes to hand the parcel over to the days the courier
+// estimates.
+func shippingOption(cost dto.ThirdPartyCostDTO, store domain.Store, subtotal domain.Money) domain.ShippingOption {
+	charge := cost.Cost
+	free := store.Shipping.ShipsFree(subtotal)
+	if free {
+		charge = domain.NewMoney(0, cost.Cost.Currency)
+	}
+
+	return domain.ShippingOption{
+		Courier:      cost.Courier,
+		Service:      cost.Service,
+		Description:  cost.Description,
+		Cost:         charge.Add(store.Shipping.HandlingFee),
+		HandlingFee:  store.Shipping.HandlingFee,
+		FreeShipping: free,
+		HandlingDays: store.HandlingDays,
+		MinDays:      cost.MinDays + store.HandlingDays,
+		MaxDays:      cost.MaxDays + store.HandlingDays,
+	}
+}
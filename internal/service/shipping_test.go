@@ -0,0 +1,120 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestShippingOptionAddsHandlingDays(t *testing.T) {
+	cost := dto.ThirdPartyCostDTO{
+		Courier: "jne",
+		Service: "REG",
+		Cost:    domain.IDR(18000),
+		MinDays: 1,
+		MaxDays: 2,
+	}
+
+	option := shippingOption(cost, domain.Store{HandlingDays: 2}, domain.IDR(50000))
+	if option.MinDays != 3 || option.MaxDays != 4 {
+		t.Errorf("days = %d-%d, want 3-4", option.MinDays, option.MaxDays)
+	}
+	if option.HandlingDays != 2 || option.Cost != cost.Cost {
+		t.Errorf("option = %+v, want handling days 2 and cost %v", option, cost.Cost)
+	}
+}
+
+func TestShippingOptionAppliesStoreRules(t *testing.T) {
+	cost := dto.ThirdPartyCostDTO{Courier: "jne", Service: "REG", Cost: domain.IDR(18000)}
+	threshold := domain.IDR(100000)
+	store := domain.Store{Shipping: domain.ShippingRules{
+		FreeShippingMin: &threshold,
+		HandlingFee:     domain.IDR(2000),
+	}}
+
+	cases := []struct {
+		name     string
+		subtotal domain.Money
+		cost     domain.Money
+		free     bool
+	}{
+		{"below threshold", domain.IDR(99999), domain.IDR(20000), false},
+		{"at threshold", domain.IDR(100000), domain.IDR(2000), true},
+		{"above threshold", domain.IDR(250000), domain.IDR(2000), true},
+	}
+	for _, c := range cases {
+		option := shippingOption(cost, store, c.subtotal)
+		if option.Cost != c.cost || option.FreeShipping != c.free {
+			t.Errorf("%s: cost = %v free = %v, want %v and %v", c.name, option.Cost, option.FreeShipping, c.cost, c.free)
+		}
+		if option.HandlingFee != store.Shipping.HandlingFee {
+			t.Errorf("%s: handling fee = %v, want %v", c.name, option.HandlingFee, store.Shipping.HandlingFee)
+		}
+	}
+}
+
+func TestShippingRulesCouriers(t *testing.T) {
+	rules := domain.ShippingRules{Couriers: []domain.CourierServices{
+		{Code: "jne", Services: []string{"REG", "YES"}},
+		{Code: "pos"},
+	}}
+
+	cases := []struct {
+		courier string
+		service string
+		want    bool
+	}{
+		{"jne", "REG", true},
+		{"jne", "yes", true},
+		{"jne", "OKE", false},
+		{"pos", "Paket Kilat Khusus", true},
+		{"tiki", "REG", false},
+	}
+	for _, c := range cases {
+		if got := rules.Allows(c.courier, c.service); got != c.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", c.courier, c.service, got, c.want)
+		}
+	}
+
+	if rules.ShipsWith("tiki") || !rules.ShipsWith("JNE") {
+		t.Error("ShipsWith does not follow the couriers of the store")
+	}
+	if !(domain.ShippingRules{}).Allows("tiki", "ECO") {
+		t.Error("store without couriers does not ship with every courier")
+	}
+}
+
+func TestShippingRulesExcludes(t *testing.T) {
+	excluded := primitive.NewObjectID()
+	rules := domain.ShippingRules{ExcludedProvinces: []primitive.ObjectID{excluded}}
+
+	if !rules.Excludes(excluded) {
+		t.Error("excluded province is shipped to")
+	}
+	if rules.Excludes(primitive.NewObjectID()) {
+		t.Error("other province is excluded")
+	}
+}
+
+func TestValidShippingRules(t *testing.T) {
+	negative := domain.IDR(-1)
+	usd := domain.NewMoney(500, domain.CurrencyUSD)
+
+	cases := map[string]struct {
+		rules domain.ShippingRules
+		want  bool
+	}{
+		"empty":              {domain.ShippingRules{}, true},
+		"handling fee":       {domain.ShippingRules{HandlingFee: domain.IDR(2000)}, true},
+		"negative fee":       {domain.ShippingRules{HandlingFee: negative}, false},
+		"negative threshold": {domain.ShippingRules{FreeShippingMin: &negative}, false},
+		"other currency":     {domain.ShippingRules{HandlingFee: usd}, false},
+	}
+	for name, c := range cases {
+		if got := validShippingRules(c.rules); got != c.want {
+			t.Errorf("%s: validShippingRules = %v, want %v", name, got, c.want)
+		}
+	}
+}
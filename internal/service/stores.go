@@ -2,22 +2,53 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
 	"github.com/sigit14ap/go-commerce/internal/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrStoreAlreadyVerified  = errors.New("store is already verified")
+	ErrStoreNotPending       = errors.New("store is not waiting for review")
+	ErrStoreDocumentsMissing = errors.New("store has not uploaded both an identity and a business document")
+	ErrStoreDocumentNotFound = errors.New("store has no such document")
+	ErrStoreNoteRequired     = errors.New("a note is required to reject a store")
+	ErrStoreOnHoliday        = errors.New("store is on holiday")
+	ErrInvalidOperatingHours = errors.New("operating hours must close after they open and list each day once")
+	ErrInvalidShippingRules  = fmt.Errorf("handling fee and free shipping threshold must not be negative and must be in %s", domain.DefaultCurrency)
 )
 
 type StoresService struct {
-	repo repository.Stores
+	repo         repository.Stores
+	productsRepo repository.Products
+	reviewsRepo  repository.Reviews
+	areasRepo    repository.Areas
+	audit        Audit
 }
 
-func NewStoresService(repo repository.Stores) *StoresService {
+func NewStoresService(repo repository.Stores, productsRepo repository.Products, reviewsRepo repository.Reviews,
+	areasRepo repository.Areas, audit Audit) *StoresService {
 	return &StoresService{
-		repo: repo,
+		repo:         repo,
+		productsRepo: productsRepo,
+		reviewsRepo:  reviewsRepo,
+		areasRepo:    areasRepo,
+		audit:        audit,
 	}
 }
 
+func (service *StoresService) FindByID(ctx context.Context, storeID primitive.ObjectID) (domain.Store, error) {
+	return service.repo.FindByID(ctx, storeID)
+}
+
 func (service *StoresService) FindByUserID(ctx context.Context, userID primitive.ObjectID) (domain.Store, error) {
 	return service.repo.FindByUserID(ctx, userID)
 }
@@ -26,10 +57,305 @@ func (service *StoresService) FindByDomain(ctx context.Context, domainStore stri
 	return service.repo.FindByDomain(ctx, domainStore)
 }
 
+// FindListedByDomain finds a store buyers can browse, a store that is
+// suspended, banned or not approved yet is reported as not found.
+func (service *StoresService) FindListedByDomain(ctx context.Context, domainStore string) (domain.Store, error) {
+	store, err := service.repo.FindByDomain(ctx, domainStore)
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	if !store.IsListed() {
+		return domain.Store{}, mongo.ErrNoDocuments
+	}
+
+	return store, nil
+}
+
+// Storefront builds the public profile of the store, its rating is the
+// average of the reviews of its published products.
+func (service *StoresService) Storefront(ctx context.Context, domainStore string) (domain.Storefront, error) {
+	store, err := service.FindListedByDomain(ctx, domainStore)
+	if err != nil {
+		return domain.Storefront{}, err
+	}
+
+	productIDs, err := service.productsRepo.ListedIDs(ctx, store.ID)
+	if err != nil {
+		return domain.Storefront{}, err
+	}
+
+	rating, reviewCount, err := service.reviewsRepo.Summary(ctx, productIDs)
+	if err != nil {
+		return domain.Storefront{}, err
+	}
+
+	storefront := domain.Storefront{
+		ID:           store.ID,
+		Name:         store.Name,
+		Domain:       store.Domain,
+		Description:  store.Description,
+		Logo:         store.Logo,
+		Banner:       store.Banner,
+		Rating:       math.Floor(rating*10) / 10,
+		ReviewCount:  reviewCount,
+		ProductCount: int64(len(productIDs)),
+		Hours:        store.OperatingHours,
+		HandlingDays: store.HandlingDays,
+	}
+
+	// Past holidays are of no interest to buyers.
+	if store.Holiday != nil && time.Now().Before(store.Holiday.End) {
+		storefront.Holiday = store.Holiday
+	}
+
+	if !store.ShipmentCityID.IsZero() {
+		city, err := service.areasRepo.FindCity(ctx, store.ShipmentCityID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Storefront{}, err
+		}
+		if err == nil {
+			storefront.City = &city
+		}
+	}
+
+	return storefront, nil
+}
+
+func (service *StoresService) UpdateProfile(ctx context.Context, storeID primitive.ObjectID, input dto.StoreProfileInput) (domain.Store, error) {
+	return service.repo.UpdateProfile(ctx, storeID, dto.StoreProfileDTO{
+		Name:        input.Name,
+		Description: input.Description,
+	})
+}
+
+func (service *StoresService) UpdateImage(ctx context.Context, storeID primitive.ObjectID, image dto.StoreImageDTO) (domain.Store, error) {
+	return service.repo.UpdateImage(ctx, storeID, image)
+}
+
+// Create registers the store as pending, it can set up a draft catalogue but
+// cannot publish until an admin approves it.
 func (service *StoresService) Create(ctx context.Context, store dto.StoreRegisterDTO) (domain.Store, error) {
+	store.Verification = domain.StoreVerification{Status: domain.VerificationPending}
 	return service.repo.Create(ctx, store)
 }
 
+func (service *StoresService) FindAll(ctx context.Context, filter dto.StoreFilterInput) ([]domain.Store, error) {
+	return service.repo.FindAll(ctx, filter.Verification)
+}
+
+// AddDocument attaches a verification document stored privately under key, a
+// rejected store goes back to pending so it can be reviewed again.
+func (service *StoresService) AddDocument(ctx context.Context, storeID primitive.ObjectID, documentType string, key string) (domain.Store, error) {
+	before, err := service.repo.FindByID(ctx, storeID)
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	if before.IsApproved() {
+		return domain.Store{}, ErrStoreAlreadyVerified
+	}
+
+	store, err := service.repo.AddDocument(ctx, storeID, domain.StoreDocument{
+		ID:         primitive.NewObjectID(),
+		Type:       documentType,
+		Key:        key,
+		UploadedAt: time.Now(),
+	})
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	service.audit.Track(ctx, domain.AuditActionStoreDocument, domain.AuditTargetStore, storeID, before, store)
+
+	return store, nil
+}
+
+func (service *StoresService) FindDocument(ctx context.Context, storeID primitive.ObjectID, documentID primitive.ObjectID) (domain.StoreDocument, error) {
+	store, err := service.repo.FindByID(ctx, storeID)
+	if err != nil {
+		return domain.StoreDocument{}, err
+	}
+
+	for _, document := range store.Verification.Documents {
+		if document.ID == documentID {
+			return document, nil
+		}
+	}
+
+	return domain.StoreDocument{}, ErrStoreDocumentNotFound
+}
+
+func (service *StoresService) Approve(ctx context.Context, storeID primitive.ObjectID, adminID primitive.ObjectID, note string) (domain.Store, error) {
+	before, err := service.repo.FindByID(ctx, storeID)
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	if before.Verification.Status != domain.VerificationPending {
+		return domain.Store{}, ErrStoreNotPending
+	}
+
+	if !hasVerificationDocuments(before.Verification.Documents) {
+		return domain.Store{}, ErrStoreDocumentsMissing
+	}
+
+	return service.review(ctx, before, adminID, domain.VerificationApproved, note, domain.AuditActionStoreApprove)
+}
+
+func (service *StoresService) Reject(ctx context.Context, storeID primitive.ObjectID, adminID primitive.ObjectID, note string) (domain.Store, error) {
+	if note == "" {
+		return domain.Store{}, ErrStoreNoteRequired
+	}
+
+	before, err := service.repo.FindByID(ctx, storeID)
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	if before.Verification.Status != domain.VerificationPending {
+		return domain.Store{}, ErrStoreNotPending
+	}
+
+	return service.review(ctx, before, adminID, domain.VerificationRejected, note, domain.AuditActionStoreReject)
+}
+
+func (service *StoresService) review(ctx context.Context, before domain.Store, adminID primitive.ObjectID,
+	status string, note string, action string) (domain.Store, error) {
+	store, err := service.repo.Review(ctx, before.ID, dto.StoreReviewDTO{
+		Status:     status,
+		Note:       note,
+		ReviewedBy: adminID,
+		ReviewedAt: time.Now(),
+	})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Another admin reviewed the store in the meantime.
+		return domain.Store{}, ErrStoreNotPending
+	}
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	service.audit.Track(ctx, action, domain.AuditTargetStore, before.ID, before, store)
+
+	return store, nil
+}
+
+func (service *StoresService) UpdateHours(ctx context.Context, storeID primitive.ObjectID, input dto.StoreHoursInput) (domain.Store, error) {
+	hours, err := operatingHours(input)
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	return service.repo.UpdateHours(ctx, storeID, dto.StoreHoursDTO{OperatingHours: hours})
+}
+
+// UpdateHoliday schedules the holiday of the store, nil ends it.
+func (service *StoresService) UpdateHoliday(ctx context.Context, storeID primitive.ObjectID, holiday *domain.StoreHoliday) (domain.Store, error) {
+	return service.repo.UpdateHoliday(ctx, storeID, dto.StoreHolidayDTO{Holiday: holiday})
+}
+
+func (service *StoresService) UpdateHandling(ctx context.Context, storeID primitive.ObjectID, handlingDays int) (domain.Store, error) {
+	return service.repo.UpdateHandling(ctx, storeID, dto.StoreHandlingDTO{HandlingDays: handlingDays})
+}
+
+func (service *StoresService) UpdateShipping(ctx context.Context, storeID primitive.ObjectID, rules domain.ShippingRules) (domain.Store, error) {
+	if !validShippingRules(rules) {
+		return domain.Store{}, ErrInvalidShippingRules
+	}
+
+	return service.repo.UpdateShipping(ctx, storeID, dto.StoreShippingDTO{Shipping: rules})
+}
+
+// validShippingRules keeps the fees in the currency couriers quote in, so
+// they can be added to the courier charge.
+func validShippingRules(rules domain.ShippingRules) bool {
+	amounts := []domain.Money{rules.HandlingFee}
+	if rules.FreeShippingMin != nil {
+		amounts = append(amounts, *rules.FreeShippingMin)
+	}
+
+	for _, amount := range amounts {
+		if amount.IsNegative() || (amount.Currency != "" && amount.Currency != domain.DefaultCurrency) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// operatingHours checks the weekly hours and sorts them by day, HH:MM times
+// compare as strings.
+func operatingHours(input dto.StoreHoursInput) ([]domain.OperatingHours, error) {
+	hours := make([]domain.OperatingHours, 0, len(input.Hours))
+	seen := map[int]bool{}
+
+	for _, day := range input.Hours {
+		if seen[*day.Day] || day.Close <= day.Open {
+			return nil, ErrInvalidOperatingHours
+		}
+		seen[*day.Day] = true
+
+		hours = append(hours, domain.OperatingHours{Day: *day.Day, Open: day.Open, Close: day.Close})
+	}
+
+	sort.Slice(hours, func(i, j int) bool {
+		return hours[i].Day < hours[j].Day
+	})
+
+	return hours, nil
+}
+
+// storeHolidayError tells the buyer until when the store is away and why.
+func storeHolidayError(store domain.Store) error {
+	err := fmt.Errorf("%w until %s", ErrStoreOnHoliday, store.Holiday.End.Format("2006-01-02 15:04"))
+	if store.Holiday.Message != "" {
+		err = fmt.Errorf("%w: %s", err, store.Holiday.Message)
+	}
+
+	return err
+}
+
+// hasVerificationDocuments reports whether both an identity and a business
+// document were uploaded.
+func hasVerificationDocuments(documents []domain.StoreDocument) bool {
+	var identity, business bool
+	for _, document := range documents {
+		switch document.Type {
+		case domain.StoreDocumentIdentity:
+			identity = true
+		case domain.StoreDocumentBusiness:
+			business = true
+		}
+	}
+
+	return identity && business
+}
+
 func (service *StoresService) UpdateShipment(ctx context.Context, storeID primitive.ObjectID, shipment dto.StoreShipmentDTO) (domain.Store, error) {
 	return service.repo.UpdateShipment(ctx, storeID, shipment)
 }
+
+func (service *StoresService) UpdateBankAccount(ctx context.Context, storeID primitive.ObjectID, bankAccount domain.BankAccount) (domain.Store, error) {
+	return service.repo.UpdateBankAccount(ctx, storeID, dto.StoreBankAccountDTO{BankAccount: bankAccount})
+}
+
+func (service *StoresService) UpdateTax(ctx context.Context, storeID primitive.ObjectID, pricesIncludeTax bool) (domain.Store, error) {
+	return service.repo.UpdateTax(ctx, storeID, dto.StoreTaxDTO{PricesIncludeTax: pricesIncludeTax})
+}
+
+func (service *StoresService) UpdateStatus(ctx context.Context, storeID primitive.ObjectID, input dto.UpdateStatusInput) (domain.Store, error) {
+	before, err := service.repo.FindByID(ctx, storeID)
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	store, err := service.repo.UpdateStatus(ctx, storeID, input.Status, input.Reason)
+	if err != nil {
+		return domain.Store{}, err
+	}
+
+	service.audit.Track(ctx, domain.AuditActionStoreStatus+"."+input.Status, domain.AuditTargetStore, storeID, before, store)
+
+	return store, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryStores only implements the lookups the tests use.
+type memoryStores struct {
+	repository.Stores
+	stores map[string]domain.Store
+}
+
+func (s *memoryStores) FindByDomain(ctx context.Context, domainStore string) (domain.Store, error) {
+	store, ok := s.stores[domainStore]
+	if !ok {
+		return domain.Store{}, mongo.ErrNoDocuments
+	}
+
+	return store, nil
+}
+
+func TestHasVerificationDocuments(t *testing.T) {
+	identity := domain.StoreDocument{Type: domain.StoreDocumentIdentity}
+	business := domain.StoreDocument{Type: domain.StoreDocumentBusiness}
+
+	tests := []struct {
+		documents []domain.StoreDocument
+		want      bool
+	}{
+		{nil, false},
+		{[]domain.StoreDocument{identity}, false},
+		{[]domain.StoreDocument{identity, identity}, false},
+		{[]domain.StoreDocument{business, identity}, true},
+	}
+
+	for _, test := range tests {
+		if got := hasVerificationDocuments(test.documents); got != test.want {
+			t.Errorf("hasVerificationDocuments(%+v) = %t, want %t", test.documents, got, test.want)
+		}
+	}
+}
+
+func TestStoreIsApproved(t *testing.T) {
+	statuses := map[string]bool{
+		"":                          true,
+		domain.VerificationApproved: true,
+		domain.VerificationPending:  false,
+		domain.VerificationRejected: false,
+	}
+
+	for status, want := range statuses {
+		store := domain.Store{Verification: domain.StoreVerification{Status: status}}
+		if got := store.IsApproved(); got != want {
+			t.Errorf("IsApproved() with status %q = %t, want %t", status, got, want)
+		}
+	}
+}
+
+func TestFindListedByDomain(t *testing.T) {
+	stores := NewStoresService(&memoryStores{stores: map[string]domain.Store{
+		"legacy":    {Domain: "legacy"},
+		"approved":  {Domain: "approved", Verification: domain.StoreVerification{Status: domain.VerificationApproved}},
+		"pending":   {Domain: "pending", Verification: domain.StoreVerification{Status: domain.VerificationPending}},
+		"suspended": {Domain: "suspended", Status: domain.StatusSuspended},
+	}}, nil, nil, nil, nil)
+
+	tests := map[string]bool{
+		"legacy":    true,
+		"approved":  true,
+		"pending":   false,
+		"suspended": false,
+		"missing":   false,
+	}
+
+	for domainStore, listed := range tests {
+		store, err := stores.FindListedByDomain(context.Background(), domainStore)
+		if listed && (err != nil || store.Domain != domainStore) {
+			t.Errorf("FindListedByDomain(%q) = %+v, %v", domainStore, store, err)
+		}
+		if !listed && !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("FindListedByDomain(%q) error = %v, want %v", domainStore, err, mongo.ErrNoDocuments)
+		}
+	}
+}
+
+func TestOperatingHours(t *testing.T) {
+	day := func(d int, open, close string) dto.StoreDayInput {
+		return dto.StoreDayInput{Day: &d, Open: open, Close: close}
+	}
+
+	hours, err := operatingHours(dto.StoreHoursInput{Hours: []dto.StoreDayInput{
+		day(3, "09:00", "17:00"),
+		day(1, "08:00", "16:00"),
+	}})
+	if err != nil {
+		t.Fatalf("operatingHours: %v", err)
+	}
+	if len(hours) != 2 || hours[0].Day != 1 || hours[1].Day != 3 {
+		t.Errorf("hours = %+v, want sorted by day", hours)
+	}
+
+	invalid := map[string][]dto.StoreDayInput{
+		"closes before opening": {day(1, "17:00", "09:00")},
+		"closes at opening":     {day(1, "09:00", "09:00")},
+		"duplicate day":         {day(1, "09:00", "12:00"), day(1, "13:00", "17:00")},
+	}
+	for name, days := range invalid {
+		_, err := operatingHours(dto.StoreHoursInput{Hours: days})
+		if !errors.Is(err, ErrInvalidOperatingHours) {
+			t.Errorf("%s: err = %v, want %v", name, err, ErrInvalidOperatingHours)
+		}
+	}
+}
+
+func TestStoreOnHoliday(t *testing.T) {
+	start := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	store := domain.Store{Holiday: &domain.StoreHoliday{Start: start, End: start.AddDate(0, 0, 7), Message: "Back next week"}}
+
+	cases := map[time.Time]bool{
+		start.Add(-time.Minute): false,
+		start:                   true,
+		start.AddDate(0, 0, 3):  true,
+		start.AddDate(0, 0, 7):  false,
+	}
+	for now, want := range cases {
+		if got := store.OnHoliday(now); got != want {
+			t.Errorf("OnHoliday(%s) = %v, want %v", now, got, want)
+		}
+	}
+
+	if (domain.Store{}).OnHoliday(start) {
+		t.Error("store without holiday is on holiday")
+	}
+
+	err := storeHolidayError(store)
+	if !errors.Is(err, ErrStoreOnHoliday) {
+		t.Errorf("err = %v, want %v", err, ErrStoreOnHoliday)
+	}
+	if !strings.Contains(err.Error(), "2022-03-08 00:00") || !strings.Contains(err.Error(), "Back next week") {
+		t.Errorf("err = %q, want end of holiday and message", err)
+	}
+}
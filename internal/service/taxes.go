@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TaxService struct {
+	name          string
+	rate          float64
+	timezone      string
+	location      *time.Location
+	storesService Stores
+	ordersRepo    repository.Orders
+}
+
+func NewTaxService(name string, rate float64, timezone string, storesService Stores, ordersRepo repository.Orders) *TaxService {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Warnf("unknown tax timezone %q, reporting in UTC: %v", timezone, err)
+		location, timezone = time.UTC, "UTC"
+	}
+
+	return &TaxService{
+		name:          name,
+		rate:          rate,
+		timezone:      timezone,
+		location:      location,
+		storesService: storesService,
+		ordersRepo:    ordersRepo,
+	}
+}
+
+// Calculate groups the items into exclusive, inclusive and exempt tax lines
+// following the pricing mode of the store each item is sold by.
+func (t *TaxService) Calculate(ctx context.Context, items []dto.TaxItem) ([]domain.TaxLine, error) {
+	inclusiveStores := map[primitive.ObjectID]bool{}
+	var exclusiveBase, inclusiveGross, exemptBase domain.Money
+
+	for _, item := range items {
+		if item.Exempt || t.rate <= 0 {
+			exemptBase = exemptBase.Add(item.Amount)
+			continue
+		}
+
+		inclusive, ok := inclusiveStores[item.StoreID]
+		if !ok {
+			store, err := t.storesService.FindByID(ctx, item.StoreID)
+			if err != nil {
+				return nil, err
+			}
+
+			inclusive = store.PricesIncludeTax
+			inclusiveStores[item.StoreID] = inclusive
+		}
+
+		if inclusive {
+			inclusiveGross = inclusiveGross.Add(item.Amount)
+		} else {
+			exclusiveBase = exclusiveBase.Add(item.Amount)
+		}
+	}
+
+	return taxLines(t.name, t.rate, exclusiveBase, inclusiveGross, exemptBase), nil
+}
+
+// Report sums collected tax per store and period, dates are taken in the tax timezone.
+func (t *TaxService) Report(ctx context.Context, filter dto.TaxReportFilter) ([]domain.TaxReportRow, error) {
+	filter.Timezone = t.timezone
+	filter.From = t.localDate(filter.From)
+	filter.To = t.localDate(filter.To)
+
+	return t.ordersRepo.TaxReport(ctx, filter)
+}
+
+func (t *TaxService) localDate(date time.Time) time.Time {
+	if date.IsZero() {
+		return date
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, t.location)
+}
+
+func taxLines(name string, rate float64, exclusiveBase domain.Money, inclusiveGross domain.Money, exemptBase domain.Money) []domain.TaxLine {
+	lines := []domain.TaxLine{}
+	label := fmt.Sprintf("%s %s%%", name, strconv.FormatFloat(math.Round(rate*10000)/100, 'f', -1, 64))
+
+	if exclusiveBase.IsPositive() {
+		lines = append(lines, domain.TaxLine{
+			Name:   label,
+			Rate:   rate,
+			Base:   exclusiveBase,
+			Amount: exclusiveBase.Scale(rate),
+		})
+	}
+
+	if inclusiveGross.IsPositive() {
+		base := inclusiveGross.Scale(1 / (1 + rate))
+		lines = append(lines, domain.TaxLine{
+			Name:      label,
+			Rate:      rate,
+			Inclusive: true,
+			Base:      base,
+			Amount:    inclusiveGross.Sub(base),
+		})
+	}
+
+	if exemptBase.IsPositive() {
+		lines = append(lines, domain.TaxLine{
+			Name:   name + " exempt",
+			Base:   exemptBase,
+			Amount: domain.NewMoney(0, exemptBase.Currency),
+		})
+	}
+
+	return lines
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// pricingStores serves the pricing mode of stores to the tax service and
+// counts the lookups.
+type pricingStores struct {
+	Stores
+	stores  map[primitive.ObjectID]domain.Store
+	lookups int
+}
+
+func (s *pricingStores) FindByID(ctx context.Context, storeID primitive.ObjectID) (domain.Store, error) {
+	s.lookups++
+	store, ok := s.stores[storeID]
+	if !ok {
+		return domain.Store{}, mongo.ErrNoDocuments
+	}
+
+	return store, nil
+}
+
+func TestCalculateTaxLines(t *testing.T) {
+	exclusiveStore := domain.Store{ID: primitive.NewObjectID()}
+	inclusiveStore := domain.Store{ID: primitive.NewObjectID(), PricesIncludeTax: true}
+
+	exclusive := func(amount int64) dto.TaxItem {
+		return dto.TaxItem{StoreID: exclusiveStore.ID, Amount: idr(amount)}
+	}
+	inclusive := func(amount int64) dto.TaxItem {
+		return dto.TaxItem{StoreID: inclusiveStore.ID, Amount: idr(amount)}
+	}
+	exempt := func(amount int64) dto.TaxItem {
+		return dto.TaxItem{StoreID: exclusiveStore.ID, Amount: idr(amount), Exempt: true}
+	}
+
+	exclusiveLine := func(base, amount int64) domain.TaxLine {
+		return domain.TaxLine{Name: "PPN 11%", Rate: 0.11, Base: idr(base), Amount: idr(amount)}
+	}
+	inclusiveLine := func(base, amount int64) domain.TaxLine {
+		return domain.TaxLine{Name: "PPN 11%", Rate: 0.11, Inclusive: true, Base: idr(base), Amount: idr(amount)}
+	}
+	exemptLine := func(base int64) domain.TaxLine {
+		return domain.TaxLine{Name: "PPN exempt", Base: idr(base), Amount: idr(0)}
+	}
+
+	tests := []struct {
+		name    string
+		rate    float64
+		items   []dto.TaxItem
+		want    []domain.TaxLine
+		lookups int
+	}{
+		{
+			name:    "exclusive",
+			rate:    0.11,
+			items:   []dto.TaxItem{exclusive(100000), exclusive(20000)},
+			want:    []domain.TaxLine{exclusiveLine(120000, 13200)},
+			lookups: 1,
+		},
+		{
+			name:    "exclusive rounds half away from zero",
+			rate:    0.11,
+			items:   []dto.TaxItem{exclusive(50)},
+			want:    []domain.TaxLine{exclusiveLine(50, 6)},
+			lookups: 1,
+		},
+		{
+			name:    "inclusive",
+			rate:    0.11,
+			items:   []dto.TaxItem{inclusive(111000)},
+			want:    []domain.TaxLine{inclusiveLine(100000, 11000)},
+			lookups: 1,
+		},
+		{
+			// 100000 / 1.11 = 90090.09 is rounded to the base, the tax is
+			// the rest so base and tax add up to the price.
+			name:    "inclusive rounding",
+			rate:    0.11,
+			items:   []dto.TaxItem{inclusive(100000)},
+			want:    []domain.TaxLine{inclusiveLine(90090, 9910)},
+			lookups: 1,
+		},
+		{
+			name:    "inclusive rounds tax away on the smallest amounts",
+			rate:    0.11,
+			items:   []dto.TaxItem{inclusive(5)},
+			want:    []domain.TaxLine{inclusiveLine(5, 0)},
+			lookups: 1,
+		},
+		{
+			name:    "exempt",
+			rate:    0.11,
+			items:   []dto.TaxItem{exempt(50000)},
+			want:    []domain.TaxLine{exemptLine(50000)},
+			lookups: 0,
+		},
+		{
+			name:    "mixed store cart",
+			rate:    0.11,
+			items:   []dto.TaxItem{exclusive(100000), inclusive(111000), exempt(50000), exclusive(20000)},
+			want:    []domain.TaxLine{exclusiveLine(120000, 13200), inclusiveLine(100000, 11000), exemptLine(50000)},
+			lookups: 2,
+		},
+		{
+			name:    "zero rate",
+			rate:    0,
+			items:   []dto.TaxItem{exclusive(100000), inclusive(111000)},
+			want:    []domain.TaxLine{exemptLine(211000)},
+			lookups: 0,
+		},
+		{
+			name:    "negative rate",
+			rate:    -0.11,
+			items:   []dto.TaxItem{exclusive(100000)},
+			want:    []domain.TaxLine{exemptLine(100000)},
+			lookups: 0,
+		},
+		{
+			name:    "empty cart",
+			rate:    0.11,
+			items:   nil,
+			want:    []domain.TaxLine{},
+			lookups: 0,
+		},
+	}
+
+	for _, test := range tests {
+		stores := &pricingStores{stores: map[primitive.ObjectID]domain.Store{
+			exclusiveStore.ID: exclusiveStore,
+			inclusiveStore.ID: inclusiveStore,
+		}}
+		taxes := NewTaxService("PPN", test.rate, "UTC", stores, nil)
+
+		lines, err := taxes.Calculate(context.Background(), test.items)
+		if err != nil {
+			t.Errorf("%s: Calculate: %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(lines, test.want) {
+			t.Errorf("%s: lines = %+v, want %+v", test.name, lines, test.want)
+		}
+		if stores.lookups != test.lookups {
+			t.Errorf("%s: looked up %d stores, want %d", test.name, stores.lookups, test.lookups)
+		}
+	}
+}
+
+func TestCalculateUnknownStore(t *testing.T) {
+	taxes := NewTaxService("PPN", 0.11, "UTC", &pricingStores{}, nil)
+
+	_, err := taxes.Calculate(context.Background(), []dto.TaxItem{{StoreID: primitive.NewObjectID(), Amount: idr(100000)}})
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("err = %v, want %v", err, mongo.ErrNoDocuments)
+	}
+}
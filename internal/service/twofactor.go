@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid or expired two-factor challenge")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotStarted  = errors.New("two-factor setup has not been started")
+	ErrTwoFactorRequired    = errors.New("two-factor authentication is required for admin accounts")
+	ErrEnrolmentNotRequired = errors.New("the challenge is not an enrolment challenge")
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// twoFactorChallenge is kept in Redis between the password check and the
+// code check of a sign-in.
+type twoFactorChallenge struct {
+	Kind        string `json:"kind"`
+	AccountID   string `json:"accountID"`
+	Fingerprint string `json:"fingerprint"`
+	Device      string `json:"device"`
+	Enrolment   bool   `json:"enrolment"`
+}
+
+type TwoFactorService struct {
+	repo          repository.TwoFactor
+	settings      repository.Settings
+	redisClient   *redis.Client
+	box           *auth.SecretBox
+	issuer        string
+	challengeTTL  time.Duration
+	maxAttempts   int64
+	recoveryCodes int
+}
+
+func NewTwoFactorService(repo repository.TwoFactor, settings repository.Settings, redisClient *redis.Client,
+	box *auth.SecretBox, issuer string, challengeTTL time.Duration, maxAttempts int, recoveryCodes int) *TwoFactorService {
+	return &TwoFactorService{
+		repo:          repo,
+		settings:      settings,
+		redisClient:   redisClient,
+		box:           box,
+		issuer:        issuer,
+		challengeTTL:  challengeTTL,
+		maxAttempts:   int64(maxAttempts),
+		recoveryCodes: recoveryCodes,
+	}
+}
+
+// Challenge is called after the password was accepted. It returns nil when
+// the account can sign in straight away, otherwise a challenge the client
+// has to answer with a code, or with an enrolment for admins that are
+// required to use two-factor authentication but have not set it up.
+func (t *TwoFactorService) Challenge(ctx context.Context, account domain.AccountRef,
+	fingerprint string, device string) (*domain.TwoFactorChallenge, error) {
+	twoFactorAccount, err := t.repo.Find(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	enrolment := false
+	if !twoFactorAccount.TwoFactor.Enabled {
+		if account.Kind != domain.AccountAdmin {
+			return nil, nil
+		}
+
+		settings, err := t.settings.Security(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if !settings.RequireAdminTwoFactor {
+			return nil, nil
+		}
+		enrolment = true
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	challengeJson, err := json.Marshal(twoFactorChallenge{
+		Kind:        account.Kind,
+		AccountID:   account.ID.Hex(),
+		Fingerprint: fingerprint,
+		Device:      device,
+		Enrolment:   enrolment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.redisClient.Set(challengeKey(token), challengeJson, t.challengeTTL).Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		EnrolmentRequired: enrolment,
+		ChallengeToken:    token,
+		ExpiresAt:         time.Now().Add(t.challengeTTL),
+	}, nil
+}
+
+// SetupWithChallenge starts enrolment for an admin who was stopped at
+// sign-in by the two-factor policy.
+func (t *TwoFactorService) SetupWithChallenge(ctx context.Context, kind string, token string) (domain.TwoFactorSetup, error) {
+	challenge, err := t.findChallenge(kind, token)
+	if err != nil {
+		return domain.TwoFactorSetup{}, err
+	}
+
+	if !challenge.Enrolment {
+		return domain.TwoFactorSetup{}, ErrEnrolmentNotRequired
+	}
+
+	account, err := challenge.account()
+	if err != nil {
+		return domain.TwoFactorSetup{}, err
+	}
+
+	return t.Setup(ctx, account)
+}
+
+// VerifyChallenge answers a sign-in challenge. For enrolment challenges the
+// code confirms the new secret and the fresh recovery codes are returned.
+// A challenge is dropped after too many wrong codes.
+func (t *TwoFactorService) VerifyChallenge(ctx context.Context, kind string, input dto.TwoFactorVerifyInput) (domain.TwoFactorLogin, error) {
+	challenge, err := t.findChallenge(kind, input.ChallengeToken)
+	if err != nil {
+		return domain.TwoFactorLogin{}, err
+	}
+
+	account, err := challenge.account()
+	if err != nil {
+		return domain.TwoFactorLogin{}, err
+	}
+
+	var recoveryCodes []string
+	if challenge.Enrolment {
+		recoveryCodes, err = t.Confirm(ctx, account, input.Code)
+	} else {
+		err = t.verifyCode(ctx, account, input.Code)
+	}
+
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		attempts, attemptsErr := t.redisClient.Incr(challengeAttemptsKey(input.ChallengeToken)).Result()
+		if attemptsErr == nil && attempts == 1 {
+			t.redisClient.Expire(challengeAttemptsKey(input.ChallengeToken), t.challengeTTL)
+		}
+		if attemptsErr == nil && attempts >= t.maxAttempts {
+			t.redisClient.Del(challengeKey(input.ChallengeToken), challengeAttemptsKey(input.ChallengeToken))
+		}
+	}
+	if err != nil {
+		return domain.TwoFactorLogin{}, err
+	}
+
+	deleted, err := t.redisClient.Del(challengeKey(input.ChallengeToken)).Result()
+	if err != nil {
+		return domain.TwoFactorLogin{}, err
+	}
+
+	// a concurrent request has already used the challenge
+	if deleted == 0 {
+		return domain.TwoFactorLogin{}, ErrInvalidChallenge
+	}
+
+	return domain.TwoFactorLogin{
+		Account:       account,
+		Fingerprint:   challenge.Fingerprint,
+		Device:        challenge.Device,
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// Setup generates a new secret and keeps it pending until it is confirmed
+// with a code from the authenticator app.
+func (t *TwoFactorService) Setup(ctx context.Context, account domain.AccountRef) (domain.TwoFactorSetup, error) {
+	twoFactorAccount, err := t.repo.Find(ctx, account)
+	if err != nil {
+		return domain.TwoFactorSetup{}, err
+	}
+
+	if twoFactorAccount.TwoFactor.Enabled {
+		return domain.TwoFactorSetup{}, ErrTwoFactorEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return domain.TwoFactorSetup{}, err
+	}
+
+	sealed, err := t.box.Seal(secret)
+	if err != nil {
+		return domain.TwoFactorSetup{}, err
+	}
+
+	if err = t.repo.SetPendingSecret(ctx, account, sealed); err != nil {
+		return domain.TwoFactorSetup{}, err
+	}
+
+	return domain.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(t.issuer, twoFactorAccount.Email, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once a code for the pending
+// secret is given and returns the recovery codes, which are shown only once.
+func (t *TwoFactorService) Confirm(ctx context.Context, account domain.AccountRef, code string) ([]string, error) {
+	twoFactorAccount, err := t.repo.Find(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor := twoFactorAccount.TwoFactor
+	if twoFactor.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	if twoFactor.PendingSecret == "" {
+		return nil, ErrTwoFactorNotStarted
+	}
+
+	if err = t.verifyTOTP(account, twoFactor.PendingSecret, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := t.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.repo.Enable(ctx, account, twoFactor.PendingSecret, hashes, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off, it needs a current code or a
+// recovery code. Admins can not turn it off while the policy requires it.
+func (t *TwoFactorService) Disable(ctx context.Context, account domain.AccountRef, code string) error {
+	if account.Kind == domain.AccountAdmin {
+		settings, err := t.settings.Security(ctx)
+		if err != nil {
+			return err
+		}
+
+		if settings.RequireAdminTwoFactor {
+			return ErrTwoFactorRequired
+		}
+	}
+
+	if err := t.verifyCode(ctx, account, code); err != nil {
+		return err
+	}
+
+	return t.repo.Disable(ctx, account)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes, the old ones stop
+// working.
+func (t *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, account domain.AccountRef, code string) ([]string, error) {
+	if err := t.verifyCode(ctx, account, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := t.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = t.repo.SetRecoveryCodes(ctx, account, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (t *TwoFactorService) SecuritySettings(ctx context.Context) (domain.SecuritySettings, error) {
+	return t.settings.Security(ctx)
+}
+
+func (t *TwoFactorService) UpdateSecuritySettings(ctx context.Context, input dto.SecuritySettingsInput) (domain.SecuritySettings, error) {
+	return t.settings.UpdateSecurity(ctx, domain.SecuritySettings{
+		RequireAdminTwoFactor: input.RequireAdminTwoFactor,
+		UpdatedAt:             time.Now(),
+	})
+}
+
+// verifyCode accepts either a TOTP code or one of the recovery codes.
+func (t *TwoFactorService) verifyCode(ctx context.Context, account domain.AccountRef, code string) error {
+	twoFactorAccount, err := t.repo.Find(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	if !twoFactorAccount.TwoFactor.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == 6 {
+		return t.verifyTOTP(account, twoFactorAccount.TwoFactor.Secret, code)
+	}
+
+	used, err := t.repo.UseRecoveryCode(ctx, account, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// verifyTOTP checks the code against the sealed secret and remembers the
+// time step it matched so the same code can not be replayed.
+func (t *TwoFactorService) verifyTOTP(account domain.AccountRef, sealedSecret string, code string) error {
+	secret, err := t.box.Open(sealedSecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	usedKey := fmt.Sprintf("2fa:used:%s:%s:%d", account.Kind, account.ID.Hex(), step)
+	fresh, err := t.redisClient.SetNX(usedKey, 1, 2*time.Minute).Result()
+	if err != nil {
+		return err
+	}
+
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func (t *TwoFactorService) findChallenge(kind string, token string) (twoFactorChallenge, error) {
+	if token == "" {
+		return twoFactorChallenge{}, ErrInvalidChallenge
+	}
+
+	challengeJson, err := t.redisClient.Get(challengeKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return twoFactorChallenge{}, ErrInvalidChallenge
+	}
+	if err != nil {
+		return twoFactorChallenge{}, err
+	}
+
+	var challenge twoFactorChallenge
+	if err = json.Unmarshal(challengeJson, &challenge); err != nil {
+		return twoFactorChallenge{}, err
+	}
+
+	// a user challenge can not be answered at the admin endpoint and back
+	if challenge.Kind != kind {
+		return twoFactorChallenge{}, ErrInvalidChallenge
+	}
+
+	return challenge, nil
+}
+
+// newRecoveryCodes returns the codes to show and the hashes to store. The
+// codes carry 50 random bits, so an unsalted SHA-256 is enough to store them.
+func (t *TwoFactorService) newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, t.recoveryCodes)
+	hashes := make([]string, t.recoveryCodes)
+
+	for i := range codes {
+		random := make([]byte, 7)
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(random))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+func (c twoFactorChallenge) account() (domain.AccountRef, error) {
+	id, err := primitive.ObjectIDFromHex(c.AccountID)
+	if err != nil {
+		return domain.AccountRef{}, ErrInvalidChallenge
+	}
+
+	return domain.AccountRef{Kind: c.Kind, ID: id}, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
+
+func challengeKey(token string) string {
+	return "2fa:challenge:" + token
+}
+
+func challengeAttemptsKey(token string) string {
+	return "2fa:challenge:attempts:" + token
+}
@@ -2,23 +2,51 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"time"
+
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
 	"github.com/sigit14ap/go-commerce/internal/repository"
+	"github.com/sigit14ap/go-commerce/pkg/auth"
+	"github.com/sigit14ap/go-commerce/pkg/mailer"
+	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidToken     = errors.New("invalid or expired token")
+	ErrEmailNotVerified = errors.New("email address is not verified")
+	ErrAccountSuspended = errors.New("account is suspended")
+	ErrAccountBanned    = errors.New("account is banned")
+)
+
 type UsersService struct {
-	repo        repository.Users
-	cartService Carts
+	repo             repository.Users
+	cartService      Carts
+	tokens           auth.OneTimeTokenProvider
+	mailer           mailer.Mailer
+	frontendURL      string
+	verifyEmailTTL   time.Duration
+	resetPasswordTTL time.Duration
+	audit            Audit
 }
 
-func NewUsersService(repo repository.Users, cartService Carts) *UsersService {
+func NewUsersService(repo repository.Users, cartService Carts, tokens auth.OneTimeTokenProvider, mailer mailer.Mailer,
+	frontendURL string, verifyEmailTTL time.Duration, resetPasswordTTL time.Duration, audit Audit) *UsersService {
 	return &UsersService{
-		repo:        repo,
-		cartService: cartService,
+		repo:             repo,
+		cartService:      cartService,
+		tokens:           tokens,
+		mailer:           mailer,
+		frontendURL:      frontendURL,
+		verifyEmailTTL:   verifyEmailTTL,
+		resetPasswordTTL: resetPasswordTTL,
+		audit:            audit,
 	}
 }
 
@@ -38,18 +66,140 @@ func (u *UsersService) FindUserInfo(ctx context.Context, userID primitive.Object
 	return u.repo.FindUserInfo(ctx, userID)
 }
 
-func (u UsersService) Create(ctx context.Context, userDTO dto.CreateUserDTO) (domain.User, error) {
+// Create registers an unverified user and mails the verification link. A
+// failed mail does not undo the sign-up, the link can be requested again.
+func (u *UsersService) Create(ctx context.Context, userDTO dto.CreateUserDTO) (domain.User, error) {
 	hashPassword, err := HashPassword(userDTO.Password)
 
 	if err != nil {
 		return domain.User{}, err
 	}
 
-	return u.repo.Create(ctx, domain.User{
+	user, err := u.repo.Create(ctx, domain.User{
 		Name:     userDTO.Name,
 		Email:    userDTO.Email,
 		Password: hashPassword,
 	})
+	if err != nil {
+		return user, err
+	}
+
+	if err = u.sendVerification(user); err != nil {
+		log.Errorf("failed to send verification email to user %s: %v", user.ID.Hex(), err)
+	}
+
+	return user, nil
+}
+
+// ResendVerification mails a new verification link. Unknown and already
+// verified addresses are ignored so the endpoint does not reveal accounts.
+func (u *UsersService) ResendVerification(ctx context.Context, email string) error {
+	user, err := u.repo.FindByEmail(ctx, email)
+	if errors.Is(err, mongo.ErrNoDocuments) || user.EmailVerified {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return u.sendVerification(user)
+}
+
+func (u *UsersService) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := u.consumeToken(auth.PurposeVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+
+	return u.repo.MarkEmailVerified(ctx, userID, time.Now())
+}
+
+// ForgotPassword mails a password reset link, unknown addresses are ignored.
+func (u *UsersService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := u.repo.FindByEmail(ctx, email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := u.tokens.Issue(auth.PurposeResetPassword, user.ID.Hex(), u.resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s.\n\n%s\n\n"+
+			"If you did not ask for a password reset you can ignore this email.\n",
+			user.Name, expiresIn(u.resetPasswordTTL), u.link("/reset-password", token)),
+	})
+}
+
+// ResetPassword sets a new password. Receiving the reset link also proves
+// the user owns the address, so the email is marked verified as well.
+func (u *UsersService) ResetPassword(ctx context.Context, token string, password string) (primitive.ObjectID, error) {
+	userID, err := u.consumeToken(auth.PurposeResetPassword, token)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	hashPassword, err := HashPassword(password)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	err = u.repo.UpdatePassword(ctx, userID, hashPassword)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return userID, u.repo.MarkEmailVerified(ctx, userID, time.Now())
+}
+
+func (u *UsersService) sendVerification(user domain.User) error {
+	token, err := u.tokens.Issue(auth.PurposeVerifyEmail, user.ID.Hex(), u.verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address with the link below. It expires in %s.\n\n%s\n",
+			user.Name, expiresIn(u.verifyEmailTTL), u.link("/verify-email", token)),
+	})
+}
+
+func (u *UsersService) consumeToken(purpose string, token string) (primitive.ObjectID, error) {
+	subject, err := u.tokens.Consume(purpose, token)
+	if errors.Is(err, auth.ErrInvalidOneTimeToken) {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	userID, err := primitive.ObjectIDFromHex(subject)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+
+	return userID, nil
+}
+
+func (u *UsersService) link(path string, token string) string {
+	return u.frontendURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func expiresIn(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d hours", ttl/time.Hour)
+	}
+
+	return fmt.Sprintf("%d minutes", ttl/time.Minute)
 }
 
 func (u *UsersService) Update(ctx context.Context, userDTO dto.UpdateUserDTO, userID primitive.ObjectID) (domain.User, error) {
@@ -59,7 +209,7 @@ func (u *UsersService) Update(ctx context.Context, userDTO dto.UpdateUserDTO, us
 }
 
 func (u *UsersService) Delete(ctx context.Context, userID primitive.ObjectID) error {
-	_, err := u.FindByID(ctx, userID)
+	user, err := u.FindByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("not found user with id: %s", userID)
 	}
@@ -67,6 +217,47 @@ func (u *UsersService) Delete(ctx context.Context, userID primitive.ObjectID) er
 	if err != nil {
 		return err
 	}
+
+	u.audit.Track(ctx, domain.AuditActionUserDelete, domain.AuditTargetUser, userID, user, nil)
+	return nil
+}
+
+func (u *UsersService) UpdateStatus(ctx context.Context, userID primitive.ObjectID, input dto.UpdateStatusInput) (domain.User, error) {
+	before, err := u.repo.FindByID(ctx, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	err = u.repo.UpdateStatus(ctx, userID, input.Status, input.Reason)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := u.repo.FindByID(ctx, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	u.audit.Track(ctx, domain.AuditActionUserStatus+"."+input.Status, domain.AuditTargetUser, userID, before, user)
+
+	return user, nil
+}
+
+// CheckStatus fails with ErrAccountSuspended or ErrAccountBanned when the
+// user may not use the account.
+func (u *UsersService) CheckStatus(ctx context.Context, userID primitive.ObjectID) error {
+	user, err := u.repo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	switch user.Status {
+	case domain.StatusSuspended:
+		return ErrAccountSuspended
+	case domain.StatusBanned:
+		return ErrAccountBanned
+	}
+
 	return nil
 }
 
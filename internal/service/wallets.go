@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"github.com/sigit14ap/go-commerce/internal/repository"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrLedgerUnbalanced = errors.New("ledger transaction is unbalanced")
+	ErrPayoutNotFound   = errors.New("payout not found")
+	ErrPayoutNotAllowed = errors.New("payout not allowed")
+)
+
+const (
+	defaultStatementLimit int64 = 20
+	payoutLockTTL               = 30 * time.Second
+)
+
+type WalletsService struct {
+	ledger         repository.Ledger
+	payouts        repository.Payouts
+	returns        repository.Returns
+	commissionRate float64
+}
+
+func NewWalletsService(ledger repository.Ledger, payouts repository.Payouts, returns repository.Returns, commissionRate float64) *WalletsService {
+	return &WalletsService{
+		ledger:         ledger,
+		payouts:        payouts,
+		returns:        returns,
+		commissionRate: commissionRate,
+	}
+}
+
+// SettleOrder posts the seller share of a completed order to the store ledger.
+// Settling an order twice is a no-op.
+func (w *WalletsService) SettleOrder(ctx context.Context, order domain.Order) error {
+	returns, err := w.returns.FindByOrderID(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	var refunded domain.Money
+	for _, returnRequest := range returns {
+		if returnRequest.Status == domain.ReturnStatusRefunded || returnRequest.Status == domain.ReturnStatusRefundPending {
+			refunded = refunded.Add(returnRequest.RefundAmount)
+		}
+	}
+
+	err = w.post(ctx, settlementTransaction(order, refunded, w.commissionRate, time.Now()))
+	if errors.Is(err, repository.ErrLedgerDuplicate) {
+		return nil
+	}
+
+	return err
+}
+
+func (w *WalletsService) FindWallet(ctx context.Context, storeID primitive.ObjectID, page int64, limit int64) (domain.Wallet, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = defaultStatementLimit
+	}
+
+	balance, pending, err := w.balances(ctx, storeID)
+	if err != nil {
+		return domain.Wallet{}, err
+	}
+
+	lines, total, err := w.ledger.Statement(ctx, domain.StoreLedgerAccount(storeID), page, limit)
+	if err != nil {
+		return domain.Wallet{}, err
+	}
+
+	return domain.Wallet{
+		Balance:   balance,
+		Pending:   pending,
+		Available: balance.Sub(pending),
+		Statement: domain.Statement{
+			Lines: lines,
+			Total: total,
+			Page:  page,
+			Limit: limit,
+		},
+	}, nil
+}
+
+func (w *WalletsService) FindPayouts(ctx context.Context, status string) ([]domain.Payout, error) {
+	return w.payouts.FindAll(ctx, status)
+}
+
+func (w *WalletsService) FindPayoutsByStore(ctx context.Context, storeID primitive.ObjectID) ([]domain.Payout, error) {
+	return w.payouts.FindByStoreID(ctx, storeID)
+}
+
+// RequestPayout reserves part of the available balance of the store for a
+// transfer to its registered bank account. Requests of the same store are
+// serialized by the payout lock, so two of them can't both spend the same
+// available balance.
+func (w *WalletsService) RequestPayout(ctx context.Context, store domain.Store, amount domain.Money) (domain.Payout, error) {
+	if store.BankAccount == nil {
+		return domain.Payout{}, fmt.Errorf("%w: register a bank account first", ErrPayoutNotAllowed)
+	}
+
+	if !amount.IsPositive() {
+		return domain.Payout{}, fmt.Errorf("%w: amount must be positive", ErrPayoutNotAllowed)
+	}
+
+	if amount.Currency != domain.DefaultCurrency {
+		return domain.Payout{}, fmt.Errorf("%w: payouts are made in %s", ErrPayoutNotAllowed, domain.DefaultCurrency)
+	}
+
+	token, err := w.payouts.Lock(ctx, store.ID, payoutLockTTL)
+	if errors.Is(err, repository.ErrPayoutLocked) {
+		return domain.Payout{}, fmt.Errorf("%w: another payout request is in progress", ErrPayoutNotAllowed)
+	}
+	if err != nil {
+		return domain.Payout{}, err
+	}
+	defer func() {
+		if err := w.payouts.Unlock(ctx, store.ID, token); err != nil {
+			log.Errorf("failed to unlock payouts of store %s: %v", store.ID.Hex(), err)
+		}
+	}()
+
+	balance, pending, err := w.balances(ctx, store.ID)
+	if err != nil {
+		return domain.Payout{}, err
+	}
+
+	if amount.Cmp(balance.Sub(pending)) > 0 {
+		return domain.Payout{}, fmt.Errorf("%w: amount exceeds available balance", ErrPayoutNotAllowed)
+	}
+
+	now := time.Now()
+	return w.payouts.Create(ctx, domain.Payout{
+		StoreID:     store.ID,
+		Amount:      amount,
+		BankAccount: *store.BankAccount,
+		Status:      domain.PayoutStatusRequested,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+}
+
+func (w *WalletsService) ApprovePayout(ctx context.Context, payoutID primitive.ObjectID, review dto.PayoutReviewDTO) (domain.Payout, error) {
+	return w.transition(ctx, payoutID, []string{domain.PayoutStatusRequested}, dto.PayoutTransitionInput{
+		Status:  domain.PayoutStatusApproved,
+		AdminID: review.AdminID,
+		Note:    review.Note,
+	})
+}
+
+func (w *WalletsService) RejectPayout(ctx context.Context, payoutID primitive.ObjectID, review dto.PayoutReviewDTO) (domain.Payout, error) {
+	return w.transition(ctx, payoutID, []string{domain.PayoutStatusRequested, domain.PayoutStatusApproved}, dto.PayoutTransitionInput{
+		Status:  domain.PayoutStatusRejected,
+		AdminID: review.AdminID,
+		Note:    review.Note,
+	})
+}
+
+// MarkPayoutPaid records the bank transfer of an approved payout and debits
+// the store ledger. The debit is posted first so a paid payout is never
+// missing from the ledger.
+func (w *WalletsService) MarkPayoutPaid(ctx context.Context, payoutID primitive.ObjectID, review dto.PayoutReviewDTO) (domain.Payout, error) {
+	payout, err := w.payouts.FindByID(ctx, payoutID)
+	if err != nil {
+		return domain.Payout{}, ErrPayoutNotFound
+	}
+
+	if payout.Status != domain.PayoutStatusApproved {
+		return domain.Payout{}, fmt.Errorf("%w: payout has not been approved", ErrPayoutNotAllowed)
+	}
+
+	now := time.Now()
+	err = w.post(ctx, payoutTransaction(payout, now))
+	if err != nil && !errors.Is(err, repository.ErrLedgerDuplicate) {
+		return domain.Payout{}, err
+	}
+
+	return w.transition(ctx, payoutID, []string{domain.PayoutStatusApproved}, dto.PayoutTransitionInput{
+		Status:  domain.PayoutStatusPaid,
+		AdminID: review.AdminID,
+		Note:    review.Note,
+		PaidAt:  now,
+	})
+}
+
+// balances returns the ledger balance of the store and the amount held by
+// payouts that have not been paid or rejected yet. Wallets are kept in the
+// default currency.
+func (w *WalletsService) balances(ctx context.Context, storeID primitive.ObjectID) (domain.Money, domain.Money, error) {
+	balance, err := w.ledger.Balance(ctx, domain.StoreLedgerAccount(storeID), domain.DefaultCurrency)
+	if err != nil {
+		return domain.Money{}, domain.Money{}, err
+	}
+
+	pending, err := w.payouts.PendingAmount(ctx, storeID, domain.DefaultCurrency)
+	if err != nil {
+		return domain.Money{}, domain.Money{}, err
+	}
+
+	return balance, pending, nil
+}
+
+func (w *WalletsService) post(ctx context.Context, transaction domain.LedgerTransaction) error {
+	if err := validateTransaction(transaction); err != nil {
+		return err
+	}
+
+	_, err := w.ledger.Insert(ctx, transaction)
+	return err
+}
+
+func (w *WalletsService) transition(ctx context.Context, payoutID primitive.ObjectID, fromStatuses []string, transition dto.PayoutTransitionInput) (domain.Payout, error) {
+	transition.UpdatedAt = time.Now()
+
+	payout, err := w.payouts.Transition(ctx, payoutID, fromStatuses, transition)
+	if errors.Is(err, repository.ErrPayoutStatusConflict) {
+		if _, findErr := w.payouts.FindByID(ctx, payoutID); findErr != nil {
+			return domain.Payout{}, ErrPayoutNotFound
+		}
+		return domain.Payout{}, fmt.Errorf("%w: payout is not in the expected state", ErrPayoutNotAllowed)
+	}
+
+	return payout, err
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/service"
+	log "github.com/sirupsen/logrus"
+)
+
+// AuditPurger deletes the audit entries that are past the retention period.
+type AuditPurger struct {
+	services *service.Services
+	interval time.Duration
+}
+
+func NewAuditPurger(services *service.Services, interval time.Duration) *AuditPurger {
+	return &AuditPurger{
+		services: services,
+		interval: interval,
+	}
+}
+
+func (p *AuditPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.services.Audit.Purge(ctx)
+		if err != nil {
+			log.Errorf("audit purger: failed to purge audit log: %v", err)
+		} else if purged > 0 {
+			log.Infof("audit purger: purged %d audit entries", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
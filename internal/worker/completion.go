@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CompletionJob completes delivered orders the buyer did not confirm within
+// the confirmation period and retries settlements and refunds of cancelled
+// orders that failed earlier.
+type CompletionJob struct {
+	services      *service.Services
+	completeAfter time.Duration
+	interval      time.Duration
+}
+
+func NewCompletionJob(services *service.Services, completeAfter time.Duration, interval time.Duration) *CompletionJob {
+	return &CompletionJob{
+		services:      services,
+		completeAfter: completeAfter,
+		interval:      interval,
+	}
+}
+
+func (j *CompletionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.completeDelivered(ctx)
+		j.settleCompleted(ctx)
+		j.refundCancelled(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *CompletionJob) completeDelivered(ctx context.Context) {
+	orders, err := j.services.Orders.FindByStatus(ctx, domain.OrderStatusDelivered)
+	if err != nil {
+		log.Errorf("completion job: failed to find delivered orders: %v", err)
+		return
+	}
+
+	for _, order := range orders {
+		if order.DeliveredAt.IsZero() || time.Since(order.DeliveredAt) < j.completeAfter {
+			continue
+		}
+
+		_, err = j.services.Orders.Complete(ctx, order.ID, domain.OrderActorSystem, primitive.NilObjectID)
+		if errors.Is(err, service.ErrOrderDisputed) || errors.Is(err, service.ErrOrderInvalidStatus) {
+			continue
+		}
+
+		if err != nil {
+			log.Errorf("completion job: failed to complete order %s: %v", order.OrderID, err)
+		}
+	}
+}
+
+func (j *CompletionJob) settleCompleted(ctx context.Context) {
+	orders, err := j.services.Orders.FindUnsettled(ctx)
+	if err != nil {
+		log.Errorf("completion job: failed to find unsettled orders: %v", err)
+		return
+	}
+
+	for _, order := range orders {
+		err = j.services.Orders.Settle(ctx, order)
+		if err != nil {
+			log.Errorf("completion job: failed to settle order %s: %v", order.OrderID, err)
+		}
+	}
+}
+
+func (j *CompletionJob) refundCancelled(ctx context.Context) {
+	orders, err := j.services.Orders.FindRefundPending(ctx)
+	if err != nil {
+		log.Errorf("completion job: failed to find cancelled orders awaiting a refund: %v", err)
+		return
+	}
+
+	for _, order := range orders {
+		_, err = j.services.Orders.RefundCancelled(ctx, order)
+		if err != nil {
+			log.Errorf("completion job: failed to refund cancelled order %s: %v", order.OrderID, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/service"
+	log "github.com/sirupsen/logrus"
+)
+
+// StatsFlusher moves the product views and cart adds counted in Redis to
+// Mongo, where the store analytics read them.
+type StatsFlusher struct {
+	services *service.Services
+	interval time.Duration
+}
+
+func NewStatsFlusher(services *service.Services, interval time.Duration) *StatsFlusher {
+	return &StatsFlusher{
+		services: services,
+		interval: interval,
+	}
+}
+
+func (f *StatsFlusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := f.services.Stats.Flush(ctx); err != nil {
+			log.Errorf("stats flusher: failed to flush product stats: %v", err)
+		}
+	}
+}
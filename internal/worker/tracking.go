@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/sigit14ap/go-commerce/internal/domain"
+	"github.com/sigit14ap/go-commerce/internal/service"
+	"github.com/sigit14ap/go-commerce/pkg/courier"
+	log "github.com/sirupsen/logrus"
+)
+
+// TrackingPoller periodically refreshes courier tracking of shipped orders.
+type TrackingPoller struct {
+	services *service.Services
+	courier  courier.CourierProvider
+	interval time.Duration
+}
+
+func NewTrackingPoller(services *service.Services, courier courier.CourierProvider, interval time.Duration) *TrackingPoller {
+	return &TrackingPoller{
+		services: services,
+		courier:  courier,
+		interval: interval,
+	}
+}
+
+func (p *TrackingPoller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrackingPoller) poll(ctx context.Context) {
+	orders, err := p.services.Orders.FindByStatus(ctx, domain.OrderStatusShipped)
+	if err != nil {
+		log.Errorf("tracking poller: failed to find shipped orders: %v", err)
+		return
+	}
+
+	for _, order := range orders {
+		if order.Shipment == nil {
+			continue
+		}
+
+		waybill, err := p.courier.GetWaybill(order.Shipment.Courier, order.Shipment.TrackingNumber)
+		if err != nil {
+			log.Warnf("tracking poller: waybill %s of order %s: %v", order.Shipment.TrackingNumber, order.OrderID, err)
+			continue
+		}
+
+		_, err = p.services.Orders.UpdateTracking(ctx, order, waybill)
+		if err != nil {
+			log.Errorf("tracking poller: failed to update order %s: %v", order.OrderID, err)
+		}
+	}
+}
@@ -16,6 +16,7 @@ type RefreshSession struct {
 	RefreshToken string        `json:"refreshToken"`
 	RefreshExp   int64         `json:"refreshExp"`
 	Fingerprint  string        `json:"fingerprint"`
+	SessionID    string        `json:"sessionID"`
 	Claims       jwt.MapClaims `json:"claims"`
 }
 
@@ -25,12 +26,15 @@ type AuthDetails struct {
 }
 
 type RefreshInput struct {
-	RefreshToken string `json:"refreshToken"`
-	Fingerprint  string `json:"fingerprint"`
+	RefreshToken string          `json:"refreshToken"`
+	Fingerprint  string          `json:"fingerprint"`
+	Metadata     SessionMetadata `json:"-"`
 }
 
 type CreateSessionInput struct {
 	Fingerprint string
+	Subject     string
+	Metadata    SessionMetadata
 	Claims      jwt.MapClaims
 }
 
@@ -38,88 +42,163 @@ type TokenProvider interface {
 	CreateJWTSession(input CreateSessionInput) (*AuthDetails, error)
 	VerifyToken(tokenString string) (jwt.MapClaims, error)
 	Refresh(refreshInput RefreshInput) (*AuthDetails, error)
+	JWKS() JWKSet
+	Sessions(subject string) ([]Session, error)
+	RevokeSession(subject string, sessionID string) error
+	RevokeAll(subject string) error
+	IssueReadOnlyToken(subject string, claims jwt.MapClaims) (string, error)
 }
 
 type Provider struct {
 	cfg         *config.Config
 	redisClient *redis.Client
+	keys        *KeySet
 }
 
-func NewTokenProvider(cfg *config.Config, redisClient *redis.Client) *Provider {
+func NewTokenProvider(cfg *config.Config, redisClient *redis.Client, keys *KeySet) *Provider {
 	return &Provider{
 		cfg:         cfg,
 		redisClient: redisClient,
+		keys:        keys,
 	}
 }
 
+// CreateJWTSession starts a new session for the subject and issues its first
+// access and refresh token pair.
 func (p *Provider) CreateJWTSession(input CreateSessionInput) (*AuthDetails, error) {
-	accessExpTime := time.Minute * time.Duration(p.cfg.JWT.AccessTokenTime)
-	accessExp := time.Now().Add(accessExpTime).Unix()
-	input.Claims["exp"] = accessExp
+	now := time.Now()
+	session := Session{
+		ID:        uuid.NewV4().String(),
+		Subject:   input.Subject,
+		Device:    input.Metadata.Device,
+		IP:        input.Metadata.IP,
+		UserAgent: input.Metadata.UserAgent,
+		CreatedAt: now,
+	}
+
+	return p.issueTokens(session, input.Fingerprint, input.Claims)
+}
+
+// Refresh rotates the refresh token of a session, the session keeps its ID.
+func (p *Provider) Refresh(refreshInput RefreshInput) (*AuthDetails, error) {
+	refreshJson, err := p.redisClient.Get(refreshInput.RefreshToken).Bytes()
+	if err != nil {
+		return nil, errors.New("Invalid refresh token")
+	}
 
-	unsignedToken := jwt.NewWithClaims(jwt.SigningMethodHS256, input.Claims)
-	accessToken, err := unsignedToken.SignedString([]byte(p.cfg.JWT.Secret))
+	err = p.redisClient.Del(refreshInput.RefreshToken).Err()
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken := uuid.NewV4().String()
-	refreshExpTime := time.Minute * time.Duration(p.cfg.JWT.RefreshTokenTime)
-	refreshExp := time.Now().Add(refreshExpTime).Unix()
+	var refresh RefreshSession
+	err = json.Unmarshal(refreshJson, &refresh)
+	if err != nil {
+		return nil, err
+	}
 
-	session := RefreshSession{
-		RefreshToken: refreshToken,
-		RefreshExp:   refreshExp,
-		Fingerprint:  input.Fingerprint,
-		Claims:       input.Claims,
+	if refresh.Fingerprint != refreshInput.Fingerprint {
+		return nil, errors.New("Invalid client fingerprint")
 	}
 
-	sessionJson, err := json.Marshal(session)
+	session, err := p.findSession(refresh.SessionID)
 	if err != nil {
-		return nil, err
+		return nil, errors.New("Invalid refresh token")
 	}
 
-	p.redisClient.Set(refreshToken, sessionJson, refreshExpTime)
+	session.IP = refreshInput.Metadata.IP
+	session.UserAgent = refreshInput.Metadata.UserAgent
 
-	return &AuthDetails{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+	return p.issueTokens(session, refreshInput.Fingerprint, refresh.Claims)
 }
 
-func (p *Provider) Refresh(refreshInput RefreshInput) (*AuthDetails, error) {
-	sessionJson, err := p.redisClient.Get(refreshInput.RefreshToken).Bytes()
+func (p *Provider) issueTokens(session Session, fingerprint string, claims jwt.MapClaims) (*AuthDetails, error) {
+	now := time.Now()
+	claims["exp"] = now.Add(p.accessTTL()).Unix()
+	claims["iat"] = now.Unix()
+	claims[issuedAtMillisClaim] = now.UnixMilli()
+	claims["sid"] = session.ID
+	claims["sub"] = session.Subject
+
+	accessToken, err := p.sign(claims)
 	if err != nil {
-		return nil, errors.New("Invalid refresh token")
+		return nil, err
 	}
 
-	err = p.redisClient.Del(refreshInput.RefreshToken).Err()
+	refreshToken := uuid.NewV4().String()
+	refreshExpTime := time.Minute * time.Duration(p.cfg.JWT.RefreshTokenTime)
+	refreshExp := now.Add(refreshExpTime)
+
+	refreshJson, err := json.Marshal(RefreshSession{
+		RefreshToken: refreshToken,
+		RefreshExp:   refreshExp.Unix(),
+		Fingerprint:  fingerprint,
+		SessionID:    session.ID,
+		Claims:       claims,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var session RefreshSession
-	err = json.Unmarshal(sessionJson, &session)
-	if err != nil {
+	session.RefreshToken = refreshToken
+	session.LastUsedAt = now
+	session.ExpiresAt = refreshExp
+
+	pipeline := p.redisClient.TxPipeline()
+	pipeline.Set(refreshToken, refreshJson, refreshExpTime)
+	if err = p.saveSession(pipeline, session, refreshExpTime); err != nil {
 		return nil, err
 	}
 
-	if session.Fingerprint != refreshInput.Fingerprint {
-		return nil, errors.New("Invalid client fingerprint")
+	if _, err = pipeline.Exec(); err != nil {
+		return nil, err
 	}
 
-	return p.CreateJWTSession(CreateSessionInput{
-		Fingerprint: refreshInput.Fingerprint,
-		Claims:      session.Claims,
-	})
+	return &AuthDetails{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
 }
 
+// IssueReadOnlyToken issues a short-lived access token for impersonating the
+// subject. It belongs to no session and cannot be refreshed, revoking all
+// sessions of the subject revokes it too.
+func (p *Provider) IssueReadOnlyToken(subject string, claims jwt.MapClaims) (string, error) {
+	now := time.Now()
+	claims["exp"] = now.Add(time.Minute * time.Duration(p.cfg.JWT.ImpersonationMinutes)).Unix()
+	claims["iat"] = now.Unix()
+	claims[issuedAtMillisClaim] = now.UnixMilli()
+	claims["sub"] = subject
+	claims["readOnly"] = true
+
+	return p.sign(claims)
+}
+
+func (p *Provider) sign(claims jwt.MapClaims) (string, error) {
+	key, err := p.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	unsignedToken := jwt.NewWithClaims(key.method(), claims)
+	unsignedToken.Header["kid"] = key.ID
+	return unsignedToken.SignedString(key.private)
+}
+
+// VerifyToken checks the signature against the published key named by the
+// token's "kid" header, the algorithm has to be the one of that key.
 func (p *Provider) VerifyToken(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		keyID, _ := token.Header["kid"].(string)
+		key, err := p.keys.VerificationKey(keyID)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Algorithm {
 			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(p.cfg.JWT.Secret), nil
+		return key.Public(), nil
 	})
 
 	if err != nil {
@@ -127,9 +206,18 @@ func (p *Provider) VerifyToken(tokenString string) (jwt.MapClaims, error) {
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		return claims, nil
+	if !ok || !token.Valid {
+		return nil, errors.New("Token or claims are invalid")
 	}
 
-	return nil, errors.New("Token or claims are invalid")
+	if err = p.checkRevoked(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// JWKS lists the public keys tokens can currently be verified with.
+func (p *Provider) JWKS() JWKSet {
+	return p.keys.JWKS()
 }
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/sigit14ap/go-commerce/internal/config"
+)
+
+func TestIssueReadOnlyToken(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.ImpersonationMinutes = 15
+	keySet := newTestKeySet(t, AlgorithmRS256)
+	provider := NewTokenProvider(cfg, nil, keySet)
+
+	signed, err := provider.IssueReadOnlyToken(Subject("userID", "42"), jwt.MapClaims{
+		"userID":         "42",
+		"impersonatorID": "7",
+	})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	parsed, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		key, err := keySet.VerificationKey(token.Header["kid"].(string))
+		if err != nil {
+			return nil, err
+		}
+		return key.Public(), nil
+	})
+	if err != nil || !parsed.Valid {
+		t.Fatalf("verify: %v", err)
+	}
+
+	claims := parsed.Claims.(jwt.MapClaims)
+	if claims["readOnly"] != true || claims["sub"] != "userID:42" || claims["impersonatorID"] != "7" {
+		t.Errorf("claims = %v", claims)
+	}
+	if _, ok := claims["sid"]; ok {
+		t.Error("read-only tokens must not belong to a session")
+	}
+
+	expiresIn := time.Until(time.Unix(int64(claims["exp"].(float64)), 0))
+	if expiresIn <= 14*time.Minute || expiresIn > 15*time.Minute {
+		t.Errorf("token expires in %s, want 15m", expiresIn)
+	}
+}
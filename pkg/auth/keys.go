@@ -0,0 +1,398 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/sigit14ap/go-commerce/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	rsaKeyBits = 2048
+
+	keyIDHeader     = "Key-ID"
+	createdAtHeader = "Created-At"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// SigningKey is one private key of the key set. Its ID is sent as the "kid"
+// header of every token it signs.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	File      string
+	private   crypto.Signer
+}
+
+func (k *SigningKey) Public() crypto.PublicKey {
+	return k.private.Public()
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmEdDSA {
+		return SigningMethodEdDSA
+	}
+
+	return jwt.SigningMethodRS256
+}
+
+// KeySet holds the keys access tokens are signed with, stored as PEM files in
+// one directory. A new key is published as soon as it is created but only
+// signs tokens once it is older than the activation delay, so verifiers that
+// cache the JWKS have time to pick it up. The key it replaces keeps verifying
+// tokens until every token it signed has expired, which is what lets keys be
+// rotated without signing anyone out.
+type KeySet struct {
+	algorithm   string
+	directory   string
+	rotateAfter time.Duration
+	activation  time.Duration
+	retention   time.Duration
+
+	mu   sync.RWMutex
+	keys []*SigningKey
+}
+
+// NewKeySet loads the keys of the configured directory and generates the
+// first one when there is none.
+func NewKeySet(cfg *config.Config) (*KeySet, error) {
+	keySet := &KeySet{
+		algorithm:   cfg.JWT.Algorithm,
+		directory:   cfg.JWT.KeysDirectory,
+		rotateAfter: time.Duration(cfg.JWT.KeyRotationDays) * 24 * time.Hour,
+		activation:  time.Duration(cfg.JWT.KeyActivationMinutes) * time.Minute,
+		retention:   time.Duration(cfg.JWT.AccessTokenTime) * time.Minute,
+	}
+
+	if keySet.algorithm != AlgorithmRS256 && keySet.algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", keySet.algorithm)
+	}
+
+	if err := keySet.Reload(); err != nil {
+		return nil, err
+	}
+
+	if len(keySet.Keys()) == 0 {
+		if _, err := keySet.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return keySet, nil
+}
+
+// Keys lists every key that is still published, oldest first.
+func (s *KeySet) Keys() []*SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	var keys []*SigningKey
+	for i, key := range s.keys {
+		if !s.expired(i, now) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// SigningKey returns the newest key past its activation delay. The oldest
+// key signs when no key is active yet, which happens on first start.
+func (s *KeySet) SigningKey() (*SigningKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if i == 0 || !now.Before(s.keys[i].CreatedAt.Add(s.activation)) {
+			return s.keys[i], nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// VerificationKey finds a published key by its ID.
+func (s *KeySet) VerificationKey(keyID string) (*SigningKey, error) {
+	for _, key := range s.Keys() {
+		if key.ID == keyID {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+func (s *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range s.Keys() {
+		set.Keys = append(set.Keys, publicJWK(key))
+	}
+
+	return set
+}
+
+// Reload reads the key directory again, picking up keys added by another
+// instance or by the rotate command.
+func (s *KeySet) Reload() error {
+	if err := os.MkdirAll(s.directory, 0700); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.directory, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*SigningKey, 0, len(files))
+	for _, file := range files {
+		key, err := readKeyFile(file)
+		if err != nil {
+			return fmt.Errorf("jwt key %s: %w", file, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Rotate generates a new key in the configured algorithm and removes the
+// files of keys that no longer verify any live token.
+func (s *KeySet) Rotate() (*SigningKey, error) {
+	key, err := generateKey(s.algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = writeKeyFile(s.directory, key); err != nil {
+		return nil, err
+	}
+
+	if err = s.Reload(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	now := time.Now()
+	var expired []*SigningKey
+	for i, stored := range s.keys {
+		if s.expired(i, now) {
+			expired = append(expired, stored)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, stored := range expired {
+		if err = os.Remove(stored.File); err != nil {
+			log.Warnf("failed to remove expired jwt key %s: %s", stored.ID, err)
+		}
+	}
+
+	log.Infof("jwt key %s (%s) created", key.ID, key.Algorithm)
+	return key, s.Reload()
+}
+
+// Watch reloads the directory on every tick and rotates the signing key once
+// the newest key is older than the rotation period.
+func (s *KeySet) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Reload(); err != nil {
+			log.Errorf("failed to reload jwt keys: %s", err)
+			continue
+		}
+
+		if s.rotationDue() {
+			if _, err := s.Rotate(); err != nil {
+				log.Errorf("failed to rotate jwt key: %s", err)
+			}
+		}
+	}
+}
+
+func (s *KeySet) rotationDue() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.rotateAfter <= 0 || len(s.keys) == 0 {
+		return false
+	}
+
+	return time.Since(s.keys[len(s.keys)-1].CreatedAt) >= s.rotateAfter
+}
+
+// expired reports whether the key at index i was replaced long enough ago
+// that every token it signed has expired. Callers hold the lock.
+func (s *KeySet) expired(i int, now time.Time) bool {
+	if i+1 >= len(s.keys) {
+		return false
+	}
+
+	replacedAt := s.keys[i+1].CreatedAt.Add(s.activation)
+	return !now.Before(replacedAt.Add(s.retention))
+}
+
+func generateKey(algorithm string) (*SigningKey, error) {
+	var private crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("unsupported jwt algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err = rand.Read(suffix); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	return &SigningKey{
+		ID:        now.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix),
+		Algorithm: algorithm,
+		CreatedAt: now,
+		private:   private,
+	}, nil
+}
+
+// writeKeyFile stores the key as PKCS#8 with its ID and creation time in the
+// PEM headers. The file is written under a temporary name first so another
+// instance never reads half a key.
+func writeKeyFile(directory string, key *SigningKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return err
+	}
+
+	block := &pem.Block{
+		Type: "PRIVATE KEY",
+		Headers: map[string]string{
+			keyIDHeader:     key.ID,
+			createdAtHeader: key.CreatedAt.Format(time.RFC3339Nano),
+		},
+		Bytes: der,
+	}
+
+	key.File = filepath.Join(directory, key.ID+".pem")
+	temporary := key.File + ".tmp"
+	if err = os.WriteFile(temporary, pem.EncodeToMemory(block), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(temporary, key.File)
+}
+
+// readKeyFile accepts the files written by writeKeyFile as well as plain
+// PKCS#1 or PKCS#8 keys supplied by an operator, which take their ID from the
+// file name and their creation time from the modification time.
+func readKeyFile(file string) (*SigningKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{
+		ID:   block.Headers[keyIDHeader],
+		File: file,
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < rsaKeyBits {
+			return nil, fmt.Errorf("rsa key must have at least %d bits", rsaKeyBits)
+		}
+		key.Algorithm = AlgorithmRS256
+		key.private = private
+	case ed25519.PrivateKey:
+		key.Algorithm = AlgorithmEdDSA
+		key.private = private
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	if key.ID == "" {
+		key.ID = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+
+	if createdAt, ok := block.Headers[createdAtHeader]; ok {
+		key.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		key.CreatedAt = info.ModTime()
+	}
+
+	return key, nil
+}
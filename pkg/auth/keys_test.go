@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func newTestKeySet(t *testing.T, algorithm string) *KeySet {
+	t.Helper()
+
+	keySet := &KeySet{
+		algorithm:  algorithm,
+		directory:  t.TempDir(),
+		activation: 10 * time.Minute,
+		retention:  15 * time.Minute,
+	}
+
+	if _, err := keySet.Rotate(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	return keySet
+}
+
+func TestKeySetSignAndVerify(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			keySet := newTestKeySet(t, algorithm)
+
+			key, err := keySet.SigningKey()
+			if err != nil {
+				t.Fatalf("signing key: %v", err)
+			}
+
+			token := jwt.NewWithClaims(key.method(), jwt.MapClaims{"userID": "42"})
+			token.Header["kid"] = key.ID
+			signed, err := token.SignedString(key.private)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+
+			parsed, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+				verificationKey, err := keySet.VerificationKey(token.Header["kid"].(string))
+				if err != nil {
+					return nil, err
+				}
+				return verificationKey.Public(), nil
+			})
+			if err != nil || !parsed.Valid {
+				t.Fatalf("verify: %v", err)
+			}
+
+			if parsed.Header["alg"] != algorithm {
+				t.Errorf("alg = %v, want %s", parsed.Header["alg"], algorithm)
+			}
+
+			jwks := keySet.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != key.ID || jwks.Keys[0].Algorithm != algorithm {
+				t.Errorf("jwks = %+v", jwks)
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	keySet := newTestKeySet(t, AlgorithmEdDSA)
+	old, _ := keySet.SigningKey()
+
+	rotated, err := keySet.Rotate()
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	// published right away but not signing before the activation delay
+	if key, _ := keySet.SigningKey(); key.ID != old.ID {
+		t.Errorf("signing key = %s, want previous key %s", key.ID, old.ID)
+	}
+	if len(keySet.JWKS().Keys) != 2 {
+		t.Errorf("published %d keys, want 2", len(keySet.JWKS().Keys))
+	}
+
+	setCreatedAt(keySet, rotated.ID, time.Now().Add(-11*time.Minute))
+	if key, _ := keySet.SigningKey(); key.ID != rotated.ID {
+		t.Errorf("signing key = %s, want rotated key %s", key.ID, rotated.ID)
+	}
+	if _, err = keySet.VerificationKey(old.ID); err != nil {
+		t.Errorf("previous key should still verify: %v", err)
+	}
+
+	setCreatedAt(keySet, rotated.ID, time.Now().Add(-30*time.Minute))
+	if _, err = keySet.VerificationKey(old.ID); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expired key lookup err = %v, want ErrUnknownKey", err)
+	}
+	if len(keySet.JWKS().Keys) != 1 {
+		t.Errorf("published %d keys, want 1", len(keySet.JWKS().Keys))
+	}
+}
+
+func TestReadOperatorKeyFile(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "primary.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	if err = os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := readKeyFile(file)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	if key.ID != "primary" || key.Algorithm != AlgorithmRS256 || key.CreatedAt.IsZero() {
+		t.Errorf("key = %+v", key)
+	}
+}
+
+func setCreatedAt(keySet *KeySet, keyID string, createdAt time.Time) {
+	keySet.mu.Lock()
+	defer keySet.mu.Unlock()
+
+	for _, key := range keySet.keys {
+		if key.ID == keyID {
+			key.CreatedAt = createdAt
+		}
+	}
+}
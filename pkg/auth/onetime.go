@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sigit14ap/go-commerce/internal/config"
+)
+
+const (
+	PurposeVerifyEmail   = "verify-email"
+	PurposeResetPassword = "reset-password"
+	PurposeChangeEmail   = "change-email"
+)
+
+var ErrInvalidOneTimeToken = errors.New("invalid or expired token")
+
+// consumeScript reads and deletes the token in one step, so a token can be
+// redeemed only once even under concurrent requests.
+var consumeScript = redis.NewScript(`
+local subject = redis.call("GET", KEYS[1])
+if subject then
+	redis.call("DEL", KEYS[1])
+	redis.call("DEL", KEYS[2])
+end
+return subject
+`)
+
+type OneTimeTokenProvider interface {
+	Issue(purpose string, subject string, ttl time.Duration) (string, error)
+	Consume(purpose string, token string) (string, error)
+}
+
+// OneTimeTokens issues signed, expiring, single-use tokens for a subject such
+// as a user ID. The token is a random nonce and its HMAC signature; the
+// nonce is kept in Redis until the token is used or expires. Issuing a new
+// token for the same purpose and subject revokes the previous one.
+type OneTimeTokens struct {
+	secret      []byte
+	redisClient *redis.Client
+}
+
+func NewOneTimeTokens(cfg *config.Config, redisClient *redis.Client) *OneTimeTokens {
+	return &OneTimeTokens{
+		secret:      []byte(cfg.JWT.Secret),
+		redisClient: redisClient,
+	}
+}
+
+func (t *OneTimeTokens) Issue(purpose string, subject string, ttl time.Duration) (string, error) {
+	nonceBytes := make([]byte, 32)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return "", err
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(nonceBytes)
+
+	previous, err := t.redisClient.GetSet(subjectKey(purpose, subject), nonce).Result()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+
+	pipeline := t.redisClient.TxPipeline()
+	if previous != "" {
+		pipeline.Del(nonceKey(purpose, previous))
+	}
+	pipeline.Set(nonceKey(purpose, nonce), subject, ttl)
+	pipeline.Expire(subjectKey(purpose, subject), ttl)
+	if _, err = pipeline.Exec(); err != nil {
+		return "", err
+	}
+
+	return nonce + "." + t.sign(purpose, nonce), nil
+}
+
+// Consume returns the subject the token was issued for and invalidates it.
+func (t *OneTimeTokens) Consume(purpose string, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(t.sign(purpose, parts[0]))) {
+		return "", ErrInvalidOneTimeToken
+	}
+
+	nonce := parts[0]
+	subject, err := t.redisClient.Get(nonceKey(purpose, nonce)).Result()
+	if err == redis.Nil {
+		return "", ErrInvalidOneTimeToken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	result, err := consumeScript.Run(t.redisClient, []string{nonceKey(purpose, nonce), subjectKey(purpose, subject)}).Result()
+	if err == redis.Nil {
+		return "", ErrInvalidOneTimeToken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	consumed, ok := result.(string)
+	if !ok || consumed != subject {
+		return "", ErrInvalidOneTimeToken
+	}
+
+	return consumed, nil
+}
+
+func (t *OneTimeTokens) sign(purpose string, nonce string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(purpose + "." + nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func nonceKey(purpose string, nonce string) string {
+	return "onetime:" + purpose + ":" + nonce
+}
+
+func subjectKey(purpose string, subject string) string {
+	return "onetime:" + purpose + ":subject:" + subject
+}
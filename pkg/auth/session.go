@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrTokenRevoked    = errors.New("token has been revoked")
+)
+
+// issuedAtMillisClaim is the issue time in milliseconds. "iat" only has
+// seconds, which can't tell a token issued just before RevokeAll from one
+// issued in the same second just after it.
+const issuedAtMillisClaim = "iatMs"
+
+// Session is a sign-in on one device. It lives as long as its refresh token
+// and keeps the same ID across refreshes.
+type Session struct {
+	ID           string    `json:"id"`
+	Subject      string    `json:"-"`
+	RefreshToken string    `json:"-"`
+	Device       string    `json:"device"`
+	IP           string    `json:"ip"`
+	UserAgent    string    `json:"userAgent"`
+	Current      bool      `json:"current"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastUsedAt   time.Time `json:"lastUsedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// storedSession keeps the fields hidden from API responses when the session
+// is written to Redis.
+type storedSession struct {
+	Session
+	Subject      string `json:"subject"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+type SessionMetadata struct {
+	Device    string
+	IP        string
+	UserAgent string
+}
+
+// Subject identifies the owner of a session, e.g. Subject("userID", id).
+func Subject(idName string, id string) string {
+	return idName + ":" + id
+}
+
+func (p *Provider) Sessions(subject string) ([]Session, error) {
+	sessionIDs, err := p.redisClient.SMembers(subjectSessionsKey(subject)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []Session{}
+	for _, sessionID := range sessionIDs {
+		session, err := p.findSession(sessionID)
+		if errors.Is(err, ErrSessionNotFound) {
+			p.redisClient.SRem(subjectSessionsKey(subject), sessionID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+// RevokeSession ends one session of the subject: its refresh token is
+// deleted and access tokens issued for it are rejected from now on.
+func (p *Provider) RevokeSession(subject string, sessionID string) error {
+	session, err := p.findSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.Subject != subject {
+		return ErrSessionNotFound
+	}
+
+	return p.revoke(session)
+}
+
+// RevokeAll ends every session of the subject. Access tokens issued up to
+// the millisecond of the call are rejected as well, even if their session
+// was still being created while the sessions were revoked.
+func (p *Provider) RevokeAll(subject string) error {
+	sessionIDs, err := p.redisClient.SMembers(subjectSessionsKey(subject)).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, sessionID := range sessionIDs {
+		session, err := p.findSession(sessionID)
+		if errors.Is(err, ErrSessionNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = p.revoke(session); err != nil {
+			return err
+		}
+	}
+
+	pipeline := p.redisClient.TxPipeline()
+	pipeline.Set(revokedBeforeKey(subject), time.Now().UnixMilli(), p.accessTTL())
+	pipeline.Del(subjectSessionsKey(subject))
+	_, err = pipeline.Exec()
+
+	return err
+}
+
+// checkRevoked consults the revocation list for the session and subject of
+// an access token.
+func (p *Provider) checkRevoked(claims map[string]interface{}) error {
+	if sessionID, ok := claims["sid"].(string); ok {
+		revoked, err := p.redisClient.Exists(revokedSessionKey(sessionID)).Result()
+		if err != nil {
+			return err
+		}
+
+		if revoked > 0 {
+			return ErrTokenRevoked
+		}
+	}
+
+	subject, ok := claims["sub"].(string)
+	if !ok {
+		return nil
+	}
+
+	revokedBefore, err := p.redisClient.Get(revokedBeforeKey(subject)).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	before, _ := strconv.ParseInt(revokedBefore, 10, 64)
+	if before < 1e12 {
+		// Written in seconds before revocations were kept in milliseconds.
+		before *= 1000
+	}
+
+	if issuedAtMillis(claims) <= before {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// issuedAtMillis reads the issue time of a token in milliseconds. Tokens
+// issued before the millisecond claim count from the start of their second,
+// so they are revoked along with everything else issued in that second.
+func issuedAtMillis(claims map[string]interface{}) int64 {
+	if issuedAt, ok := claims[issuedAtMillisClaim].(float64); ok {
+		return int64(issuedAt)
+	}
+
+	issuedAt, _ := claims["iat"].(float64)
+	return int64(issuedAt) * 1000
+}
+
+func (p *Provider) revoke(session Session) error {
+	pipeline := p.redisClient.TxPipeline()
+	pipeline.Set(revokedSessionKey(session.ID), 1, p.accessTTL())
+	pipeline.Del(sessionKey(session.ID))
+	if session.RefreshToken != "" {
+		pipeline.Del(session.RefreshToken)
+	}
+	pipeline.SRem(subjectSessionsKey(session.Subject), session.ID)
+	_, err := pipeline.Exec()
+
+	return err
+}
+
+func (p *Provider) findSession(sessionID string) (Session, error) {
+	sessionJson, err := p.redisClient.Get(sessionKey(sessionID)).Bytes()
+	if err == redis.Nil {
+		return Session{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return Session{}, err
+	}
+
+	var stored storedSession
+	if err = json.Unmarshal(sessionJson, &stored); err != nil {
+		return Session{}, err
+	}
+
+	session := stored.Session
+	session.Subject = stored.Subject
+	session.RefreshToken = stored.RefreshToken
+
+	return session, nil
+}
+
+func (p *Provider) saveSession(pipeline redis.Pipeliner, session Session, ttl time.Duration) error {
+	sessionJson, err := json.Marshal(storedSession{
+		Session:      session,
+		Subject:      session.Subject,
+		RefreshToken: session.RefreshToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	pipeline.Set(sessionKey(session.ID), sessionJson, ttl)
+	pipeline.SAdd(subjectSessionsKey(session.Subject), session.ID)
+	pipeline.Expire(subjectSessionsKey(session.Subject), ttl)
+
+	return nil
+}
+
+func (p *Provider) accessTTL() time.Duration {
+	return time.Minute * time.Duration(p.cfg.JWT.AccessTokenTime)
+}
+
+func sessionKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+func subjectSessionsKey(subject string) string {
+	return "sessions:" + subject
+}
+
+func revokedSessionKey(sessionID string) string {
+	return "revoked:session:" + sessionID
+}
+
+func revokedBeforeKey(subject string) string {
+	return "revoked:before:" + subject
+}
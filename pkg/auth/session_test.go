@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-redis/redis/v7"
+	"github.com/sigit14ap/go-commerce/internal/config"
+)
+
+// fakeRedis speaks enough of the Redis protocol for the session store:
+// strings, sets and MULTI/EXEC. Expirations are accepted and ignored.
+type fakeRedis struct {
+	mu      sync.Mutex
+	strings map[string]string
+	sets    map[string]map[string]bool
+}
+
+func newFakeRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeRedis{strings: map[string]string{}, sets: map[string]map[string]bool{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: listener.Addr().String()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	var queued [][]string
+	inMulti := false
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch name := strings.ToLower(args[0]); {
+		case name == "multi":
+			inMulti, queued = true, nil
+			reply = "+OK\r\n"
+		case name == "exec":
+			reply = fmt.Sprintf("*%d\r\n", len(queued))
+			for _, command := range queued {
+				reply += s.execute(command)
+			}
+			inMulti, queued = false, nil
+		case inMulti:
+			queued = append(queued, args)
+			reply = "+QUEUED\r\n"
+		default:
+			reply = s.execute(args)
+		}
+
+		if _, err = io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) execute(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToLower(args[0]) {
+	case "get":
+		value, ok := s.strings[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulkString(value)
+	case "set":
+		s.strings[args[1]] = args[2]
+		return "+OK\r\n"
+	case "del":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.strings[key]; ok {
+				deleted++
+			}
+			if _, ok := s.sets[key]; ok {
+				deleted++
+			}
+			delete(s.strings, key)
+			delete(s.sets, key)
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "exists":
+		found := 0
+		for _, key := range args[1:] {
+			if _, ok := s.strings[key]; ok {
+				found++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", found)
+	case "expire", "pexpire":
+		return ":1\r\n"
+	case "sadd":
+		if s.sets[args[1]] == nil {
+			s.sets[args[1]] = map[string]bool{}
+		}
+		for _, member := range args[2:] {
+			s.sets[args[1]][member] = true
+		}
+		return fmt.Sprintf(":%d\r\n", len(args)-2)
+	case "srem":
+		for _, member := range args[2:] {
+			delete(s.sets[args[1]], member)
+		}
+		return fmt.Sprintf(":%d\r\n", len(args)-2)
+	case "smembers":
+		reply := fmt.Sprintf("*%d\r\n", len(s.sets[args[1]]))
+		for member := range s.sets[args[1]] {
+			reply += bulkString(member)
+		}
+		return reply
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, errors.New("expected an array")
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		length, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+
+		value := make([]byte, length+2)
+		if _, err = io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:length])
+	}
+
+	return args, nil
+}
+
+func bulkString(value string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func newTestSessions(t *testing.T) *Provider {
+	cfg := &config.Config{}
+	cfg.JWT.AccessTokenTime = 15
+	cfg.JWT.RefreshTokenTime = 60
+	cfg.JWT.ImpersonationMinutes = 15
+
+	return NewTokenProvider(cfg, newFakeRedis(t), newTestKeySet(t, AlgorithmRS256))
+}
+
+func signIn(t *testing.T, provider *Provider, subject string, device string) *AuthDetails {
+	t.Helper()
+
+	tokens, err := provider.CreateJWTSession(CreateSessionInput{
+		Fingerprint: "fingerprint",
+		Subject:     subject,
+		Metadata:    SessionMetadata{Device: device, IP: "203.0.113.7", UserAgent: "test"},
+		Claims:      jwt.MapClaims{"userID": "42"},
+	})
+	if err != nil {
+		t.Fatalf("sign in: %v", err)
+	}
+
+	return tokens
+}
+
+func TestSessionsListAndRevoke(t *testing.T) {
+	provider := newTestSessions(t)
+	subject := Subject("userID", "42")
+
+	phone := signIn(t, provider, subject, "phone")
+	laptop := signIn(t, provider, subject, "laptop")
+
+	sessions, err := provider.Sessions(subject)
+	if err != nil {
+		t.Fatalf("Sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %+v, want phone and laptop", sessions)
+	}
+
+	claims, err := provider.VerifyToken(phone.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	phoneSession := claims["sid"].(string)
+
+	if err = provider.RevokeSession(Subject("userID", "7"), phoneSession); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("revoking another subject's session err = %v, want %v", err, ErrSessionNotFound)
+	}
+
+	// Logging out revokes the current session only.
+	if err = provider.RevokeSession(subject, phoneSession); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+
+	if _, err = provider.VerifyToken(phone.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("revoked session token err = %v, want %v", err, ErrTokenRevoked)
+	}
+	if _, err = provider.Refresh(RefreshInput{RefreshToken: phone.RefreshToken, Fingerprint: "fingerprint"}); err == nil {
+		t.Error("refresh token of a revoked session still works")
+	}
+	if _, err = provider.VerifyToken(laptop.AccessToken); err != nil {
+		t.Errorf("other session token: %v", err)
+	}
+
+	sessions, err = provider.Sessions(subject)
+	if err != nil || len(sessions) != 1 || sessions[0].Device != "laptop" {
+		t.Errorf("sessions after logout = %+v, %v, want the laptop only", sessions, err)
+	}
+}
+
+func TestRevokeAllRejectsTokensIssuedInTheSameSecond(t *testing.T) {
+	provider := newTestSessions(t)
+	subject := Subject("userID", "42")
+
+	tokens := signIn(t, provider, subject, "phone")
+	readOnly, err := provider.IssueReadOnlyToken(subject, jwt.MapClaims{"userID": "42"})
+	if err != nil {
+		t.Fatalf("IssueReadOnlyToken: %v", err)
+	}
+
+	if err = provider.RevokeAll(subject); err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+
+	for name, token := range map[string]string{"session": tokens.AccessToken, "read-only": readOnly} {
+		if _, err = provider.VerifyToken(token); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("%s token issued before RevokeAll err = %v, want %v", name, err, ErrTokenRevoked)
+		}
+	}
+
+	sessions, err := provider.Sessions(subject)
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("sessions after RevokeAll = %+v, %v, want none", sessions, err)
+	}
+
+	// Signing in again right after, within the same second, works.
+	time.Sleep(2 * time.Millisecond)
+	tokens = signIn(t, provider, subject, "phone")
+	if _, err = provider.VerifyToken(tokens.AccessToken); err != nil {
+		t.Errorf("token issued after RevokeAll: %v", err)
+	}
+}
+
+func TestIssuedAtMillisOfOlderTokens(t *testing.T) {
+	claims := map[string]interface{}{"iat": float64(1700000000)}
+	if got := issuedAtMillis(claims); got != 1700000000000 {
+		t.Errorf("issuedAtMillis = %d, want the start of the second", got)
+	}
+
+	claims[issuedAtMillisClaim] = float64(1700000000123)
+	if got := issuedAtMillis(claims); got != 1700000000123 {
+		t.Errorf("issuedAtMillis = %d, want 1700000000123", got)
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B, SHA1 with the 20 byte ASCII key "12345678901234567890".
+func TestValidateTOTPVectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, vector := range vectors {
+		step, ok := ValidateTOTP(secret, vector.code, time.Unix(vector.unix, 0))
+		if !ok || step != vector.unix/30 {
+			t.Errorf("code %s at %d: ok=%t step=%d", vector.code, vector.unix, ok, step)
+		}
+	}
+
+	if _, ok := ValidateTOTP(secret, "287082", time.Unix(59+90, 0)); ok {
+		t.Error("code accepted three steps later")
+	}
+}
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	box, _ := NewSecretBox("passphrase")
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := box.Open(sealed)
+	if err != nil || opened != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("opened %q, %v", opened, err)
+	}
+
+	other, _ := NewSecretBox("other")
+	if _, err = other.Open(sealed); err != ErrSecretBox {
+		t.Errorf("other key err = %v, want ErrSecretBox", err)
+	}
+}
@@ -2,11 +2,16 @@ package courier
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/sigit14ap/go-commerce/internal/domain"
 	"github.com/sigit14ap/go-commerce/internal/domain/dto"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -45,10 +50,58 @@ type cityResponse struct {
 	} `json:"rajaongkir"`
 }
 
+type Manifest struct {
+	Code        string `json:"manifest_code"`
+	Description string `json:"manifest_description"`
+	Date        string `json:"manifest_date"`
+	Time        string `json:"manifest_time"`
+	City        string `json:"city_name"`
+}
+
+type Waybill struct {
+	Delivered      bool `json:"delivered"`
+	DeliveryStatus struct {
+		Status      string `json:"status"`
+		PodReceiver string `json:"pod_receiver"`
+		PodDate     string `json:"pod_date"`
+		PodTime     string `json:"pod_time"`
+	} `json:"delivery_status"`
+	Manifest []Manifest `json:"manifest"`
+}
+
+type waybillResponse struct {
+	Rajaongkir struct {
+		Query  query   `json:"query"`
+		Status status  `json:"status"`
+		Result Waybill `json:"result"`
+	} `json:"rajaongkir"`
+}
+
+type Cost struct {
+	Code  string `json:"code"`
+	Costs []struct {
+		Service     string `json:"service"`
+		Description string `json:"description"`
+		Cost        []struct {
+			Value int64  `json:"value"`
+			Etd   string `json:"etd"`
+		} `json:"cost"`
+	} `json:"costs"`
+}
+
+type costResponse struct {
+	Rajaongkir struct {
+		Query   query  `json:"query"`
+		Status  status `json:"status"`
+		Results []Cost `json:"results"`
+	} `json:"rajaongkir"`
+}
+
 type CourierProvider interface {
 	GetProvinces() ([]domain.Province, error)
 	GetCities() ([]dto.ThirdPartyCityDTO, error)
-	GetDeliveryCost() ([]dto.ThirdPartyCityDTO, error)
+	GetDeliveryCost(origin string, destination string, weight int64, courier string) ([]dto.ThirdPartyCostDTO, error)
+	GetWaybill(courier string, waybill string) (dto.ThirdPartyWaybillDTO, error)
 }
 
 type Provider struct {
@@ -65,7 +118,13 @@ func call(method string, endpoint string, data interface{}) ([]byte, error) {
 		Timeout: time.Second * 10,
 	}
 
-	req, err := http.NewRequest(method, endpoint, nil)
+	var body io.Reader
+	form, isForm := data.(url.Values)
+	if isForm {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, endpoint, body)
 
 	var result []byte
 
@@ -74,6 +133,9 @@ func call(method string, endpoint string, data interface{}) ([]byte, error) {
 	}
 
 	req.Header.Set("key", os.Getenv("RAJAONGKIR_API_KEY"))
+	if isForm {
+		req.Header.Set("content-type", "application/x-www-form-urlencoded")
+	}
 
 	response, err := client.Do(req)
 	if err != nil {
@@ -82,9 +144,9 @@ func call(method string, endpoint string, data interface{}) ([]byte, error) {
 
 	defer response.Body.Close()
 
-	body, err := ioutil.ReadAll(response.Body)
+	result, err = ioutil.ReadAll(response.Body)
 
-	return body, err
+	return result, err
 }
 
 func (p *Provider) GetProvinces() ([]domain.Province, error) {
@@ -134,6 +196,130 @@ func (p *Provider) GetCities() ([]dto.ThirdPartyCityDTO, error) {
 	return cityList, err
 }
 
-func (p *Provider) GetDeliveryCost() ([]dto.ThirdPartyCityDTO, error) {
-	panic("implement me")
+// GetDeliveryCost lists the services of the courier between two cities, the
+// weight is in grams.
+func (p *Provider) GetDeliveryCost(origin string, destination string, weight int64, courier string) ([]dto.ThirdPartyCostDTO, error) {
+	data := url.Values{}
+	data.Set("origin", origin)
+	data.Set("destination", destination)
+	data.Set("weight", strconv.FormatInt(weight, 10))
+	data.Set("courier", courier)
+
+	body, err := call("POST", "/cost", data)
+	if err != nil {
+		return nil, err
+	}
+
+	var response costResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Rajaongkir.Status.Code != http.StatusOK {
+		return nil, errors.New(response.Rajaongkir.Status.Description)
+	}
+
+	costs := []dto.ThirdPartyCostDTO{}
+	for _, result := range response.Rajaongkir.Results {
+		for _, service := range result.Costs {
+			if len(service.Cost) == 0 {
+				continue
+			}
+
+			minDays, maxDays := parseEtd(service.Cost[0].Etd)
+			costs = append(costs, dto.ThirdPartyCostDTO{
+				Courier:     result.Code,
+				Service:     service.Service,
+				Description: service.Description,
+				Cost:        domain.IDR(service.Cost[0].Value),
+				MinDays:     minDays,
+				MaxDays:     maxDays,
+			})
+		}
+	}
+
+	return costs, nil
+}
+
+func (p *Provider) GetWaybill(courier string, waybill string) (dto.ThirdPartyWaybillDTO, error) {
+	data := url.Values{}
+	data.Set("waybill", waybill)
+	data.Set("courier", courier)
+
+	body, err := call("POST", "/waybill", data)
+	if err != nil {
+		return dto.ThirdPartyWaybillDTO{}, err
+	}
+
+	var response waybillResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return dto.ThirdPartyWaybillDTO{}, err
+	}
+
+	if response.Rajaongkir.Status.Code != http.StatusOK {
+		return dto.ThirdPartyWaybillDTO{}, errors.New(response.Rajaongkir.Status.Description)
+	}
+
+	result := response.Rajaongkir.Result
+	waybillDTO := dto.ThirdPartyWaybillDTO{
+		Delivered: result.Delivered,
+		Events:    []domain.TrackingEvent{},
+	}
+
+	for _, manifest := range result.Manifest {
+		waybillDTO.Events = append(waybillDTO.Events, domain.TrackingEvent{
+			Code:        manifest.Code,
+			Description: manifest.Description,
+			City:        manifest.City,
+			OccurredAt:  parseDateTime(manifest.Date, manifest.Time),
+		})
+	}
+
+	if result.Delivered {
+		waybillDTO.DeliveredAt = parseDateTime(result.DeliveryStatus.PodDate, result.DeliveryStatus.PodTime)
+	}
+
+	return waybillDTO, nil
+}
+
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+func parseDateTime(date string, clock string) time.Time {
+	if len(clock) == 5 {
+		clock += ":00"
+	}
+
+	parsed, err := time.ParseInLocation("2006-01-02 15:04:05", date+" "+clock, jakarta)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return parsed
+}
+
+// parseEtd reads estimates such as "1-2", "2-3 HARI" or "1" as a range of
+// days, an estimate it cannot read is zero.
+func parseEtd(etd string) (int, int) {
+	fields := strings.Fields(etd)
+	if len(fields) == 0 {
+		return 0, 0
+	}
+
+	bounds := strings.SplitN(fields[0], "-", 2)
+	minDays, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return 0, 0
+	}
+
+	maxDays := minDays
+	if len(bounds) == 2 {
+		maxDays, err = strconv.Atoi(bounds[1])
+		if err != nil || maxDays < minDays {
+			maxDays = minDays
+		}
+	}
+
+	return minDays, maxDays
 }
@@ -0,0 +1,23 @@
+package courier
+
+import "testing"
+
+func TestParseEtd(t *testing.T) {
+	tests := []struct {
+		etd      string
+		min, max int
+	}{
+		{"1-2", 1, 2},
+		{"2-3 HARI", 2, 3},
+		{"1", 1, 1},
+		{"3-1", 3, 3},
+		{"", 0, 0},
+		{"HARI", 0, 0},
+	}
+
+	for _, test := range tests {
+		if minDays, maxDays := parseEtd(test.etd); minDays != test.min || maxDays != test.max {
+			t.Errorf("parseEtd(%q) = %d, %d, want %d, %d", test.etd, minDays, maxDays, test.min, test.max)
+		}
+	}
+}
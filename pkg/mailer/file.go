@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FileMailer writes every message as an .eml file instead of sending it,
+// for local development and testing.
+type FileMailer struct {
+	directory string
+	from      string
+}
+
+func NewFileMailer(directory string, from string) *FileMailer {
+	return &FileMailer{
+		directory: directory,
+		from:      from,
+	}
+}
+
+func (m *FileMailer) Send(message Message) error {
+	err := os.MkdirAll(m.directory, 0o755)
+	if err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(message.To)
+	filename := filepath.Join(m.directory, fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), recipient))
+
+	err = os.WriteFile(filename, compose(m.from, message), 0o644)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("mail %q to %s written to %s", message.Subject, message.To, filename)
+	return nil
+}
@@ -0,0 +1,30 @@
+package mailer
+
+import (
+	"github.com/sigit14ap/go-commerce/internal/config"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+// NewMailer returns the mailer configured in mail.driver, the file mailer is
+// used when no driver is set so local setups never send real email.
+func NewMailer(cfg *config.Config) Mailer {
+	if cfg.Mail.Driver == DriverSMTP {
+		return NewSMTPMailer(cfg.Mail.Host, cfg.Mail.Port, cfg.Mail.Username, cfg.Mail.Password, cfg.Mail.From)
+	}
+
+	return NewFileMailer(cfg.Mail.Directory, cfg.Mail.From)
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+type SMTPMailer struct {
+	address string
+	host    string
+	auth    smtp.Auth
+	from    string
+}
+
+func NewSMTPMailer(host string, port string, username string, password string, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{
+		address: net.JoinHostPort(host, port),
+		host:    host,
+		auth:    auth,
+		from:    from,
+	}
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	return smtp.SendMail(m.address, m.auth, m.from, []string{message.To}, compose(m.from, message))
+}
+
+// compose renders the message as a plain text RFC 5322 email.
+func compose(from string, message Message) []byte {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", from)
+	fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	buffer.WriteString(message.Body)
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,328 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/sigit14ap/go-commerce/internal/config"
+)
+
+var (
+	ErrNotConfigured  = errors.New("oidc login is not configured")
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrTokenExchange  = errors.New("authorization code exchange failed")
+)
+
+// Discovery is the part of the provider metadata the login flow needs.
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// Identity is what the verified ID token says about the user.
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Provider interface {
+	Name() string
+	AuthCodeURL(state string, nonce string, codeChallenge string) (string, error)
+	Exchange(code string, codeVerifier string) (TokenResponse, error)
+	VerifyIDToken(rawIDToken string, nonce string) (Identity, error)
+}
+
+// Client talks to one OpenID Connect provider using the authorization code
+// flow with PKCE. Provider metadata and signing keys are fetched lazily and
+// cached, the keys are fetched again when a token names an unknown key.
+type Client struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      map[string]interface{}
+}
+
+func NewClient(cfg *config.Config) *Client {
+	return &Client{
+		name:         cfg.OIDC.Name,
+		issuer:       strings.TrimSuffix(cfg.OIDC.Issuer, "/"),
+		clientID:     cfg.OIDC.ClientID,
+		clientSecret: cfg.OIDC.ClientSecret,
+		redirectURL:  cfg.OIDC.RedirectURL,
+		scopes:       cfg.OIDC.Scopes,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) Name() string {
+	return c.name
+}
+
+func (c *Client) AuthCodeURL(state string, nonce string, codeChallenge string) (string, error) {
+	discovery, err := c.discover()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.clientID},
+		"redirect_uri":          {c.redirectURL},
+		"scope":                 {strings.Join(c.scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+func (c *Client) Exchange(code string, codeVerifier string) (TokenResponse, error) {
+	discovery, err := c.discover()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.redirectURL},
+		"client_id":     {c.clientID},
+		"code_verifier": {codeVerifier},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if c.clientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+	}
+
+	var token TokenResponse
+	if err = c.do(request, &token); err != nil {
+		return TokenResponse{}, fmt.Errorf("%w: %s", ErrTokenExchange, err)
+	}
+
+	if token.IDToken == "" {
+		return TokenResponse{}, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+
+	return token, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// an ID token and returns the identity it asserts.
+func (c *Client) VerifyIDToken(rawIDToken string, nonce string) (Identity, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.Alg() {
+		case "RS256", "RS384", "RS512", "ES256", "ES384", "ES512":
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+
+		keyID, _ := token.Header["kid"].(string)
+		return c.key(keyID)
+	})
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %s", ErrInvalidIDToken, err)
+	}
+
+	if claims.Issuer != c.issuer {
+		return Identity{}, fmt.Errorf("%w: unexpected issuer %s", ErrInvalidIDToken, claims.Issuer)
+	}
+
+	if !claims.Audience.contains(c.clientID) {
+		return Identity{}, fmt.Errorf("%w: token was issued for another client", ErrInvalidIDToken)
+	}
+
+	if claims.Nonce != nonce {
+		return Identity{}, fmt.Errorf("%w: nonce does not match", ErrInvalidIDToken)
+	}
+
+	if claims.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	return Identity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+func (c *Client) discover() (*Discovery, error) {
+	if c.issuer == "" || c.clientID == "" {
+		return nil, ErrNotConfigured
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	request, err := http.NewRequest(http.MethodGet, c.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovery Discovery
+	if err = c.do(request, &discovery); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != c.issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %s does not match %s", discovery.Issuer, c.issuer)
+	}
+
+	c.discovery = &discovery
+	return c.discovery, nil
+}
+
+func (c *Client) key(keyID string) (interface{}, error) {
+	c.mu.Lock()
+	key, ok := c.keys[keyID]
+	c.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	if err := c.fetchKeys(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok = c.keys[keyID]; ok {
+		return key, nil
+	}
+
+	// providers with a single key do not always name it
+	if keyID == "" && len(c.keys) == 1 {
+		for _, key = range c.keys {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", keyID)
+}
+
+func (c *Client) fetchKeys() error {
+	discovery, err := c.discover()
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+
+	var set jsonWebKeySet
+	if err = c.do(request, &set); err != nil {
+		return fmt.Errorf("oidc keys: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *Client) do(request *http.Request, result interface{}) error {
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d: %s", request.URL.Host, response.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// NewCodeVerifier returns a PKCE code verifier and its S256 challenge.
+func NewCodeVerifier() (string, string, error) {
+	verifier, err := RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// RandomString returns n random bytes encoded as unpadded base64url.
+func RandomString(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
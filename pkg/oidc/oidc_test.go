@@ -0,0 +1,205 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// mockProvider is a minimal OpenID Connect provider that issues an ID token
+// for one authorization code.
+type mockProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	code     string
+	claims   jwt.MapClaims
+	verifier string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &mockProvider{key: key, code: "code-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Discovery{
+			Issuer:                provider.server.URL,
+			AuthorizationEndpoint: provider.server.URL + "/authorize",
+			TokenEndpoint:         provider.server.URL + "/token",
+			JWKSURI:               provider.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "mock",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != provider.code ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != provider.verifier {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, provider.claims)
+		token.Header["kid"] = "mock"
+		idToken, _ := token.SignedString(key)
+		_ = json.NewEncoder(w).Encode(TokenResponse{IDToken: idToken, TokenType: "Bearer"})
+	})
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+
+	return provider
+}
+
+func (p *mockProvider) client() *Client {
+	return &Client{
+		name:        "mock",
+		issuer:      p.server.URL,
+		clientID:    "shop",
+		redirectURL: "http://localhost:3000/oidc/callback",
+		scopes:      []string{"openid", "email"},
+		httpClient:  p.server.Client(),
+	}
+}
+
+func (p *mockProvider) authorize(t *testing.T, client *Client, nonce string) string {
+	t.Helper()
+
+	verifier, challenge, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := client.AuthCodeURL("state-1", nonce, challenge)
+	if err != nil {
+		t.Fatalf("auth url: %v", err)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	if parsed.Query().Get("code_challenge_method") != "S256" || parsed.Query().Get("nonce") != nonce {
+		t.Fatalf("auth url = %s", authURL)
+	}
+
+	p.verifier = parsed.Query().Get("code_challenge")
+	return verifier
+}
+
+func (p *mockProvider) idTokenClaims(audience interface{}, nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            p.server.URL,
+		"sub":            "user-123",
+		"aud":            audience,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "Buyer@Example.com",
+		"email_verified": "true",
+		"name":           "Buyer",
+	}
+}
+
+func TestLoginFlow(t *testing.T) {
+	provider := newMockProvider(t)
+	client := provider.client()
+
+	verifier := provider.authorize(t, client, "nonce-1")
+	provider.claims = provider.idTokenClaims([]string{"other", "shop"}, "nonce-1")
+
+	token, err := client.Exchange(provider.code, verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	identity, err := client.VerifyIDToken(token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if identity.Subject != "user-123" || identity.Email != "buyer@example.com" || !identity.EmailVerified {
+		t.Errorf("identity = %+v", identity)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	provider := newMockProvider(t)
+	client := provider.client()
+	provider.authorize(t, client, "nonce-1")
+
+	_, err := client.Exchange(provider.code, "not-the-verifier")
+	if !errors.Is(err, ErrTokenExchange) {
+		t.Errorf("err = %v, want ErrTokenExchange", err)
+	}
+}
+
+func TestVerifyIDTokenRejections(t *testing.T) {
+	provider := newMockProvider(t)
+	client := provider.client()
+
+	tests := []struct {
+		name   string
+		claims func() jwt.MapClaims
+	}{
+		{"nonce", func() jwt.MapClaims { return provider.idTokenClaims("shop", "replayed") }},
+		{"audience", func() jwt.MapClaims { return provider.idTokenClaims("other", "nonce-1") }},
+		{"issuer", func() jwt.MapClaims {
+			claims := provider.idTokenClaims("shop", "nonce-1")
+			claims["iss"] = "https://evil.example.com"
+			return claims
+		}},
+		{"expired", func() jwt.MapClaims {
+			claims := provider.idTokenClaims("shop", "nonce-1")
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			return claims
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, test.claims())
+			token.Header["kid"] = "mock"
+			idToken, _ := token.SignedString(provider.key)
+
+			_, err := client.VerifyIDToken(idToken, "nonce-1")
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+
+	t.Run("signature", func(t *testing.T) {
+		other, _ := rsa.GenerateKey(rand.Reader, 2048)
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, provider.idTokenClaims("shop", "nonce-1"))
+		token.Header["kid"] = "mock"
+		idToken, _ := token.SignedString(other)
+
+		_, err := client.VerifyIDToken(idToken, "nonce-1")
+		if err == nil || !strings.Contains(err.Error(), "verification error") {
+			t.Errorf("err = %v, want a signature error", err)
+		}
+	})
+}
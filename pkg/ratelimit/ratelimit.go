@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sigit14ap/go-commerce/internal/config"
+)
+
+const (
+	KeyIP    = "ip"
+	KeyEmail = "email"
+	KeyUser  = "user"
+)
+
+// slidingWindow keeps the timestamps of the requests of one key in a sorted
+// set. Requests older than the window are dropped, a new one is only added
+// while the set holds fewer than the limit. It returns whether the request
+// was allowed, the number of requests in the window and the time in
+// milliseconds at which the oldest of them leaves the window.
+var slidingWindow = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], 0, now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = now + window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window
+end
+
+return {allowed, count, reset}
+`)
+
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Keys   []string
+}
+
+// Result describes the state of one key after a request was counted.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAt    time.Time
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests per policy and key in Redis, so the limits hold
+// across every instance of the API.
+type Limiter struct {
+	redisClient *redis.Client
+	enabled     bool
+	policies    map[string]Policy
+}
+
+func NewLimiter(cfg *config.Config, redisClient *redis.Client) *Limiter {
+	policies := make(map[string]Policy, len(cfg.RateLimit.Policies))
+	for name, policy := range cfg.RateLimit.Policies {
+		if policy.Limit <= 0 || policy.WindowSeconds <= 0 {
+			continue
+		}
+
+		keys := policy.Keys
+		if len(keys) == 0 {
+			keys = []string{KeyIP}
+		}
+
+		policies[name] = Policy{
+			Name:   name,
+			Limit:  policy.Limit,
+			Window: time.Duration(policy.WindowSeconds) * time.Second,
+			Keys:   keys,
+		}
+	}
+
+	return &Limiter{
+		redisClient: redisClient,
+		enabled:     cfg.RateLimit.Enabled,
+		policies:    policies,
+	}
+}
+
+// Policy returns the configured policy, false means the route is not limited.
+func (l *Limiter) Policy(name string) (Policy, bool) {
+	if !l.enabled {
+		return Policy{}, false
+	}
+
+	policy, ok := l.policies[name]
+	return policy, ok
+}
+
+// Allow counts a request for the key under the policy.
+func (l *Limiter) Allow(policy Policy, keyType string, key string) (Result, error) {
+	member := make([]byte, 8)
+	if _, err := rand.Read(member); err != nil {
+		return Result{}, err
+	}
+
+	now := time.Now()
+	redisKey := fmt.Sprintf("ratelimit:%s:%s:%s", policy.Name, keyType, key)
+	values, err := slidingWindow.Run(l.redisClient, []string{redisKey},
+		now.UnixMilli(), policy.Window.Milliseconds(), policy.Limit,
+		hex.EncodeToString(member)).Result()
+	if err != nil {
+		return Result{}, err
+	}
+
+	reply, ok := values.([]interface{})
+	if !ok || len(reply) != 3 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", values)
+	}
+
+	allowed, _ := reply[0].(int64)
+	count, _ := reply[1].(int64)
+	reset, _ := reply[2].(int64)
+
+	result := Result{
+		Allowed:   allowed == 1,
+		Limit:     policy.Limit,
+		Remaining: policy.Limit - int(count),
+		ResetAt:   time.UnixMilli(reset),
+	}
+
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+
+	if !result.Allowed {
+		result.RetryAfter = result.ResetAt.Sub(now)
+	}
+
+	return result, nil
+}
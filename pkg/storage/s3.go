@@ -1,16 +1,21 @@
 package storage
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/sigit14ap/go-commerce/internal/config"
 	"math/rand"
 	"mime/multipart"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 type UploadInput struct {
@@ -19,6 +24,8 @@ type UploadInput struct {
 
 type StorageProvider interface {
 	Upload(typeFile string, file *multipart.FileHeader) string
+	PutPrivate(typeFile string, filename string, body []byte, contentType string) (string, error)
+	PresignPrivate(key string, expiry time.Duration) (string, error)
 }
 
 type Provider struct {
@@ -33,26 +40,17 @@ func NewStorageProvider(cfg *config.Config) *Provider {
 
 func (p *Provider) Upload(typeFile string, file *multipart.FileHeader) string {
 
-	s3Config := &aws.Config{
-		Region:      aws.String(os.Getenv("AWS_REGION")),
-		Credentials: credentials.NewStaticCredentials(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), ""),
-	}
-
-	s3Session, _ := session.NewSession(s3Config)
-
-	uploader := s3manager.NewUploader(s3Session)
+	uploader := newUploader()
 
 	f, err := file.Open()
 	if err != nil {
 		panic(err)
 	}
 
-	extension := strings.Split(file.Filename, ".")
-
-	name := generateName(20)
-	ext := extension[1]
-
-	filename := name + "." + ext
+	filename := generateName(20)
+	if ext := filepath.Ext(file.Filename); ext != "" {
+		filename += strings.ToLower(ext)
+	}
 
 	result, err := uploader.Upload(&s3manager.UploadInput{
 		Bucket: aws.String(os.Getenv("AWS_BUCKET")),
@@ -69,6 +67,62 @@ func (p *Provider) Upload(typeFile string, file *multipart.FileHeader) string {
 	return result.Location
 }
 
+// PutPrivate stores body in the private bucket, which is never served
+// publicly, under an unguessable key and returns the key. Documents with
+// personal data go there and are only handed out by the application.
+func (p *Provider) PutPrivate(typeFile string, filename string, body []byte, contentType string) (string, error) {
+	bucket := os.Getenv("AWS_PRIVATE_BUCKET")
+	if bucket == "" {
+		return "", errors.New("AWS_PRIVATE_BUCKET is not configured")
+	}
+
+	key := typeFile + "/" + generateName(20) + "/" + filename
+	_, err := newUploader().Upload(&s3manager.UploadInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(body),
+		ContentType:          aws.String(contentType),
+		ACL:                  aws.String(s3.ObjectCannedACLPrivate),
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// PresignPrivate returns a URL that grants read access to a private object
+// until expiry passes.
+func (p *Provider) PresignPrivate(key string, expiry time.Duration) (string, error) {
+	bucket := os.Getenv("AWS_PRIVATE_BUCKET")
+	if bucket == "" {
+		return "", errors.New("AWS_PRIVATE_BUCKET is not configured")
+	}
+
+	req, _ := s3.New(newSession()).GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	return req.Presign(expiry)
+}
+
+func newUploader() *s3manager.Uploader {
+	return s3manager.NewUploader(newSession())
+}
+
+func newSession() *session.Session {
+	s3Config := &aws.Config{
+		Region:      aws.String(os.Getenv("AWS_REGION")),
+		Credentials: credentials.NewStaticCredentials(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), ""),
+	}
+
+	s3Session, _ := session.NewSession(s3Config)
+
+	return s3Session
+}
+
 func generateName(length int) string {
 	var output strings.Builder
 